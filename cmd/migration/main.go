@@ -1,15 +1,24 @@
 package main
 
 import (
+	"database/sql"
+	"encoding/json"
 	"flag"
 	"fmt"
 	"log"
 	"modular-fx-fiber/internal/core/config"
+	"modular-fx-fiber/internal/modules"
+	"modular-fx-fiber/internal/shared/database"
 	"modular-fx-fiber/internal/shared/logger"
+	"modular-fx-fiber/internal/shared/rbac"
+	"modular-fx-fiber/internal/shared/repositories"
+	"modular-fx-fiber/internal/shared/util"
 	"os"
+	"strings"
 
 	_ "github.com/jackc/pgx/v5/stdlib"
 	"github.com/pressly/goose/v3"
+	"go.uber.org/fx"
 )
 
 // Command line flags
@@ -17,12 +26,18 @@ var (
 	migrationPath string
 	command       string
 	name          string
+	redirectURIs  string
+	scopes        string
+	public        bool
 )
 
 func init() {
 	flag.StringVar(&migrationPath, "dir", "internal/shared/database/migrations", "Directory with migration files")
-	flag.StringVar(&command, "cmd", "help", "Migration command (up, down, status, create, help)")
-	flag.StringVar(&name, "name", "", "Name for new migration (for create command)")
+	flag.StringVar(&command, "cmd", "help", "Migration command (up, down, status, create, create-client, seed-permissions, help)")
+	flag.StringVar(&name, "name", "", "Name for new migration or OAuth client (for create and create-client commands)")
+	flag.StringVar(&redirectURIs, "redirect-uris", "", "Comma separated redirect URIs allowed for the OAuth client (for create-client command)")
+	flag.StringVar(&scopes, "scopes", "", "Space separated scopes the OAuth client may request (for create-client command)")
+	flag.BoolVar(&public, "public", false, "Register a public OAuth client without secret that must use PKCE (for create-client command)")
 }
 
 func Run() {
@@ -78,6 +93,15 @@ func Run() {
 		}
 		err = goose.Create(db, migrationPath, name, "sql")
 
+	case "create-client":
+		if name == "" {
+			log.Fatal("Client name is required for create-client command")
+		}
+		err = createOAuthClient(db, name, splitList(redirectURIs, ","), strings.Fields(scopes), public)
+
+	case "seed-permissions":
+		err = seedPermissions(cfg, l)
+
 	case "reset":
 		err = goose.Reset(db, migrationPath)
 
@@ -95,6 +119,8 @@ func Run() {
 		fmt.Println("  down    Roll back the version by 1")
 		fmt.Println("  status  Display migration status")
 		fmt.Println("  create  Create a new migration file (requires -name)")
+		fmt.Println("  create-client Register an OAuth client (requires -name, see -redirect-uris, -scopes and -public)")
+		fmt.Println("  seed-permissions Upsert the permissions declared by the modules and the default roles")
 		fmt.Println("  reset   Roll back all migrations")
 		fmt.Println("  version Display current migration version")
 		fmt.Println("  help    Show this help")
@@ -110,6 +136,94 @@ func Run() {
 	}
 }
 
+// createOAuthClient registers an OAuth client and prints its credentials.
+// The secret is only stored hashed, so it cannot be shown again. Public clients get no secret.
+func createOAuthClient(db *sql.DB, clientName string, redirectURIs []string, scopes []string, public bool) error {
+	clientID, err := util.GenerateRandomToken(16)
+	if err != nil {
+		return err
+	}
+
+	clientSecret, secretHash := "", ""
+	if !public {
+		clientSecret, err = util.GenerateRandomToken(32)
+		if err != nil {
+			return err
+		}
+		secretHash = util.HashToken(clientSecret)
+	}
+
+	redirectURIsJSON, err := json.Marshal(redirectURIs)
+	if err != nil {
+		return err
+	}
+	scopesJSON, err := json.Marshal(scopes)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(
+		"INSERT INTO oauth_clients (client_id, client_secret_hash, name, redirect_uris, scopes, is_public) VALUES ($1, $2, $3, $4, $5, $6)",
+		clientID, secretHash, clientName, string(redirectURIsJSON), string(scopesJSON), public,
+	)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Client ID:     %s\n", clientID)
+	if !public {
+		fmt.Printf("Client secret: %s\n", clientSecret)
+	}
+	return nil
+}
+
+// seedPermissions upserts the permissions declared by the feature modules and the default roles
+// of auth.rbac.default_roles, then prints what changed and the orphaned permissions
+func seedPermissions(cfg *config.Config, l *logger.ZapLogger) error {
+	var result *rbac.SyncResult
+
+	app := fx.New(
+		fx.NopLogger,
+		fx.Supply(cfg, l),
+		fx.Provide(
+			database.NewDatabase,
+			repositories.NewPermissionRepository,
+			repositories.NewRoleRepository,
+			rbac.NewCatalog,
+			rbac.NewSyncer,
+		),
+		// Permissions declared by the feature modules of cmd/server
+		modules.Permissions,
+		fx.Invoke(func(s rbac.Syncer) error {
+			var err error
+			result, err = s.Sync()
+			return err
+		}),
+	)
+	if err := app.Err(); err != nil {
+		return err
+	}
+
+	fmt.Printf("Created permissions:  %s\n", strings.Join(result.Created, ", "))
+	fmt.Printf("Updated permissions:  %s\n", strings.Join(result.Updated, ", "))
+	fmt.Printf("Created roles:        %s\n", strings.Join(result.RolesCreated, ", "))
+	if len(result.Orphaned) > 0 {
+		fmt.Printf("Orphaned permissions: %s (declared by no module, delete them or grant them by hand)\n", strings.Join(result.Orphaned, ", "))
+	}
+	return nil
+}
+
+// splitList splits a separated flag value, dropping empty items
+func splitList(value string, separator string) []string {
+	items := []string{}
+	for _, item := range strings.Split(value, separator) {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
 // Main function for migration command
 func main() {
 	Run()
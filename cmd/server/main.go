@@ -2,9 +2,7 @@ package main
 
 import (
 	"modular-fx-fiber/internal/core"
-	"modular-fx-fiber/internal/modules/auth"
-	"modular-fx-fiber/internal/modules/mailer"
-	"modular-fx-fiber/internal/modules/user"
+	"modular-fx-fiber/internal/modules"
 	"modular-fx-fiber/internal/shared"
 
 	"go.uber.org/fx"
@@ -23,6 +21,10 @@ import (
 // @securityDefinitions.apikey BearerAuth
 // @in header
 // @name Authorization
+// @securityDefinitions.basic BasicAuth
+// @securityDefinitions.apikey ApiKeyAuth
+// @in header
+// @name X-API-Key
 func main() {
 	fx.New(
 		// Core module
@@ -32,8 +34,6 @@ func main() {
 		shared.Module,
 
 		// Feature modules
-		user.Module,
-		auth.Module,
-		mailer.Module,
+		modules.Module,
 	).Run()
 }
@@ -13,16 +13,22 @@ import (
 
 // Config holds application configuration
 type Config struct {
-	App  AppConfig  `mapstructure:"app"`
-	DB   DBConfig   `mapstructure:"db"`
-	JWT  JWTConfig  `mapstructure:"jwt"`
-	Mail MailConfig `mapstructure:"mail"`
+	App   AppConfig   `mapstructure:"app"`
+	DB    DBConfig    `mapstructure:"db"`
+	JWT   JWTConfig   `mapstructure:"jwt"`
+	Auth  AuthConfig  `mapstructure:"auth"`
+	OAuth OAuthConfig `mapstructure:"oauth"`
+	Mail  MailConfig  `mapstructure:"mail"`
+	SMS   SMSConfig   `mapstructure:"sms"`
 }
 
 type AppConfig struct {
 	Port string `mapstructure:"port"`
 	Name string `mapstructure:"name"`
 	Env  string `mapstructure:"env"`
+
+	// FrontendURL is the base URL used to build links sent to users by email
+	FrontendURL string `mapstructure:"frontend_url"`
 }
 
 type DBConfig struct {
@@ -35,9 +41,171 @@ type DBConfig struct {
 }
 
 type JWTConfig struct {
-	Secret              string `mapstructure:"secret"`
-	AccessExpiryMinutes int    `mapstructure:"access_expiry_minutes"`
-	RefreshExpiryDays   int    `mapstructure:"refresh_expiry_days"`
+	Secret              string         `mapstructure:"secret"`
+	AccessExpiryMinutes int            `mapstructure:"access_expiry_minutes"`
+	RefreshExpiryDays   int            `mapstructure:"refresh_expiry_days"`
+	Keys                []JWTKeyConfig `mapstructure:"keys"`             // oldest first, the last key with a private key signs
+	RevocationStore     string         `mapstructure:"revocation_store"` // postgres or memory
+
+	// AcceptLegacyHS256 keeps verifying tokens signed with the secret once keys are configured,
+	// until AcceptLegacyHS256Until (RFC 3339), so tokens issued before the switch stay valid
+	AcceptLegacyHS256      bool   `mapstructure:"accept_legacy_hs256"`
+	AcceptLegacyHS256Until string `mapstructure:"accept_legacy_hs256_until"`
+}
+
+// JWTKeyConfig describes an asymmetric JWT key loaded from PEM files.
+// Keys with only a public key are kept to verify tokens signed before a rotation.
+type JWTKeyConfig struct {
+	ID             string `mapstructure:"id"`
+	Algorithm      string `mapstructure:"algorithm"` // RS256 or EdDSA
+	PrivateKeyFile string `mapstructure:"private_key_file"`
+	PublicKeyFile  string `mapstructure:"public_key_file"`
+}
+
+type AuthConfig struct {
+	PasswordResetExpiryMinutes int                   `mapstructure:"password_reset_expiry_minutes"`
+	MFA                        MFAConfig             `mapstructure:"mfa"`
+	Lockout                    LockoutConfig         `mapstructure:"lockout"`
+	MagicLink                  MagicLinkConfig       `mapstructure:"magic_link"`
+	Social                     SocialConfig          `mapstructure:"social"`
+	ServiceAccount             ServiceAccountConfig  `mapstructure:"service_account"`
+	PasswordHashing            PasswordHashingConfig `mapstructure:"password_hashing"`
+	PasswordPolicy             PasswordPolicyConfig  `mapstructure:"password_policy"`
+	Verification               VerificationConfig    `mapstructure:"verification"`
+	EmailChange                EmailChangeConfig     `mapstructure:"email_change"`
+	Phone                      PhoneConfig           `mapstructure:"phone"`
+	WebAuthn                   WebAuthnConfig        `mapstructure:"webauthn"`
+	RBAC                       RBACConfig            `mapstructure:"rbac"`
+}
+
+type MFAConfig struct {
+	Issuer                 string `mapstructure:"issuer"`
+	EncryptionKey          string `mapstructure:"encryption_key"` // base64 encoded 32-byte AES key
+	ChallengeExpiryMinutes int    `mapstructure:"challenge_expiry_minutes"`
+	MaxAttempts            int    `mapstructure:"max_attempts"` // wrong second factors before a challenge is invalidated
+	RecoveryCodeCount      int    `mapstructure:"recovery_code_count"`
+}
+
+// LockoutConfig controls the brute-force protection of the login endpoint.
+// Every failed attempt doubles the wait before the next one, starting at BaseDelaySeconds.
+type LockoutConfig struct {
+	MaxAttempts          int `mapstructure:"max_attempts"`    // failed attempts before an account is locked
+	IPMaxAttempts        int `mapstructure:"ip_max_attempts"` // failed attempts before a source IP is locked
+	LockoutMinutes       int `mapstructure:"lockout_minutes"`
+	AttemptWindowMinutes int `mapstructure:"attempt_window_minutes"` // failures older than this are forgotten
+	BaseDelaySeconds     int `mapstructure:"base_delay_seconds"`
+	MaxDelaySeconds      int `mapstructure:"max_delay_seconds"`
+}
+
+// VerificationConfig controls the codes sent to users by email or SMS
+type VerificationConfig struct {
+	CodeExpiryMinutes     int `mapstructure:"code_expiry_minutes"`
+	MaxAttempts           int `mapstructure:"max_attempts"`            // wrong guesses before a code is locked
+	ResendCooldownSeconds int `mapstructure:"resend_cooldown_seconds"` // minimum time between two codes
+}
+
+// EmailChangeConfig controls the links sent when a user changes their email address
+type EmailChangeConfig struct {
+	ConfirmExpiryMinutes int `mapstructure:"confirm_expiry_minutes"` // how long the new address has to confirm
+	UndoExpiryDays       int `mapstructure:"undo_expiry_days"`       // how long the old address can undo the change
+}
+
+// PhoneConfig limits the codes sent by SMS, for phone verification and login.
+// The limit applies per phone number, whatever account the codes are sent for.
+type PhoneConfig struct {
+	MaxPerNumber  int `mapstructure:"max_per_number"` // codes sent to one number per window
+	WindowMinutes int `mapstructure:"window_minutes"`
+}
+
+// WebAuthnConfig describes this API as a WebAuthn relying party for passkey login.
+// RPID is the domain passkeys are bound to, RPOrigins the frontend origins allowed to use them.
+type WebAuthnConfig struct {
+	RPID                  string   `mapstructure:"rp_id"`
+	RPDisplayName         string   `mapstructure:"rp_display_name"`
+	RPOrigins             []string `mapstructure:"rp_origins"`
+	SessionTimeoutSeconds int      `mapstructure:"session_timeout_seconds"` // time allowed to answer a challenge
+}
+
+// RBACConfig controls the role-based access control of routes
+type RBACConfig struct {
+	PermissionCacheSeconds int              `mapstructure:"permission_cache_seconds"` // how long the roles and permissions of a caller are cached
+	SyncOnStartup          bool             `mapstructure:"sync_on_startup"`          // upsert declared permissions and default roles when the server starts
+	DefaultRoles           []RoleSeedConfig `mapstructure:"default_roles"`
+}
+
+// RoleSeedConfig defines a role created by the permission sync. Permissions are "resource:action",
+// "resource:*" for every action on a resource or "*" for every permission.
+type RoleSeedConfig struct {
+	Name        string   `mapstructure:"name"`
+	Description string   `mapstructure:"description"`
+	Parent      string   `mapstructure:"parent"` // name of the role it inherits from, set when the role has no parent yet
+	Permissions []string `mapstructure:"permissions"`
+}
+
+type MagicLinkConfig struct {
+	ExpiryMinutes int `mapstructure:"expiry_minutes"`
+	MaxPerWindow  int `mapstructure:"max_per_window"` // links sent to one email per window
+	WindowMinutes int `mapstructure:"window_minutes"`
+}
+
+// SocialConfig lists the external identity providers users can log in with
+type SocialConfig struct {
+	StateExpiryMinutes int                    `mapstructure:"state_expiry_minutes"`
+	Providers          []SocialProviderConfig `mapstructure:"providers"`
+}
+
+// SocialProviderConfig describes an external identity provider.
+// Type "oidc" discovers the endpoints from IssuerURL, type "github" uses the GitHub API.
+type SocialProviderConfig struct {
+	Name         string   `mapstructure:"name"`
+	Type         string   `mapstructure:"type"`
+	IssuerURL    string   `mapstructure:"issuer_url"`
+	ClientID     string   `mapstructure:"client_id"`
+	ClientSecret string   `mapstructure:"client_secret"`
+	RedirectURL  string   `mapstructure:"redirect_url"` // frontend page that posts the code back to the callback endpoint
+	Scopes       []string `mapstructure:"scopes"`
+}
+
+// OAuthConfig controls the OAuth 2.0 / OpenID Connect provider endpoints
+type OAuthConfig struct {
+	Issuer                         string `mapstructure:"issuer"`                 // public base URL of this API, used as the "iss" claim
+	AuthorizationEndpoint          string `mapstructure:"authorization_endpoint"` // frontend page that asks the user for consent
+	AuthorizationCodeExpirySeconds int    `mapstructure:"authorization_code_expiry_seconds"`
+	ClientTokenExpiryMinutes       int    `mapstructure:"client_token_expiry_minutes"` // client_credentials access tokens
+}
+
+// PasswordHashingConfig selects the algorithm of new password hashes.
+// Hashes of the other algorithm, or with other parameters, are upgraded on the next login.
+type PasswordHashingConfig struct {
+	Algorithm  string         `mapstructure:"algorithm"` // argon2id or bcrypt
+	BcryptCost int            `mapstructure:"bcrypt_cost"`
+	Argon2id   Argon2idConfig `mapstructure:"argon2id"`
+}
+
+type Argon2idConfig struct {
+	MemoryKiB   uint32 `mapstructure:"memory_kib"`
+	Iterations  uint32 `mapstructure:"iterations"`
+	Parallelism uint8  `mapstructure:"parallelism"`
+	SaltLength  uint32 `mapstructure:"salt_length"` // in bytes
+	KeyLength   uint32 `mapstructure:"key_length"`  // in bytes
+}
+
+// PasswordPolicyConfig controls the rules new passwords must follow
+type PasswordPolicyConfig struct {
+	MinLength          int    `mapstructure:"min_length"`
+	MaxLength          int    `mapstructure:"max_length"` // keep at 72 or less with bcrypt, it ignores the rest
+	RequireLowercase   bool   `mapstructure:"require_lowercase"`
+	RequireUppercase   bool   `mapstructure:"require_uppercase"`
+	RequireDigit       bool   `mapstructure:"require_digit"`
+	RequireSymbol      bool   `mapstructure:"require_symbol"`
+	RejectPersonalInfo bool   `mapstructure:"reject_personal_info"` // reject passwords containing the email or names
+	BlocklistFile      string `mapstructure:"blocklist_file"`       // SHA-1 hashes of breached passwords, one per line
+}
+
+// ServiceAccountConfig controls the client_credentials tokens of service accounts
+type ServiceAccountConfig struct {
+	TokenExpiryMinutes int `mapstructure:"token_expiry_minutes"`
+	SecretGraceMinutes int `mapstructure:"secret_grace_minutes"` // how long replaced secrets keep working after a rotation
 }
 
 type MailConfig struct {
@@ -49,6 +217,21 @@ type MailConfig struct {
 	SMTPPassword string `mapstructure:"smtp_password"`
 }
 
+// SMSConfig selects how text messages are sent.
+// Provider "console" logs them, or appends them to ConsoleFile, provider "http" posts them to a gateway.
+type SMSConfig struct {
+	Provider    string        `mapstructure:"provider"`
+	ConsoleFile string        `mapstructure:"console_file"`
+	HTTP        SMSHTTPConfig `mapstructure:"http"`
+}
+
+type SMSHTTPConfig struct {
+	URL            string `mapstructure:"url"`
+	APIKey         string `mapstructure:"api_key"` // sent as a Bearer token
+	From           string `mapstructure:"from"`    // sender ID or number
+	TimeoutSeconds int    `mapstructure:"timeout_seconds"`
+}
+
 // NewConfig creates a new configuration instance
 func NewConfig(l *logger.ZapLogger) (*Config, error) {
 	// Get environment
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"modular-fx-fiber/internal/core/config"
 	appLogger "modular-fx-fiber/internal/shared/logger"
+	"modular-fx-fiber/internal/shared/password"
 	"modular-fx-fiber/internal/shared/validator"
 
 	"github.com/gofiber/fiber/v2"
@@ -85,8 +86,28 @@ func Start(lc fx.Lifecycle, s Server, l *appLogger.ZapLogger) {
 	})
 }
 
+// BadRequest answers a failed service call with 400. Password policy violations are returned
+// unchanged, customErrorHandler renders them with their reasons.
+func BadRequest(err error) error {
+	if errors.As(err, new(*password.PolicyError)) {
+		return err
+	}
+	return fiber.NewError(fiber.StatusBadRequest, err.Error())
+}
+
 func customErrorHandler(c *fiber.Ctx, err error) error {
 
+	// Password policy violations are returned with their machine-readable reasons
+	var policyErr *password.PolicyError
+	if errors.As(err, &policyErr) {
+		return c.Status(fiber.StatusBadRequest).JSON(password.PolicyErrorResponse{
+			Success:    false,
+			Message:    policyErr.Error(),
+			Status:     fiber.StatusBadRequest,
+			Violations: policyErr.Violations,
+		})
+	}
+
 	// Default status code is 500
 	code := fiber.StatusInternalServerError
 
@@ -0,0 +1,105 @@
+package auth
+
+import (
+	"modular-fx-fiber/internal/shared/dto/auth_dto"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// CreateAPIKey handles creating a personal API key
+// @Summary Create API key
+// @Description Create a personal API key for scripts and CI jobs, sent in the X-API-Key header. The key is only shown in this response
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body auth_dto.CreateAPIKeyDTO true "API key name, scopes and optional expiry"
+// @Success 201 {object} auth_dto.CreateAPIKeySuccessResponseDTO
+// @Router /auth/api-keys [post]
+func (h *handlers) CreateAPIKey(c *fiber.Ctx) error {
+	var createAPIKeyDto auth_dto.CreateAPIKeyDTO
+
+	// Parse request body
+	if err := c.BodyParser(&createAPIKeyDto); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	// Validate request body
+	errs := h.validator.Validate(&createAPIKeyDto)
+	if errs != nil {
+		err := h.validator.ParseErrorToString(errs)
+		return fiber.NewError(fiber.StatusBadRequest, err)
+	}
+
+	// Get user ID from context
+	userId := c.Locals("user_id").(uint64)
+
+	apiKey, err := h.service.CreateAPIKey(&createAPIKeyDto, userId)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	// Return response
+	return c.Status(fiber.StatusCreated).JSON(&auth_dto.CreateAPIKeySuccessResponseDTO{
+		Success: true,
+		Data:    apiKey,
+	})
+}
+
+// ListAPIKeys handles listing the current user's API keys
+// @Summary List API keys
+// @Description List the personal API keys of the current user
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} auth_dto.ListAPIKeysSuccessResponseDTO
+// @Router /auth/api-keys [get]
+func (h *handlers) ListAPIKeys(c *fiber.Ctx) error {
+	// Get user ID from context
+	userId := c.Locals("user_id").(uint64)
+
+	apiKeys, err := h.service.ListAPIKeys(userId)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	// Return response
+	return c.JSON(&auth_dto.ListAPIKeysSuccessResponseDTO{
+		Success: true,
+		Data:    apiKeys,
+	})
+}
+
+// RevokeAPIKey handles revoking an API key
+// @Summary Revoke API key
+// @Description Revoke one of the current user's API keys
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "API key ID"
+// @Success 200 {object} auth_dto.RevokeAPIKeySuccessResponseDTO
+// @Router /auth/api-keys/{id} [delete]
+func (h *handlers) RevokeAPIKey(c *fiber.Ctx) error {
+	apiKeyId, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid api key id")
+	}
+
+	// Get user ID from context
+	userId := c.Locals("user_id").(uint64)
+
+	if err := h.service.RevokeAPIKey(userId, apiKeyId); err != nil {
+		if err == ErrAPIKeyNotFound {
+			return fiber.NewError(fiber.StatusNotFound, err.Error())
+		}
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	// Return response
+	return c.JSON(&auth_dto.RevokeAPIKeySuccessResponseDTO{
+		Success: true,
+	})
+}
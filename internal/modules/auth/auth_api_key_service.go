@@ -0,0 +1,104 @@
+package auth
+
+import (
+	"errors"
+	"modular-fx-fiber/internal/shared/dto/auth_dto"
+	"modular-fx-fiber/internal/shared/middleware"
+	"modular-fx-fiber/internal/shared/models"
+	"modular-fx-fiber/internal/shared/util"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+var (
+	ErrAPIKeyNotFound       = errors.New("api key not found")
+	ErrAPIKeyScopesRequired = errors.New("at least one scope is required")
+	ErrInvalidAPIKeyExpiry  = errors.New("expiry must be in the future")
+)
+
+// CreateAPIKey creates a personal API key for the user.
+// The plaintext key is only returned here, the database keeps its hash.
+func (s *service) CreateAPIKey(dto *auth_dto.CreateAPIKeyDTO, userId uint64) (*auth_dto.CreatedAPIKeyDTO, error) {
+	if len(dto.Scopes) == 0 {
+		return nil, ErrAPIKeyScopesRequired
+	}
+	if dto.ExpiresAt != nil && !dto.ExpiresAt.After(time.Now()) {
+		return nil, ErrInvalidAPIKeyExpiry
+	}
+
+	token, err := util.GenerateRandomToken(32)
+	if err != nil {
+		s.logger.Error("Failed to generate api key", zap.Error(err))
+		return nil, err
+	}
+	key := models.API_KEY_PREFIX + token
+
+	apiKey := models.APIKey{
+		UserID:    userId,
+		Name:      dto.Name,
+		KeyPrefix: key[:middleware.APIKeyPrefixLength],
+		KeyHash:   util.HashToken(key),
+		Scopes:    dto.Scopes,
+		ExpiresAt: dto.ExpiresAt,
+	}
+	if err := s.apiKeyRepo.CreateAPIKey(&apiKey); err != nil {
+		s.logger.Error("Failed to save api key", zap.Uint64("user_id", userId), zap.Error(err))
+		return nil, err
+	}
+
+	s.logger.Info("API key created",
+		zap.Uint64("user_id", userId),
+		zap.Uint64("api_key_id", apiKey.ID),
+		zap.Strings("scopes", apiKey.Scopes))
+	return &auth_dto.CreatedAPIKeyDTO{
+		APIKeyDTO: *toAPIKeyDTO(&apiKey),
+		Key:       key,
+	}, nil
+}
+
+// ListAPIKeys lists the API keys of the user
+func (s *service) ListAPIKeys(userId uint64) ([]*auth_dto.APIKeyDTO, error) {
+	apiKeys, err := s.apiKeyRepo.ListUserAPIKeys(userId)
+	if err != nil {
+		s.logger.Error("Failed to list api keys", zap.Uint64("user_id", userId), zap.Error(err))
+		return nil, err
+	}
+
+	result := make([]*auth_dto.APIKeyDTO, 0, len(apiKeys))
+	for _, apiKey := range apiKeys {
+		result = append(result, toAPIKeyDTO(apiKey))
+	}
+	return result, nil
+}
+
+// RevokeAPIKey revokes one of the API keys of the user
+func (s *service) RevokeAPIKey(userId uint64, apiKeyId uint64) error {
+	deleted, err := s.apiKeyRepo.DeleteUserAPIKey(userId, apiKeyId)
+	if err != nil {
+		s.logger.Error("Failed to delete api key",
+			zap.Uint64("user_id", userId),
+			zap.Uint64("api_key_id", apiKeyId),
+			zap.Error(err))
+		return err
+	}
+	if !deleted {
+		return ErrAPIKeyNotFound
+	}
+
+	s.logger.Info("API key revoked", zap.Uint64("user_id", userId), zap.Uint64("api_key_id", apiKeyId))
+	return nil
+}
+
+// toAPIKeyDTO converts an API key to its response representation
+func toAPIKeyDTO(apiKey *models.APIKey) *auth_dto.APIKeyDTO {
+	return &auth_dto.APIKeyDTO{
+		ID:         apiKey.ID,
+		Name:       apiKey.Name,
+		KeyPrefix:  apiKey.KeyPrefix,
+		Scopes:     apiKey.Scopes,
+		ExpiresAt:  apiKey.ExpiresAt,
+		LastUsedAt: apiKey.LastUsedAt,
+		CreatedAt:  apiKey.CreatedAt,
+	}
+}
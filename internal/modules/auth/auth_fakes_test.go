@@ -0,0 +1,321 @@
+package auth
+
+import (
+	"bytes"
+	"modular-fx-fiber/internal/core/config"
+	"modular-fx-fiber/internal/shared/jwks"
+	"modular-fx-fiber/internal/shared/logger"
+	"modular-fx-fiber/internal/shared/models"
+	"modular-fx-fiber/internal/shared/password"
+	"modular-fx-fiber/internal/shared/repositories"
+	"modular-fx-fiber/internal/shared/revocation"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// The fakes below keep their rows in memory. They embed the repository interface they stand in for,
+// so calling a method a test did not expect panics instead of silently doing nothing.
+
+type fakeUserRepo struct {
+	repositories.UserRepository
+	users  map[uint64]*models.User
+	nextID uint64
+}
+
+func (r *fakeUserRepo) Create(user *models.User) error {
+	r.nextID++
+	user.ID = r.nextID
+	stored := *user
+	r.users[user.ID] = &stored
+	return nil
+}
+
+func (r *fakeUserRepo) Update(user *models.User) error {
+	stored := *user
+	r.users[user.ID] = &stored
+	return nil
+}
+
+func (r *fakeUserRepo) GetByID(id uint64) (*models.User, error) {
+	if u, ok := r.users[id]; ok {
+		copied := *u
+		return &copied, nil
+	}
+	return nil, nil
+}
+
+func (r *fakeUserRepo) GetByEmail(email string) (*models.User, error) {
+	for _, u := range r.users {
+		if u.Email == email {
+			copied := *u
+			return &copied, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *fakeUserRepo) GetByWebAuthnHandle(handle []byte) (*models.User, error) {
+	for _, u := range r.users {
+		if len(u.WebAuthnHandle) > 0 && bytes.Equal(u.WebAuthnHandle, handle) {
+			copied := *u
+			return &copied, nil
+		}
+	}
+	return nil, nil
+}
+
+type fakeRefreshTokenRepo struct {
+	repositories.RefreshTokenRepository
+	tokens []*models.RefreshToken
+}
+
+func (r *fakeRefreshTokenRepo) SaveRefreshToken(token *models.RefreshToken) error {
+	token.ID = uint64(len(r.tokens) + 1)
+	r.tokens = append(r.tokens, token)
+	return nil
+}
+
+type fakeTOTPSecretRepo struct {
+	repositories.TOTPSecretRepository
+}
+
+func (r *fakeTOTPSecretRepo) GetTOTPSecret(userID uint64) (*models.UserTOTPSecret, error) {
+	return nil, nil
+}
+
+type fakeLoginThrottleRepo struct {
+	repositories.LoginThrottleRepository
+	throttles map[string]*models.LoginThrottle
+}
+
+func (r *fakeLoginThrottleRepo) GetThrottle(keyType string, key string) (*models.LoginThrottle, error) {
+	if throttle, ok := r.throttles[keyType+"/"+key]; ok {
+		copied := *throttle
+		return &copied, nil
+	}
+	return nil, nil
+}
+
+func (r *fakeLoginThrottleRepo) RecordFailure(keyType string, key string, windowStart time.Time) (*models.LoginThrottle, error) {
+	now := time.Now()
+	throttle, ok := r.throttles[keyType+"/"+key]
+	if !ok {
+		throttle = &models.LoginThrottle{ID: uint64(len(r.throttles) + 1), KeyType: keyType, Key: key}
+		r.throttles[keyType+"/"+key] = throttle
+	}
+	if throttle.LastFailedAt != nil && throttle.LastFailedAt.Before(windowStart) {
+		throttle.FailedAttempts = 0
+	}
+	throttle.FailedAttempts++
+	throttle.LastFailedAt = &now
+
+	copied := *throttle
+	return &copied, nil
+}
+
+func (r *fakeLoginThrottleRepo) LockThrottle(id uint64, until time.Time) error {
+	for _, throttle := range r.throttles {
+		if throttle.ID == id {
+			throttle.LockedUntil = &until
+			throttle.FailedAttempts = 0
+		}
+	}
+	return nil
+}
+
+func (r *fakeLoginThrottleRepo) DeleteThrottle(keyType string, key string) error {
+	delete(r.throttles, keyType+"/"+key)
+	return nil
+}
+
+type fakeUserIdentityRepo struct {
+	repositories.UserIdentityRepository
+	identities []*models.UserIdentity
+}
+
+func (r *fakeUserIdentityRepo) CreateIdentity(identity *models.UserIdentity) error {
+	identity.ID = uint64(len(r.identities) + 1)
+	r.identities = append(r.identities, identity)
+	return nil
+}
+
+func (r *fakeUserIdentityRepo) GetIdentity(provider string, subject string) (*models.UserIdentity, error) {
+	for _, identity := range r.identities {
+		if identity.Provider == provider && identity.Subject == subject {
+			return identity, nil
+		}
+	}
+	return nil, nil
+}
+
+type fakeSocialLoginStateRepo struct {
+	repositories.SocialLoginStateRepository
+	states map[string]*models.SocialLoginState
+}
+
+func (r *fakeSocialLoginStateRepo) SaveLoginState(state *models.SocialLoginState) error {
+	r.states[state.StateHash] = state
+	return nil
+}
+
+func (r *fakeSocialLoginStateRepo) ConsumeLoginState(stateHash string) (*models.SocialLoginState, error) {
+	state, ok := r.states[stateHash]
+	if !ok || time.Now().After(state.ExpiresAt) {
+		return nil, nil
+	}
+	delete(r.states, stateHash)
+	return state, nil
+}
+
+type fakeWebAuthnCredentialRepo struct {
+	repositories.WebAuthnCredentialRepository
+	credentials []*models.WebAuthnCredential
+}
+
+func (r *fakeWebAuthnCredentialRepo) CreateCredential(credential *models.WebAuthnCredential) error {
+	credential.ID = uint64(len(r.credentials) + 1)
+	credential.CreatedAt = time.Now()
+	r.credentials = append(r.credentials, credential)
+	return nil
+}
+
+func (r *fakeWebAuthnCredentialRepo) ListUserCredentials(userID uint64) ([]*models.WebAuthnCredential, error) {
+	credentials := []*models.WebAuthnCredential{}
+	for _, credential := range r.credentials {
+		if credential.UserID == userID {
+			copied := *credential
+			credentials = append(credentials, &copied)
+		}
+	}
+	return credentials, nil
+}
+
+func (r *fakeWebAuthnCredentialRepo) UpdateCredentialUse(credential *models.WebAuthnCredential) error {
+	for i, stored := range r.credentials {
+		if stored.ID == credential.ID {
+			now := time.Now()
+			copied := *credential
+			copied.LastUsedAt = &now
+			r.credentials[i] = &copied
+		}
+	}
+	return nil
+}
+
+type fakeWebAuthnSessionRepo struct {
+	repositories.WebAuthnSessionRepository
+	sessions map[string]*models.WebAuthnSession
+}
+
+func (r *fakeWebAuthnSessionRepo) SaveSession(session *models.WebAuthnSession) error {
+	r.sessions[session.SessionHash] = session
+	return nil
+}
+
+func (r *fakeWebAuthnSessionRepo) ConsumeSession(sessionHash string, ceremony string) (*models.WebAuthnSession, error) {
+	session, ok := r.sessions[sessionHash]
+	if !ok || session.Ceremony != ceremony || time.Now().After(session.ExpiresAt) {
+		return nil, nil
+	}
+	delete(r.sessions, sessionHash)
+	return session, nil
+}
+
+// testService is an auth service wired to in-memory fakes
+type testService struct {
+	*service
+
+	users          *fakeUserRepo
+	refreshTokens  *fakeRefreshTokenRepo
+	throttles      *fakeLoginThrottleRepo
+	identities     *fakeUserIdentityRepo
+	credentials    *fakeWebAuthnCredentialRepo
+	webAuthnStates *fakeWebAuthnSessionRepo
+}
+
+// newTestConfig returns the configuration the tests run with, signing tokens with HS256
+func newTestConfig() *config.Config {
+	c := &config.Config{}
+	c.JWT.Secret = "test-secret"
+	c.JWT.AccessExpiryMinutes = 15
+	c.JWT.RefreshExpiryDays = 7
+	c.Auth.MFA.ChallengeExpiryMinutes = 5
+	c.Auth.MFA.MaxAttempts = 3
+	c.Auth.Lockout = config.LockoutConfig{
+		MaxAttempts:          5,
+		IPMaxAttempts:        20,
+		LockoutMinutes:       15,
+		AttemptWindowMinutes: 15,
+	}
+	c.Auth.Social.StateExpiryMinutes = 10
+	c.Auth.PasswordHashing.Algorithm = password.AlgorithmBcrypt
+	c.Auth.PasswordHashing.BcryptCost = 4
+	c.Auth.WebAuthn = config.WebAuthnConfig{
+		RPID:                  "example.com",
+		RPDisplayName:         "Example",
+		RPOrigins:             []string{"https://example.com"},
+		SessionTimeoutSeconds: 300,
+	}
+	return c
+}
+
+// newTestService creates an auth service for the configuration and identity providers
+func newTestService(t *testing.T, c *config.Config, providers IdentityProviders) *testService {
+	t.Helper()
+
+	l := &logger.ZapLogger{Logger: zap.NewNop()}
+	keyManager, err := jwks.NewKeyManager(c, l)
+	if err != nil {
+		t.Fatalf("NewKeyManager: %v", err)
+	}
+	hasher, err := password.NewHasher(c)
+	if err != nil {
+		t.Fatalf("NewHasher: %v", err)
+	}
+	webAuthn, err := NewWebAuthn(c)
+	if err != nil {
+		t.Fatalf("NewWebAuthn: %v", err)
+	}
+
+	ts := &testService{
+		users:          &fakeUserRepo{users: map[uint64]*models.User{}},
+		refreshTokens:  &fakeRefreshTokenRepo{},
+		throttles:      &fakeLoginThrottleRepo{throttles: map[string]*models.LoginThrottle{}},
+		identities:     &fakeUserIdentityRepo{},
+		credentials:    &fakeWebAuthnCredentialRepo{},
+		webAuthnStates: &fakeWebAuthnSessionRepo{sessions: map[string]*models.WebAuthnSession{}},
+	}
+	ts.service = &service{
+		config:                 c,
+		logger:                 l,
+		keyManager:             keyManager,
+		revocation:             revocation.NewMemoryStore(),
+		passwordHasher:         hasher,
+		identityProviders:      providers,
+		webAuthn:               webAuthn,
+		userRepo:               ts.users,
+		refreshTokenRepo:       ts.refreshTokens,
+		totpSecretRepo:         &fakeTOTPSecretRepo{},
+		loginThrottleRepo:      ts.throttles,
+		userIdentityRepo:       ts.identities,
+		socialLoginStateRepo:   &fakeSocialLoginStateRepo{states: map[string]*models.SocialLoginState{}},
+		webAuthnCredentialRepo: ts.credentials,
+		webAuthnSessionRepo:    ts.webAuthnStates,
+	}
+	return ts
+}
+
+// addUser stores an active user and returns it
+func (ts *testService) addUser(t *testing.T, u *models.User) *models.User {
+	t.Helper()
+
+	if u.Status == 0 {
+		u.Status = models.USER_STATUS_ACTIVE
+	}
+	if err := ts.users.Create(u); err != nil {
+		t.Fatalf("Create user: %v", err)
+	}
+	return u
+}
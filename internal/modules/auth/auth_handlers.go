@@ -1,9 +1,13 @@
 package auth
 
 import (
+	"errors"
+	"math"
+	"modular-fx-fiber/internal/core/server"
 	"modular-fx-fiber/internal/shared/dto/auth_dto"
 	"modular-fx-fiber/internal/shared/logger"
 	"modular-fx-fiber/internal/shared/validator"
+	"strconv"
 
 	"github.com/gofiber/fiber/v2"
 )
@@ -16,6 +20,37 @@ type (
 		Register(c *fiber.Ctx) error
 		RefreshToken(c *fiber.Ctx) error
 		VerifyEmail(c *fiber.Ctx) error
+		ResendVerification(c *fiber.Ctx) error
+		ForgotPassword(c *fiber.Ctx) error
+		ResetPassword(c *fiber.Ctx) error
+		EnrollTOTP(c *fiber.Ctx) error
+		ConfirmTOTP(c *fiber.Ctx) error
+		DisableTOTP(c *fiber.Ctx) error
+		VerifyMFA(c *fiber.Ctx) error
+		SendMFASMSCode(c *fiber.Ctx) error
+		EnableSMSMFA(c *fiber.Ctx) error
+		DisableSMSMFA(c *fiber.Ctx) error
+		SendPhoneVerification(c *fiber.Ctx) error
+		VerifyPhone(c *fiber.Ctx) error
+		ListSessions(c *fiber.Ctx) error
+		RevokeSession(c *fiber.Ctx) error
+		RevokeOtherSessions(c *fiber.Ctx) error
+		SendMagicLink(c *fiber.Ctx) error
+		ConsumeMagicLink(c *fiber.Ctx) error
+		StartSocialLogin(c *fiber.Ctx) error
+		CompleteSocialLogin(c *fiber.Ctx) error
+		CreateAPIKey(c *fiber.Ctx) error
+		ListAPIKeys(c *fiber.Ctx) error
+		RevokeAPIKey(c *fiber.Ctx) error
+		ServiceAccountToken(c *fiber.Ctx) error
+		BeginWebAuthnRegistration(c *fiber.Ctx) error
+		FinishWebAuthnRegistration(c *fiber.Ctx) error
+		ListWebAuthnCredentials(c *fiber.Ctx) error
+		DeleteWebAuthnCredential(c *fiber.Ctx) error
+		BeginPasskeyLogin(c *fiber.Ctx) error
+		FinishPasskeyLogin(c *fiber.Ctx) error
+		BeginWebAuthnMFA(c *fiber.Ctx) error
+		FinishWebAuthnMFA(c *fiber.Ctx) error
 	}
 
 	handlers struct {
@@ -36,7 +71,7 @@ func NewHandlers(s Service, l *logger.ZapLogger, v *validator.Validator) Handler
 
 // Login handles user login
 // @Summary User login
-// @Description Authenticate a user and return tokens
+// @Description Authenticate a user and return tokens. Users with MFA enabled receive an mfa_required challenge instead, see /auth/mfa/verify. Repeated failures are answered with 429 and a Retry-After header
 // @Tags auth
 // @Accept json
 // @Produce json
@@ -59,9 +94,17 @@ func (h *handlers) Login(c *fiber.Ctx) error {
 	}
 
 	// Login user
-	tokens, err := h.service.Login(&loginDto)
+	tokens, challenge, err := h.service.Login(&loginDto, clientInfo(c, loginDto.DeviceLabel))
 	if err != nil {
-		return fiber.NewError(fiber.StatusUnauthorized, err.Error())
+		return loginError(c, err)
+	}
+
+	// A second factor is required before tokens are issued
+	if challenge != nil {
+		return c.JSON(&auth_dto.LoginMFARequiredResponseDTO{
+			Success: true,
+			Data:    challenge,
+		})
 	}
 
 	// Return response
@@ -106,6 +149,7 @@ func (h *handlers) Logout(c *fiber.Ctx) error {
 // @Produce json
 // @Param user body auth_dto.RegisterDTO true "Registration data"
 // @Success 201 {object} auth_dto.RegisterSuccessResponseDTO
+// @Failure 400 {object} password.PolicyErrorResponse
 // @Router /auth/register [post]
 func (h *handlers) Register(c *fiber.Ctx) error {
 	var registerDto auth_dto.RegisterDTO
@@ -123,9 +167,9 @@ func (h *handlers) Register(c *fiber.Ctx) error {
 	}
 
 	// Register user
-	tokens, err := h.service.Register(&registerDto)
+	tokens, err := h.service.Register(&registerDto, clientInfo(c, registerDto.DeviceLabel))
 	if err != nil {
-		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+		return server.BadRequest(err)
 	}
 
 	// Return response
@@ -160,7 +204,7 @@ func (h *handlers) RefreshToken(c *fiber.Ctx) error {
 	}
 
 	// Refresh token
-	tokens, err := h.service.RefreshToken(&refreshDto)
+	tokens, err := h.service.RefreshToken(&refreshDto, clientInfo(c, ""))
 	if err != nil {
 		return fiber.NewError(fiber.StatusBadRequest, err.Error())
 	}
@@ -203,6 +247,9 @@ func (h *handlers) VerifyEmail(c *fiber.Ctx) error {
 	// check verification code
 	err := h.service.VerifyEmail(&verifyDto, userId)
 	if err != nil {
+		if err == ErrVerificationCodeLocked {
+			return fiber.NewError(fiber.StatusTooManyRequests, err.Error())
+		}
 		return fiber.NewError(fiber.StatusBadRequest, err.Error())
 	}
 
@@ -212,3 +259,113 @@ func (h *handlers) VerifyEmail(c *fiber.Ctx) error {
 	})
 
 }
+
+// ResendVerification handles sending a new email verification code
+// @Summary Resend verification code
+// @Description Send a new email verification code, the previous one stops working. Codes can only be requested once per cooldown, Retry-After tells when the next one can be sent
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} auth_dto.ResendVerificationSuccessResponseDTO
+// @Failure 429 {object} map[string]string
+// @Router /auth/register/resend-verification [post]
+func (h *handlers) ResendVerification(c *fiber.Ctx) error {
+	// Get user ID from context
+	userId := c.Locals("user_id").(uint64)
+
+	if err := h.service.ResendVerification(userId); err != nil {
+		var cooldown *VerificationCooldownError
+		if errors.As(err, &cooldown) {
+			c.Set(fiber.HeaderRetryAfter, strconv.Itoa(int(math.Ceil(cooldown.RetryAfter.Seconds()))))
+			return fiber.NewError(fiber.StatusTooManyRequests, err.Error())
+		}
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	// Return response
+	return c.JSON(&auth_dto.ResendVerificationSuccessResponseDTO{
+		Success: true,
+	})
+}
+
+// ForgotPassword handles password reset requests
+// @Summary Forgot password
+// @Description Send a password reset link to the email if it belongs to an account
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param email body auth_dto.ForgotPasswordDTO true "Account email"
+// @Success 200 {object} auth_dto.ForgotPasswordSuccessResponseDTO
+// @Router /auth/password/forgot [post]
+func (h *handlers) ForgotPassword(c *fiber.Ctx) error {
+	var forgotDto auth_dto.ForgotPasswordDTO
+
+	// Parse request body
+	if err := c.BodyParser(&forgotDto); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	// Validate request body
+	errs := h.validator.Validate(forgotDto)
+	if errs != nil {
+		err := h.validator.ParseErrorToString(errs)
+		return fiber.NewError(fiber.StatusBadRequest, err)
+	}
+
+	// Send reset email, the response is the same whether the email exists or not
+	if err := h.service.ForgotPassword(&forgotDto); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	// Return response
+	return c.Status(fiber.StatusOK).JSON(&auth_dto.ForgotPasswordSuccessResponseDTO{
+		Success: true,
+	})
+}
+
+// ResetPassword handles password reset
+// @Summary Reset password
+// @Description Set a new password using a password reset token and log out all sessions
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param reset body auth_dto.ResetPasswordDTO true "Reset token and new password"
+// @Success 200 {object} auth_dto.ResetPasswordSuccessResponseDTO
+// @Failure 400 {object} password.PolicyErrorResponse
+// @Router /auth/password/reset [post]
+func (h *handlers) ResetPassword(c *fiber.Ctx) error {
+	var resetDto auth_dto.ResetPasswordDTO
+
+	// Parse request body
+	if err := c.BodyParser(&resetDto); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	// Validate request body
+	errs := h.validator.Validate(resetDto)
+	if errs != nil {
+		err := h.validator.ParseErrorToString(errs)
+		return fiber.NewError(fiber.StatusBadRequest, err)
+	}
+
+	// Reset password
+	if err := h.service.ResetPassword(&resetDto); err != nil {
+		return server.BadRequest(err)
+	}
+
+	// Return response
+	return c.Status(fiber.StatusOK).JSON(&auth_dto.ResetPasswordSuccessResponseDTO{
+		Success: true,
+	})
+}
+
+// loginError answers 429 with Retry-After when login attempts are throttled, and 401 otherwise
+func loginError(c *fiber.Ctx, err error) error {
+	var throttled *LoginThrottledError
+	if errors.As(err, &throttled) {
+		c.Set(fiber.HeaderRetryAfter, strconv.Itoa(int(math.Ceil(throttled.RetryAfter.Seconds()))))
+		return fiber.NewError(fiber.StatusTooManyRequests, err.Error())
+	}
+	return fiber.NewError(fiber.StatusUnauthorized, err.Error())
+}
@@ -0,0 +1,259 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"modular-fx-fiber/internal/core/config"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/github"
+)
+
+// Identity provider types accepted in auth.social.providers
+const (
+	identityProviderTypeOIDC   = "oidc"
+	identityProviderTypeGitHub = "github"
+)
+
+type (
+	// IdentityProvider is an external provider users can log in with through the
+	// authorization code flow with PKCE
+	IdentityProvider interface {
+		// Name is the provider name used in routes and stored with linked identities
+		Name() string
+		// AuthCodeURL builds the URL the user is sent to, carrying the state, the nonce
+		// and the S256 challenge of the code verifier
+		AuthCodeURL(ctx context.Context, state string, nonce string, codeVerifier string) (string, error)
+		// Exchange redeems an authorization code and returns the verified identity of the user
+		Exchange(ctx context.Context, code string, codeVerifier string, nonce string) (*ExternalIdentity, error)
+	}
+
+	// IdentityProviders are the configured identity providers keyed by name
+	IdentityProviders map[string]IdentityProvider
+
+	// ExternalIdentity is the user as described by an identity provider
+	ExternalIdentity struct {
+		Subject       string
+		Email         string
+		EmailVerified bool
+		FirstName     string
+		LastName      string
+	}
+
+	// oidcProvider is an OpenID Connect provider, its endpoints and keys are discovered
+	// from the issuer on first use
+	oidcProvider struct {
+		config config.SocialProviderConfig
+
+		mu       sync.Mutex
+		oauth2   *oauth2.Config
+		verifier *oidc.IDTokenVerifier
+	}
+
+	// githubProvider logs users in with GitHub, which only supports plain OAuth2,
+	// so the identity is read from the GitHub API
+	githubProvider struct {
+		name   string
+		oauth2 *oauth2.Config
+	}
+)
+
+// NewIdentityProviders creates the identity providers listed in auth.social.providers
+func NewIdentityProviders(c *config.Config) (IdentityProviders, error) {
+	providers := make(IdentityProviders)
+	for _, providerConfig := range c.Auth.Social.Providers {
+		if providerConfig.Name == "" {
+			return nil, errors.New("identity provider without name")
+		}
+		if _, ok := providers[providerConfig.Name]; ok {
+			return nil, fmt.Errorf("duplicate identity provider %q", providerConfig.Name)
+		}
+
+		switch providerConfig.Type {
+		case identityProviderTypeOIDC:
+			if providerConfig.IssuerURL == "" {
+				return nil, fmt.Errorf("identity provider %q has no issuer_url", providerConfig.Name)
+			}
+			providers[providerConfig.Name] = &oidcProvider{config: providerConfig}
+		case identityProviderTypeGitHub:
+			providers[providerConfig.Name] = &githubProvider{
+				name: providerConfig.Name,
+				oauth2: &oauth2.Config{
+					ClientID:     providerConfig.ClientID,
+					ClientSecret: providerConfig.ClientSecret,
+					RedirectURL:  providerConfig.RedirectURL,
+					Endpoint:     github.Endpoint,
+					Scopes:       providerConfig.Scopes,
+				},
+			}
+		default:
+			return nil, fmt.Errorf("identity provider %q has unsupported type %q", providerConfig.Name, providerConfig.Type)
+		}
+	}
+	return providers, nil
+}
+
+func (p *oidcProvider) Name() string {
+	return p.config.Name
+}
+
+// discover fetches the discovery document of the issuer once it succeeded
+func (p *oidcProvider) discover(ctx context.Context) (*oauth2.Config, *oidc.IDTokenVerifier, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.oauth2 != nil {
+		return p.oauth2, p.verifier, nil
+	}
+
+	provider, err := oidc.NewProvider(ctx, p.config.IssuerURL)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	scopes := p.config.Scopes
+	if len(scopes) == 0 {
+		scopes = []string{oidc.ScopeOpenID, "email", "profile"}
+	}
+
+	p.oauth2 = &oauth2.Config{
+		ClientID:     p.config.ClientID,
+		ClientSecret: p.config.ClientSecret,
+		RedirectURL:  p.config.RedirectURL,
+		Endpoint:     provider.Endpoint(),
+		Scopes:       scopes,
+	}
+	p.verifier = provider.Verifier(&oidc.Config{ClientID: p.config.ClientID})
+	return p.oauth2, p.verifier, nil
+}
+
+func (p *oidcProvider) AuthCodeURL(ctx context.Context, state string, nonce string, codeVerifier string) (string, error) {
+	oauth2Config, _, err := p.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+	return oauth2Config.AuthCodeURL(state, oidc.Nonce(nonce), oauth2.S256ChallengeOption(codeVerifier)), nil
+}
+
+// Exchange redeems the code and verifies the ID token signature against the provider's JWKS,
+// its audience, expiry and nonce
+func (p *oidcProvider) Exchange(ctx context.Context, code string, codeVerifier string, nonce string) (*ExternalIdentity, error) {
+	oauth2Config, verifier, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := oauth2Config.Exchange(ctx, code, oauth2.VerifierOption(codeVerifier))
+	if err != nil {
+		return nil, err
+	}
+
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok || rawIDToken == "" {
+		return nil, errors.New("token response has no id_token")
+	}
+
+	idToken, err := verifier.Verify(ctx, rawIDToken)
+	if err != nil {
+		return nil, err
+	}
+	if idToken.Nonce != nonce {
+		return nil, errors.New("id_token nonce mismatch")
+	}
+
+	var claims struct {
+		Email         string `json:"email"`
+		EmailVerified any    `json:"email_verified"`
+		GivenName     string `json:"given_name"`
+		FamilyName    string `json:"family_name"`
+	}
+	if err := idToken.Claims(&claims); err != nil {
+		return nil, err
+	}
+
+	return &ExternalIdentity{
+		Subject:       idToken.Subject,
+		Email:         strings.ToLower(claims.Email),
+		EmailVerified: isTrueClaim(claims.EmailVerified),
+		FirstName:     claims.GivenName,
+		LastName:      claims.FamilyName,
+	}, nil
+}
+
+func (p *githubProvider) Name() string {
+	return p.name
+}
+
+func (p *githubProvider) AuthCodeURL(ctx context.Context, state string, nonce string, codeVerifier string) (string, error) {
+	// GitHub has no ID token, the nonce is only checked for OIDC providers
+	return p.oauth2.AuthCodeURL(state, oauth2.S256ChallengeOption(codeVerifier)), nil
+}
+
+// Exchange redeems the code and reads the user and their primary email from the GitHub API
+func (p *githubProvider) Exchange(ctx context.Context, code string, codeVerifier string, nonce string) (*ExternalIdentity, error) {
+	token, err := p.oauth2.Exchange(ctx, code, oauth2.VerifierOption(codeVerifier))
+	if err != nil {
+		return nil, err
+	}
+	client := p.oauth2.Client(ctx, token)
+
+	var user struct {
+		ID   int64  `json:"id"`
+		Name string `json:"name"`
+	}
+	if err := getJSON(client, "https://api.github.com/user", &user); err != nil {
+		return nil, err
+	}
+
+	var emails []struct {
+		Email    string `json:"email"`
+		Primary  bool   `json:"primary"`
+		Verified bool   `json:"verified"`
+	}
+	if err := getJSON(client, "https://api.github.com/user/emails", &emails); err != nil {
+		return nil, err
+	}
+
+	identity := &ExternalIdentity{Subject: strconv.FormatInt(user.ID, 10)}
+	identity.FirstName, identity.LastName, _ = strings.Cut(user.Name, " ")
+	for _, email := range emails {
+		if email.Primary {
+			identity.Email = strings.ToLower(email.Email)
+			identity.EmailVerified = email.Verified
+		}
+	}
+	return identity, nil
+}
+
+// getJSON fetches a JSON document with an authenticated client
+func getJSON(client *http.Client, url string, target any) error {
+	resp, err := client.Get(url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s: unexpected status %d", url, resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(target)
+}
+
+// isTrueClaim reads a boolean claim, some providers send "true" as a string
+func isTrueClaim(value any) bool {
+	switch v := value.(type) {
+	case bool:
+		return v
+	case string:
+		return v == "true"
+	default:
+		return false
+	}
+}
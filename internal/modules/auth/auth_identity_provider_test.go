@@ -0,0 +1,392 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"modular-fx-fiber/internal/core/config"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"golang.org/x/oauth2"
+)
+
+const (
+	stubClientID     = "stub-client"
+	stubClientSecret = "stub-secret"
+	stubRedirectURL  = "https://app.example.com/social/callback"
+	stubSubject      = "stub-subject-1"
+	stubKeyID        = "stub-key"
+)
+
+// stubIssuer is an OpenID Connect provider serving discovery, JWKS, an authorization endpoint
+// that redirects straight back with a code, and a token endpoint returning a signed ID token
+type stubIssuer struct {
+	t      *testing.T
+	server *httptest.Server
+	key    *rsa.PrivateKey
+
+	// Set by the authorization endpoint, the ID token carries the nonce of the last authorization
+	nonce         string
+	codeChallenge string
+	code          string
+
+	// idTokenClaims changes the claims of the ID token before it is signed
+	idTokenClaims func(claims jwt.MapClaims)
+	// signingKey signs the ID token instead of the key published in the JWKS
+	signingKey *rsa.PrivateKey
+}
+
+func newStubIssuer(t *testing.T) *stubIssuer {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+
+	stub := &stubIssuer{t: t, key: key}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", stub.discovery)
+	mux.HandleFunc("/jwks", stub.jwks)
+	mux.HandleFunc("/authorize", stub.authorize)
+	mux.HandleFunc("/token", stub.token)
+	stub.server = httptest.NewServer(mux)
+	t.Cleanup(stub.server.Close)
+	return stub
+}
+
+// providerConfig returns the configuration of an oidc identity provider pointing at the stub
+func (s *stubIssuer) providerConfig() config.SocialProviderConfig {
+	return config.SocialProviderConfig{
+		Name:         "stub",
+		Type:         identityProviderTypeOIDC,
+		IssuerURL:    s.server.URL,
+		ClientID:     stubClientID,
+		ClientSecret: stubClientSecret,
+		RedirectURL:  stubRedirectURL,
+	}
+}
+
+func (s *stubIssuer) discovery(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]any{
+		"issuer":                                s.server.URL,
+		"authorization_endpoint":                s.server.URL + "/authorize",
+		"token_endpoint":                        s.server.URL + "/token",
+		"jwks_uri":                              s.server.URL + "/jwks",
+		"response_types_supported":              []string{"code"},
+		"subject_types_supported":               []string{"public"},
+		"id_token_signing_alg_values_supported": []string{"RS256"},
+	})
+}
+
+func (s *stubIssuer) jwks(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]any{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": stubKeyID,
+			"use": "sig",
+			"alg": "RS256",
+			"n":   base64.RawURLEncoding.EncodeToString(s.key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(s.key.E)).Bytes()),
+		}},
+	})
+}
+
+// authorize logs the user in at once and redirects back with a code
+func (s *stubIssuer) authorize(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	if query.Get("client_id") != stubClientID || query.Get("code_challenge_method") != "S256" {
+		http.Error(w, "invalid authorization request", http.StatusBadRequest)
+		return
+	}
+
+	s.nonce = query.Get("nonce")
+	s.codeChallenge = query.Get("code_challenge")
+	s.code = "code-" + query.Get("state")
+
+	redirect := query.Get("redirect_uri") + "?" + url.Values{
+		"code":  {s.code},
+		"state": {query.Get("state")},
+	}.Encode()
+	http.Redirect(w, r, redirect, http.StatusFound)
+}
+
+// token redeems the code once the PKCE verifier matches the challenge of the authorization
+func (s *stubIssuer) token(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	clientID, clientSecret, ok := r.BasicAuth()
+	if !ok {
+		clientID, clientSecret = r.PostForm.Get("client_id"), r.PostForm.Get("client_secret")
+	}
+	if clientID != stubClientID || clientSecret != stubClientSecret {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid_client"})
+		return
+	}
+
+	verifier := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+	if r.PostForm.Get("code") != s.code || base64.RawURLEncoding.EncodeToString(verifier[:]) != s.codeChallenge {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+		return
+	}
+
+	now := time.Now()
+	claims := jwt.MapClaims{
+		"iss":            s.server.URL,
+		"sub":            stubSubject,
+		"aud":            stubClientID,
+		"iat":            now.Unix(),
+		"exp":            now.Add(5 * time.Minute).Unix(),
+		"nonce":          s.nonce,
+		"email":          "Jane.Doe@Example.com",
+		"email_verified": true,
+		"given_name":     "Jane",
+		"family_name":    "Doe",
+	}
+	if s.idTokenClaims != nil {
+		s.idTokenClaims(claims)
+	}
+
+	signingKey := s.key
+	if s.signingKey != nil {
+		signingKey = s.signingKey
+	}
+	idToken := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	idToken.Header["kid"] = stubKeyID
+	rawIDToken, err := idToken.SignedString(signingKey)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"access_token": "stub-access-token",
+		"token_type":   "Bearer",
+		"expires_in":   300,
+		"id_token":     rawIDToken,
+	})
+}
+
+// login follows the authorization URL like a browser and returns the code it was redirected back with
+func (s *stubIssuer) login(authorizationURL string) string {
+	s.t.Helper()
+
+	client := &http.Client{
+		CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+	}
+	resp, err := client.Get(authorizationURL)
+	if err != nil {
+		s.t.Fatalf("GET authorization URL: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusFound {
+		s.t.Fatalf("authorization endpoint answered %d, want a redirect", resp.StatusCode)
+	}
+	location, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil {
+		s.t.Fatalf("parse redirect: %v", err)
+	}
+	if !strings.HasPrefix(location.String(), stubRedirectURL) {
+		s.t.Fatalf("redirected to %s, want %s", location, stubRedirectURL)
+	}
+	return location.Query().Get("code")
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+// newStubProvider creates the oidc identity provider of the stub issuer
+func newStubProvider(t *testing.T, stub *stubIssuer) IdentityProvider {
+	t.Helper()
+
+	c := &config.Config{}
+	c.Auth.Social.Providers = []config.SocialProviderConfig{stub.providerConfig()}
+	providers, err := NewIdentityProviders(c)
+	if err != nil {
+		t.Fatalf("NewIdentityProviders: %v", err)
+	}
+	return providers["stub"]
+}
+
+func TestOIDCProviderExchange(t *testing.T) {
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+
+	tests := []struct {
+		name          string
+		idTokenClaims func(claims jwt.MapClaims)
+		signingKey    *rsa.PrivateKey
+		nonce         string // nonce expected by the caller, the one of the authorization when empty
+		codeVerifier  string // verifier sent to the token endpoint, the one of the authorization when empty
+		wantErr       bool
+		wantVerified  bool
+	}{
+		{
+			name:         "valid id token",
+			wantVerified: true,
+		},
+		{
+			name:          "email_verified sent as a string",
+			idTokenClaims: func(claims jwt.MapClaims) { claims["email_verified"] = "true" },
+			wantVerified:  true,
+		},
+		{
+			name:          "unverified email",
+			idTokenClaims: func(claims jwt.MapClaims) { claims["email_verified"] = false },
+			wantVerified:  false,
+		},
+		{
+			name:    "wrong nonce",
+			nonce:   "nonce-of-another-login",
+			wantErr: true,
+		},
+		{
+			name:          "wrong audience",
+			idTokenClaims: func(claims jwt.MapClaims) { claims["aud"] = "another-client" },
+			wantErr:       true,
+		},
+		{
+			name: "expired id token",
+			idTokenClaims: func(claims jwt.MapClaims) {
+				claims["iat"] = time.Now().Add(-2 * time.Hour).Unix()
+				claims["exp"] = time.Now().Add(-time.Hour).Unix()
+			},
+			wantErr: true,
+		},
+		{
+			name:          "wrong issuer",
+			idTokenClaims: func(claims jwt.MapClaims) { claims["iss"] = "https://issuer.example.com" },
+			wantErr:       true,
+		},
+		{
+			name:       "signed with an unknown key",
+			signingKey: otherKey,
+			wantErr:    true,
+		},
+		{
+			name:         "wrong PKCE verifier",
+			codeVerifier: oauth2.GenerateVerifier(),
+			wantErr:      true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stub := newStubIssuer(t)
+			stub.idTokenClaims = tt.idTokenClaims
+			stub.signingKey = tt.signingKey
+			provider := newStubProvider(t, stub)
+
+			ctx := context.Background()
+			nonce, codeVerifier := "login-nonce", oauth2.GenerateVerifier()
+			authorizationURL, err := provider.AuthCodeURL(ctx, "login-state", nonce, codeVerifier)
+			if err != nil {
+				t.Fatalf("AuthCodeURL: %v", err)
+			}
+			code := stub.login(authorizationURL)
+
+			if tt.nonce != "" {
+				nonce = tt.nonce
+			}
+			if tt.codeVerifier != "" {
+				codeVerifier = tt.codeVerifier
+			}
+			identity, err := provider.Exchange(ctx, code, codeVerifier, nonce)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("Exchange succeeded, want an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Exchange: %v", err)
+			}
+
+			if identity.Subject != stubSubject {
+				t.Errorf("Subject = %q, want %q", identity.Subject, stubSubject)
+			}
+			if identity.Email != "jane.doe@example.com" {
+				t.Errorf("Email = %q, want the lowercased email", identity.Email)
+			}
+			if identity.EmailVerified != tt.wantVerified {
+				t.Errorf("EmailVerified = %v, want %v", identity.EmailVerified, tt.wantVerified)
+			}
+			if identity.FirstName != "Jane" || identity.LastName != "Doe" {
+				t.Errorf("name = %q %q, want Jane Doe", identity.FirstName, identity.LastName)
+			}
+		})
+	}
+}
+
+func TestOIDCProviderAuthCodeURL(t *testing.T) {
+	stub := newStubIssuer(t)
+	provider := newStubProvider(t, stub)
+
+	codeVerifier := oauth2.GenerateVerifier()
+	authorizationURL, err := provider.AuthCodeURL(context.Background(), "login-state", "login-nonce", codeVerifier)
+	if err != nil {
+		t.Fatalf("AuthCodeURL: %v", err)
+	}
+
+	parsed, err := url.Parse(authorizationURL)
+	if err != nil {
+		t.Fatalf("parse authorization URL: %v", err)
+	}
+	query := parsed.Query()
+	if parsed.Path != "/authorize" {
+		t.Errorf("path = %q, want the discovered authorization endpoint", parsed.Path)
+	}
+	if query.Get("state") != "login-state" || query.Get("nonce") != "login-nonce" {
+		t.Errorf("state and nonce = %q %q, want login-state login-nonce", query.Get("state"), query.Get("nonce"))
+	}
+	if query.Get("code_challenge") != oauth2.S256ChallengeFromVerifier(codeVerifier) {
+		t.Errorf("code_challenge = %q, want the S256 challenge of the verifier", query.Get("code_challenge"))
+	}
+	if query.Get("scope") != "openid email profile" {
+		t.Errorf("scope = %q, want the default scopes", query.Get("scope"))
+	}
+}
+
+func TestNewIdentityProvidersRejectsInvalidConfig(t *testing.T) {
+	tests := []struct {
+		name      string
+		providers []config.SocialProviderConfig
+	}{
+		{"missing name", []config.SocialProviderConfig{{Type: identityProviderTypeOIDC, IssuerURL: "https://issuer.example.com"}}},
+		{"missing issuer", []config.SocialProviderConfig{{Name: "google", Type: identityProviderTypeOIDC}}},
+		{"unsupported type", []config.SocialProviderConfig{{Name: "saml", Type: "saml"}}},
+		{"duplicate name", []config.SocialProviderConfig{
+			{Name: "github", Type: identityProviderTypeGitHub},
+			{Name: "github", Type: identityProviderTypeGitHub},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &config.Config{}
+			c.Auth.Social.Providers = tt.providers
+			if _, err := NewIdentityProviders(c); err == nil {
+				t.Fatalf("NewIdentityProviders succeeded, want an error")
+			}
+		})
+	}
+}
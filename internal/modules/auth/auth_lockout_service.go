@@ -0,0 +1,172 @@
+package auth
+
+import (
+	"modular-fx-fiber/internal/modules/mailer"
+	"modular-fx-fiber/internal/shared/dto/auth_dto"
+	"modular-fx-fiber/internal/shared/models"
+	"modular-fx-fiber/internal/shared/util"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// LoginThrottledError is returned when login attempts are refused until RetryAfter has passed
+type LoginThrottledError struct {
+	RetryAfter time.Duration
+}
+
+func (e *LoginThrottledError) Error() string {
+	return "too many failed login attempts, try again later"
+}
+
+// checkLoginThrottle refuses a login attempt while the account or the source IP is
+// locked or still waiting out the backoff of its previous failure
+func (s *service) checkLoginThrottle(email string, client *auth_dto.ClientInfo) error {
+	now := time.Now()
+
+	accountThrottle, err := s.loginThrottleRepo.GetThrottle(models.LOGIN_THROTTLE_ACCOUNT, strings.ToLower(email))
+	if err != nil {
+		s.logger.Error("Failed to fetch account login throttle", zap.String("email", email), zap.Error(err))
+		return err
+	}
+	wait := s.throttleWait(accountThrottle, now)
+
+	if client != nil && client.IPAddress != "" {
+		ipThrottle, err := s.loginThrottleRepo.GetThrottle(models.LOGIN_THROTTLE_IP, client.IPAddress)
+		if err != nil {
+			s.logger.Error("Failed to fetch IP login throttle", zap.String("ip_address", client.IPAddress), zap.Error(err))
+			return err
+		}
+		wait = max(wait, s.throttleWait(ipThrottle, now))
+	}
+
+	if wait > 0 {
+		s.logger.Info("Login attempt throttled",
+			zap.String("email", email),
+			zap.Duration("retry_after", wait))
+		return &LoginThrottledError{RetryAfter: wait}
+	}
+	return nil
+}
+
+// recordLoginFailure counts a failed attempt for the account and the source IP and locks
+// them once they reach their threshold. The user is nil when the email has no account.
+func (s *service) recordLoginFailure(u *models.User, email string, client *auth_dto.ClientInfo) {
+	lockout := s.config.Auth.Lockout
+	now := time.Now()
+	windowStart := now.Add(-time.Duration(lockout.AttemptWindowMinutes) * time.Minute)
+	lockedUntil := now.Add(time.Duration(lockout.LockoutMinutes) * time.Minute)
+
+	ipAddress := ""
+	if client != nil {
+		ipAddress = client.IPAddress
+	}
+
+	accountThrottle, err := s.loginThrottleRepo.RecordFailure(models.LOGIN_THROTTLE_ACCOUNT, strings.ToLower(email), windowStart)
+	if err != nil {
+		s.logger.Error("Failed to record account login failure", zap.String("email", email), zap.Error(err))
+	} else if accountThrottle.FailedAttempts >= lockout.MaxAttempts {
+		if err := s.loginThrottleRepo.LockThrottle(accountThrottle.ID, lockedUntil); err != nil {
+			s.logger.Error("Failed to lock account", zap.String("email", email), zap.Error(err))
+		} else {
+			s.logger.Warn("Security event: account locked after repeated login failures",
+				zap.String("security_event", "account_lockout"),
+				zap.String("email", email),
+				zap.String("ip_address", ipAddress),
+				zap.Time("locked_until", lockedUntil))
+
+			if u != nil {
+				go func() {
+					// Send lockout notification
+					err := s.sendAccountLockedEmail(u, ipAddress)
+					if err != nil {
+						s.logger.Error("Failed to send account locked email",
+							zap.String("email", u.Email),
+							zap.Error(err))
+					}
+				}()
+			}
+		}
+	}
+
+	if ipAddress == "" {
+		return
+	}
+
+	ipThrottle, err := s.loginThrottleRepo.RecordFailure(models.LOGIN_THROTTLE_IP, ipAddress, windowStart)
+	if err != nil {
+		s.logger.Error("Failed to record IP login failure", zap.String("ip_address", ipAddress), zap.Error(err))
+	} else if ipThrottle.FailedAttempts >= lockout.IPMaxAttempts {
+		if err := s.loginThrottleRepo.LockThrottle(ipThrottle.ID, lockedUntil); err != nil {
+			s.logger.Error("Failed to lock IP address", zap.String("ip_address", ipAddress), zap.Error(err))
+		} else {
+			s.logger.Warn("Security event: IP address locked after repeated login failures",
+				zap.String("security_event", "ip_lockout"),
+				zap.String("ip_address", ipAddress),
+				zap.Time("locked_until", lockedUntil))
+		}
+	}
+}
+
+// clearLoginThrottle forgets the failed attempts of an account after a successful login,
+// once the password and the second factor of an MFA user were verified.
+// The IP throttle is kept so one valid account cannot reset it.
+func (s *service) clearLoginThrottle(email string) {
+	if err := s.loginThrottleRepo.DeleteThrottle(models.LOGIN_THROTTLE_ACCOUNT, strings.ToLower(email)); err != nil {
+		s.logger.Error("Failed to clear account login throttle", zap.String("email", email), zap.Error(err))
+	}
+}
+
+// throttleWait returns how long the throttle still refuses attempts
+func (s *service) throttleWait(throttle *models.LoginThrottle, now time.Time) time.Duration {
+	if throttle == nil {
+		return 0
+	}
+
+	if throttle.LockedUntil != nil && now.Before(*throttle.LockedUntil) {
+		return throttle.LockedUntil.Sub(now)
+	}
+
+	window := time.Duration(s.config.Auth.Lockout.AttemptWindowMinutes) * time.Minute
+	if throttle.FailedAttempts == 0 || throttle.LastFailedAt == nil || now.Sub(*throttle.LastFailedAt) > window {
+		return 0
+	}
+
+	nextAttemptAt := throttle.LastFailedAt.Add(s.loginBackoff(throttle.FailedAttempts))
+	if now.Before(nextAttemptAt) {
+		return nextAttemptAt.Sub(now)
+	}
+	return 0
+}
+
+// loginBackoff returns the wait after the given number of failures, doubling with every failure
+func (s *service) loginBackoff(failedAttempts int) time.Duration {
+	delay := time.Duration(s.config.Auth.Lockout.BaseDelaySeconds) * time.Second
+	maxDelay := time.Duration(s.config.Auth.Lockout.MaxDelaySeconds) * time.Second
+
+	for i := 1; i < failedAttempts && delay < maxDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, maxDelay)
+}
+
+// sendAccountLockedEmail tells the user their account was locked after repeated login failures
+func (s *service) sendAccountLockedEmail(u *models.User, ipAddress string) error {
+	mailData, err := util.StructToMap(&mailer.AccountLockedData{
+		Name:           u.FullName(),
+		LockoutMinutes: s.config.Auth.Lockout.LockoutMinutes,
+		IPAddress:      ipAddress,
+	})
+	if err != nil {
+		s.logger.Error("[sendAccountLockedEmail] Failed to convert struct to map", zap.Error(err))
+		return err
+	}
+
+	return s.gmailMailer.SendTemplatedEmail(
+		u.Email,
+		mailer.AccountLockedSubject,
+		mailer.AccountLockedTemplate,
+		mailData,
+	)
+}
@@ -0,0 +1,85 @@
+package auth
+
+import (
+	"modular-fx-fiber/internal/shared/dto/auth_dto"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// SendMagicLink handles passwordless login link requests
+// @Summary Request magic link
+// @Description Email a single-use login link if the email belongs to an account. Requests over the per-email limit are ignored
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param email body auth_dto.MagicLinkDTO true "Account email"
+// @Success 200 {object} auth_dto.MagicLinkSuccessResponseDTO
+// @Router /auth/magic-link [post]
+func (h *handlers) SendMagicLink(c *fiber.Ctx) error {
+	var magicLinkDto auth_dto.MagicLinkDTO
+
+	// Parse request body
+	if err := c.BodyParser(&magicLinkDto); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	// Validate request body
+	errs := h.validator.Validate(&magicLinkDto)
+	if errs != nil {
+		err := h.validator.ParseErrorToString(errs)
+		return fiber.NewError(fiber.StatusBadRequest, err)
+	}
+
+	if err := h.service.SendMagicLink(&magicLinkDto); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	// Return response
+	return c.JSON(&auth_dto.MagicLinkSuccessResponseDTO{
+		Success: true,
+	})
+}
+
+// ConsumeMagicLink handles logging in with a magic link
+// @Summary Consume magic link
+// @Description Exchange a magic link token for tokens and mark the email as verified. Users with MFA enabled receive an mfa_required challenge instead, see /auth/mfa/verify
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param token body auth_dto.ConsumeMagicLinkDTO true "Magic link token"
+// @Success 200 {object} auth_dto.LoginSuccessResponseDTO
+// @Router /auth/magic-link/consume [post]
+func (h *handlers) ConsumeMagicLink(c *fiber.Ctx) error {
+	var consumeDto auth_dto.ConsumeMagicLinkDTO
+
+	// Parse request body
+	if err := c.BodyParser(&consumeDto); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	// Validate request body
+	errs := h.validator.Validate(&consumeDto)
+	if errs != nil {
+		err := h.validator.ParseErrorToString(errs)
+		return fiber.NewError(fiber.StatusBadRequest, err)
+	}
+
+	tokens, challenge, err := h.service.ConsumeMagicLink(&consumeDto, clientInfo(c, consumeDto.DeviceLabel))
+	if err != nil {
+		return fiber.NewError(fiber.StatusUnauthorized, err.Error())
+	}
+
+	// A second factor is required before tokens are issued
+	if challenge != nil {
+		return c.JSON(&auth_dto.LoginMFARequiredResponseDTO{
+			Success: true,
+			Data:    challenge,
+		})
+	}
+
+	// Return response
+	return c.JSON(&auth_dto.LoginSuccessResponseDTO{
+		Success: true,
+		Data:    tokens,
+	})
+}
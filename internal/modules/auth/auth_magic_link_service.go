@@ -0,0 +1,211 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"modular-fx-fiber/internal/modules/mailer"
+	"modular-fx-fiber/internal/shared/dto/auth_dto"
+	"modular-fx-fiber/internal/shared/models"
+	"modular-fx-fiber/internal/shared/util"
+	"net/url"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// tokenTypeMagicLink is the "typ" claim of the token embedded in a magic link
+const tokenTypeMagicLink = "magic_link"
+
+var (
+	ErrInvalidMagicLink = errors.New("invalid or expired magic link")
+)
+
+// SendMagicLink emails a passwordless login link to the user.
+// Like ForgotPassword it never reveals whether the email belongs to an account,
+// so requests over the per-email rate limit are dropped silently.
+func (s *service) SendMagicLink(dto *auth_dto.MagicLinkDTO) error {
+	go func() {
+		u, err := s.userRepo.GetByEmail(dto.Email)
+		if err != nil {
+			s.logger.Error("Failed to fetch user by email", zap.String("email", dto.Email), zap.Error(err))
+			return
+		}
+		if u == nil || u.ID == 0 {
+			s.logger.Info("Magic link requested for non-existent email", zap.String("email", dto.Email))
+			return
+		}
+		if u.Status != models.USER_STATUS_ACTIVE {
+			s.logger.Info("Magic link requested for inactive account", zap.Uint64("user_id", u.ID))
+			return
+		}
+
+		if err := s.sendMagicLinkEmail(u); err != nil {
+			s.logger.Error("Failed to send magic link email",
+				zap.Uint64("user_id", u.ID),
+				zap.Error(err))
+		}
+	}()
+
+	return nil
+}
+
+// sendMagicLinkEmail issues a new magic link and emails it, unless the rate limit of the user is reached
+func (s *service) sendMagicLinkEmail(u *models.User) error {
+	magicLinkConfig := s.config.Auth.MagicLink
+
+	// Apply the per-email rate limit
+	windowStart := time.Now().Add(-time.Duration(magicLinkConfig.WindowMinutes) * time.Minute)
+	sent, err := s.magicLinkTokenRepo.CountUserMagicLinkTokensSince(u.ID, windowStart)
+	if err != nil {
+		s.logger.Error("Failed to count magic links", zap.Uint64("user_id", u.ID), zap.Error(err))
+		return err
+	}
+	if sent >= int64(magicLinkConfig.MaxPerWindow) {
+		s.logger.Warn("Magic link rate limit reached", zap.Uint64("user_id", u.ID), zap.Int64("sent", sent))
+		return nil
+	}
+
+	expiry := time.Duration(magicLinkConfig.ExpiryMinutes) * time.Minute
+	magicLinkToken := models.MagicLinkToken{
+		UserID:    u.ID,
+		JTI:       uuid.NewString(),
+		ExpiresAt: time.Now().Add(expiry),
+	}
+
+	claims := jwt.MapClaims{}
+	claims["user_id"] = u.ID
+	claims["email"] = u.Email
+	claims["typ"] = tokenTypeMagicLink
+	claims["jti"] = magicLinkToken.JTI
+	claims["exp"] = magicLinkToken.ExpiresAt.Unix()
+
+	token, err := s.keyManager.Sign(claims)
+	if err != nil {
+		s.logger.Error("Failed to sign magic link token", zap.Error(err))
+		return err
+	}
+
+	if err := s.magicLinkTokenRepo.SaveMagicLinkToken(&magicLinkToken); err != nil {
+		s.logger.Error("Failed to save magic link token", zap.Uint64("user_id", u.ID), zap.Error(err))
+		return err
+	}
+
+	mailData, err := util.StructToMap(&mailer.MagicLinkData{
+		Name:             u.FullName(),
+		Link:             fmt.Sprintf("%s/magic-link?token=%s", s.config.App.FrontendURL, url.QueryEscape(token)),
+		ExpiresInMinutes: magicLinkConfig.ExpiryMinutes,
+	})
+	if err != nil {
+		s.logger.Error("[sendMagicLinkEmail] Failed to convert struct to map", zap.Error(err))
+		return err
+	}
+
+	return s.gmailMailer.SendTemplatedEmail(
+		u.Email,
+		mailer.MagicLinkSubject,
+		mailer.MagicLinkTemplate,
+		mailData,
+	)
+}
+
+// ConsumeMagicLink exchanges a magic link for tokens and marks the email as verified.
+// Users with MFA enabled get an MFA challenge instead of tokens, as with Login.
+func (s *service) ConsumeMagicLink(dto *auth_dto.ConsumeMagicLinkDTO, client *auth_dto.ClientInfo) (*auth_dto.TokenResponseDTO, *auth_dto.MFAChallengeDTO, error) {
+	userId, jti, err := s.parseMagicLink(dto.Token)
+	if err != nil {
+		s.logger.Warn("Invalid magic link token", zap.Error(err))
+		return nil, nil, ErrInvalidMagicLink
+	}
+
+	// Get magic link from database
+	magicLinkToken, err := s.magicLinkTokenRepo.GetValidMagicLinkToken(jti)
+	if err != nil {
+		s.logger.Error("Failed to retrieve magic link token", zap.Error(err))
+		return nil, nil, err
+	}
+	if magicLinkToken == nil || magicLinkToken.UserID != userId {
+		s.logger.Warn("Unknown or used magic link", zap.Uint64("user_id", userId))
+		return nil, nil, ErrInvalidMagicLink
+	}
+
+	// Get user
+	u, err := s.userRepo.GetByID(userId)
+	if err != nil {
+		s.logger.Error("Failed to fetch user by ID", zap.Uint64("user_id", userId), zap.Error(err))
+		return nil, nil, err
+	}
+	if u == nil {
+		return nil, nil, ErrInvalidMagicLink
+	}
+	if u.Status != models.USER_STATUS_ACTIVE {
+		return nil, nil, ErrUserNotActive
+	}
+
+	// Consume the link before issuing anything so it can only be used once
+	consumed, err := s.magicLinkTokenRepo.MarkMagicLinkTokenUsed(magicLinkToken.ID)
+	if err != nil {
+		s.logger.Error("Failed to mark magic link as used", zap.Uint64("user_id", u.ID), zap.Error(err))
+		return nil, nil, err
+	}
+	if !consumed {
+		s.logger.Warn("Magic link already used", zap.Uint64("user_id", u.ID))
+		return nil, nil, ErrInvalidMagicLink
+	}
+
+	// Opening the link proves the user owns the inbox
+	if !u.EmailVerified {
+		u.EmailVerified = true
+		if err := s.userRepo.Update(u); err != nil {
+			s.logger.Error("Failed to update user", zap.Uint64("user_id", u.ID), zap.Error(err))
+			return nil, nil, ErrUpdateUserFailed
+		}
+		s.logger.Info("User email verified by magic link", zap.Uint64("user_id", u.ID))
+	}
+
+	// The link only replaces the password, a second factor is still required
+	if u.MFAEnabled {
+		challenge, err := s.issueMFAChallenge(u)
+		if err != nil {
+			s.logger.Error("Failed to issue MFA challenge",
+				zap.Uint64("user_id", u.ID),
+				zap.Error(err))
+			return nil, nil, err
+		}
+
+		s.logger.Info("MFA challenge issued", zap.Uint64("user_id", u.ID))
+		return nil, challenge, nil
+	}
+
+	tokens, err := s.completeLogin(u, client)
+	if err != nil {
+		return nil, nil, err
+	}
+	return tokens, nil, nil
+}
+
+// parseMagicLink validates a magic link token and returns the user ID and jti it was issued with
+func (s *service) parseMagicLink(tokenString string) (uint64, string, error) {
+	claims := jwt.MapClaims{}
+	_, err := s.keyManager.Parse(tokenString, claims)
+	if err != nil {
+		return 0, "", err
+	}
+
+	if typ, _ := claims["typ"].(string); typ != tokenTypeMagicLink {
+		return 0, "", errors.New("token is not a magic link")
+	}
+
+	userId, ok := claims["user_id"].(float64)
+	if !ok || userId <= 0 {
+		return 0, "", errors.New("missing user_id claim")
+	}
+
+	jti, _ := claims["jti"].(string)
+	if jti == "" {
+		return 0, "", errors.New("missing jti claim")
+	}
+
+	return uint64(userId), jti, nil
+}
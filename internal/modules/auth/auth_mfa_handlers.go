@@ -0,0 +1,153 @@
+package auth
+
+import (
+	"modular-fx-fiber/internal/shared/dto/auth_dto"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// EnrollTOTP handles starting TOTP enrollment
+// @Summary Start TOTP enrollment
+// @Description Generate a new TOTP secret and otpauth:// URI for an authenticator app
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} auth_dto.TOTPEnrollSuccessResponseDTO
+// @Router /auth/mfa/totp/enroll [post]
+func (h *handlers) EnrollTOTP(c *fiber.Ctx) error {
+	// Get user ID from context
+	userId := c.Locals("user_id").(uint64)
+
+	// Generate secret
+	enrollment, err := h.service.EnrollTOTP(userId)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	// Return response
+	return c.JSON(&auth_dto.TOTPEnrollSuccessResponseDTO{
+		Success: true,
+		Data:    enrollment,
+	})
+}
+
+// ConfirmTOTP handles confirming TOTP enrollment
+// @Summary Confirm TOTP enrollment
+// @Description Confirm the TOTP secret with a first code, enable MFA and return one-time recovery codes
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param code body auth_dto.TOTPCodeDTO true "TOTP code"
+// @Success 200 {object} auth_dto.TOTPConfirmSuccessResponseDTO
+// @Router /auth/mfa/totp/confirm [post]
+func (h *handlers) ConfirmTOTP(c *fiber.Ctx) error {
+	var codeDto auth_dto.TOTPCodeDTO
+
+	// Parse request body
+	if err := c.BodyParser(&codeDto); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	// Validate request body
+	errs := h.validator.Validate(codeDto)
+	if errs != nil {
+		err := h.validator.ParseErrorToString(errs)
+		return fiber.NewError(fiber.StatusBadRequest, err)
+	}
+
+	// Get user ID from context
+	userId := c.Locals("user_id").(uint64)
+
+	// Confirm enrollment
+	recoveryCodes, err := h.service.ConfirmTOTP(&codeDto, userId)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	// Return response
+	return c.JSON(&auth_dto.TOTPConfirmSuccessResponseDTO{
+		Success: true,
+		Data:    recoveryCodes,
+	})
+}
+
+// DisableTOTP handles disabling TOTP
+// @Summary Disable TOTP
+// @Description Disable two-factor authentication after checking a current TOTP code
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param code body auth_dto.TOTPCodeDTO true "TOTP code"
+// @Success 200 {object} auth_dto.TOTPDisableSuccessResponseDTO
+// @Router /auth/mfa/totp/disable [post]
+func (h *handlers) DisableTOTP(c *fiber.Ctx) error {
+	var codeDto auth_dto.TOTPCodeDTO
+
+	// Parse request body
+	if err := c.BodyParser(&codeDto); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	// Validate request body
+	errs := h.validator.Validate(codeDto)
+	if errs != nil {
+		err := h.validator.ParseErrorToString(errs)
+		return fiber.NewError(fiber.StatusBadRequest, err)
+	}
+
+	// Get user ID from context
+	userId := c.Locals("user_id").(uint64)
+
+	// Disable MFA
+	if err := h.service.DisableTOTP(&codeDto, userId); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	// Return response
+	return c.JSON(&auth_dto.TOTPDisableSuccessResponseDTO{
+		Success: true,
+	})
+}
+
+// VerifyMFA handles the second step of an MFA login
+// @Summary Verify MFA
+// @Description Exchange an MFA challenge token and a TOTP code, a code received by SMS or a recovery code for tokens. The challenge is single use and invalidated after auth.mfa.max_attempts wrong codes, wrong codes also count as failed logins and throttled attempts get 429 with Retry-After
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param mfa body auth_dto.MFAVerifyDTO true "Challenge token and code"
+// @Success 200 {object} auth_dto.MFAVerifySuccessResponseDTO
+// @Router /auth/mfa/verify [post]
+func (h *handlers) VerifyMFA(c *fiber.Ctx) error {
+	var verifyDto auth_dto.MFAVerifyDTO
+
+	// Parse request body
+	if err := c.BodyParser(&verifyDto); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	// Validate request body
+	errs := h.validator.Validate(verifyDto)
+	if errs != nil {
+		err := h.validator.ParseErrorToString(errs)
+		return fiber.NewError(fiber.StatusBadRequest, err)
+	}
+
+	// Verify second factor
+	tokens, err := h.service.VerifyMFA(&verifyDto, clientInfo(c, verifyDto.DeviceLabel))
+	if err != nil {
+		if err == ErrVerificationCodeLocked {
+			return fiber.NewError(fiber.StatusTooManyRequests, err.Error())
+		}
+		return loginError(c, err)
+	}
+
+	// Return response
+	return c.JSON(&auth_dto.MFAVerifySuccessResponseDTO{
+		Success: true,
+		Data:    tokens,
+	})
+}
@@ -0,0 +1,531 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"errors"
+	"modular-fx-fiber/internal/shared/dto/auth_dto"
+	"modular-fx-fiber/internal/shared/models"
+	"modular-fx-fiber/internal/shared/util"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/pquerna/otp"
+	"github.com/pquerna/otp/totp"
+	"go.uber.org/zap"
+)
+
+const (
+	// tokenTypeMFAChallenge is the "typ" claim of the token returned by Login when MFA is required
+	tokenTypeMFAChallenge = "mfa_challenge"
+
+	// mfaChallengeStatus is the status reported to clients when a second factor is required
+	mfaChallengeStatus = "mfa_required"
+
+	// totpPeriod is the TOTP time step in seconds
+	totpPeriod = 30
+
+	// recoveryCodeCharset avoids characters that are easy to confuse when typed
+	recoveryCodeCharset = "abcdefghjkmnpqrstuvwxyz23456789"
+)
+
+// Second factors listed in an MFA challenge
+const (
+	mfaMethodTOTP         = "totp"
+	mfaMethodSMS          = "sms"
+	mfaMethodWebAuthn     = "webauthn"
+	mfaMethodRecoveryCode = "recovery_code"
+)
+
+// mfaChallenge is a valid MFA challenge token
+type mfaChallenge struct {
+	userId    uint64
+	jti       string
+	expiresAt time.Time
+}
+
+var totpValidateOpts = totp.ValidateOpts{
+	Period:    totpPeriod,
+	Skew:      1,
+	Digits:    otp.DigitsSix,
+	Algorithm: otp.AlgorithmSHA1,
+}
+
+// EnrollTOTP generates a new, unconfirmed TOTP secret for the user
+func (s *service) EnrollTOTP(userId uint64) (*auth_dto.TOTPEnrollmentDTO, error) {
+	u, err := s.userRepo.GetByID(userId)
+	if err != nil {
+		s.logger.Error("Failed to fetch user by ID", zap.Uint64("user_id", userId), zap.Error(err))
+		return nil, err
+	}
+	if u == nil {
+		return nil, ErrUserNotFound
+	}
+
+	// SMS may already be enabled, TOTP can be added next to it
+	totpEnabled, err := s.totpEnabled(userId)
+	if err != nil {
+		return nil, err
+	}
+	if totpEnabled {
+		return nil, ErrMFAAlreadyEnabled
+	}
+
+	// Generate secret
+	key, err := totp.Generate(totp.GenerateOpts{
+		Issuer:      s.config.Auth.MFA.Issuer,
+		AccountName: u.Email,
+		Period:      totpPeriod,
+		Digits:      otp.DigitsSix,
+		Algorithm:   otp.AlgorithmSHA1,
+	})
+	if err != nil {
+		s.logger.Error("Failed to generate TOTP secret", zap.Uint64("user_id", userId), zap.Error(err))
+		return nil, err
+	}
+
+	// Encrypt secret before storing it
+	encryptionKey, err := util.DecodeEncryptionKey(s.config.Auth.MFA.EncryptionKey)
+	if err != nil {
+		s.logger.Error("Invalid MFA encryption key", zap.Error(err))
+		return nil, err
+	}
+	encryptedSecret, err := util.Encrypt(encryptionKey, key.Secret())
+	if err != nil {
+		s.logger.Error("Failed to encrypt TOTP secret", zap.Uint64("user_id", userId), zap.Error(err))
+		return nil, err
+	}
+
+	err = s.totpSecretRepo.SaveTOTPSecret(&models.UserTOTPSecret{
+		UserID:          u.ID,
+		SecretEncrypted: encryptedSecret,
+	})
+	if err != nil {
+		s.logger.Error("Failed to save TOTP secret", zap.Uint64("user_id", userId), zap.Error(err))
+		return nil, err
+	}
+
+	s.logger.Info("TOTP enrollment started", zap.Uint64("user_id", userId))
+	return &auth_dto.TOTPEnrollmentDTO{
+		Secret:     key.Secret(),
+		OTPAuthURI: key.URL(),
+	}, nil
+}
+
+// ConfirmTOTP confirms a pending TOTP enrollment with a first code, enables MFA
+// and returns freshly generated recovery codes
+func (s *service) ConfirmTOTP(dto *auth_dto.TOTPCodeDTO, userId uint64) (*auth_dto.RecoveryCodesDTO, error) {
+	u, err := s.userRepo.GetByID(userId)
+	if err != nil {
+		s.logger.Error("Failed to fetch user by ID", zap.Uint64("user_id", userId), zap.Error(err))
+		return nil, err
+	}
+	if u == nil {
+		return nil, ErrUserNotFound
+	}
+
+	secret, err := s.totpSecretRepo.GetTOTPSecret(userId)
+	if err != nil {
+		s.logger.Error("Failed to fetch TOTP secret", zap.Uint64("user_id", userId), zap.Error(err))
+		return nil, err
+	}
+	if secret == nil {
+		return nil, ErrMFANotEnrolled
+	}
+	if secret.ConfirmedAt != nil {
+		return nil, ErrMFAAlreadyEnabled
+	}
+
+	// Check the first code
+	valid, err := s.validateTOTP(secret, dto.Code)
+	if err != nil {
+		return nil, err
+	}
+	if !valid {
+		s.logger.Info("Invalid TOTP code during enrollment", zap.Uint64("user_id", userId))
+		return nil, ErrInvalidMFACode
+	}
+
+	if err := s.totpSecretRepo.ConfirmTOTPSecret(secret.ID); err != nil {
+		s.logger.Error("Failed to confirm TOTP secret", zap.Uint64("user_id", userId), zap.Error(err))
+		return nil, err
+	}
+
+	recoveryCodes, err := s.generateRecoveryCodes(userId)
+	if err != nil {
+		return nil, err
+	}
+
+	// Enable MFA on the user
+	u.MFAEnabled = true
+	if err := s.userRepo.Update(u); err != nil {
+		s.logger.Error("Failed to update user", zap.Uint64("user_id", userId), zap.Error(err))
+		return nil, ErrUpdateUserFailed
+	}
+
+	s.logger.Info("TOTP enabled", zap.Uint64("user_id", userId))
+	return &auth_dto.RecoveryCodesDTO{
+		RecoveryCodes: recoveryCodes,
+	}, nil
+}
+
+// DisableTOTP turns off TOTP after checking a current TOTP code.
+// MFA stays enabled when the user also has SMS codes.
+func (s *service) DisableTOTP(dto *auth_dto.TOTPCodeDTO, userId uint64) error {
+	u, err := s.userRepo.GetByID(userId)
+	if err != nil {
+		s.logger.Error("Failed to fetch user by ID", zap.Uint64("user_id", userId), zap.Error(err))
+		return err
+	}
+	if u == nil {
+		return ErrUserNotFound
+	}
+	if !u.MFAEnabled {
+		return ErrMFANotEnabled
+	}
+
+	secret, err := s.totpSecretRepo.GetTOTPSecret(userId)
+	if err != nil {
+		s.logger.Error("Failed to fetch TOTP secret", zap.Uint64("user_id", userId), zap.Error(err))
+		return err
+	}
+	if secret == nil || secret.ConfirmedAt == nil {
+		return ErrMFANotEnabled
+	}
+
+	valid, err := s.validateTOTP(secret, dto.Code)
+	if err != nil {
+		return err
+	}
+	if !valid {
+		s.logger.Info("Invalid TOTP code while disabling MFA", zap.Uint64("user_id", userId))
+		return ErrInvalidMFACode
+	}
+
+	// Disable MFA on the user, unless SMS codes remain a second factor
+	u.MFAEnabled = u.SMSMFAEnabled
+	if err := s.userRepo.Update(u); err != nil {
+		s.logger.Error("Failed to update user", zap.Uint64("user_id", userId), zap.Error(err))
+		return ErrUpdateUserFailed
+	}
+
+	if err := s.totpSecretRepo.DeleteTOTPSecret(userId); err != nil {
+		s.logger.Error("Failed to delete TOTP secret", zap.Uint64("user_id", userId), zap.Error(err))
+	}
+	if !u.MFAEnabled {
+		if err := s.recoveryCodeRepo.DeleteUserRecoveryCodes(userId); err != nil {
+			s.logger.Error("Failed to delete recovery codes", zap.Uint64("user_id", userId), zap.Error(err))
+		}
+	}
+
+	s.logger.Info("TOTP disabled", zap.Uint64("user_id", userId))
+	return nil
+}
+
+// VerifyMFA completes a login by checking the second factor for an MFA challenge.
+// Wrong codes count against the challenge and, like wrong passwords, against the account and source IP.
+func (s *service) VerifyMFA(dto *auth_dto.MFAVerifyDTO, client *auth_dto.ClientInfo) (*auth_dto.TokenResponseDTO, error) {
+	challenge, err := s.parseMFAChallenge(dto.ChallengeToken)
+	if err != nil {
+		s.logger.Warn("Invalid MFA challenge token", zap.Error(err))
+		return nil, ErrInvalidMFAChallenge
+	}
+	userId := challenge.userId
+
+	u, err := s.userRepo.GetByID(userId)
+	if err != nil {
+		s.logger.Error("Failed to fetch user by ID", zap.Uint64("user_id", userId), zap.Error(err))
+		return nil, err
+	}
+	if u == nil || !u.MFAEnabled {
+		return nil, ErrInvalidMFAChallenge
+	}
+	if u.Status != models.USER_STATUS_ACTIVE {
+		return nil, ErrUserNotActive
+	}
+
+	// Refuse attempts while the account or source IP is throttled
+	if err := s.checkLoginThrottle(u.Email, client); err != nil {
+		return nil, err
+	}
+
+	if dto.Code != "" {
+		secret, err := s.totpSecretRepo.GetTOTPSecret(userId)
+		if err != nil {
+			s.logger.Error("Failed to fetch TOTP secret", zap.Uint64("user_id", userId), zap.Error(err))
+			return nil, err
+		}
+		if secret == nil || secret.ConfirmedAt == nil {
+			return nil, ErrMFANotEnabled
+		}
+
+		valid, err := s.validateTOTP(secret, dto.Code)
+		if err != nil {
+			return nil, err
+		}
+		if !valid {
+			s.logger.Info("Invalid TOTP code during login", zap.Uint64("user_id", userId))
+			s.recordMFAFailure(challenge, u, client)
+			return nil, ErrInvalidMFACode
+		}
+	} else if dto.SMSCode != "" {
+		if !u.SMSMFAEnabled {
+			return nil, ErrSMSMFANotEnabled
+		}
+
+		if _, err := s.checkVerificationCode(userId, models.VERIFICATION_PURPOSE_MFA_SMS, dto.SMSCode); err != nil {
+			if err == ErrInvalidVerifyCode {
+				s.recordMFAFailure(challenge, u, client)
+				return nil, ErrInvalidMFACode
+			}
+			return nil, err
+		}
+	} else {
+		used, err := s.recoveryCodeRepo.UseRecoveryCode(userId, util.HashToken(normalizeRecoveryCode(dto.RecoveryCode)))
+		if err != nil {
+			s.logger.Error("Failed to use recovery code", zap.Uint64("user_id", userId), zap.Error(err))
+			return nil, err
+		}
+		if !used {
+			s.logger.Info("Invalid recovery code during login", zap.Uint64("user_id", userId))
+			s.recordMFAFailure(challenge, u, client)
+			return nil, ErrInvalidMFACode
+		}
+		s.logger.Info("Recovery code used", zap.Uint64("user_id", userId))
+	}
+
+	return s.completeMFALogin(challenge, u, client)
+}
+
+// completeMFALogin consumes the challenge once the second factor was verified, forgets the failed
+// attempts of the account and issues tokens
+func (s *service) completeMFALogin(challenge *mfaChallenge, u *models.User, client *auth_dto.ClientInfo) (*auth_dto.TokenResponseDTO, error) {
+	if err := s.consumeMFAChallenge(challenge); err != nil {
+		return nil, err
+	}
+	s.clearLoginThrottle(u.Email)
+
+	return s.completeLogin(u, client)
+}
+
+// recordMFAFailure counts a wrong second factor against the account and source IP, as a wrong password,
+// and against the challenge, which is invalidated after auth.mfa.max_attempts failures
+func (s *service) recordMFAFailure(challenge *mfaChallenge, u *models.User, client *auth_dto.ClientInfo) {
+	s.recordLoginFailure(u, u.Email, client)
+
+	// Failures of a challenge are never forgotten, the challenge expires soon anyway
+	throttle, err := s.loginThrottleRepo.RecordFailure(models.LOGIN_THROTTLE_MFA_CHALLENGE, challenge.jti, time.Time{})
+	if err != nil {
+		s.logger.Error("Failed to record MFA challenge failure", zap.Uint64("user_id", u.ID), zap.Error(err))
+		return
+	}
+	if throttle.FailedAttempts < s.config.Auth.MFA.MaxAttempts {
+		return
+	}
+
+	s.logger.Warn("Security event: MFA challenge invalidated after repeated failures",
+		zap.String("security_event", "mfa_challenge_lockout"),
+		zap.Uint64("user_id", u.ID))
+	if err := s.consumeMFAChallenge(challenge); err != nil {
+		s.logger.Error("Failed to invalidate MFA challenge", zap.Uint64("user_id", u.ID), zap.Error(err))
+	}
+}
+
+// consumeMFAChallenge revokes the jti of a challenge so the token cannot be used again
+func (s *service) consumeMFAChallenge(challenge *mfaChallenge) error {
+	if err := s.revocation.RevokeToken(challenge.jti, challenge.userId, challenge.expiresAt); err != nil {
+		s.logger.Error("Failed to revoke MFA challenge", zap.Uint64("user_id", challenge.userId), zap.Error(err))
+		return err
+	}
+
+	if err := s.loginThrottleRepo.DeleteThrottle(models.LOGIN_THROTTLE_MFA_CHALLENGE, challenge.jti); err != nil {
+		s.logger.Error("Failed to delete MFA challenge throttle", zap.Uint64("user_id", challenge.userId), zap.Error(err))
+	}
+	return nil
+}
+
+// issueMFAChallenge creates a short-lived token proving the first factor was verified.
+// Its jti is revoked once the challenge is answered or failed too often, see consumeMFAChallenge.
+func (s *service) issueMFAChallenge(u *models.User) (*auth_dto.MFAChallengeDTO, error) {
+	expiry := time.Duration(s.config.Auth.MFA.ChallengeExpiryMinutes) * time.Minute
+
+	claims := jwt.MapClaims{}
+	claims["user_id"] = u.ID
+	claims["typ"] = tokenTypeMFAChallenge
+	claims["jti"] = uuid.NewString()
+	claims["exp"] = time.Now().Add(expiry).Unix()
+
+	challengeTokenString, err := s.keyManager.Sign(claims)
+	if err != nil {
+		return nil, err
+	}
+
+	// Tell the client which factors it can ask the user for
+	methods := []string{}
+	totpEnabled, err := s.totpEnabled(u.ID)
+	if err != nil {
+		return nil, err
+	}
+	if totpEnabled {
+		methods = append(methods, mfaMethodTOTP)
+	}
+	if u.SMSMFAEnabled {
+		methods = append(methods, mfaMethodSMS)
+	}
+	credentials, err := s.webAuthnCredentialRepo.ListUserCredentials(u.ID)
+	if err != nil {
+		s.logger.Error("Failed to list WebAuthn credentials", zap.Uint64("user_id", u.ID), zap.Error(err))
+		return nil, err
+	}
+	if len(credentials) > 0 {
+		methods = append(methods, mfaMethodWebAuthn)
+	}
+	methods = append(methods, mfaMethodRecoveryCode)
+
+	return &auth_dto.MFAChallengeDTO{
+		Status:         mfaChallengeStatus,
+		ChallengeToken: challengeTokenString,
+		ExpiresIn:      uint(expiry.Seconds()),
+		Methods:        methods,
+	}, nil
+}
+
+// totpEnabled reports whether the user confirmed a TOTP secret
+func (s *service) totpEnabled(userId uint64) (bool, error) {
+	secret, err := s.totpSecretRepo.GetTOTPSecret(userId)
+	if err != nil {
+		s.logger.Error("Failed to fetch TOTP secret", zap.Uint64("user_id", userId), zap.Error(err))
+		return false, err
+	}
+	return secret != nil && secret.ConfirmedAt != nil, nil
+}
+
+// parseMFAChallenge validates an MFA challenge token that was not consumed yet
+func (s *service) parseMFAChallenge(tokenString string) (*mfaChallenge, error) {
+	claims := jwt.MapClaims{}
+	_, err := s.keyManager.Parse(tokenString, claims)
+	if err != nil {
+		return nil, err
+	}
+
+	if typ, _ := claims["typ"].(string); typ != tokenTypeMFAChallenge {
+		return nil, errors.New("token is not an mfa challenge")
+	}
+
+	userId, ok := claims["user_id"].(float64)
+	if !ok || userId <= 0 {
+		return nil, errors.New("missing user_id claim")
+	}
+
+	jti, _ := claims["jti"].(string)
+	if jti == "" {
+		return nil, errors.New("missing jti claim")
+	}
+
+	expiresAt, err := claims.GetExpirationTime()
+	if err != nil || expiresAt == nil {
+		return nil, errors.New("missing exp claim")
+	}
+
+	consumed, err := s.revocation.IsTokenRevoked(jti)
+	if err != nil {
+		return nil, err
+	}
+	if consumed {
+		return nil, errors.New("mfa challenge was already used")
+	}
+
+	return &mfaChallenge{
+		userId:    uint64(userId),
+		jti:       jti,
+		expiresAt: expiresAt.Time,
+	}, nil
+}
+
+// validateTOTP checks a code against the user's secret and rejects codes that were already used
+func (s *service) validateTOTP(secret *models.UserTOTPSecret, code string) (bool, error) {
+	encryptionKey, err := util.DecodeEncryptionKey(s.config.Auth.MFA.EncryptionKey)
+	if err != nil {
+		s.logger.Error("Invalid MFA encryption key", zap.Error(err))
+		return false, err
+	}
+	plainSecret, err := util.Decrypt(encryptionKey, secret.SecretEncrypted)
+	if err != nil {
+		s.logger.Error("Failed to decrypt TOTP secret", zap.Uint64("user_id", secret.UserID), zap.Error(err))
+		return false, err
+	}
+
+	// Find the time step the code belongs to, allowing one step of clock skew
+	now := time.Now()
+	currentStep := now.Unix() / totpPeriod
+	for skew := int64(-1); skew <= 1; skew++ {
+		step := currentStep + skew
+		expected, err := totp.GenerateCodeCustom(plainSecret, time.Unix(step*totpPeriod, 0), totpValidateOpts)
+		if err != nil {
+			return false, err
+		}
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) != 1 {
+			continue
+		}
+
+		// Record the step so the same code cannot be replayed
+		fresh, err := s.totpSecretRepo.UpdateLastUsedStep(secret.ID, step)
+		if err != nil {
+			s.logger.Error("Failed to update TOTP last used step", zap.Uint64("user_id", secret.UserID), zap.Error(err))
+			return false, err
+		}
+		if !fresh {
+			s.logger.Warn("TOTP code replayed", zap.Uint64("user_id", secret.UserID))
+		}
+		return fresh, nil
+	}
+
+	return false, nil
+}
+
+// generateRecoveryCodes replaces the user's recovery codes and returns the plain codes
+func (s *service) generateRecoveryCodes(userId uint64) ([]string, error) {
+	count := s.config.Auth.MFA.RecoveryCodeCount
+	if count <= 0 {
+		count = 10
+	}
+
+	codes := make([]string, 0, count)
+	hashes := make([]string, 0, count)
+	for range count {
+		code, err := newRecoveryCode()
+		if err != nil {
+			s.logger.Error("Failed to generate recovery code", zap.Error(err))
+			return nil, err
+		}
+		codes = append(codes, code)
+		hashes = append(hashes, util.HashToken(normalizeRecoveryCode(code)))
+	}
+
+	if err := s.recoveryCodeRepo.ReplaceRecoveryCodes(userId, hashes); err != nil {
+		s.logger.Error("Failed to save recovery codes", zap.Uint64("user_id", userId), zap.Error(err))
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+// newRecoveryCode returns a random recovery code formatted as xxxxx-xxxxx
+func newRecoveryCode() (string, error) {
+	b := make([]byte, 10)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	for i := range b {
+		b[i] = recoveryCodeCharset[int(b[i])%len(recoveryCodeCharset)]
+	}
+	return string(b[:5]) + "-" + string(b[5:]), nil
+}
+
+// normalizeRecoveryCode makes recovery code comparison ignore case, spaces and dashes
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(strings.TrimSpace(code))
+	return strings.NewReplacer("-", "", " ", "").Replace(code)
+}
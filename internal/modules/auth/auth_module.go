@@ -10,6 +10,8 @@ var Module = fx.Options(
 		NewRoutes,
 		NewHandlers,
 		NewService,
+		NewIdentityProviders,
+		NewWebAuthn,
 	),
 	fx.Invoke(Register),
 )
@@ -0,0 +1,211 @@
+package auth
+
+import (
+	"errors"
+	"math"
+	"modular-fx-fiber/internal/shared/dto/auth_dto"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// SendPhoneVerification handles sending a phone verification code by SMS
+// @Summary Send phone verification code
+// @Description Send a code by SMS to the given phone number, or to the number of the user when none is given. The number is saved once the code is verified. Codes are limited per user and per phone number, Retry-After tells when the next one can be sent
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param phone body auth_dto.SendPhoneCodeDTO false "Phone number to verify"
+// @Success 200 {object} auth_dto.SendPhoneCodeSuccessResponseDTO
+// @Failure 429 {object} map[string]string
+// @Router /auth/phone/send-code [post]
+func (h *handlers) SendPhoneVerification(c *fiber.Ctx) error {
+	var sendDto auth_dto.SendPhoneCodeDTO
+
+	// Parse request body, it may be empty to use the number of the user
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&sendDto); err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, err.Error())
+		}
+	}
+
+	// Validate request body
+	errs := h.validator.Validate(sendDto)
+	if errs != nil {
+		err := h.validator.ParseErrorToString(errs)
+		return fiber.NewError(fiber.StatusBadRequest, err)
+	}
+
+	userId := c.Locals("user_id").(uint64)
+
+	// Send code
+	if err := h.service.SendPhoneVerification(&sendDto, userId); err != nil {
+		return sendCodeError(c, err)
+	}
+
+	// Return response
+	return c.JSON(&auth_dto.SendPhoneCodeSuccessResponseDTO{
+		Success: true,
+	})
+}
+
+// VerifyPhone handles phone number verification
+// @Summary Verify phone number
+// @Description Verify the phone number a code was sent to and save it as the verified number of the user
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param code body auth_dto.VerifyPhoneDTO true "Verification code"
+// @Success 200 {object} auth_dto.VerifyPhoneSuccessResponseDTO
+// @Router /auth/phone/verify [post]
+func (h *handlers) VerifyPhone(c *fiber.Ctx) error {
+	var verifyDto auth_dto.VerifyPhoneDTO
+
+	// Parse request body
+	if err := c.BodyParser(&verifyDto); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	// Validate request body
+	errs := h.validator.Validate(verifyDto)
+	if errs != nil {
+		err := h.validator.ParseErrorToString(errs)
+		return fiber.NewError(fiber.StatusBadRequest, err)
+	}
+
+	userId := c.Locals("user_id").(uint64)
+
+	// Check verification code
+	if err := h.service.VerifyPhone(&verifyDto, userId); err != nil {
+		if err == ErrVerificationCodeLocked {
+			return fiber.NewError(fiber.StatusTooManyRequests, err.Error())
+		}
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	// Return response
+	return c.JSON(&auth_dto.VerifyPhoneSuccessResponseDTO{
+		Success: true,
+	})
+}
+
+// SendMFASMSCode handles sending a login code by SMS during an MFA challenge
+// @Summary Send MFA code by SMS
+// @Description Send a login code to the verified phone number of a user with SMS MFA enabled, to be used as sms_code with /auth/mfa/verify. Codes are limited per user and per phone number, Retry-After tells when the next one can be sent
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param challenge body auth_dto.MFASMSCodeDTO true "Challenge token"
+// @Success 200 {object} auth_dto.MFASMSCodeSuccessResponseDTO
+// @Failure 429 {object} map[string]string
+// @Router /auth/mfa/sms/send [post]
+func (h *handlers) SendMFASMSCode(c *fiber.Ctx) error {
+	var sendDto auth_dto.MFASMSCodeDTO
+
+	// Parse request body
+	if err := c.BodyParser(&sendDto); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	// Validate request body
+	errs := h.validator.Validate(sendDto)
+	if errs != nil {
+		err := h.validator.ParseErrorToString(errs)
+		return fiber.NewError(fiber.StatusBadRequest, err)
+	}
+
+	// Send code
+	if err := h.service.SendMFASMSCode(&sendDto); err != nil {
+		if err == ErrInvalidMFAChallenge {
+			return fiber.NewError(fiber.StatusUnauthorized, err.Error())
+		}
+		return sendCodeError(c, err)
+	}
+
+	// Return response
+	return c.JSON(&auth_dto.MFASMSCodeSuccessResponseDTO{
+		Success: true,
+	})
+}
+
+// EnableSMSMFA handles enabling SMS as a second factor
+// @Summary Enable SMS MFA
+// @Description Require a code sent by SMS to the verified phone number, or another enabled factor, at login. Returns new recovery codes, the previous ones stop working
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} auth_dto.SMSMFAEnableSuccessResponseDTO
+// @Router /auth/mfa/sms/enable [post]
+func (h *handlers) EnableSMSMFA(c *fiber.Ctx) error {
+	userId := c.Locals("user_id").(uint64)
+
+	recoveryCodes, err := h.service.EnableSMSMFA(userId)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	// Return response
+	return c.JSON(&auth_dto.SMSMFAEnableSuccessResponseDTO{
+		Success: true,
+		Data:    recoveryCodes,
+	})
+}
+
+// DisableSMSMFA handles disabling SMS as a second factor
+// @Summary Disable SMS MFA
+// @Description Stop accepting codes sent by SMS at login after checking the password. MFA stays enabled when TOTP is enabled
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param password body auth_dto.DisableSMSMFADTO true "Current password"
+// @Success 200 {object} auth_dto.SMSMFADisableSuccessResponseDTO
+// @Router /auth/mfa/sms/disable [post]
+func (h *handlers) DisableSMSMFA(c *fiber.Ctx) error {
+	var disableDto auth_dto.DisableSMSMFADTO
+
+	// Parse request body
+	if err := c.BodyParser(&disableDto); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	// Validate request body
+	errs := h.validator.Validate(disableDto)
+	if errs != nil {
+		err := h.validator.ParseErrorToString(errs)
+		return fiber.NewError(fiber.StatusBadRequest, err)
+	}
+
+	userId := c.Locals("user_id").(uint64)
+
+	if err := h.service.DisableSMSMFA(&disableDto, userId); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	// Return response
+	return c.JSON(&auth_dto.SMSMFADisableSuccessResponseDTO{
+		Success: true,
+	})
+}
+
+// sendCodeError answers 429 with Retry-After when a code cannot be sent yet, and 400 otherwise
+func sendCodeError(c *fiber.Ctx, err error) error {
+	var retryAfter time.Duration
+	var cooldown *VerificationCooldownError
+	var rateLimit *SMSRateLimitError
+	switch {
+	case errors.As(err, &cooldown):
+		retryAfter = cooldown.RetryAfter
+	case errors.As(err, &rateLimit):
+		retryAfter = rateLimit.RetryAfter
+	default:
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	c.Set(fiber.HeaderRetryAfter, strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+	return fiber.NewError(fiber.StatusTooManyRequests, err.Error())
+}
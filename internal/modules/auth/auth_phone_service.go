@@ -0,0 +1,258 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"modular-fx-fiber/internal/modules/sms"
+	"modular-fx-fiber/internal/shared/dto/auth_dto"
+	"modular-fx-fiber/internal/shared/models"
+	"modular-fx-fiber/internal/shared/util"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// smsSendTimeout bounds the call made to the SMS provider
+const smsSendTimeout = 10 * time.Second
+
+var (
+	ErrPhoneNumberMissing   = errors.New("no phone number to verify")
+	ErrPhoneAlreadyVerified = errors.New("phone number is already verified")
+	ErrPhoneNotVerified     = errors.New("a verified phone number is required")
+	ErrSMSMFAAlreadyEnabled = errors.New("sms two-factor authentication is already enabled")
+	ErrSMSMFANotEnabled     = errors.New("sms two-factor authentication is not enabled")
+)
+
+// SMSRateLimitError is returned when too many codes were sent to a phone number, a new one can be sent after RetryAfter
+type SMSRateLimitError struct {
+	RetryAfter time.Duration
+}
+
+func (e *SMSRateLimitError) Error() string {
+	return "too many codes were sent to this phone number, try again later"
+}
+
+// SendPhoneVerification sends a code by SMS to verify a phone number, the number of the user
+// unless another one is given. The number is only saved on the user once the code is verified.
+func (s *service) SendPhoneVerification(dto *auth_dto.SendPhoneCodeDTO, userId uint64) error {
+	u, err := s.userRepo.GetByID(userId)
+	if err != nil {
+		s.logger.Error("Failed to fetch user by ID", zap.Uint64("user_id", userId), zap.Error(err))
+		return err
+	}
+	if u == nil {
+		return ErrUserNotFound
+	}
+
+	var phone string
+	switch {
+	case dto.PhoneNumber != "":
+		phone = util.NormalizePhoneNumber(dto.PhoneNumber)
+	case u.PhoneNumber != nil && *u.PhoneNumber != "":
+		phone = util.NormalizePhoneNumber(*u.PhoneNumber)
+	default:
+		return ErrPhoneNumberMissing
+	}
+	if u.PhoneVerified && u.PhoneNumber != nil && util.NormalizePhoneNumber(*u.PhoneNumber) == phone {
+		return ErrPhoneAlreadyVerified
+	}
+
+	if err := s.checkResendCooldown(userId, models.VERIFICATION_PURPOSE_PHONE); err != nil {
+		return err
+	}
+
+	return s.sendSMSCode(u, models.VERIFICATION_PURPOSE_PHONE, phone, sms.PhoneVerificationMessage)
+}
+
+// VerifyPhone checks a phone verification code and marks the number it was sent to as the verified number of the user
+func (s *service) VerifyPhone(dto *auth_dto.VerifyPhoneDTO, userId uint64) error {
+	u, err := s.userRepo.GetByID(userId)
+	if err != nil {
+		s.logger.Error("Failed to fetch user by ID", zap.Uint64("user_id", userId), zap.Error(err))
+		return err
+	}
+	if u == nil {
+		return ErrUserNotFound
+	}
+
+	verificationCode, err := s.checkVerificationCode(userId, models.VERIFICATION_PURPOSE_PHONE, dto.Code)
+	if err != nil {
+		return err
+	}
+	if verificationCode.Target == nil {
+		return ErrInvalidVerifyCode
+	}
+
+	phone := *verificationCode.Target
+	u.PhoneNumber = &phone
+	u.PhoneVerified = true
+	if err := s.userRepo.Update(u); err != nil {
+		s.logger.Error("Failed to update user", zap.Uint64("user_id", userId), zap.Error(err))
+		return ErrUpdateUserFailed
+	}
+
+	s.logger.Info("User phone number verified", zap.Uint64("user_id", userId))
+	return nil
+}
+
+// EnableSMSMFA makes codes sent by SMS to the verified phone number a second factor of the user,
+// and returns freshly generated recovery codes
+func (s *service) EnableSMSMFA(userId uint64) (*auth_dto.RecoveryCodesDTO, error) {
+	u, err := s.userRepo.GetByID(userId)
+	if err != nil {
+		s.logger.Error("Failed to fetch user by ID", zap.Uint64("user_id", userId), zap.Error(err))
+		return nil, err
+	}
+	if u == nil {
+		return nil, ErrUserNotFound
+	}
+	if u.SMSMFAEnabled {
+		return nil, ErrSMSMFAAlreadyEnabled
+	}
+	if !u.PhoneVerified || u.PhoneNumber == nil {
+		return nil, ErrPhoneNotVerified
+	}
+
+	recoveryCodes, err := s.generateRecoveryCodes(userId)
+	if err != nil {
+		return nil, err
+	}
+
+	u.SMSMFAEnabled = true
+	u.MFAEnabled = true
+	if err := s.userRepo.Update(u); err != nil {
+		s.logger.Error("Failed to update user", zap.Uint64("user_id", userId), zap.Error(err))
+		return nil, ErrUpdateUserFailed
+	}
+
+	s.logger.Info("SMS MFA enabled", zap.Uint64("user_id", userId))
+	return &auth_dto.RecoveryCodesDTO{
+		RecoveryCodes: recoveryCodes,
+	}, nil
+}
+
+// DisableSMSMFA stops accepting codes sent by SMS as a second factor after checking the password.
+// MFA stays enabled when the user also has TOTP.
+func (s *service) DisableSMSMFA(dto *auth_dto.DisableSMSMFADTO, userId uint64) error {
+	u, err := s.userRepo.GetByID(userId)
+	if err != nil {
+		s.logger.Error("Failed to fetch user by ID", zap.Uint64("user_id", userId), zap.Error(err))
+		return err
+	}
+	if u == nil {
+		return ErrUserNotFound
+	}
+	if !u.SMSMFAEnabled {
+		return ErrSMSMFANotEnabled
+	}
+
+	match, _, err := s.passwordHasher.Verify(u.Password, dto.Password)
+	if err != nil {
+		s.logger.Error("Failed to verify password", zap.Uint64("user_id", userId), zap.Error(err))
+		return err
+	}
+	if !match {
+		s.logger.Info("Invalid password while disabling SMS MFA", zap.Uint64("user_id", userId))
+		return ErrInvalidCredentials
+	}
+
+	totpEnabled, err := s.totpEnabled(userId)
+	if err != nil {
+		return err
+	}
+
+	u.SMSMFAEnabled = false
+	u.MFAEnabled = totpEnabled
+	if err := s.userRepo.Update(u); err != nil {
+		s.logger.Error("Failed to update user", zap.Uint64("user_id", userId), zap.Error(err))
+		return ErrUpdateUserFailed
+	}
+
+	if !u.MFAEnabled {
+		if err := s.recoveryCodeRepo.DeleteUserRecoveryCodes(userId); err != nil {
+			s.logger.Error("Failed to delete recovery codes", zap.Uint64("user_id", userId), zap.Error(err))
+		}
+	}
+
+	s.logger.Info("SMS MFA disabled", zap.Uint64("user_id", userId))
+	return nil
+}
+
+// SendMFASMSCode sends a login code by SMS for an MFA challenge
+func (s *service) SendMFASMSCode(dto *auth_dto.MFASMSCodeDTO) error {
+	challenge, err := s.parseMFAChallenge(dto.ChallengeToken)
+	if err != nil {
+		s.logger.Warn("Invalid MFA challenge token", zap.Error(err))
+		return ErrInvalidMFAChallenge
+	}
+	userId := challenge.userId
+
+	u, err := s.userRepo.GetByID(userId)
+	if err != nil {
+		s.logger.Error("Failed to fetch user by ID", zap.Uint64("user_id", userId), zap.Error(err))
+		return err
+	}
+	if u == nil || !u.MFAEnabled {
+		return ErrInvalidMFAChallenge
+	}
+	if !u.SMSMFAEnabled || !u.PhoneVerified || u.PhoneNumber == nil {
+		return ErrSMSMFANotEnabled
+	}
+
+	if err := s.checkResendCooldown(userId, models.VERIFICATION_PURPOSE_MFA_SMS); err != nil {
+		return err
+	}
+
+	return s.sendSMSCode(u, models.VERIFICATION_PURPOSE_MFA_SMS, util.NormalizePhoneNumber(*u.PhoneNumber), sms.MFACodeMessage)
+}
+
+// sendSMSCode issues a code for the purpose and sends it to the phone number,
+// unless the number already received auth.phone.max_per_number codes in the window
+func (s *service) sendSMSCode(u *models.User, purpose string, phone string, messageFormat string) error {
+	if err := s.checkPhoneRateLimit(phone); err != nil {
+		return err
+	}
+
+	code, err := s.issueVerificationCode(u.ID, purpose, &phone)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), smsSendTimeout)
+	defer cancel()
+
+	message := fmt.Sprintf(messageFormat, code, s.config.Auth.Verification.CodeExpiryMinutes)
+	if err := s.smsSender.Send(ctx, phone, message); err != nil {
+		s.logger.Error("Failed to send SMS code",
+			zap.Uint64("user_id", u.ID),
+			zap.String("purpose", purpose),
+			zap.Error(err))
+		return err
+	}
+
+	s.logger.Info("SMS code sent", zap.Uint64("user_id", u.ID), zap.String("purpose", purpose))
+	return nil
+}
+
+// checkPhoneRateLimit refuses to send more codes to a phone number than auth.phone.max_per_number per window
+func (s *service) checkPhoneRateLimit(phone string) error {
+	phoneConfig := s.config.Auth.Phone
+	window := time.Duration(phoneConfig.WindowMinutes) * time.Minute
+
+	sent, oldest, err := s.verificationCodeRepo.CountTargetCodesSince(phone, time.Now().Add(-window))
+	if err != nil {
+		s.logger.Error("Failed to count codes sent to phone number", zap.Error(err))
+		return err
+	}
+	if sent < int64(phoneConfig.MaxPerNumber) {
+		return nil
+	}
+
+	retryAfter := window
+	if oldest != nil {
+		retryAfter = time.Until(oldest.Add(window))
+	}
+	s.logger.Warn("SMS rate limit reached for phone number", zap.Int64("sent", sent))
+	return &SMSRateLimitError{RetryAfter: retryAfter}
+}
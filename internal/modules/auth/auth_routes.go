@@ -31,7 +31,38 @@ func Register(s server.Server, h Handlers, m middleware.Middleware) {
 	group.Post("/login", h.Login)
 	group.Post("/register", h.Register)
 	group.Post("/refresh-token", h.RefreshToken)
+	group.Post("/password/forgot", h.ForgotPassword)
+	group.Post("/password/reset", h.ResetPassword)
+	group.Post("/mfa/verify", h.VerifyMFA)
+	group.Post("/mfa/sms/send", h.SendMFASMSCode)
+	group.Post("/mfa/webauthn/begin", h.BeginWebAuthnMFA)
+	group.Post("/mfa/webauthn/finish", h.FinishWebAuthnMFA)
+	group.Post("/webauthn/login/begin", h.BeginPasskeyLogin)
+	group.Post("/webauthn/login/finish", h.FinishPasskeyLogin)
+	group.Post("/magic-link", h.SendMagicLink)
+	group.Post("/magic-link/consume", h.ConsumeMagicLink)
+	group.Get("/social/:provider", h.StartSocialLogin)
+	group.Post("/social/:provider/callback", h.CompleteSocialLogin)
+	group.Post("/token", h.ServiceAccountToken)
 	// Protected routes
 	group.Post("/register/verify-email", m.JWT(), h.VerifyEmail)
+	group.Post("/register/resend-verification", m.JWT(), h.ResendVerification)
 	group.Post("logout", m.JWT(), h.Logout)
+	group.Post("/mfa/totp/enroll", m.JWT(), h.EnrollTOTP)
+	group.Post("/mfa/totp/confirm", m.JWT(), h.ConfirmTOTP)
+	group.Post("/mfa/totp/disable", m.JWT(), h.DisableTOTP)
+	group.Post("/mfa/sms/enable", m.JWT(), h.EnableSMSMFA)
+	group.Post("/mfa/sms/disable", m.JWT(), h.DisableSMSMFA)
+	group.Post("/phone/send-code", m.JWT(), h.SendPhoneVerification)
+	group.Post("/phone/verify", m.JWT(), h.VerifyPhone)
+	group.Get("/sessions", m.JWT(), h.ListSessions)
+	group.Post("/sessions/logout-others", m.JWT(), h.RevokeOtherSessions)
+	group.Delete("/sessions/:id", m.JWT(), h.RevokeSession)
+	group.Post("/api-keys", m.JWT(), h.CreateAPIKey)
+	group.Get("/api-keys", m.JWT(), h.ListAPIKeys)
+	group.Delete("/api-keys/:id", m.JWT(), h.RevokeAPIKey)
+	group.Post("/webauthn/register/begin", m.JWT(), h.BeginWebAuthnRegistration)
+	group.Post("/webauthn/register/finish", m.JWT(), h.FinishWebAuthnRegistration)
+	group.Get("/webauthn/credentials", m.JWT(), h.ListWebAuthnCredentials)
+	group.Delete("/webauthn/credentials/:id", m.JWT(), h.DeleteWebAuthnCredential)
 }
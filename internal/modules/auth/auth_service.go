@@ -2,20 +2,28 @@ package auth
 
 import (
 	"errors"
+	"fmt"
 	"modular-fx-fiber/internal/core/config"
 	"modular-fx-fiber/internal/modules/mailer"
+	"modular-fx-fiber/internal/modules/sms"
 	"modular-fx-fiber/internal/modules/user"
 	"modular-fx-fiber/internal/shared/dto/auth_dto"
 	"modular-fx-fiber/internal/shared/dto/user_dto"
+	"modular-fx-fiber/internal/shared/jwks"
 	"modular-fx-fiber/internal/shared/logger"
+	"modular-fx-fiber/internal/shared/middleware"
 	"modular-fx-fiber/internal/shared/models"
+	"modular-fx-fiber/internal/shared/password"
 	"modular-fx-fiber/internal/shared/repositories"
+	"modular-fx-fiber/internal/shared/revocation"
 	"modular-fx-fiber/internal/shared/util"
+	"net/url"
 	"time"
 
+	"github.com/go-webauthn/webauthn/webauthn"
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 	"go.uber.org/zap"
-	"golang.org/x/crypto/bcrypt"
 )
 
 var (
@@ -25,26 +33,84 @@ var (
 	ErrUserNotFound        = errors.New("user not found")
 	ErrInvalidVerifyCode   = errors.New("invalid verification code")
 	ErrUpdateUserFailed    = errors.New("failed to update user")
+	ErrInvalidResetToken   = errors.New("invalid or expired reset token")
+	ErrMFAAlreadyEnabled   = errors.New("two-factor authentication is already enabled")
+	ErrMFANotEnabled       = errors.New("two-factor authentication is not enabled")
+	ErrMFANotEnrolled      = errors.New("two-factor authentication enrollment not started")
+	ErrInvalidMFACode      = errors.New("invalid two-factor authentication code")
+	ErrInvalidMFAChallenge = errors.New("invalid or expired mfa challenge")
 )
 
 type (
 	Service interface {
-		Login(dto *auth_dto.LoginDTO) (*auth_dto.TokenResponseDTO, error)
+		Login(dto *auth_dto.LoginDTO, client *auth_dto.ClientInfo) (*auth_dto.TokenResponseDTO, *auth_dto.MFAChallengeDTO, error)
 		Logout(dto *auth_dto.LogoutDTO) error
-		Register(dto *auth_dto.RegisterDTO) (*auth_dto.TokenResponseDTO, error)
-		RefreshToken(dto *auth_dto.RefreshTokenDTO) (*auth_dto.TokenResponseDTO, error)
+		Register(dto *auth_dto.RegisterDTO, client *auth_dto.ClientInfo) (*auth_dto.TokenResponseDTO, error)
+		RefreshToken(dto *auth_dto.RefreshTokenDTO, client *auth_dto.ClientInfo) (*auth_dto.TokenResponseDTO, error)
 		VerifyEmail(token *auth_dto.VerifyEmailDTO, userId uint64) error
+		ResendVerification(userId uint64) error
+		ForgotPassword(dto *auth_dto.ForgotPasswordDTO) error
+		ResetPassword(dto *auth_dto.ResetPasswordDTO) error
+		EnrollTOTP(userId uint64) (*auth_dto.TOTPEnrollmentDTO, error)
+		ConfirmTOTP(dto *auth_dto.TOTPCodeDTO, userId uint64) (*auth_dto.RecoveryCodesDTO, error)
+		DisableTOTP(dto *auth_dto.TOTPCodeDTO, userId uint64) error
+		VerifyMFA(dto *auth_dto.MFAVerifyDTO, client *auth_dto.ClientInfo) (*auth_dto.TokenResponseDTO, error)
+		SendMFASMSCode(dto *auth_dto.MFASMSCodeDTO) error
+		EnableSMSMFA(userId uint64) (*auth_dto.RecoveryCodesDTO, error)
+		DisableSMSMFA(dto *auth_dto.DisableSMSMFADTO, userId uint64) error
+		SendPhoneVerification(dto *auth_dto.SendPhoneCodeDTO, userId uint64) error
+		VerifyPhone(dto *auth_dto.VerifyPhoneDTO, userId uint64) error
+		ListSessions(userId uint64, currentSessionId string) ([]*auth_dto.SessionDTO, error)
+		RevokeSession(userId uint64, sessionId string) error
+		RevokeOtherSessions(userId uint64, currentSessionId string) error
+		SendMagicLink(dto *auth_dto.MagicLinkDTO) error
+		ConsumeMagicLink(dto *auth_dto.ConsumeMagicLinkDTO, client *auth_dto.ClientInfo) (*auth_dto.TokenResponseDTO, *auth_dto.MFAChallengeDTO, error)
+		StartSocialLogin(providerName string) (*auth_dto.SocialAuthorizationDTO, error)
+		CompleteSocialLogin(providerName string, dto *auth_dto.SocialCallbackDTO, client *auth_dto.ClientInfo) (*auth_dto.TokenResponseDTO, *auth_dto.MFAChallengeDTO, error)
+		IssueTokens(userId uint64, client *auth_dto.ClientInfo) (*auth_dto.TokenResponseDTO, error)
+		CreateAPIKey(dto *auth_dto.CreateAPIKeyDTO, userId uint64) (*auth_dto.CreatedAPIKeyDTO, error)
+		ListAPIKeys(userId uint64) ([]*auth_dto.APIKeyDTO, error)
+		RevokeAPIKey(userId uint64, apiKeyId uint64) error
+		IssueServiceAccountToken(dto *auth_dto.ServiceAccountTokenRequestDTO, clientID string, clientSecret string) (*auth_dto.ServiceAccountTokenDTO, error)
+		BeginWebAuthnRegistration(userId uint64) (*auth_dto.WebAuthnOptionsDTO, error)
+		FinishWebAuthnRegistration(dto *auth_dto.WebAuthnRegisterFinishDTO, userId uint64) (*auth_dto.WebAuthnCredentialDTO, error)
+		ListWebAuthnCredentials(userId uint64) ([]*auth_dto.WebAuthnCredentialDTO, error)
+		DeleteWebAuthnCredential(userId uint64, credentialId uint64) error
+		BeginPasskeyLogin() (*auth_dto.WebAuthnOptionsDTO, error)
+		FinishPasskeyLogin(dto *auth_dto.WebAuthnLoginFinishDTO, client *auth_dto.ClientInfo) (*auth_dto.TokenResponseDTO, error)
+		BeginWebAuthnMFA(dto *auth_dto.WebAuthnMFABeginDTO) (*auth_dto.WebAuthnOptionsDTO, error)
+		FinishWebAuthnMFA(dto *auth_dto.WebAuthnMFAFinishDTO, client *auth_dto.ClientInfo) (*auth_dto.TokenResponseDTO, error)
 	}
 
 	service struct {
-		config *config.Config
-		logger *logger.ZapLogger
+		config         *config.Config
+		logger         *logger.ZapLogger
+		keyManager     jwks.KeyManager
+		revocation     revocation.Store
+		passwordHasher password.Hasher
+		passwordPolicy password.Policy
+
+		identityProviders IdentityProviders
+		webAuthn          *webauthn.WebAuthn
 
 		userService user.Service
 		gmailMailer mailer.GmailMailer
-
-		userRepo         repositories.UserRepository
-		refreshTokenRepo repositories.RefreshTokenRepository
+		smsSender   sms.SMSSender
+
+		userRepo               repositories.UserRepository
+		refreshTokenRepo       repositories.RefreshTokenRepository
+		passwordResetTokenRepo repositories.PasswordResetTokenRepository
+		totpSecretRepo         repositories.TOTPSecretRepository
+		recoveryCodeRepo       repositories.MFARecoveryCodeRepository
+		loginThrottleRepo      repositories.LoginThrottleRepository
+		magicLinkTokenRepo     repositories.MagicLinkTokenRepository
+		userIdentityRepo       repositories.UserIdentityRepository
+		socialLoginStateRepo   repositories.SocialLoginStateRepository
+		apiKeyRepo             repositories.APIKeyRepository
+		serviceAccountRepo     repositories.ServiceAccountRepository
+		verificationCodeRepo   repositories.VerificationCodeRepository
+		webAuthnCredentialRepo repositories.WebAuthnCredentialRepository
+		webAuthnSessionRepo    repositories.WebAuthnSessionRepository
 	}
 )
 
@@ -52,32 +118,78 @@ type (
 func NewService(
 	config *config.Config,
 	logger *logger.ZapLogger,
+	keyManager jwks.KeyManager,
+	revocationStore revocation.Store,
+	passwordHasher password.Hasher,
+	passwordPolicy password.Policy,
+	identityProviders IdentityProviders,
+	webAuthn *webauthn.WebAuthn,
 	userService user.Service,
 	gmailMailer mailer.GmailMailer,
+	smsSender sms.SMSSender,
 	userRepo repositories.UserRepository,
 	refreshTokenRepo repositories.RefreshTokenRepository,
+	passwordResetTokenRepo repositories.PasswordResetTokenRepository,
+	totpSecretRepo repositories.TOTPSecretRepository,
+	recoveryCodeRepo repositories.MFARecoveryCodeRepository,
+	loginThrottleRepo repositories.LoginThrottleRepository,
+	magicLinkTokenRepo repositories.MagicLinkTokenRepository,
+	userIdentityRepo repositories.UserIdentityRepository,
+	socialLoginStateRepo repositories.SocialLoginStateRepository,
+	apiKeyRepo repositories.APIKeyRepository,
+	serviceAccountRepo repositories.ServiceAccountRepository,
+	verificationCodeRepo repositories.VerificationCodeRepository,
+	webAuthnCredentialRepo repositories.WebAuthnCredentialRepository,
+	webAuthnSessionRepo repositories.WebAuthnSessionRepository,
 ) Service {
 	return &service{
-		config:           config,
-		logger:           logger,
-		userService:      userService,
-		gmailMailer:      gmailMailer,
-		userRepo:         userRepo,
-		refreshTokenRepo: refreshTokenRepo,
+		config:                 config,
+		logger:                 logger,
+		keyManager:             keyManager,
+		revocation:             revocationStore,
+		passwordHasher:         passwordHasher,
+		passwordPolicy:         passwordPolicy,
+		identityProviders:      identityProviders,
+		webAuthn:               webAuthn,
+		userService:            userService,
+		gmailMailer:            gmailMailer,
+		smsSender:              smsSender,
+		userRepo:               userRepo,
+		refreshTokenRepo:       refreshTokenRepo,
+		passwordResetTokenRepo: passwordResetTokenRepo,
+		totpSecretRepo:         totpSecretRepo,
+		recoveryCodeRepo:       recoveryCodeRepo,
+		loginThrottleRepo:      loginThrottleRepo,
+		magicLinkTokenRepo:     magicLinkTokenRepo,
+		userIdentityRepo:       userIdentityRepo,
+		socialLoginStateRepo:   socialLoginStateRepo,
+		apiKeyRepo:             apiKeyRepo,
+		serviceAccountRepo:     serviceAccountRepo,
+		verificationCodeRepo:   verificationCodeRepo,
+		webAuthnCredentialRepo: webAuthnCredentialRepo,
+		webAuthnSessionRepo:    webAuthnSessionRepo,
 	}
 }
 
-// Login authenticates a user and returns tokens
-func (s *service) Login(dto *auth_dto.LoginDTO) (*auth_dto.TokenResponseDTO, error) {
+// Login authenticates a user and returns tokens.
+// When the user has MFA enabled, an MFA challenge is returned instead of tokens.
+// Repeated failures are throttled per account and per source IP, see LoginThrottledError.
+func (s *service) Login(dto *auth_dto.LoginDTO, client *auth_dto.ClientInfo) (*auth_dto.TokenResponseDTO, *auth_dto.MFAChallengeDTO, error) {
+	// Refuse attempts while the account or source IP is throttled
+	if err := s.checkLoginThrottle(dto.Email, client); err != nil {
+		return nil, nil, err
+	}
+
 	// Get user by email
 	u, err := s.userRepo.GetByEmail(dto.Email)
 	if err != nil {
 		s.logger.Error("Failed to fetch user by email", zap.String("email", dto.Email), zap.Error(err))
-		return nil, err
+		return nil, nil, err
 	}
 	if u == nil {
 		s.logger.Info("Login attempt with non-existent email", zap.String("email", dto.Email))
-		return nil, ErrInvalidCredentials
+		s.recordLoginFailure(nil, dto.Email, client)
+		return nil, nil, ErrInvalidCredentials
 	}
 
 	// Check if user is active
@@ -85,20 +197,72 @@ func (s *service) Login(dto *auth_dto.LoginDTO) (*auth_dto.TokenResponseDTO, err
 		s.logger.Info("Login attempt with inactive account",
 			zap.String("email", dto.Email),
 			zap.Uint8("status", u.Status))
-		return nil, ErrUserNotActive
+		return nil, nil, ErrUserNotActive
 	}
 
 	// Verify password
-	err = bcrypt.CompareHashAndPassword([]byte(u.Password), []byte(dto.Password))
+	match, needsRehash, err := s.passwordHasher.Verify(u.Password, dto.Password)
 	if err != nil {
+		s.logger.Error("Failed to verify password", zap.Uint64("user_id", u.ID), zap.Error(err))
+		return nil, nil, err
+	}
+	if !match {
 		s.logger.Info("Failed password verification", zap.String("email", dto.Email))
-		return nil, ErrInvalidCredentials
+		s.recordLoginFailure(u, dto.Email, client)
+		return nil, nil, ErrInvalidCredentials
+	}
+
+	// Upgrade a hash made with an outdated algorithm or parameters while the password is at hand
+	if needsRehash {
+		s.rehashPassword(u, dto.Password)
 	}
 
+	// Require a second factor before issuing tokens, failed attempts are only forgotten once it is verified
+	if u.MFAEnabled {
+		challenge, err := s.issueMFAChallenge(u)
+		if err != nil {
+			s.logger.Error("Failed to issue MFA challenge",
+				zap.Uint64("user_id", u.ID),
+				zap.Error(err))
+			return nil, nil, err
+		}
+
+		s.logger.Info("MFA challenge issued", zap.Uint64("user_id", u.ID))
+		return nil, challenge, nil
+	}
+	s.clearLoginThrottle(dto.Email)
+
+	tokens, err := s.completeLogin(u, client)
+	if err != nil {
+		return nil, nil, err
+	}
+	return tokens, nil, nil
+}
+
+// rehashPassword stores a new hash of the password made with the configured algorithm.
+// Failures are only logged, the old hash keeps working.
+func (s *service) rehashPassword(u *models.User, plainPassword string) {
+	hashedPassword, err := s.passwordHasher.Hash(plainPassword)
+	if err != nil {
+		s.logger.Error("Failed to rehash password", zap.Uint64("user_id", u.ID), zap.Error(err))
+		return
+	}
+
+	u.Password = hashedPassword
+	if err := s.userRepo.Update(u); err != nil {
+		s.logger.Error("Failed to save rehashed password", zap.Uint64("user_id", u.ID), zap.Error(err))
+		return
+	}
+
+	s.logger.Info("Password hash upgraded", zap.Uint64("user_id", u.ID))
+}
+
+// completeLogin records the login and issues tokens for a fully authenticated user
+func (s *service) completeLogin(u *models.User, client *auth_dto.ClientInfo) (*auth_dto.TokenResponseDTO, error) {
 	// Update last login timestamp
 	now := time.Now()
 	u.LastLoginAt = &now
-	err = s.userRepo.Update(u)
+	err := s.userRepo.Update(u)
 	if err != nil {
 		s.logger.Error("Failed to update last login time",
 			zap.String("email", u.Email),
@@ -108,7 +272,7 @@ func (s *service) Login(dto *auth_dto.LoginDTO) (*auth_dto.TokenResponseDTO, err
 	}
 
 	// Generate tokens
-	tokens, err := s.generateTokens(u)
+	tokens, err := s.generateTokens(u, nil, client)
 	if err != nil {
 		s.logger.Error("Failed to generate tokens",
 			zap.String("email", u.Email),
@@ -124,7 +288,7 @@ func (s *service) Login(dto *auth_dto.LoginDTO) (*auth_dto.TokenResponseDTO, err
 }
 
 // Register creates a new user and returns tokens
-func (s *service) Register(dto *auth_dto.RegisterDTO) (*auth_dto.TokenResponseDTO, error) {
+func (s *service) Register(dto *auth_dto.RegisterDTO, client *auth_dto.ClientInfo) (*auth_dto.TokenResponseDTO, error) {
 	// Convert RegisterDTO to user.CreateUserDTO
 	createUserDto := &user_dto.CreateUserDTO{
 		Email:       dto.Email,
@@ -160,7 +324,7 @@ func (s *service) Register(dto *auth_dto.RegisterDTO) (*auth_dto.TokenResponseDT
 	}
 
 	// Generate tokens
-	tokens, err := s.generateTokens(createdUser)
+	tokens, err := s.generateTokens(createdUser, nil, client)
 	if err != nil {
 		s.logger.Error("Failed to generate tokens for new user",
 			zap.String("email", createdUser.Email),
@@ -185,30 +349,52 @@ func (s *service) Register(dto *auth_dto.RegisterDTO) (*auth_dto.TokenResponseDT
 	return tokens, nil
 }
 
-// RefreshToken validates a refresh token and issues new tokens
-func (s *service) RefreshToken(dto *auth_dto.RefreshTokenDTO) (*auth_dto.TokenResponseDTO, error) {
+// RefreshToken validates a refresh token and issues new tokens.
+// Presenting a token that was already rotated revokes its whole token family.
+func (s *service) RefreshToken(dto *auth_dto.RefreshTokenDTO, client *auth_dto.ClientInfo) (*auth_dto.TokenResponseDTO, error) {
+	tokenHash := util.HashToken(dto.RefreshToken)
+
 	// Get refresh token from database
-	savedToken, err := s.refreshTokenRepo.GetRefreshToken(dto.RefreshToken)
+	savedToken, err := s.refreshTokenRepo.GetRefreshToken(tokenHash)
 	if err != nil {
-		s.logger.Warn("Failed to retrieve refresh token",
-			zap.String("token", dto.RefreshToken),
-			zap.Error(err))
+		s.logger.Warn("Failed to retrieve refresh token", zap.Error(err))
 		return nil, ErrInvalidRefreshToken
 	}
 	if savedToken == nil {
-		s.logger.Warn("Refresh token not found", zap.String("token", dto.RefreshToken))
+		s.logger.Warn("Refresh token not found")
+		return nil, ErrInvalidRefreshToken
+	}
+
+	// Tokens granted to an oauth client can only be refreshed by that client
+	clientID, boundClientID := "", ""
+	if client != nil {
+		clientID = client.OAuthClientID
+	}
+	if savedToken.ClientID != nil {
+		boundClientID = *savedToken.ClientID
+	}
+	if clientID != boundClientID {
+		s.logger.Warn("Refresh token used by another client",
+			zap.Uint64("token_id", savedToken.ID),
+			zap.String("client_id", clientID))
+		return nil, ErrInvalidRefreshToken
+	}
+
+	// A revoked token being presented again means it was stolen or replayed
+	if savedToken.RevokedAt != nil {
+		s.handleRefreshTokenReuse(savedToken)
 		return nil, ErrInvalidRefreshToken
 	}
 
 	// Check if token is expired
 	if time.Now().After(savedToken.ExpiresAt) {
 		s.logger.Warn("Expired refresh token used",
-			zap.String("token", dto.RefreshToken),
+			zap.Uint64("token_id", savedToken.ID),
 			zap.Time("expires_at", savedToken.ExpiresAt))
 		// Clean up expired token
-		if err := s.refreshTokenRepo.DeleteRefreshToken(dto.RefreshToken); err != nil {
+		if err := s.refreshTokenRepo.DeleteRefreshToken(tokenHash); err != nil {
 			s.logger.Error("Failed to delete expired token",
-				zap.String("token", dto.RefreshToken),
+				zap.Uint64("token_id", savedToken.ID),
 				zap.Error(err))
 		}
 		return nil, ErrInvalidRefreshToken
@@ -236,16 +422,21 @@ func (s *service) RefreshToken(dto *auth_dto.RefreshTokenDTO) (*auth_dto.TokenRe
 		return nil, ErrUserNotActive
 	}
 
-	// Remove used refresh token
-	if err = s.refreshTokenRepo.DeleteRefreshToken(dto.RefreshToken); err != nil {
-		s.logger.Error("Failed to delete used refresh token",
-			zap.String("token", dto.RefreshToken),
+	// Revoke used refresh token, losing a race against a concurrent rotation counts as reuse
+	rotated, err := s.refreshTokenRepo.RevokeRefreshToken(savedToken.ID)
+	if err != nil {
+		s.logger.Error("Failed to revoke used refresh token",
+			zap.Uint64("token_id", savedToken.ID),
 			zap.Error(err))
-		// Continue despite error - don't block token refresh
+		return nil, err
+	}
+	if !rotated {
+		s.handleRefreshTokenReuse(savedToken)
+		return nil, ErrInvalidRefreshToken
 	}
 
-	// Generate new tokens
-	tokens, err := s.generateTokens(u)
+	// Generate new tokens in the same family
+	tokens, err := s.generateTokens(u, savedToken, client)
 	if err != nil {
 		s.logger.Error("Failed to generate new tokens",
 			zap.Uint64("user_id", u.ID),
@@ -257,10 +448,54 @@ func (s *service) RefreshToken(dto *auth_dto.RefreshTokenDTO) (*auth_dto.TokenRe
 	return tokens, nil
 }
 
-// generateTokens generates JWT access and refresh tokens
-func (s *service) generateTokens(user *models.User) (*auth_dto.TokenResponseDTO, error) {
+// handleRefreshTokenReuse revokes the family of a replayed refresh token and logs a security event
+func (s *service) handleRefreshTokenReuse(token *models.RefreshToken) {
+	s.logger.Warn("Security event: refresh token reuse detected, revoking token family",
+		zap.String("security_event", "refresh_token_reuse"),
+		zap.Uint64("user_id", token.UserID),
+		zap.Uint64("token_id", token.ID),
+		zap.String("family_id", token.FamilyID))
+
+	if err := s.refreshTokenRepo.RevokeRefreshTokenFamily(token.FamilyID); err != nil {
+		s.logger.Error("Failed to revoke refresh token family",
+			zap.String("family_id", token.FamilyID),
+			zap.Error(err))
+	}
+}
+
+// IssueTokens starts a new session for an active user that was authenticated elsewhere,
+// such as by the authorization code grant of the oauth module
+func (s *service) IssueTokens(userId uint64, client *auth_dto.ClientInfo) (*auth_dto.TokenResponseDTO, error) {
+	u, err := s.userRepo.GetByID(userId)
+	if err != nil {
+		s.logger.Error("Failed to fetch user by ID", zap.Uint64("user_id", userId), zap.Error(err))
+		return nil, err
+	}
+	if u == nil {
+		s.logger.Warn("Tokens requested for non-existent user", zap.Uint64("user_id", userId))
+		return nil, ErrUserNotFound
+	}
+	if u.Status != models.USER_STATUS_ACTIVE {
+		s.logger.Info("Tokens requested for inactive user", zap.Uint64("user_id", userId))
+		return nil, ErrUserNotActive
+	}
+
+	tokens, err := s.generateTokens(u, nil, client)
+	if err != nil {
+		s.logger.Error("Failed to generate tokens",
+			zap.Uint64("user_id", u.ID),
+			zap.Error(err))
+		return nil, err
+	}
+	return tokens, nil
+}
+
+// generateTokens generates JWT access and refresh tokens.
+// A new token family is started unless the tokens replace a parent refresh token.
+// The client info describes the device of the session and the oauth client it is granted to,
+// refreshed tokens keep the oauth client and scope of their parent.
+func (s *service) generateTokens(user *models.User, parent *models.RefreshToken, client *auth_dto.ClientInfo) (*auth_dto.TokenResponseDTO, error) {
 	// Get JWT config
-	jwtSecret := []byte(s.config.JWT.Secret)
 	accessTokenExpiry := time.Duration(s.config.JWT.AccessExpiryMinutes) * time.Minute
 	refreshTokenExpiry := time.Duration(s.config.JWT.RefreshExpiryDays) * 24 * time.Hour
 
@@ -271,39 +506,52 @@ func (s *service) generateTokens(user *models.User) (*auth_dto.TokenResponseDTO,
 		zap.Duration("access_duration", accessTokenExpiry),
 		zap.Duration("refresh_duration", refreshTokenExpiry))
 
-	// Create access token
-	accessToken := jwt.New(jwt.SigningMethodHS256)
-	accessClaims := accessToken.Claims.(jwt.MapClaims)
-	accessClaims["user_id"] = user.ID
-	accessClaims["email"] = user.Email
-	accessClaims["exp"] = time.Now().Add(accessTokenExpiry).Unix()
-
-	// Sign access token
-	accessTokenString, err := accessToken.SignedString(jwtSecret)
-	if err != nil {
-		s.logger.Error("Failed to sign access token", zap.Error(err))
-		return nil, err
-	}
-
 	// Create refresh token
-	refreshToken := jwt.New(jwt.SigningMethodHS256)
-	refreshClaims := refreshToken.Claims.(jwt.MapClaims)
+	refreshClaims := jwt.MapClaims{}
 	refreshClaims["user_id"] = user.ID
 	refreshClaims["email"] = user.Email
+	refreshClaims["typ"] = middleware.TokenTypeRefresh
+	refreshClaims["jti"] = uuid.NewString()
 	refreshClaims["exp"] = time.Now().Add(refreshTokenExpiry).Unix()
 
 	// Sign refresh token
-	refreshTokenString, err := refreshToken.SignedString(jwtSecret)
+	refreshTokenString, err := s.keyManager.Sign(refreshClaims)
 	if err != nil {
 		s.logger.Error("Failed to sign refresh token", zap.Error(err))
 		return nil, err
 	}
 
-	// Save refresh token to database
+	// Tokens issued at or before the revocation watermark of the user are rejected
+	if err := s.waitPastWatermark(user.ID); err != nil {
+		return nil, err
+	}
+
+	// Save refresh token hash to database
+	now := time.Now()
 	refreshTokenModel := models.RefreshToken{
-		UserID:    user.ID,
-		Token:     refreshTokenString,
-		ExpiresAt: time.Now().Add(refreshTokenExpiry),
+		UserID:     user.ID,
+		TokenHash:  util.HashToken(refreshTokenString),
+		FamilyID:   uuid.NewString(),
+		LastUsedAt: &now,
+		ExpiresAt:  now.Add(refreshTokenExpiry),
+	}
+	if parent != nil {
+		refreshTokenModel.FamilyID = parent.FamilyID
+		refreshTokenModel.ParentID = &parent.ID
+		refreshTokenModel.DeviceLabel = parent.DeviceLabel
+		refreshTokenModel.ClientID = parent.ClientID
+		refreshTokenModel.Scope = parent.Scope
+	}
+	if parent == nil && client != nil {
+		refreshTokenModel.ClientID = nonEmpty(client.OAuthClientID)
+		refreshTokenModel.Scope = nonEmpty(client.Scope)
+	}
+	if client != nil {
+		refreshTokenModel.UserAgent = nonEmpty(client.UserAgent)
+		refreshTokenModel.IPAddress = nonEmpty(client.IPAddress)
+		if client.DeviceLabel != "" {
+			refreshTokenModel.DeviceLabel = &client.DeviceLabel
+		}
 	}
 
 	err = s.refreshTokenRepo.SaveRefreshToken(&refreshTokenModel)
@@ -312,6 +560,29 @@ func (s *service) generateTokens(user *models.User) (*auth_dto.TokenResponseDTO,
 		return nil, err
 	}
 
+	// Create access token, bound to the session (token family) of the refresh token
+	accessClaims := jwt.MapClaims{}
+	accessClaims["user_id"] = user.ID
+	accessClaims["email"] = user.Email
+	accessClaims["sid"] = refreshTokenModel.FamilyID
+	accessClaims["typ"] = middleware.TokenTypeAccess
+	accessClaims["jti"] = uuid.NewString()
+	accessClaims["iat"] = now.Unix()
+	accessClaims["exp"] = now.Add(accessTokenExpiry).Unix()
+	if refreshTokenModel.ClientID != nil {
+		accessClaims["client_id"] = *refreshTokenModel.ClientID
+		if refreshTokenModel.Scope != nil {
+			accessClaims["scope"] = *refreshTokenModel.Scope
+		}
+	}
+
+	// Sign access token
+	accessTokenString, err := s.keyManager.Sign(accessClaims)
+	if err != nil {
+		s.logger.Error("Failed to sign access token", zap.Error(err))
+		return nil, err
+	}
+
 	// Create response
 	return &auth_dto.TokenResponseDTO{
 		AccessToken:  accessTokenString,
@@ -321,104 +592,194 @@ func (s *service) generateTokens(user *models.User) (*auth_dto.TokenResponseDTO,
 	}, nil
 }
 
-func (s *service) VerifyEmail(ved *auth_dto.VerifyEmailDTO, userId uint64) error {
-	// Get user by ID
-	u, err := s.userRepo.GetByID(userId)
+// waitPastWatermark waits for the second after the revocation watermark of a user. The iat claim
+// only has second precision, so an access token issued in the second of a revocation, like the
+// one the caller of a password change gets by refreshing, would be rejected by the watermark.
+func (s *service) waitPastWatermark(userId uint64) error {
+	revokedBefore, err := s.revocation.UserTokensRevokedBefore(userId)
+	if err != nil {
+		s.logger.Error("Failed to fetch revocation watermark", zap.Uint64("user_id", userId), zap.Error(err))
+		return err
+	}
+	if revokedBefore == nil {
+		return nil
+	}
+
+	if wait := time.Until(revokedBefore.Truncate(time.Second).Add(time.Second)); wait > 0 {
+		time.Sleep(wait)
+	}
+	return nil
+}
 
-	// Check if there was an error
+// Logout invalidates user tokens
+func (s *service) Logout(dto *auth_dto.LogoutDTO) error {
+	// Delete all refresh tokens for user
+	err := s.refreshTokenRepo.DeleteUserRefreshTokens(dto.UserId)
 	if err != nil {
-		s.logger.Error("Failed to fetch user by ID", zap.Uint64("user_id", userId), zap.Error(err))
+		s.logger.Error("Failed to delete refresh tokens for user",
+			zap.Uint64("user_id", dto.UserId),
+			zap.Error(err))
 		return err
 	}
 
-	// Check if user exists
-	if u == nil {
-		s.logger.Warn("User not found", zap.Uint64("user_id", userId))
-		return ErrUserNotFound
+	// Invalidate access tokens that were already issued
+	if err := s.revocation.RevokeUserTokens(dto.UserId, time.Now()); err != nil {
+		s.logger.Error("Failed to revoke access tokens for user",
+			zap.Uint64("user_id", dto.UserId),
+			zap.Error(err))
+		return err
 	}
 
-	// Check if user is already verified
-	if u.EmailVerified {
-		s.logger.Warn("User already verified", zap.Uint64("user_id", userId))
-		return nil
+	s.logger.Info("User logged out", zap.Uint64("user_id", dto.UserId))
+	return nil
+}
+
+// ForgotPassword sends a password reset link to the user's email.
+// It never reveals whether the email belongs to an account.
+func (s *service) ForgotPassword(dto *auth_dto.ForgotPasswordDTO) error {
+	// Do the lookup and send the email in the background so the response
+	// time is the same whether the account exists or not
+	go func() {
+		u, err := s.userRepo.GetByEmail(dto.Email)
+		if err != nil {
+			s.logger.Error("Failed to fetch user by email", zap.String("email", dto.Email), zap.Error(err))
+			return
+		}
+		if u == nil || u.ID == 0 {
+			s.logger.Info("Password reset requested for non-existent email", zap.String("email", dto.Email))
+			return
+		}
+		if u.Status != models.USER_STATUS_ACTIVE {
+			s.logger.Info("Password reset requested for inactive account", zap.Uint64("user_id", u.ID))
+			return
+		}
+
+		if err := s.sendPasswordResetEmail(u); err != nil {
+			s.logger.Error("Failed to send password reset email",
+				zap.Uint64("user_id", u.ID),
+				zap.Error(err))
+		}
+	}()
+
+	return nil
+}
+
+// sendPasswordResetEmail issues a new reset token and emails the reset link to the user
+func (s *service) sendPasswordResetEmail(u *models.User) error {
+	// Invalidate previously issued reset tokens
+	if err := s.passwordResetTokenRepo.DeleteUserResetTokens(u.ID); err != nil {
+		s.logger.Error("Failed to delete outstanding reset tokens", zap.Uint64("user_id", u.ID), zap.Error(err))
+		return err
 	}
 
-	// Check if verification code is correct
-	if *u.VerifyEmailCode != *ved.Code {
-		s.logger.Warn("Invalid verification code", zap.Uint64("user_id", userId), zap.Any("code", ved.Code), zap.Any("expected", u.VerifyEmailCode))
-		return ErrInvalidVerifyCode
+	token, err := util.GenerateRandomToken(32)
+	if err != nil {
+		s.logger.Error("Failed to generate reset token", zap.Error(err))
+		return err
 	}
 
-	// Update user
-	u.EmailVerified = true
-	u.VerifyEmailCode = nil
-	err = s.userRepo.Update(u)
+	expiryMinutes := s.config.Auth.PasswordResetExpiryMinutes
+	resetToken := models.PasswordResetToken{
+		UserID:    u.ID,
+		TokenHash: util.HashToken(token),
+		ExpiresAt: time.Now().Add(time.Duration(expiryMinutes) * time.Minute),
+	}
+	if err := s.passwordResetTokenRepo.SaveResetToken(&resetToken); err != nil {
+		s.logger.Error("Failed to save reset token", zap.Uint64("user_id", u.ID), zap.Error(err))
+		return err
+	}
 
-	// Check if there was an error
+	mailData, err := util.StructToMap(&mailer.PasswordResetData{
+		Name:             u.FullName(),
+		Link:             fmt.Sprintf("%s/reset-password?token=%s", s.config.App.FrontendURL, url.QueryEscape(token)),
+		ExpiresInMinutes: expiryMinutes,
+	})
 	if err != nil {
-		s.logger.Error("Failed to update user", zap.Uint64("user_id", userId), zap.Error(err))
+		s.logger.Error("[sendPasswordResetEmail] Failed to convert struct to map", zap.Error(err))
 		return err
 	}
 
-	// Log success
-	s.logger.Info("User email verified", zap.Uint64("user_id", userId))
-	return nil
+	return s.gmailMailer.SendTemplatedEmail(
+		u.Email,
+		mailer.PasswordResetSubject,
+		mailer.PasswordResetTemplate,
+		mailData,
+	)
 }
 
-func (s *service) SendVerifyEmailCode(userId uint64) error {
-	code := util.GenerateRandomCode(6)
+// ResetPassword sets a new password using a reset token and revokes all sessions of the user
+func (s *service) ResetPassword(dto *auth_dto.ResetPasswordDTO) error {
+	// Get reset token from database
+	resetToken, err := s.passwordResetTokenRepo.GetValidResetToken(util.HashToken(dto.Token))
+	if err != nil {
+		s.logger.Error("Failed to retrieve reset token", zap.Error(err))
+		return err
+	}
+	if resetToken == nil {
+		s.logger.Warn("Invalid or expired reset token used")
+		return ErrInvalidResetToken
+	}
 
-	// update code in user
-	u, err := s.userRepo.GetByID(userId)
+	// Get user
+	u, err := s.userRepo.GetByID(resetToken.UserID)
 	if err != nil {
-		s.logger.Error("Failed to fetch user by ID", zap.Uint64("user_id", userId), zap.Error(err))
+		s.logger.Error("Failed to fetch user by ID", zap.Uint64("user_id", resetToken.UserID), zap.Error(err))
 		return err
 	}
 	if u == nil {
-		s.logger.Warn("User not found", zap.Uint64("user_id", userId))
-		return ErrUserNotFound
+		s.logger.Warn("Reset token used for non-existent user", zap.Uint64("user_id", resetToken.UserID))
+		return ErrInvalidResetToken
+	}
+	if u.Status != models.USER_STATUS_ACTIVE {
+		s.logger.Warn("Reset token used for inactive user", zap.Uint64("user_id", u.ID))
+		return ErrUserNotActive
 	}
 
-	// update code in user
-	u.VerifyEmailCode = &code
+	// Check the new password before the token is consumed, so the user can try another one
+	if err := s.passwordPolicy.Check(dto.NewPassword, u.Email, u.FirstName, u.LastName); err != nil {
+		return err
+	}
 
-	// update user
-	err = s.userRepo.Update(u)
+	// Consume the token before changing anything so it can only be used once
+	consumed, err := s.passwordResetTokenRepo.MarkResetTokenUsed(resetToken.ID)
 	if err != nil {
-		s.logger.Error("Failed to update user", zap.Uint64("user_id", userId), zap.Error(err))
-		return ErrUpdateUserFailed
+		s.logger.Error("Failed to mark reset token as used", zap.Uint64("user_id", u.ID), zap.Error(err))
+		return err
+	}
+	if !consumed {
+		s.logger.Warn("Reset token already used", zap.Uint64("user_id", u.ID))
+		return ErrInvalidResetToken
 	}
 
-	mailData, err := util.StructToMap(&mailer.EmailVerificationData{
-		Name: u.FullName(),
-		Code: code,
-	})
+	// Hash new password
+	hashedPassword, err := s.passwordHasher.Hash(dto.NewPassword)
 	if err != nil {
-		s.logger.Error("[SendVerifyEmailCode] Failed to convert struct to map", zap.Error(err))
+		s.logger.Error("Failed to hash password", zap.Uint64("user_id", u.ID), zap.Error(err))
 		return err
 	}
 
-	// send email
-	return s.gmailMailer.SendTemplatedEmail(
-		u.Email,
-		mailer.EmailVerificationSubject,
-		mailer.EmailVerificationTemplate,
-		mailData,
-	)
+	// Update user
+	u.Password = hashedPassword
+	if err := s.userRepo.Update(u); err != nil {
+		s.logger.Error("Failed to update user", zap.Uint64("user_id", u.ID), zap.Error(err))
+		return ErrUpdateUserFailed
+	}
 
-}
+	// Invalidate any other reset tokens
+	if err := s.passwordResetTokenRepo.DeleteUserResetTokens(u.ID); err != nil {
+		s.logger.Error("Failed to delete outstanding reset tokens", zap.Uint64("user_id", u.ID), zap.Error(err))
+	}
 
-// Logout invalidates user tokens
-func (s *service) Logout(dto *auth_dto.LogoutDTO) error {
-	// Delete all refresh tokens for user
-	err := s.refreshTokenRepo.DeleteUserRefreshTokens(dto.UserId)
-	if err != nil {
-		s.logger.Error("Failed to delete refresh tokens for user",
-			zap.Uint64("user_id", dto.UserId),
-			zap.Error(err))
+	// Revoke all sessions
+	if err := s.refreshTokenRepo.DeleteUserRefreshTokens(u.ID); err != nil {
+		s.logger.Error("Failed to delete refresh tokens for user", zap.Uint64("user_id", u.ID), zap.Error(err))
+		return err
+	}
+	if err := s.revocation.RevokeUserTokens(u.ID, time.Now()); err != nil {
+		s.logger.Error("Failed to revoke access tokens for user", zap.Uint64("user_id", u.ID), zap.Error(err))
 		return err
 	}
 
-	s.logger.Info("User logged out", zap.Uint64("user_id", dto.UserId))
+	s.logger.Info("User password reset", zap.Uint64("user_id", u.ID))
 	return nil
 }
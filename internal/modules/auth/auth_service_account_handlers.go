@@ -0,0 +1,57 @@
+package auth
+
+import (
+	"modular-fx-fiber/internal/shared/dto/auth_dto"
+	"modular-fx-fiber/internal/shared/util"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ServiceAccountToken handles client_credentials token requests of service accounts
+// @Summary Service account token
+// @Description Issue an access token to a service account with the client_credentials grant. The account authenticates with HTTP Basic or client_id and client_secret. The token is accepted on routes open to service accounts, its scopes are the permissions of the account's roles
+// @Tags auth
+// @Accept x-www-form-urlencoded
+// @Produce json
+// @Security BasicAuth
+// @Param grant_type formData string true "Must be client_credentials"
+// @Param client_id formData string false "Client ID, when not using HTTP Basic"
+// @Param client_secret formData string false "Client secret, when not using HTTP Basic"
+// @Success 200 {object} auth_dto.ServiceAccountTokenDTO
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Router /auth/token [post]
+func (h *handlers) ServiceAccountToken(c *fiber.Ctx) error {
+	var tokenRequestDto auth_dto.ServiceAccountTokenRequestDTO
+
+	// Parse request body
+	if err := c.BodyParser(&tokenRequestDto); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	// Validate request body
+	errs := h.validator.Validate(&tokenRequestDto)
+	if errs != nil {
+		err := h.validator.ParseErrorToString(errs)
+		return fiber.NewError(fiber.StatusBadRequest, err)
+	}
+
+	// Get client credentials
+	clientID, clientSecret, ok := util.BasicCredentials(c.Get(fiber.HeaderAuthorization))
+	if !ok {
+		clientID = c.FormValue("client_id")
+		clientSecret = c.FormValue("client_secret")
+	}
+
+	token, err := h.service.IssueServiceAccountToken(&tokenRequestDto, clientID, clientSecret)
+	if err != nil {
+		if err == ErrInvalidClientCredentials {
+			return fiber.NewError(fiber.StatusUnauthorized, err.Error())
+		}
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	// Return response
+	c.Set(fiber.HeaderCacheControl, "no-store")
+	return c.JSON(token)
+}
@@ -0,0 +1,102 @@
+package auth
+
+import (
+	"crypto/subtle"
+	"errors"
+	"modular-fx-fiber/internal/shared/dto/auth_dto"
+	"modular-fx-fiber/internal/shared/dto/oauth_dto"
+	"modular-fx-fiber/internal/shared/middleware"
+	"modular-fx-fiber/internal/shared/models"
+	"modular-fx-fiber/internal/shared/util"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrUnsupportedGrantType     = errors.New("grant_type must be client_credentials")
+	ErrInvalidClientCredentials = errors.New("invalid client credentials")
+)
+
+// IssueServiceAccountToken authenticates a service account with its client credentials and issues
+// an access token for it. The token carries the permissions of the account's roles as scopes,
+// so changes to the roles apply to the next token.
+func (s *service) IssueServiceAccountToken(dto *auth_dto.ServiceAccountTokenRequestDTO, clientID string, clientSecret string) (*auth_dto.ServiceAccountTokenDTO, error) {
+	if dto.GrantType != oauth_dto.GrantTypeClientCredentials {
+		return nil, ErrUnsupportedGrantType
+	}
+	if clientID == "" || clientSecret == "" {
+		return nil, ErrInvalidClientCredentials
+	}
+
+	account, err := s.serviceAccountRepo.GetByClientID(clientID)
+	if err != nil {
+		s.logger.Error("Failed to fetch service account", zap.String("client_id", clientID), zap.Error(err))
+		return nil, err
+	}
+	if account == nil || !account.Active {
+		s.logger.Warn("Token request for unknown or inactive service account", zap.String("client_id", clientID))
+		return nil, ErrInvalidClientCredentials
+	}
+
+	secret, err := s.matchServiceAccountSecret(account, clientSecret)
+	if err != nil {
+		return nil, err
+	}
+	if secret == nil {
+		s.logger.Warn("Invalid service account secret", zap.Uint64("service_account_id", account.ID))
+		return nil, ErrInvalidClientCredentials
+	}
+
+	if err := s.serviceAccountRepo.TouchSecret(secret.ID); err != nil {
+		s.logger.Warn("Failed to record service account secret use", zap.Uint64("service_account_id", account.ID), zap.Error(err))
+	}
+
+	scope := strings.Join(account.Scopes(), " ")
+	expiry := time.Duration(s.config.Auth.ServiceAccount.TokenExpiryMinutes) * time.Minute
+	now := time.Now()
+	claims := jwt.MapClaims{}
+	claims["sub_typ"] = middleware.SubjectTypeServiceAccount
+	claims["service_account_id"] = account.ID
+	claims["scope"] = scope
+	claims["typ"] = middleware.TokenTypeAccess
+	claims["jti"] = uuid.NewString()
+	claims["iat"] = now.Unix()
+	claims["exp"] = now.Add(expiry).Unix()
+
+	accessToken, err := s.keyManager.Sign(claims)
+	if err != nil {
+		s.logger.Error("Failed to sign service account token", zap.Uint64("service_account_id", account.ID), zap.Error(err))
+		return nil, err
+	}
+
+	s.logger.Info("Service account token issued",
+		zap.Uint64("service_account_id", account.ID),
+		zap.String("scope", scope))
+	return &auth_dto.ServiceAccountTokenDTO{
+		AccessToken: accessToken,
+		TokenType:   "Bearer",
+		ExpiresIn:   uint(expiry.Seconds()),
+		Scope:       scope,
+	}, nil
+}
+
+// matchServiceAccountSecret returns the unexpired secret of the account matching the given one, or nil
+func (s *service) matchServiceAccountSecret(account *models.ServiceAccount, clientSecret string) (*models.ServiceAccountSecret, error) {
+	secrets, err := s.serviceAccountRepo.GetActiveSecrets(account.ID)
+	if err != nil {
+		s.logger.Error("Failed to fetch service account secrets", zap.Uint64("service_account_id", account.ID), zap.Error(err))
+		return nil, err
+	}
+
+	hash := []byte(util.HashToken(clientSecret))
+	for i := range secrets {
+		if subtle.ConstantTimeCompare(hash, []byte(secrets[i].SecretHash)) == 1 {
+			return &secrets[i], nil
+		}
+	}
+	return nil, nil
+}
@@ -0,0 +1,102 @@
+package auth
+
+import (
+	"modular-fx-fiber/internal/shared/dto/auth_dto"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// maxUserAgentLength matches the size of the refresh_tokens.user_agent column
+const maxUserAgentLength = 512
+
+// ListSessions handles listing the current user's sessions
+// @Summary List sessions
+// @Description List the devices the current user is logged in on
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} auth_dto.ListSessionsSuccessResponseDTO
+// @Router /auth/sessions [get]
+func (h *handlers) ListSessions(c *fiber.Ctx) error {
+	// Get user and session ID from context
+	userId := c.Locals("user_id").(uint64)
+	sessionId := c.Locals("session_id").(string)
+
+	sessions, err := h.service.ListSessions(userId, sessionId)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	// Return response
+	return c.JSON(&auth_dto.ListSessionsSuccessResponseDTO{
+		Success: true,
+		Data:    sessions,
+	})
+}
+
+// RevokeSession handles logging out a single session
+// @Summary Revoke session
+// @Description Log out one of the current user's sessions
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Session ID"
+// @Success 200 {object} auth_dto.RevokeSessionSuccessResponseDTO
+// @Router /auth/sessions/{id} [delete]
+func (h *handlers) RevokeSession(c *fiber.Ctx) error {
+	// Get user ID from context
+	userId := c.Locals("user_id").(uint64)
+
+	err := h.service.RevokeSession(userId, c.Params("id"))
+	if err != nil {
+		if err == ErrSessionNotFound {
+			return fiber.NewError(fiber.StatusNotFound, err.Error())
+		}
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	// Return response
+	return c.JSON(&auth_dto.RevokeSessionSuccessResponseDTO{
+		Success: true,
+	})
+}
+
+// RevokeOtherSessions handles logging out every session except the current one
+// @Summary Log out other sessions
+// @Description Log out everywhere except the current session
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} auth_dto.RevokeSessionSuccessResponseDTO
+// @Router /auth/sessions/logout-others [post]
+func (h *handlers) RevokeOtherSessions(c *fiber.Ctx) error {
+	// Get user and session ID from context
+	userId := c.Locals("user_id").(uint64)
+	sessionId := c.Locals("session_id").(string)
+
+	if err := h.service.RevokeOtherSessions(userId, sessionId); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	// Return response
+	return c.JSON(&auth_dto.RevokeSessionSuccessResponseDTO{
+		Success: true,
+	})
+}
+
+// clientInfo collects the device information of the request for the session
+func clientInfo(c *fiber.Ctx, deviceLabel string) *auth_dto.ClientInfo {
+	userAgent := c.Get(fiber.HeaderUserAgent)
+	if len(userAgent) > maxUserAgentLength {
+		userAgent = userAgent[:maxUserAgentLength]
+	}
+
+	return &auth_dto.ClientInfo{
+		UserAgent:   userAgent,
+		IPAddress:   c.IP(),
+		DeviceLabel: deviceLabel,
+	}
+}
@@ -0,0 +1,107 @@
+package auth
+
+import (
+	"errors"
+	"modular-fx-fiber/internal/shared/dto/auth_dto"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+var (
+	ErrSessionNotFound = errors.New("session not found")
+)
+
+// ListSessions lists the active sessions of a user, flagging the caller's session
+func (s *service) ListSessions(userId uint64, currentSessionId string) ([]*auth_dto.SessionDTO, error) {
+	refreshTokens, err := s.refreshTokenRepo.ListActiveUserRefreshTokens(userId)
+	if err != nil {
+		s.logger.Error("Failed to list refresh tokens", zap.Uint64("user_id", userId), zap.Error(err))
+		return nil, err
+	}
+
+	sessions := make([]*auth_dto.SessionDTO, 0, len(refreshTokens))
+	for _, token := range refreshTokens {
+		sessions = append(sessions, &auth_dto.SessionDTO{
+			ID:          token.FamilyID,
+			DeviceLabel: token.DeviceLabel,
+			UserAgent:   token.UserAgent,
+			IPAddress:   token.IPAddress,
+			LastUsedAt:  token.LastUsedAt,
+			ExpiresAt:   token.ExpiresAt,
+			Current:     token.FamilyID == currentSessionId,
+		})
+	}
+
+	return sessions, nil
+}
+
+// RevokeSession logs out one session of a user. Its refresh tokens are deleted and the
+// access tokens it was issued are rejected at once.
+func (s *service) RevokeSession(userId uint64, sessionId string) error {
+	deleted, err := s.refreshTokenRepo.DeleteUserRefreshTokenFamily(userId, sessionId)
+	if err != nil {
+		s.logger.Error("Failed to delete session",
+			zap.Uint64("user_id", userId),
+			zap.String("session_id", sessionId),
+			zap.Error(err))
+		return err
+	}
+	if !deleted {
+		return ErrSessionNotFound
+	}
+	if err := s.revokeSessionAccessTokens(userId, sessionId); err != nil {
+		return err
+	}
+
+	s.logger.Info("Session revoked", zap.Uint64("user_id", userId), zap.String("session_id", sessionId))
+	return nil
+}
+
+// RevokeOtherSessions logs out every session of a user except the current one
+func (s *service) RevokeOtherSessions(userId uint64, currentSessionId string) error {
+	refreshTokens, err := s.refreshTokenRepo.ListActiveUserRefreshTokens(userId)
+	if err != nil {
+		s.logger.Error("Failed to list refresh tokens", zap.Uint64("user_id", userId), zap.Error(err))
+		return err
+	}
+	for _, token := range refreshTokens {
+		if token.FamilyID == currentSessionId {
+			continue
+		}
+		if err := s.revokeSessionAccessTokens(userId, token.FamilyID); err != nil {
+			return err
+		}
+	}
+
+	err = s.refreshTokenRepo.DeleteUserRefreshTokensExcept(userId, currentSessionId)
+	if err != nil {
+		s.logger.Error("Failed to delete other sessions", zap.Uint64("user_id", userId), zap.Error(err))
+		return err
+	}
+
+	s.logger.Info("Other sessions revoked", zap.Uint64("user_id", userId), zap.String("session_id", currentSessionId))
+	return nil
+}
+
+// revokeSessionAccessTokens rejects the access tokens carrying the session id until the last
+// of them expires. The session cannot get new ones since its refresh tokens are deleted.
+func (s *service) revokeSessionAccessTokens(userId uint64, sessionId string) error {
+	expiresAt := time.Now().Add(time.Duration(s.config.JWT.AccessExpiryMinutes) * time.Minute)
+	if err := s.revocation.RevokeSession(sessionId, userId, expiresAt); err != nil {
+		s.logger.Error("Failed to revoke session access tokens",
+			zap.Uint64("user_id", userId),
+			zap.String("session_id", sessionId),
+			zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// nonEmpty returns a pointer to the string, or nil when it is empty
+func nonEmpty(value string) *string {
+	if value == "" {
+		return nil
+	}
+	return &value
+}
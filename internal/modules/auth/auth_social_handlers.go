@@ -0,0 +1,83 @@
+package auth
+
+import (
+	"modular-fx-fiber/internal/shared/dto/auth_dto"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// StartSocialLogin handles starting a login with an external identity provider
+// @Summary Start social login
+// @Description Get the identity provider URL to send the user to. The provider redirects back to the configured redirect URL with a code and the state
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param provider path string true "Identity provider name" example(google)
+// @Success 200 {object} auth_dto.SocialAuthorizationSuccessResponseDTO
+// @Router /auth/social/{provider} [get]
+func (h *handlers) StartSocialLogin(c *fiber.Ctx) error {
+	authorization, err := h.service.StartSocialLogin(c.Params("provider"))
+	if err != nil {
+		if err == ErrUnknownIdentityProvider {
+			return fiber.NewError(fiber.StatusNotFound, err.Error())
+		}
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	// Return response
+	return c.JSON(&auth_dto.SocialAuthorizationSuccessResponseDTO{
+		Success: true,
+		Data:    authorization,
+	})
+}
+
+// CompleteSocialLogin handles the redirect back from an external identity provider
+// @Summary Complete social login
+// @Description Exchange the code and state the identity provider redirected back with for tokens. Unknown identities are linked by verified email or registered, an existing account whose email was never verified is not linked and gets 409. Users with MFA enabled receive an mfa_required challenge instead, see /auth/mfa/verify
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param provider path string true "Identity provider name" example(google)
+// @Param callback body auth_dto.SocialCallbackDTO true "Code and state from the identity provider"
+// @Success 200 {object} auth_dto.LoginSuccessResponseDTO
+// @Router /auth/social/{provider}/callback [post]
+func (h *handlers) CompleteSocialLogin(c *fiber.Ctx) error {
+	var callbackDto auth_dto.SocialCallbackDTO
+
+	// Parse request body
+	if err := c.BodyParser(&callbackDto); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	// Validate request body
+	errs := h.validator.Validate(&callbackDto)
+	if errs != nil {
+		err := h.validator.ParseErrorToString(errs)
+		return fiber.NewError(fiber.StatusBadRequest, err)
+	}
+
+	tokens, challenge, err := h.service.CompleteSocialLogin(c.Params("provider"), &callbackDto, clientInfo(c, callbackDto.DeviceLabel))
+	if err != nil {
+		if err == ErrUnknownIdentityProvider {
+			return fiber.NewError(fiber.StatusNotFound, err.Error())
+		}
+		if err == ErrSocialAccountNotVerified {
+			return fiber.NewError(fiber.StatusConflict, err.Error())
+		}
+		return fiber.NewError(fiber.StatusUnauthorized, err.Error())
+	}
+
+	// A second factor is required before tokens are issued
+	if challenge != nil {
+		return c.JSON(&auth_dto.LoginMFARequiredResponseDTO{
+			Success: true,
+			Data:    challenge,
+		})
+	}
+
+	// Return response
+	return c.JSON(&auth_dto.LoginSuccessResponseDTO{
+		Success: true,
+		Data:    tokens,
+	})
+}
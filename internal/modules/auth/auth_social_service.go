@@ -0,0 +1,225 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"modular-fx-fiber/internal/shared/dto/auth_dto"
+	"modular-fx-fiber/internal/shared/models"
+	"modular-fx-fiber/internal/shared/util"
+	"time"
+
+	"go.uber.org/zap"
+	"golang.org/x/oauth2"
+)
+
+// socialExchangeTimeout bounds the calls made to an identity provider during the callback
+const socialExchangeTimeout = 10 * time.Second
+
+var (
+	ErrUnknownIdentityProvider  = errors.New("unknown identity provider")
+	ErrInvalidSocialState       = errors.New("invalid or expired social login state")
+	ErrSocialLoginFailed        = errors.New("identity provider login failed")
+	ErrSocialEmailNotVerified   = errors.New("identity provider did not return a verified email")
+	ErrSocialAccountNotVerified = errors.New("an account with this email exists but its email is not verified, log in with its password or reset it and verify the email first")
+)
+
+// StartSocialLogin creates the state, nonce and PKCE verifier of a social login and
+// returns the provider URL to send the user to
+func (s *service) StartSocialLogin(providerName string) (*auth_dto.SocialAuthorizationDTO, error) {
+	provider, ok := s.identityProviders[providerName]
+	if !ok {
+		return nil, ErrUnknownIdentityProvider
+	}
+
+	state, err := util.GenerateRandomToken(32)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := util.GenerateRandomToken(16)
+	if err != nil {
+		return nil, err
+	}
+	codeVerifier := oauth2.GenerateVerifier()
+
+	ctx, cancel := context.WithTimeout(context.Background(), socialExchangeTimeout)
+	defer cancel()
+
+	authorizationURL, err := provider.AuthCodeURL(ctx, state, nonce, codeVerifier)
+	if err != nil {
+		s.logger.Error("Failed to build authorization URL", zap.String("provider", providerName), zap.Error(err))
+		return nil, ErrSocialLoginFailed
+	}
+
+	expiry := time.Duration(s.config.Auth.Social.StateExpiryMinutes) * time.Minute
+	loginState := models.SocialLoginState{
+		StateHash:    util.HashToken(state),
+		Provider:     providerName,
+		Nonce:        nonce,
+		CodeVerifier: codeVerifier,
+		ExpiresAt:    time.Now().Add(expiry),
+	}
+	if err := s.socialLoginStateRepo.SaveLoginState(&loginState); err != nil {
+		s.logger.Error("Failed to save social login state", zap.String("provider", providerName), zap.Error(err))
+		return nil, err
+	}
+
+	return &auth_dto.SocialAuthorizationDTO{
+		AuthorizationURL: authorizationURL,
+		State:            state,
+		ExpiresIn:        uint(expiry.Seconds()),
+	}, nil
+}
+
+// CompleteSocialLogin redeems the authorization code of a social login and logs the user in.
+// Unknown identities are linked to the account with the same email, or a new account is
+// created, but only when the provider verified the email and the account verified it too.
+// Users with MFA enabled get an MFA challenge instead of tokens, as with Login.
+func (s *service) CompleteSocialLogin(providerName string, dto *auth_dto.SocialCallbackDTO, client *auth_dto.ClientInfo) (*auth_dto.TokenResponseDTO, *auth_dto.MFAChallengeDTO, error) {
+	provider, ok := s.identityProviders[providerName]
+	if !ok {
+		return nil, nil, ErrUnknownIdentityProvider
+	}
+
+	// Consume the state so the callback cannot be replayed
+	loginState, err := s.socialLoginStateRepo.ConsumeLoginState(util.HashToken(dto.State))
+	if err != nil {
+		s.logger.Error("Failed to consume social login state", zap.Error(err))
+		return nil, nil, err
+	}
+	if loginState == nil || loginState.Provider != providerName {
+		s.logger.Warn("Invalid social login state", zap.String("provider", providerName))
+		return nil, nil, ErrInvalidSocialState
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), socialExchangeTimeout)
+	defer cancel()
+
+	identity, err := provider.Exchange(ctx, dto.Code, loginState.CodeVerifier, loginState.Nonce)
+	if err != nil {
+		s.logger.Warn("Identity provider exchange failed", zap.String("provider", providerName), zap.Error(err))
+		return nil, nil, ErrSocialLoginFailed
+	}
+
+	u, err := s.resolveSocialUser(providerName, identity)
+	if err != nil {
+		return nil, nil, err
+	}
+	if u.Status != models.USER_STATUS_ACTIVE {
+		s.logger.Info("Social login with inactive account", zap.Uint64("user_id", u.ID))
+		return nil, nil, ErrUserNotActive
+	}
+
+	// A second factor is still required for users with MFA enabled
+	if u.MFAEnabled {
+		challenge, err := s.issueMFAChallenge(u)
+		if err != nil {
+			s.logger.Error("Failed to issue MFA challenge",
+				zap.Uint64("user_id", u.ID),
+				zap.Error(err))
+			return nil, nil, err
+		}
+
+		s.logger.Info("MFA challenge issued", zap.Uint64("user_id", u.ID))
+		return nil, challenge, nil
+	}
+
+	tokens, err := s.completeLogin(u, client)
+	if err != nil {
+		return nil, nil, err
+	}
+	return tokens, nil, nil
+}
+
+// resolveSocialUser finds the user linked to an external identity, linking or creating one when needed
+func (s *service) resolveSocialUser(providerName string, identity *ExternalIdentity) (*models.User, error) {
+	linked, err := s.userIdentityRepo.GetIdentity(providerName, identity.Subject)
+	if err != nil {
+		s.logger.Error("Failed to fetch user identity", zap.String("provider", providerName), zap.Error(err))
+		return nil, err
+	}
+	if linked != nil {
+		u, err := s.userRepo.GetByID(linked.UserID)
+		if err != nil {
+			s.logger.Error("Failed to fetch user by ID", zap.Uint64("user_id", linked.UserID), zap.Error(err))
+			return nil, err
+		}
+		if u == nil {
+			return nil, ErrUserNotFound
+		}
+		return u, nil
+	}
+
+	// Linking by email is only safe when the provider proved the user owns it
+	if identity.Email == "" || !identity.EmailVerified {
+		s.logger.Info("Social login without verified email", zap.String("provider", providerName))
+		return nil, ErrSocialEmailNotVerified
+	}
+
+	u, err := s.userRepo.GetByEmail(identity.Email)
+	if err != nil {
+		s.logger.Error("Failed to fetch user by email", zap.String("email", identity.Email), zap.Error(err))
+		return nil, err
+	}
+	if u == nil {
+		if u, err = s.createSocialUser(identity); err != nil {
+			return nil, err
+		}
+	} else if !u.EmailVerified {
+		// Anyone can register an email they do not own, linking would hand the owner an account
+		// whose password, sessions and second factors were set by someone else
+		s.logger.Warn("Social login refused for account with unverified email",
+			zap.Uint64("user_id", u.ID),
+			zap.String("provider", providerName))
+		return nil, ErrSocialAccountNotVerified
+	}
+
+	email := identity.Email
+	err = s.userIdentityRepo.CreateIdentity(&models.UserIdentity{
+		UserID:   u.ID,
+		Provider: providerName,
+		Subject:  identity.Subject,
+		Email:    &email,
+	})
+	if err != nil {
+		s.logger.Error("Failed to link user identity",
+			zap.Uint64("user_id", u.ID),
+			zap.String("provider", providerName),
+			zap.Error(err))
+		return nil, err
+	}
+
+	s.logger.Info("External identity linked",
+		zap.Uint64("user_id", u.ID),
+		zap.String("provider", providerName))
+	return u, nil
+}
+
+// createSocialUser registers a user from an external identity.
+// The account gets a random password, so it can only log in through the provider
+// until a password is set with the reset flow.
+func (s *service) createSocialUser(identity *ExternalIdentity) (*models.User, error) {
+	password, err := util.GenerateRandomToken(32)
+	if err != nil {
+		return nil, err
+	}
+	hashedPassword, err := s.passwordHasher.Hash(password)
+	if err != nil {
+		return nil, err
+	}
+
+	u := &models.User{
+		Email:         identity.Email,
+		Password:      hashedPassword,
+		FirstName:     identity.FirstName,
+		LastName:      identity.LastName,
+		EmailVerified: true,
+		Status:        models.USER_STATUS_ACTIVE,
+	}
+	if err := s.userRepo.Create(u); err != nil {
+		s.logger.Error("Failed to create user from external identity", zap.String("email", identity.Email), zap.Error(err))
+		return nil, err
+	}
+
+	s.logger.Info("User registered from external identity", zap.Uint64("user_id", u.ID))
+	return u, nil
+}
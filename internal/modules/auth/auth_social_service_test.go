@@ -0,0 +1,196 @@
+package auth
+
+import (
+	"errors"
+	"modular-fx-fiber/internal/shared/dto/auth_dto"
+	"modular-fx-fiber/internal/shared/models"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// newSocialTestService creates an auth service whose only identity provider is the stub issuer
+func newSocialTestService(t *testing.T, stub *stubIssuer) *testService {
+	t.Helper()
+	return newTestService(t, newTestConfig(), IdentityProviders{"stub": newStubProvider(t, stub)})
+}
+
+// socialLogin runs a social login through the stub issuer up to the callback
+func socialLogin(t *testing.T, ts *testService, stub *stubIssuer) (*auth_dto.TokenResponseDTO, *auth_dto.MFAChallengeDTO, error) {
+	t.Helper()
+
+	authorization, err := ts.StartSocialLogin("stub")
+	if err != nil {
+		t.Fatalf("StartSocialLogin: %v", err)
+	}
+	code := stub.login(authorization.AuthorizationURL)
+
+	return ts.CompleteSocialLogin("stub", &auth_dto.SocialCallbackDTO{
+		Code:  code,
+		State: authorization.State,
+	}, &auth_dto.ClientInfo{})
+}
+
+func TestCompleteSocialLoginRegistersUser(t *testing.T) {
+	stub := newStubIssuer(t)
+	ts := newSocialTestService(t, stub)
+
+	tokens, challenge, err := socialLogin(t, ts, stub)
+	if err != nil {
+		t.Fatalf("CompleteSocialLogin: %v", err)
+	}
+	if challenge != nil || tokens == nil || tokens.AccessToken == "" || tokens.RefreshToken == "" {
+		t.Fatalf("got tokens %+v and challenge %+v, want tokens only", tokens, challenge)
+	}
+
+	u, _ := ts.users.GetByEmail("jane.doe@example.com")
+	if u == nil {
+		t.Fatalf("no user registered for the identity")
+	}
+	if !u.EmailVerified || u.FirstName != "Jane" || u.LastName != "Doe" {
+		t.Errorf("registered user = %+v, want a verified Jane Doe", u)
+	}
+	if linked, _ := ts.identities.GetIdentity("stub", stubSubject); linked == nil || linked.UserID != u.ID {
+		t.Errorf("identity linked to %+v, want user %d", linked, u.ID)
+	}
+
+	// The next login finds the user through the linked identity, even after the email changed
+	stub.idTokenClaims = func(claims jwt.MapClaims) { claims["email"] = "jane@example.org" }
+	if _, _, err := socialLogin(t, ts, stub); err != nil {
+		t.Fatalf("second CompleteSocialLogin: %v", err)
+	}
+	if len(ts.users.users) != 1 || len(ts.identities.identities) != 1 {
+		t.Errorf("got %d users and %d identities after the second login, want 1 and 1",
+			len(ts.users.users), len(ts.identities.identities))
+	}
+}
+
+func TestCompleteSocialLoginLinksVerifiedAccount(t *testing.T) {
+	stub := newStubIssuer(t)
+	ts := newSocialTestService(t, stub)
+	existing := ts.addUser(t, &models.User{Email: "jane.doe@example.com", EmailVerified: true})
+
+	if _, _, err := socialLogin(t, ts, stub); err != nil {
+		t.Fatalf("CompleteSocialLogin: %v", err)
+	}
+	if linked, _ := ts.identities.GetIdentity("stub", stubSubject); linked == nil || linked.UserID != existing.ID {
+		t.Errorf("identity linked to %+v, want user %d", linked, existing.ID)
+	}
+	if len(ts.users.users) != 1 {
+		t.Errorf("got %d users, want the existing account only", len(ts.users.users))
+	}
+}
+
+func TestCompleteSocialLoginRefusesUnverifiedAccount(t *testing.T) {
+	stub := newStubIssuer(t)
+	ts := newSocialTestService(t, stub)
+	ts.addUser(t, &models.User{Email: "jane.doe@example.com", EmailVerified: false})
+
+	tokens, _, err := socialLogin(t, ts, stub)
+	if !errors.Is(err, ErrSocialAccountNotVerified) {
+		t.Fatalf("got error %v, want ErrSocialAccountNotVerified", err)
+	}
+	if tokens != nil || len(ts.identities.identities) != 0 {
+		t.Errorf("got tokens %+v and %d identities, want neither", tokens, len(ts.identities.identities))
+	}
+}
+
+func TestCompleteSocialLoginChallengesMFAUsers(t *testing.T) {
+	stub := newStubIssuer(t)
+	ts := newSocialTestService(t, stub)
+	ts.addUser(t, &models.User{Email: "jane.doe@example.com", EmailVerified: true, MFAEnabled: true})
+
+	tokens, challenge, err := socialLogin(t, ts, stub)
+	if err != nil {
+		t.Fatalf("CompleteSocialLogin: %v", err)
+	}
+	if tokens != nil || challenge == nil || challenge.ChallengeToken == "" {
+		t.Fatalf("got tokens %+v and challenge %+v, want a challenge only", tokens, challenge)
+	}
+}
+
+func TestCompleteSocialLoginRejectsInvalidIdentity(t *testing.T) {
+	tests := []struct {
+		name          string
+		idTokenClaims func(claims jwt.MapClaims)
+		wantErr       error
+	}{
+		{
+			name:          "wrong nonce",
+			idTokenClaims: func(claims jwt.MapClaims) { claims["nonce"] = "nonce-of-another-login" },
+			wantErr:       ErrSocialLoginFailed,
+		},
+		{
+			name:          "wrong audience",
+			idTokenClaims: func(claims jwt.MapClaims) { claims["aud"] = "another-client" },
+			wantErr:       ErrSocialLoginFailed,
+		},
+		{
+			name: "expired id token",
+			idTokenClaims: func(claims jwt.MapClaims) {
+				claims["iat"] = time.Now().Add(-2 * time.Hour).Unix()
+				claims["exp"] = time.Now().Add(-time.Hour).Unix()
+			},
+			wantErr: ErrSocialLoginFailed,
+		},
+		{
+			name:          "unverified email",
+			idTokenClaims: func(claims jwt.MapClaims) { claims["email_verified"] = false },
+			wantErr:       ErrSocialEmailNotVerified,
+		},
+		{
+			name:          "missing email",
+			idTokenClaims: func(claims jwt.MapClaims) { delete(claims, "email") },
+			wantErr:       ErrSocialEmailNotVerified,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stub := newStubIssuer(t)
+			stub.idTokenClaims = tt.idTokenClaims
+			ts := newSocialTestService(t, stub)
+
+			tokens, _, err := socialLogin(t, ts, stub)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got error %v, want %v", err, tt.wantErr)
+			}
+			if tokens != nil || len(ts.users.users) != 0 || len(ts.identities.identities) != 0 {
+				t.Errorf("got tokens %+v, %d users and %d identities, want none",
+					tokens, len(ts.users.users), len(ts.identities.identities))
+			}
+		})
+	}
+}
+
+func TestCompleteSocialLoginRejectsReplayedState(t *testing.T) {
+	stub := newStubIssuer(t)
+	ts := newSocialTestService(t, stub)
+
+	authorization, err := ts.StartSocialLogin("stub")
+	if err != nil {
+		t.Fatalf("StartSocialLogin: %v", err)
+	}
+	callback := &auth_dto.SocialCallbackDTO{Code: stub.login(authorization.AuthorizationURL), State: authorization.State}
+
+	if _, _, err := ts.CompleteSocialLogin("stub", callback, &auth_dto.ClientInfo{}); err != nil {
+		t.Fatalf("CompleteSocialLogin: %v", err)
+	}
+	if _, _, err := ts.CompleteSocialLogin("stub", callback, &auth_dto.ClientInfo{}); !errors.Is(err, ErrInvalidSocialState) {
+		t.Fatalf("replayed callback got error %v, want ErrInvalidSocialState", err)
+	}
+}
+
+func TestCompleteSocialLoginRejectsUnknownState(t *testing.T) {
+	stub := newStubIssuer(t)
+	ts := newSocialTestService(t, stub)
+
+	_, _, err := ts.CompleteSocialLogin("stub", &auth_dto.SocialCallbackDTO{Code: "code", State: "forged-state"}, &auth_dto.ClientInfo{})
+	if !errors.Is(err, ErrInvalidSocialState) {
+		t.Fatalf("got error %v, want ErrInvalidSocialState", err)
+	}
+	if _, err := ts.StartSocialLogin("unknown"); !errors.Is(err, ErrUnknownIdentityProvider) {
+		t.Fatalf("StartSocialLogin with unknown provider got error %v, want ErrUnknownIdentityProvider", err)
+	}
+}
@@ -0,0 +1,219 @@
+package auth
+
+import (
+	"crypto/subtle"
+	"errors"
+	"modular-fx-fiber/internal/modules/mailer"
+	"modular-fx-fiber/internal/shared/dto/auth_dto"
+	"modular-fx-fiber/internal/shared/models"
+	"modular-fx-fiber/internal/shared/util"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// verificationCodeLength is the number of digits of a verification code
+const verificationCodeLength = 6
+
+var (
+	ErrEmailAlreadyVerified   = errors.New("email is already verified")
+	ErrVerificationCodeLocked = errors.New("too many wrong verification codes, request a new one")
+)
+
+// VerificationCooldownError is returned when a new code is requested before RetryAfter has passed
+type VerificationCooldownError struct {
+	RetryAfter time.Duration
+}
+
+func (e *VerificationCooldownError) Error() string {
+	return "a verification code was sent recently, try again later"
+}
+
+// VerifyEmail checks the email verification code of the user.
+// A code is locked after auth.verification.max_attempts wrong guesses, the user must then request a new one.
+func (s *service) VerifyEmail(ved *auth_dto.VerifyEmailDTO, userId uint64) error {
+	// Get user by ID
+	u, err := s.userRepo.GetByID(userId)
+	if err != nil {
+		s.logger.Error("Failed to fetch user by ID", zap.Uint64("user_id", userId), zap.Error(err))
+		return err
+	}
+	if u == nil {
+		s.logger.Warn("User not found", zap.Uint64("user_id", userId))
+		return ErrUserNotFound
+	}
+
+	// Check if user is already verified
+	if u.EmailVerified {
+		s.logger.Warn("User already verified", zap.Uint64("user_id", userId))
+		return nil
+	}
+
+	if _, err := s.checkVerificationCode(userId, models.VERIFICATION_PURPOSE_EMAIL, ved.Code); err != nil {
+		return err
+	}
+
+	// Update user
+	u.EmailVerified = true
+	if err := s.userRepo.Update(u); err != nil {
+		s.logger.Error("Failed to update user", zap.Uint64("user_id", userId), zap.Error(err))
+		return err
+	}
+
+	// Log success
+	s.logger.Info("User email verified", zap.Uint64("user_id", userId))
+	return nil
+}
+
+// ResendVerification sends a new email verification code, replacing the previous one.
+// Codes can only be requested once per auth.verification.resend_cooldown_seconds.
+func (s *service) ResendVerification(userId uint64) error {
+	u, err := s.userRepo.GetByID(userId)
+	if err != nil {
+		s.logger.Error("Failed to fetch user by ID", zap.Uint64("user_id", userId), zap.Error(err))
+		return err
+	}
+	if u == nil {
+		return ErrUserNotFound
+	}
+	if u.EmailVerified {
+		return ErrEmailAlreadyVerified
+	}
+
+	if err := s.checkResendCooldown(userId, models.VERIFICATION_PURPOSE_EMAIL); err != nil {
+		return err
+	}
+
+	return s.sendVerifyEmailCode(u)
+}
+
+// SendVerifyEmailCode sends a new email verification code to the user
+func (s *service) SendVerifyEmailCode(userId uint64) error {
+	u, err := s.userRepo.GetByID(userId)
+	if err != nil {
+		s.logger.Error("Failed to fetch user by ID", zap.Uint64("user_id", userId), zap.Error(err))
+		return err
+	}
+	if u == nil {
+		s.logger.Warn("User not found", zap.Uint64("user_id", userId))
+		return ErrUserNotFound
+	}
+
+	return s.sendVerifyEmailCode(u)
+}
+
+// sendVerifyEmailCode stores the hash of a new code, invalidating the previous ones, and emails the code
+func (s *service) sendVerifyEmailCode(u *models.User) error {
+	code, err := s.issueVerificationCode(u.ID, models.VERIFICATION_PURPOSE_EMAIL, nil)
+	if err != nil {
+		return err
+	}
+
+	mailData, err := util.StructToMap(&mailer.EmailVerificationData{
+		Name: u.FullName(),
+		Code: code,
+	})
+	if err != nil {
+		s.logger.Error("[SendVerifyEmailCode] Failed to convert struct to map", zap.Error(err))
+		return err
+	}
+
+	// send email
+	return s.gmailMailer.SendTemplatedEmail(
+		u.Email,
+		mailer.EmailVerificationSubject,
+		mailer.EmailVerificationTemplate,
+		mailData,
+	)
+}
+
+// checkResendCooldown refuses a new code of the purpose until auth.verification.resend_cooldown_seconds
+// have passed since the previous one
+func (s *service) checkResendCooldown(userId uint64, purpose string) error {
+	latest, err := s.verificationCodeRepo.GetLatestCode(userId, purpose)
+	if err != nil {
+		s.logger.Error("Failed to fetch latest verification code", zap.Uint64("user_id", userId), zap.Error(err))
+		return err
+	}
+	if latest != nil {
+		cooldown := time.Duration(s.config.Auth.Verification.ResendCooldownSeconds) * time.Second
+		if wait := time.Until(latest.CreatedAt.Add(cooldown)); wait > 0 {
+			s.logger.Info("Verification code requested during cooldown",
+				zap.Uint64("user_id", userId),
+				zap.String("purpose", purpose))
+			return &VerificationCooldownError{RetryAfter: wait}
+		}
+	}
+	return nil
+}
+
+// issueVerificationCode generates a code for the purpose and stores its hash, it returns the plaintext code.
+// Target is the address the code is sent to when it is not the email of the user.
+func (s *service) issueVerificationCode(userId uint64, purpose string, target *string) (string, error) {
+	code := util.GenerateRandomCode(verificationCodeLength)
+
+	verificationCode := models.VerificationCode{
+		UserID:    userId,
+		Purpose:   purpose,
+		CodeHash:  util.HashToken(code),
+		Target:    target,
+		ExpiresAt: time.Now().Add(time.Duration(s.config.Auth.Verification.CodeExpiryMinutes) * time.Minute),
+	}
+	if err := s.verificationCodeRepo.ReplaceCode(&verificationCode); err != nil {
+		s.logger.Error("Failed to save verification code",
+			zap.Uint64("user_id", userId),
+			zap.String("purpose", purpose),
+			zap.Error(err))
+		return "", err
+	}
+
+	return code, nil
+}
+
+// checkVerificationCode consumes the active code of the purpose when it matches, and counts a wrong guess otherwise.
+// It returns the consumed code.
+func (s *service) checkVerificationCode(userId uint64, purpose string, code string) (*models.VerificationCode, error) {
+	verificationCode, err := s.verificationCodeRepo.GetActiveCode(userId, purpose)
+	if err != nil {
+		s.logger.Error("Failed to fetch verification code", zap.Uint64("user_id", userId), zap.Error(err))
+		return nil, err
+	}
+	if verificationCode == nil {
+		s.logger.Warn("No active verification code", zap.Uint64("user_id", userId), zap.String("purpose", purpose))
+		return nil, ErrInvalidVerifyCode
+	}
+
+	maxAttempts := s.config.Auth.Verification.MaxAttempts
+	if verificationCode.Attempts >= maxAttempts {
+		return nil, ErrVerificationCodeLocked
+	}
+
+	if subtle.ConstantTimeCompare([]byte(util.HashToken(code)), []byte(verificationCode.CodeHash)) != 1 {
+		attempts, err := s.verificationCodeRepo.IncrementAttempts(verificationCode.ID)
+		if err != nil {
+			s.logger.Error("Failed to record verification attempt", zap.Uint64("user_id", userId), zap.Error(err))
+			return nil, err
+		}
+
+		s.logger.Warn("Invalid verification code",
+			zap.Uint64("user_id", userId),
+			zap.String("purpose", purpose),
+			zap.Int("attempts", attempts))
+		if attempts >= maxAttempts {
+			return nil, ErrVerificationCodeLocked
+		}
+		return nil, ErrInvalidVerifyCode
+	}
+
+	// Consume the code so it can only be used once
+	consumed, err := s.verificationCodeRepo.MarkCodeUsed(verificationCode.ID)
+	if err != nil {
+		s.logger.Error("Failed to mark verification code as used", zap.Uint64("user_id", userId), zap.Error(err))
+		return nil, err
+	}
+	if !consumed {
+		return nil, ErrInvalidVerifyCode
+	}
+
+	return verificationCode, nil
+}
@@ -0,0 +1,122 @@
+package auth
+
+import (
+	"bytes"
+	"modular-fx-fiber/internal/core/config"
+	"modular-fx-fiber/internal/shared/models"
+	"strings"
+	"time"
+
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/webauthn"
+)
+
+// NewWebAuthn creates the WebAuthn relying party from auth.webauthn
+func NewWebAuthn(c *config.Config) (*webauthn.WebAuthn, error) {
+	cfg := c.Auth.WebAuthn
+	timeout := time.Duration(cfg.SessionTimeoutSeconds) * time.Second
+
+	return webauthn.New(&webauthn.Config{
+		RPID:          cfg.RPID,
+		RPDisplayName: cfg.RPDisplayName,
+		RPOrigins:     cfg.RPOrigins,
+		Timeouts: webauthn.TimeoutsConfig{
+			Login: webauthn.TimeoutConfig{
+				Enforce:    true,
+				Timeout:    timeout,
+				TimeoutUVD: timeout,
+			},
+			Registration: webauthn.TimeoutConfig{
+				Enforce:    true,
+				Timeout:    timeout,
+				TimeoutUVD: timeout,
+			},
+		},
+	})
+}
+
+// webAuthnUser adapts a user and their stored credentials to webauthn.User
+type webAuthnUser struct {
+	user        *models.User
+	credentials []*models.WebAuthnCredential
+}
+
+func (u *webAuthnUser) WebAuthnID() []byte {
+	return u.user.WebAuthnHandle
+}
+
+func (u *webAuthnUser) WebAuthnName() string {
+	return u.user.Email
+}
+
+func (u *webAuthnUser) WebAuthnDisplayName() string {
+	return u.user.FullName()
+}
+
+func (u *webAuthnUser) WebAuthnCredentials() []webauthn.Credential {
+	credentials := make([]webauthn.Credential, 0, len(u.credentials))
+	for _, credential := range u.credentials {
+		credentials = append(credentials, toWebAuthnCredential(credential))
+	}
+	return credentials
+}
+
+// credential returns the stored credential with the given credential ID
+func (u *webAuthnUser) credential(credentialID []byte) *models.WebAuthnCredential {
+	for _, credential := range u.credentials {
+		if bytes.Equal(credential.CredentialID, credentialID) {
+			return credential
+		}
+	}
+	return nil
+}
+
+// toWebAuthnCredential converts a stored credential to the form the WebAuthn library verifies assertions with
+func toWebAuthnCredential(credential *models.WebAuthnCredential) webauthn.Credential {
+	var transports []protocol.AuthenticatorTransport
+	if credential.Transports != "" {
+		for _, transport := range strings.Split(credential.Transports, ",") {
+			transports = append(transports, protocol.AuthenticatorTransport(transport))
+		}
+	}
+
+	return webauthn.Credential{
+		ID:              credential.CredentialID,
+		PublicKey:       credential.PublicKey,
+		AttestationType: credential.AttestationType,
+		Transport:       transports,
+		Flags: webauthn.CredentialFlags{
+			UserPresent:    true,
+			UserVerified:   credential.UserVerified,
+			BackupEligible: credential.BackupEligible,
+			BackupState:    credential.BackupState,
+		},
+		Authenticator: webauthn.Authenticator{
+			AAGUID:       credential.AAGUID,
+			SignCount:    credential.SignCount,
+			CloneWarning: credential.CloneWarning,
+		},
+	}
+}
+
+// fromWebAuthnCredential converts a newly registered credential to the stored form
+func fromWebAuthnCredential(userId uint64, name string, credential *webauthn.Credential) *models.WebAuthnCredential {
+	transports := make([]string, 0, len(credential.Transport))
+	for _, transport := range credential.Transport {
+		transports = append(transports, string(transport))
+	}
+
+	return &models.WebAuthnCredential{
+		UserID:          userId,
+		Name:            name,
+		CredentialID:    credential.ID,
+		PublicKey:       credential.PublicKey,
+		AttestationType: credential.AttestationType,
+		AAGUID:          credential.Authenticator.AAGUID,
+		SignCount:       credential.Authenticator.SignCount,
+		Transports:      strings.Join(transports, ","),
+		UserVerified:    credential.Flags.UserVerified,
+		BackupEligible:  credential.Flags.BackupEligible,
+		BackupState:     credential.Flags.BackupState,
+	}
+}
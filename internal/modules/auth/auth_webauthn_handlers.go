@@ -0,0 +1,263 @@
+package auth
+
+import (
+	"modular-fx-fiber/internal/shared/dto/auth_dto"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// BeginWebAuthnRegistration handles starting the registration of a passkey
+// @Summary Begin passkey registration
+// @Description Get the options to pass to navigator.credentials.create() and the session ID to send back with the new credential
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} auth_dto.WebAuthnOptionsSuccessResponseDTO
+// @Router /auth/webauthn/register/begin [post]
+func (h *handlers) BeginWebAuthnRegistration(c *fiber.Ctx) error {
+	// Get user ID from context
+	userId := c.Locals("user_id").(uint64)
+
+	options, err := h.service.BeginWebAuthnRegistration(userId)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	// Return response
+	return c.JSON(&auth_dto.WebAuthnOptionsSuccessResponseDTO{
+		Success: true,
+		Data:    options,
+	})
+}
+
+// FinishWebAuthnRegistration handles verifying and saving a new passkey
+// @Summary Finish passkey registration
+// @Description Verify the credential created by the authenticator and save it as a passkey of the current user
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body auth_dto.WebAuthnRegisterFinishDTO true "Session ID, passkey name and created credential"
+// @Success 201 {object} auth_dto.WebAuthnRegisterSuccessResponseDTO
+// @Router /auth/webauthn/register/finish [post]
+func (h *handlers) FinishWebAuthnRegistration(c *fiber.Ctx) error {
+	var registerDto auth_dto.WebAuthnRegisterFinishDTO
+
+	// Parse request body
+	if err := c.BodyParser(&registerDto); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	// Validate request body
+	errs := h.validator.Validate(&registerDto)
+	if errs != nil {
+		err := h.validator.ParseErrorToString(errs)
+		return fiber.NewError(fiber.StatusBadRequest, err)
+	}
+
+	// Get user ID from context
+	userId := c.Locals("user_id").(uint64)
+
+	credential, err := h.service.FinishWebAuthnRegistration(&registerDto, userId)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	// Return response
+	return c.Status(fiber.StatusCreated).JSON(&auth_dto.WebAuthnRegisterSuccessResponseDTO{
+		Success: true,
+		Data:    credential,
+	})
+}
+
+// ListWebAuthnCredentials handles listing the current user's passkeys
+// @Summary List passkeys
+// @Description List the passkeys of the current user
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} auth_dto.ListWebAuthnCredentialsSuccessResponseDTO
+// @Router /auth/webauthn/credentials [get]
+func (h *handlers) ListWebAuthnCredentials(c *fiber.Ctx) error {
+	// Get user ID from context
+	userId := c.Locals("user_id").(uint64)
+
+	credentials, err := h.service.ListWebAuthnCredentials(userId)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	// Return response
+	return c.JSON(&auth_dto.ListWebAuthnCredentialsSuccessResponseDTO{
+		Success: true,
+		Data:    credentials,
+	})
+}
+
+// DeleteWebAuthnCredential handles removing a passkey
+// @Summary Delete passkey
+// @Description Remove one of the current user's passkeys
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Passkey ID"
+// @Success 200 {object} auth_dto.DeleteWebAuthnCredentialSuccessResponseDTO
+// @Router /auth/webauthn/credentials/{id} [delete]
+func (h *handlers) DeleteWebAuthnCredential(c *fiber.Ctx) error {
+	credentialId, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid passkey id")
+	}
+
+	// Get user ID from context
+	userId := c.Locals("user_id").(uint64)
+
+	if err := h.service.DeleteWebAuthnCredential(userId, credentialId); err != nil {
+		if err == ErrWebAuthnCredentialNotFound {
+			return fiber.NewError(fiber.StatusNotFound, err.Error())
+		}
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	// Return response
+	return c.JSON(&auth_dto.DeleteWebAuthnCredentialSuccessResponseDTO{
+		Success: true,
+	})
+}
+
+// BeginPasskeyLogin handles starting a usernameless passkey login
+// @Summary Begin passkey login
+// @Description Get the options to pass to navigator.credentials.get() and the session ID to send back with the assertion. No email is needed, the authenticator picks the passkey
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Success 200 {object} auth_dto.WebAuthnOptionsSuccessResponseDTO
+// @Router /auth/webauthn/login/begin [post]
+func (h *handlers) BeginPasskeyLogin(c *fiber.Ctx) error {
+	options, err := h.service.BeginPasskeyLogin()
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	// Return response
+	return c.JSON(&auth_dto.WebAuthnOptionsSuccessResponseDTO{
+		Success: true,
+		Data:    options,
+	})
+}
+
+// FinishPasskeyLogin handles verifying a passkey login
+// @Summary Finish passkey login
+// @Description Exchange the assertion of a passkey for tokens. Passkeys verify the user, so no MFA challenge follows
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body auth_dto.WebAuthnLoginFinishDTO true "Session ID and assertion"
+// @Success 200 {object} auth_dto.LoginSuccessResponseDTO
+// @Router /auth/webauthn/login/finish [post]
+func (h *handlers) FinishPasskeyLogin(c *fiber.Ctx) error {
+	var loginDto auth_dto.WebAuthnLoginFinishDTO
+
+	// Parse request body
+	if err := c.BodyParser(&loginDto); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	// Validate request body
+	errs := h.validator.Validate(&loginDto)
+	if errs != nil {
+		err := h.validator.ParseErrorToString(errs)
+		return fiber.NewError(fiber.StatusBadRequest, err)
+	}
+
+	tokens, err := h.service.FinishPasskeyLogin(&loginDto, clientInfo(c, loginDto.DeviceLabel))
+	if err != nil {
+		return fiber.NewError(fiber.StatusUnauthorized, err.Error())
+	}
+
+	// Return response
+	return c.JSON(&auth_dto.LoginSuccessResponseDTO{
+		Success: true,
+		Data:    tokens,
+	})
+}
+
+// BeginWebAuthnMFA handles starting a passkey check for an MFA challenge
+// @Summary Begin passkey MFA
+// @Description Get the options to pass to navigator.credentials.get() to answer an MFA challenge with one of the user's passkeys
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body auth_dto.WebAuthnMFABeginDTO true "MFA challenge token"
+// @Success 200 {object} auth_dto.WebAuthnOptionsSuccessResponseDTO
+// @Router /auth/mfa/webauthn/begin [post]
+func (h *handlers) BeginWebAuthnMFA(c *fiber.Ctx) error {
+	var beginDto auth_dto.WebAuthnMFABeginDTO
+
+	// Parse request body
+	if err := c.BodyParser(&beginDto); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	// Validate request body
+	errs := h.validator.Validate(&beginDto)
+	if errs != nil {
+		err := h.validator.ParseErrorToString(errs)
+		return fiber.NewError(fiber.StatusBadRequest, err)
+	}
+
+	options, err := h.service.BeginWebAuthnMFA(&beginDto)
+	if err != nil {
+		if err == ErrInvalidMFAChallenge {
+			return fiber.NewError(fiber.StatusUnauthorized, err.Error())
+		}
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	// Return response
+	return c.JSON(&auth_dto.WebAuthnOptionsSuccessResponseDTO{
+		Success: true,
+		Data:    options,
+	})
+}
+
+// FinishWebAuthnMFA handles verifying a passkey as the second factor of a login
+// @Summary Finish passkey MFA
+// @Description Exchange an MFA challenge token and the assertion of one of the user's passkeys for tokens
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body auth_dto.WebAuthnMFAFinishDTO true "Challenge token, session ID and assertion"
+// @Success 200 {object} auth_dto.MFAVerifySuccessResponseDTO
+// @Router /auth/mfa/webauthn/finish [post]
+func (h *handlers) FinishWebAuthnMFA(c *fiber.Ctx) error {
+	var finishDto auth_dto.WebAuthnMFAFinishDTO
+
+	// Parse request body
+	if err := c.BodyParser(&finishDto); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	// Validate request body
+	errs := h.validator.Validate(&finishDto)
+	if errs != nil {
+		err := h.validator.ParseErrorToString(errs)
+		return fiber.NewError(fiber.StatusBadRequest, err)
+	}
+
+	// Verify second factor
+	tokens, err := h.service.FinishWebAuthnMFA(&finishDto, clientInfo(c, finishDto.DeviceLabel))
+	if err != nil {
+		return loginError(c, err)
+	}
+
+	// Return response
+	return c.JSON(&auth_dto.MFAVerifySuccessResponseDTO{
+		Success: true,
+		Data:    tokens,
+	})
+}
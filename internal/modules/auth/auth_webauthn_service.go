@@ -0,0 +1,429 @@
+package auth
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"modular-fx-fiber/internal/shared/dto/auth_dto"
+	"modular-fx-fiber/internal/shared/models"
+	"modular-fx-fiber/internal/shared/util"
+	"strings"
+	"time"
+
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/webauthn"
+	"go.uber.org/zap"
+)
+
+const (
+	// webAuthnHandleLength is the size in bytes of the random user handle given to authenticators
+	webAuthnHandleLength = 32
+
+	// defaultWebAuthnCredentialName names passkeys registered without a name
+	defaultWebAuthnCredentialName = "Passkey"
+)
+
+var (
+	ErrInvalidWebAuthnSession     = errors.New("invalid or expired webauthn session")
+	ErrWebAuthnVerificationFailed = errors.New("webauthn verification failed")
+	ErrWebAuthnCredentialNotFound = errors.New("passkey not found")
+	ErrWebAuthnCredentialCloned   = errors.New("passkey may have been cloned, remove it and register it again")
+	ErrWebAuthnNotRegistered      = errors.New("no passkey registered")
+)
+
+// BeginWebAuthnRegistration starts the registration of a passkey for the user.
+// Passkeys the user already registered are excluded so an authenticator is not registered twice.
+func (s *service) BeginWebAuthnRegistration(userId uint64) (*auth_dto.WebAuthnOptionsDTO, error) {
+	user, err := s.loadWebAuthnUser(userId)
+	if err != nil {
+		return nil, err
+	}
+
+	// Give the user a random handle on their first registration
+	if len(user.user.WebAuthnHandle) == 0 {
+		handle := make([]byte, webAuthnHandleLength)
+		if _, err := rand.Read(handle); err != nil {
+			return nil, err
+		}
+
+		user.user.WebAuthnHandle = handle
+		if err := s.userRepo.Update(user.user); err != nil {
+			s.logger.Error("Failed to save WebAuthn handle", zap.Uint64("user_id", userId), zap.Error(err))
+			return nil, ErrUpdateUserFailed
+		}
+	}
+
+	creation, session, err := s.webAuthn.BeginRegistration(user,
+		webauthn.WithResidentKeyRequirement(protocol.ResidentKeyRequirementPreferred),
+		webauthn.WithExclusions(webauthn.Credentials(user.WebAuthnCredentials()).CredentialDescriptors()),
+	)
+	if err != nil {
+		s.logger.Error("Failed to begin WebAuthn registration", zap.Uint64("user_id", userId), zap.Error(err))
+		return nil, err
+	}
+
+	sessionId, err := s.saveWebAuthnSession(models.WEBAUTHN_CEREMONY_REGISTRATION, &userId, session)
+	if err != nil {
+		return nil, err
+	}
+
+	return &auth_dto.WebAuthnOptionsDTO{
+		SessionID: sessionId,
+		Options:   creation,
+	}, nil
+}
+
+// FinishWebAuthnRegistration verifies the answer of the authenticator and saves the new passkey
+func (s *service) FinishWebAuthnRegistration(dto *auth_dto.WebAuthnRegisterFinishDTO, userId uint64) (*auth_dto.WebAuthnCredentialDTO, error) {
+	session, err := s.consumeWebAuthnSession(dto.SessionID, models.WEBAUTHN_CEREMONY_REGISTRATION, &userId)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.loadWebAuthnUser(userId)
+	if err != nil {
+		return nil, err
+	}
+
+	parsed, err := protocol.ParseCredentialCreationResponseBytes(dto.Credential)
+	if err != nil {
+		s.logger.Info("Invalid WebAuthn registration response", zap.Uint64("user_id", userId), zap.Error(err))
+		return nil, ErrWebAuthnVerificationFailed
+	}
+
+	credential, err := s.webAuthn.CreateCredential(user, *session, parsed)
+	if err != nil {
+		s.logger.Info("WebAuthn registration verification failed", zap.Uint64("user_id", userId), zap.Error(err))
+		return nil, ErrWebAuthnVerificationFailed
+	}
+
+	name := strings.TrimSpace(dto.Name)
+	if name == "" {
+		name = defaultWebAuthnCredentialName
+	}
+
+	webAuthnCredential := fromWebAuthnCredential(userId, name, credential)
+	if err := s.webAuthnCredentialRepo.CreateCredential(webAuthnCredential); err != nil {
+		s.logger.Error("Failed to save WebAuthn credential", zap.Uint64("user_id", userId), zap.Error(err))
+		return nil, err
+	}
+
+	s.logger.Info("Passkey registered",
+		zap.Uint64("user_id", userId),
+		zap.Uint64("credential_id", webAuthnCredential.ID))
+	return toWebAuthnCredentialDTO(webAuthnCredential), nil
+}
+
+// ListWebAuthnCredentials lists the passkeys of the user
+func (s *service) ListWebAuthnCredentials(userId uint64) ([]*auth_dto.WebAuthnCredentialDTO, error) {
+	credentials, err := s.webAuthnCredentialRepo.ListUserCredentials(userId)
+	if err != nil {
+		s.logger.Error("Failed to list WebAuthn credentials", zap.Uint64("user_id", userId), zap.Error(err))
+		return nil, err
+	}
+
+	credentialDtos := make([]*auth_dto.WebAuthnCredentialDTO, 0, len(credentials))
+	for _, credential := range credentials {
+		credentialDtos = append(credentialDtos, toWebAuthnCredentialDTO(credential))
+	}
+	return credentialDtos, nil
+}
+
+// DeleteWebAuthnCredential removes one of the user's passkeys
+func (s *service) DeleteWebAuthnCredential(userId uint64, credentialId uint64) error {
+	deleted, err := s.webAuthnCredentialRepo.DeleteUserCredential(userId, credentialId)
+	if err != nil {
+		s.logger.Error("Failed to delete WebAuthn credential",
+			zap.Uint64("user_id", userId),
+			zap.Uint64("credential_id", credentialId),
+			zap.Error(err))
+		return err
+	}
+	if !deleted {
+		return ErrWebAuthnCredentialNotFound
+	}
+
+	s.logger.Info("Passkey deleted",
+		zap.Uint64("user_id", userId),
+		zap.Uint64("credential_id", credentialId))
+	return nil
+}
+
+// BeginPasskeyLogin starts a usernameless login, the authenticator picks one of its passkeys for this site
+func (s *service) BeginPasskeyLogin() (*auth_dto.WebAuthnOptionsDTO, error) {
+	assertion, session, err := s.webAuthn.BeginDiscoverableLogin(
+		webauthn.WithUserVerification(protocol.VerificationRequired),
+	)
+	if err != nil {
+		s.logger.Error("Failed to begin passkey login", zap.Error(err))
+		return nil, err
+	}
+
+	sessionId, err := s.saveWebAuthnSession(models.WEBAUTHN_CEREMONY_LOGIN, nil, session)
+	if err != nil {
+		return nil, err
+	}
+
+	return &auth_dto.WebAuthnOptionsDTO{
+		SessionID: sessionId,
+		Options:   assertion,
+	}, nil
+}
+
+// FinishPasskeyLogin verifies a usernameless login and issues tokens for the owner of the passkey.
+// The passkey requires user verification, so no MFA challenge follows.
+func (s *service) FinishPasskeyLogin(dto *auth_dto.WebAuthnLoginFinishDTO, client *auth_dto.ClientInfo) (*auth_dto.TokenResponseDTO, error) {
+	session, err := s.consumeWebAuthnSession(dto.SessionID, models.WEBAUTHN_CEREMONY_LOGIN, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	parsed, err := protocol.ParseCredentialRequestResponseBytes(dto.Credential)
+	if err != nil {
+		s.logger.Info("Invalid passkey login response", zap.Error(err))
+		return nil, ErrWebAuthnVerificationFailed
+	}
+
+	// Find the user from the handle the authenticator stored with the passkey
+	var user *webAuthnUser
+	findUser := func(rawID, userHandle []byte) (webauthn.User, error) {
+		u, err := s.userRepo.GetByWebAuthnHandle(userHandle)
+		if err != nil {
+			return nil, err
+		}
+		if u == nil {
+			return nil, ErrUserNotFound
+		}
+
+		credentials, err := s.webAuthnCredentialRepo.ListUserCredentials(u.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		user = &webAuthnUser{user: u, credentials: credentials}
+		return user, nil
+	}
+
+	_, credential, err := s.webAuthn.ValidatePasskeyLogin(findUser, *session, parsed)
+	if err != nil {
+		s.logger.Info("Passkey login verification failed", zap.Error(err))
+		return nil, ErrWebAuthnVerificationFailed
+	}
+
+	if err := s.recordWebAuthnUse(user, credential); err != nil {
+		return nil, err
+	}
+
+	if user.user.Status != models.USER_STATUS_ACTIVE {
+		s.logger.Info("Passkey login with inactive account", zap.Uint64("user_id", user.user.ID))
+		return nil, ErrUserNotActive
+	}
+
+	return s.completeLogin(user.user, client)
+}
+
+// BeginWebAuthnMFA starts a WebAuthn ceremony answering an MFA challenge, limited to the passkeys of the user
+func (s *service) BeginWebAuthnMFA(dto *auth_dto.WebAuthnMFABeginDTO) (*auth_dto.WebAuthnOptionsDTO, error) {
+	user, _, err := s.loadMFAWebAuthnUser(dto.ChallengeToken)
+	if err != nil {
+		return nil, err
+	}
+
+	assertion, session, err := s.webAuthn.BeginLogin(user,
+		webauthn.WithUserVerification(protocol.VerificationPreferred),
+	)
+	if err != nil {
+		s.logger.Error("Failed to begin WebAuthn MFA", zap.Uint64("user_id", user.user.ID), zap.Error(err))
+		return nil, err
+	}
+
+	sessionId, err := s.saveWebAuthnSession(models.WEBAUTHN_CEREMONY_MFA, &user.user.ID, session)
+	if err != nil {
+		return nil, err
+	}
+
+	return &auth_dto.WebAuthnOptionsDTO{
+		SessionID: sessionId,
+		Options:   assertion,
+	}, nil
+}
+
+// FinishWebAuthnMFA completes a login by verifying a passkey as the second factor of an MFA challenge.
+// Failed verifications count against the challenge, the account and the source IP like wrong codes.
+func (s *service) FinishWebAuthnMFA(dto *auth_dto.WebAuthnMFAFinishDTO, client *auth_dto.ClientInfo) (*auth_dto.TokenResponseDTO, error) {
+	user, challenge, err := s.loadMFAWebAuthnUser(dto.ChallengeToken)
+	if err != nil {
+		return nil, err
+	}
+
+	// Refuse attempts while the account or source IP is throttled
+	if err := s.checkLoginThrottle(user.user.Email, client); err != nil {
+		return nil, err
+	}
+
+	session, err := s.consumeWebAuthnSession(dto.SessionID, models.WEBAUTHN_CEREMONY_MFA, &user.user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	parsed, err := protocol.ParseCredentialRequestResponseBytes(dto.Credential)
+	if err != nil {
+		s.logger.Info("Invalid WebAuthn MFA response", zap.Uint64("user_id", user.user.ID), zap.Error(err))
+		s.recordMFAFailure(challenge, user.user, client)
+		return nil, ErrInvalidMFACode
+	}
+
+	credential, err := s.webAuthn.ValidateLogin(user, *session, parsed)
+	if err != nil {
+		s.logger.Info("WebAuthn MFA verification failed", zap.Uint64("user_id", user.user.ID), zap.Error(err))
+		s.recordMFAFailure(challenge, user.user, client)
+		return nil, ErrInvalidMFACode
+	}
+
+	if err := s.recordWebAuthnUse(user, credential); err != nil {
+		return nil, err
+	}
+
+	return s.completeMFALogin(challenge, user.user, client)
+}
+
+// loadWebAuthnUser fetches a user together with their passkeys
+func (s *service) loadWebAuthnUser(userId uint64) (*webAuthnUser, error) {
+	u, err := s.userRepo.GetByID(userId)
+	if err != nil {
+		s.logger.Error("Failed to fetch user by ID", zap.Uint64("user_id", userId), zap.Error(err))
+		return nil, err
+	}
+	if u == nil {
+		return nil, ErrUserNotFound
+	}
+
+	credentials, err := s.webAuthnCredentialRepo.ListUserCredentials(userId)
+	if err != nil {
+		s.logger.Error("Failed to list WebAuthn credentials", zap.Uint64("user_id", userId), zap.Error(err))
+		return nil, err
+	}
+
+	return &webAuthnUser{user: u, credentials: credentials}, nil
+}
+
+// loadMFAWebAuthnUser fetches the user an MFA challenge was issued for, who must have registered a passkey
+func (s *service) loadMFAWebAuthnUser(challengeToken string) (*webAuthnUser, *mfaChallenge, error) {
+	challenge, err := s.parseMFAChallenge(challengeToken)
+	if err != nil {
+		s.logger.Warn("Invalid MFA challenge token", zap.Error(err))
+		return nil, nil, ErrInvalidMFAChallenge
+	}
+
+	user, err := s.loadWebAuthnUser(challenge.userId)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !user.user.MFAEnabled {
+		return nil, nil, ErrInvalidMFAChallenge
+	}
+	if user.user.Status != models.USER_STATUS_ACTIVE {
+		return nil, nil, ErrUserNotActive
+	}
+	if len(user.credentials) == 0 {
+		return nil, nil, ErrWebAuthnNotRegistered
+	}
+
+	return user, challenge, nil
+}
+
+// recordWebAuthnUse saves the sign count and flags reported on a login. Logins with a passkey
+// whose sign count went backwards are refused, the authenticator may have been cloned.
+func (s *service) recordWebAuthnUse(user *webAuthnUser, credential *webauthn.Credential) error {
+	stored := user.credential(credential.ID)
+	if stored == nil {
+		return ErrWebAuthnCredentialNotFound
+	}
+
+	stored.SignCount = credential.Authenticator.SignCount
+	stored.CloneWarning = credential.Authenticator.CloneWarning
+	stored.UserVerified = credential.Flags.UserVerified
+	stored.BackupState = credential.Flags.BackupState
+	if err := s.webAuthnCredentialRepo.UpdateCredentialUse(stored); err != nil {
+		s.logger.Error("Failed to update WebAuthn credential",
+			zap.Uint64("user_id", user.user.ID),
+			zap.Uint64("credential_id", stored.ID),
+			zap.Error(err))
+		return err
+	}
+
+	if stored.CloneWarning {
+		s.logger.Warn("Passkey sign count went backwards, it may have been cloned",
+			zap.Uint64("user_id", user.user.ID),
+			zap.Uint64("credential_id", stored.ID))
+		return ErrWebAuthnCredentialCloned
+	}
+	return nil
+}
+
+// saveWebAuthnSession stores the session data of a ceremony and returns the ID the client answers with
+func (s *service) saveWebAuthnSession(ceremony string, userId *uint64, session *webauthn.SessionData) (string, error) {
+	sessionId, err := util.GenerateRandomToken(32)
+	if err != nil {
+		return "", err
+	}
+
+	data, err := json.Marshal(session)
+	if err != nil {
+		return "", err
+	}
+
+	webAuthnSession := models.WebAuthnSession{
+		SessionHash: util.HashToken(sessionId),
+		UserID:      userId,
+		Ceremony:    ceremony,
+		Data:        string(data),
+		ExpiresAt:   time.Now().Add(time.Duration(s.config.Auth.WebAuthn.SessionTimeoutSeconds) * time.Second),
+	}
+	if err := s.webAuthnSessionRepo.SaveSession(&webAuthnSession); err != nil {
+		s.logger.Error("Failed to save WebAuthn session", zap.String("ceremony", ceremony), zap.Error(err))
+		return "", err
+	}
+
+	return sessionId, nil
+}
+
+// consumeWebAuthnSession loads and deletes the session of a ceremony, checking it was started for the given user
+func (s *service) consumeWebAuthnSession(sessionId string, ceremony string, userId *uint64) (*webauthn.SessionData, error) {
+	webAuthnSession, err := s.webAuthnSessionRepo.ConsumeSession(util.HashToken(sessionId), ceremony)
+	if err != nil {
+		s.logger.Error("Failed to consume WebAuthn session", zap.String("ceremony", ceremony), zap.Error(err))
+		return nil, err
+	}
+	if webAuthnSession == nil {
+		return nil, ErrInvalidWebAuthnSession
+	}
+	if (userId == nil) != (webAuthnSession.UserID == nil) ||
+		(userId != nil && *userId != *webAuthnSession.UserID) {
+		return nil, ErrInvalidWebAuthnSession
+	}
+
+	var session webauthn.SessionData
+	if err := json.Unmarshal([]byte(webAuthnSession.Data), &session); err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// toWebAuthnCredentialDTO converts a passkey to its response form
+func toWebAuthnCredentialDTO(credential *models.WebAuthnCredential) *auth_dto.WebAuthnCredentialDTO {
+	transports := []string{}
+	if credential.Transports != "" {
+		transports = strings.Split(credential.Transports, ",")
+	}
+
+	return &auth_dto.WebAuthnCredentialDTO{
+		ID:             credential.ID,
+		Name:           credential.Name,
+		Transports:     transports,
+		BackupEligible: credential.BackupEligible,
+		BackupState:    credential.BackupState,
+		LastUsedAt:     credential.LastUsedAt,
+		CreatedAt:      credential.CreatedAt,
+	}
+}
@@ -0,0 +1,544 @@
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"modular-fx-fiber/internal/shared/dto/auth_dto"
+	"modular-fx-fiber/internal/shared/models"
+	"testing"
+
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/protocol/webauthncbor"
+	"github.com/go-webauthn/webauthn/protocol/webauthncose"
+)
+
+const testOrigin = "https://example.com"
+
+// Authenticator data flags, see https://www.w3.org/TR/webauthn-3/#authdata-flags
+const (
+	authenticatorFlagUserPresent  byte = 0x01
+	authenticatorFlagUserVerified byte = 0x04
+	authenticatorFlagAttestedData byte = 0x40
+	softAuthenticatorDefaultFlags      = authenticatorFlagUserPresent | authenticatorFlagUserVerified
+)
+
+// softAuthenticator is a platform authenticator holding one ES256 passkey in memory.
+// It answers ceremonies the way a browser passes them on: clientDataJSON, authenticator data
+// and an attestation of format "none" on registration or a signature on login.
+type softAuthenticator struct {
+	t            *testing.T
+	rpID         string
+	origin       string
+	key          *ecdsa.PrivateKey
+	credentialID []byte
+	userHandle   []byte
+	signCount    uint32
+}
+
+func newSoftAuthenticator(t *testing.T) *softAuthenticator {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	credentialID := make([]byte, 16)
+	if _, err := rand.Read(credentialID); err != nil {
+		t.Fatalf("rand.Read: %v", err)
+	}
+
+	return &softAuthenticator{t: t, rpID: "example.com", origin: testOrigin, key: key, credentialID: credentialID}
+}
+
+// register answers the options of BeginWebAuthnRegistration with a new passkey
+func (a *softAuthenticator) register(options *auth_dto.WebAuthnOptionsDTO) json.RawMessage {
+	a.t.Helper()
+
+	creation, ok := options.Options.(*protocol.CredentialCreation)
+	if !ok {
+		a.t.Fatalf("registration options are %T, want *protocol.CredentialCreation", options.Options)
+	}
+	a.userHandle = creation.Response.User.ID.(protocol.URLEncodedBase64)
+
+	publicKey, err := webauthncbor.Marshal(webauthncose.EC2PublicKeyData{
+		PublicKeyData: webauthncose.PublicKeyData{
+			KeyType:   int64(webauthncose.EllipticKey),
+			Algorithm: int64(webauthncose.AlgES256),
+		},
+		Curve:  int64(webauthncose.P256),
+		XCoord: a.key.X.FillBytes(make([]byte, 32)),
+		YCoord: a.key.Y.FillBytes(make([]byte, 32)),
+	})
+	if err != nil {
+		a.t.Fatalf("marshal COSE key: %v", err)
+	}
+
+	// Attested credential data: AAGUID, credential ID length, credential ID and COSE public key
+	attestedData := make([]byte, 16, 16+2+len(a.credentialID)+len(publicKey))
+	attestedData = binary.BigEndian.AppendUint16(attestedData, uint16(len(a.credentialID)))
+	attestedData = append(attestedData, a.credentialID...)
+	attestedData = append(attestedData, publicKey...)
+
+	attestationObject, err := webauthncbor.Marshal(map[string]any{
+		"fmt":      "none",
+		"attStmt":  map[string]any{},
+		"authData": append(a.authenticatorData(softAuthenticatorDefaultFlags|authenticatorFlagAttestedData), attestedData...),
+	})
+	if err != nil {
+		a.t.Fatalf("marshal attestation object: %v", err)
+	}
+
+	return a.credential(map[string]any{
+		"clientDataJSON":    encode(a.clientData("webauthn.create", creation.Response.Challenge)),
+		"attestationObject": encode(attestationObject),
+		"transports":        []string{"internal"},
+	})
+}
+
+// login answers the options of a login ceremony by signing the challenge with the passkey
+func (a *softAuthenticator) login(options *auth_dto.WebAuthnOptionsDTO) json.RawMessage {
+	a.t.Helper()
+
+	assertion, ok := options.Options.(*protocol.CredentialAssertion)
+	if !ok {
+		a.t.Fatalf("login options are %T, want *protocol.CredentialAssertion", options.Options)
+	}
+
+	a.signCount++
+	authenticatorData := a.authenticatorData(softAuthenticatorDefaultFlags)
+	clientData := a.clientData("webauthn.get", assertion.Response.Challenge)
+
+	clientDataHash := sha256.Sum256(clientData)
+	digest := sha256.Sum256(append(authenticatorData, clientDataHash[:]...))
+	signature, err := ecdsa.SignASN1(rand.Reader, a.key, digest[:])
+	if err != nil {
+		a.t.Fatalf("sign assertion: %v", err)
+	}
+
+	return a.credential(map[string]any{
+		"clientDataJSON":    encode(clientData),
+		"authenticatorData": encode(authenticatorData),
+		"signature":         encode(signature),
+		"userHandle":        encode(a.userHandle),
+	})
+}
+
+// authenticatorData returns the RP ID hash, flags and sign count of an answer
+func (a *softAuthenticator) authenticatorData(flags byte) []byte {
+	rpIDHash := sha256.Sum256([]byte(a.rpID))
+	data := append(rpIDHash[:], flags)
+	return binary.BigEndian.AppendUint32(data, a.signCount)
+}
+
+func (a *softAuthenticator) clientData(ceremony string, challenge protocol.URLEncodedBase64) []byte {
+	clientData, err := json.Marshal(map[string]any{
+		"type":      ceremony,
+		"challenge": encode(challenge),
+		"origin":    a.origin,
+	})
+	if err != nil {
+		a.t.Fatalf("marshal client data: %v", err)
+	}
+	return clientData
+}
+
+// credential wraps an authenticator response in the PublicKeyCredential a browser returns
+func (a *softAuthenticator) credential(response map[string]any) json.RawMessage {
+	credential, err := json.Marshal(map[string]any{
+		"id":       encode(a.credentialID),
+		"rawId":    encode(a.credentialID),
+		"type":     "public-key",
+		"response": response,
+	})
+	if err != nil {
+		a.t.Fatalf("marshal credential: %v", err)
+	}
+	return credential
+}
+
+func encode(data []byte) string {
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// registerPasskey registers the passkey of the authenticator for the user
+func registerPasskey(t *testing.T, ts *testService, authenticator *softAuthenticator, userId uint64) *auth_dto.WebAuthnCredentialDTO {
+	t.Helper()
+
+	options, err := ts.BeginWebAuthnRegistration(userId)
+	if err != nil {
+		t.Fatalf("BeginWebAuthnRegistration: %v", err)
+	}
+	credential, err := ts.FinishWebAuthnRegistration(&auth_dto.WebAuthnRegisterFinishDTO{
+		SessionID:  options.SessionID,
+		Name:       "Test key",
+		Credential: authenticator.register(options),
+	}, userId)
+	if err != nil {
+		t.Fatalf("FinishWebAuthnRegistration: %v", err)
+	}
+	return credential
+}
+
+// passkeyLogin runs a usernameless login with the authenticator
+func passkeyLogin(t *testing.T, ts *testService, authenticator *softAuthenticator) (*auth_dto.TokenResponseDTO, error) {
+	t.Helper()
+
+	options, err := ts.BeginPasskeyLogin()
+	if err != nil {
+		t.Fatalf("BeginPasskeyLogin: %v", err)
+	}
+	return ts.FinishPasskeyLogin(&auth_dto.WebAuthnLoginFinishDTO{
+		SessionID:  options.SessionID,
+		Credential: authenticator.login(options),
+	}, &auth_dto.ClientInfo{})
+}
+
+func TestWebAuthnRegistration(t *testing.T) {
+	ts := newTestService(t, newTestConfig(), nil)
+	u := ts.addUser(t, &models.User{Email: "jane@example.com", FirstName: "Jane", LastName: "Doe"})
+	authenticator := newSoftAuthenticator(t)
+
+	registered := registerPasskey(t, ts, authenticator, u.ID)
+	if registered.Name != "Test key" || len(registered.Transports) != 1 || registered.Transports[0] != "internal" {
+		t.Errorf("registered passkey = %+v, want Test key over internal transport", registered)
+	}
+
+	stored, _ := ts.users.GetByID(u.ID)
+	if len(stored.WebAuthnHandle) != webAuthnHandleLength {
+		t.Errorf("user handle has %d bytes, want %d", len(stored.WebAuthnHandle), webAuthnHandleLength)
+	}
+	if len(ts.credentials.credentials) != 1 || !ts.credentials.credentials[0].UserVerified {
+		t.Fatalf("stored credentials = %+v, want one user verified passkey", ts.credentials.credentials)
+	}
+
+	// The registered passkey is excluded from the next registration, the handle is kept
+	options, err := ts.BeginWebAuthnRegistration(u.ID)
+	if err != nil {
+		t.Fatalf("second BeginWebAuthnRegistration: %v", err)
+	}
+	creation := options.Options.(*protocol.CredentialCreation)
+	if len(creation.Response.CredentialExcludeList) != 1 {
+		t.Errorf("exclude list has %d passkeys, want 1", len(creation.Response.CredentialExcludeList))
+	}
+	if again, _ := ts.users.GetByID(u.ID); string(again.WebAuthnHandle) != string(stored.WebAuthnHandle) {
+		t.Errorf("user handle changed on the second registration")
+	}
+}
+
+func TestWebAuthnRegistrationRejectsInvalidResponse(t *testing.T) {
+	tests := []struct {
+		name      string
+		configure func(authenticator *softAuthenticator)
+	}{
+		{"wrong origin", func(a *softAuthenticator) { a.origin = "https://evil.example.org" }},
+		{"wrong relying party", func(a *softAuthenticator) { a.rpID = "evil.example.org" }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := newTestService(t, newTestConfig(), nil)
+			u := ts.addUser(t, &models.User{Email: "jane@example.com"})
+			authenticator := newSoftAuthenticator(t)
+			tt.configure(authenticator)
+
+			options, err := ts.BeginWebAuthnRegistration(u.ID)
+			if err != nil {
+				t.Fatalf("BeginWebAuthnRegistration: %v", err)
+			}
+			_, err = ts.FinishWebAuthnRegistration(&auth_dto.WebAuthnRegisterFinishDTO{
+				SessionID:  options.SessionID,
+				Credential: authenticator.register(options),
+			}, u.ID)
+			if !errors.Is(err, ErrWebAuthnVerificationFailed) {
+				t.Fatalf("got error %v, want ErrWebAuthnVerificationFailed", err)
+			}
+			if len(ts.credentials.credentials) != 0 {
+				t.Errorf("stored %d passkeys, want none", len(ts.credentials.credentials))
+			}
+		})
+	}
+}
+
+func TestWebAuthnRegistrationSessionBelongsToUser(t *testing.T) {
+	ts := newTestService(t, newTestConfig(), nil)
+	owner := ts.addUser(t, &models.User{Email: "jane@example.com"})
+	other := ts.addUser(t, &models.User{Email: "john@example.com"})
+	authenticator := newSoftAuthenticator(t)
+
+	options, err := ts.BeginWebAuthnRegistration(owner.ID)
+	if err != nil {
+		t.Fatalf("BeginWebAuthnRegistration: %v", err)
+	}
+	_, err = ts.FinishWebAuthnRegistration(&auth_dto.WebAuthnRegisterFinishDTO{
+		SessionID:  options.SessionID,
+		Credential: authenticator.register(options),
+	}, other.ID)
+	if !errors.Is(err, ErrInvalidWebAuthnSession) {
+		t.Fatalf("got error %v, want ErrInvalidWebAuthnSession", err)
+	}
+}
+
+func TestPasskeyLogin(t *testing.T) {
+	ts := newTestService(t, newTestConfig(), nil)
+	u := ts.addUser(t, &models.User{Email: "jane@example.com"})
+	authenticator := newSoftAuthenticator(t)
+	registerPasskey(t, ts, authenticator, u.ID)
+
+	// The login options name no user, the authenticator finds the passkey and returns its user handle
+	options, err := ts.BeginPasskeyLogin()
+	if err != nil {
+		t.Fatalf("BeginPasskeyLogin: %v", err)
+	}
+	assertion := options.Options.(*protocol.CredentialAssertion)
+	if len(assertion.Response.AllowedCredentials) != 0 {
+		t.Errorf("login options allow %d passkeys, want a discoverable login", len(assertion.Response.AllowedCredentials))
+	}
+	if assertion.Response.UserVerification != protocol.VerificationRequired {
+		t.Errorf("user verification = %q, want required", assertion.Response.UserVerification)
+	}
+
+	tokens, err := ts.FinishPasskeyLogin(&auth_dto.WebAuthnLoginFinishDTO{
+		SessionID:  options.SessionID,
+		Credential: authenticator.login(options),
+	}, &auth_dto.ClientInfo{})
+	if err != nil {
+		t.Fatalf("FinishPasskeyLogin: %v", err)
+	}
+	if tokens.AccessToken == "" || len(ts.refreshTokens.tokens) != 1 || ts.refreshTokens.tokens[0].UserID != u.ID {
+		t.Errorf("got tokens %+v, want a session for user %d", tokens, u.ID)
+	}
+
+	stored := ts.credentials.credentials[0]
+	if stored.SignCount != 1 || stored.LastUsedAt == nil {
+		t.Errorf("stored passkey = %+v, want sign count 1 and a last use", stored)
+	}
+
+	// The session is single use
+	_, err = ts.FinishPasskeyLogin(&auth_dto.WebAuthnLoginFinishDTO{
+		SessionID:  options.SessionID,
+		Credential: authenticator.login(options),
+	}, &auth_dto.ClientInfo{})
+	if !errors.Is(err, ErrInvalidWebAuthnSession) {
+		t.Fatalf("replayed session got error %v, want ErrInvalidWebAuthnSession", err)
+	}
+}
+
+func TestPasskeyLoginRejectsInvalidAssertion(t *testing.T) {
+	tests := []struct {
+		name      string
+		configure func(ts *testService, authenticator *softAuthenticator)
+		wantErr   error
+	}{
+		{
+			name: "signed with another key",
+			configure: func(ts *testService, a *softAuthenticator) {
+				a.key = newSoftAuthenticator(a.t).key
+			},
+			wantErr: ErrWebAuthnVerificationFailed,
+		},
+		{
+			name:      "wrong origin",
+			configure: func(ts *testService, a *softAuthenticator) { a.origin = "https://evil.example.org" },
+			wantErr:   ErrWebAuthnVerificationFailed,
+		},
+		{
+			name:      "unknown user handle",
+			configure: func(ts *testService, a *softAuthenticator) { a.userHandle = []byte("unknown-handle") },
+			wantErr:   ErrWebAuthnVerificationFailed,
+		},
+		{
+			name: "inactive user",
+			configure: func(ts *testService, a *softAuthenticator) {
+				u, _ := ts.users.GetByEmail("jane@example.com")
+				u.Status = models.USER_STATUS_INACTIVE
+				_ = ts.users.Update(u)
+			},
+			wantErr: ErrUserNotActive,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := newTestService(t, newTestConfig(), nil)
+			u := ts.addUser(t, &models.User{Email: "jane@example.com"})
+			authenticator := newSoftAuthenticator(t)
+			registerPasskey(t, ts, authenticator, u.ID)
+			tt.configure(ts, authenticator)
+
+			tokens, err := passkeyLogin(t, ts, authenticator)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got error %v, want %v", err, tt.wantErr)
+			}
+			if tokens != nil || len(ts.refreshTokens.tokens) != 0 {
+				t.Errorf("got tokens %+v, want none", tokens)
+			}
+		})
+	}
+}
+
+func TestPasskeyLoginRejectsClonedAuthenticator(t *testing.T) {
+	ts := newTestService(t, newTestConfig(), nil)
+	u := ts.addUser(t, &models.User{Email: "jane@example.com"})
+	authenticator := newSoftAuthenticator(t)
+	registerPasskey(t, ts, authenticator, u.ID)
+
+	for i := 0; i < 2; i++ {
+		if _, err := passkeyLogin(t, ts, authenticator); err != nil {
+			t.Fatalf("login %d: %v", i+1, err)
+		}
+	}
+
+	// A copy of the passkey still counting from an earlier state is refused
+	clone := *authenticator
+	clone.signCount = 0
+	if _, err := passkeyLogin(t, ts, &clone); !errors.Is(err, ErrWebAuthnCredentialCloned) {
+		t.Fatalf("cloned authenticator got error %v, want ErrWebAuthnCredentialCloned", err)
+	}
+	if !ts.credentials.credentials[0].CloneWarning {
+		t.Errorf("clone warning was not saved")
+	}
+
+	// The passkey stays refused until it is registered again, even with a higher sign count
+	if _, err := passkeyLogin(t, ts, authenticator); !errors.Is(err, ErrWebAuthnCredentialCloned) {
+		t.Fatalf("login after clone warning got error %v, want ErrWebAuthnCredentialCloned", err)
+	}
+	if len(ts.refreshTokens.tokens) != 2 {
+		t.Errorf("issued %d sessions, want only the 2 before the clone was detected", len(ts.refreshTokens.tokens))
+	}
+}
+
+// webAuthnMFA answers an MFA challenge with the authenticator
+func webAuthnMFA(t *testing.T, ts *testService, authenticator *softAuthenticator, challengeToken string) (*auth_dto.TokenResponseDTO, error) {
+	t.Helper()
+
+	options, err := ts.BeginWebAuthnMFA(&auth_dto.WebAuthnMFABeginDTO{ChallengeToken: challengeToken})
+	if err != nil {
+		return nil, err
+	}
+	return ts.FinishWebAuthnMFA(&auth_dto.WebAuthnMFAFinishDTO{
+		ChallengeToken: challengeToken,
+		SessionID:      options.SessionID,
+		Credential:     authenticator.login(options),
+	}, &auth_dto.ClientInfo{})
+}
+
+func TestWebAuthnMFA(t *testing.T) {
+	ts := newTestService(t, newTestConfig(), nil)
+	u := ts.addUser(t, &models.User{Email: "jane@example.com", MFAEnabled: true})
+	authenticator := newSoftAuthenticator(t)
+	registerPasskey(t, ts, authenticator, u.ID)
+
+	challenge, err := ts.issueMFAChallenge(u)
+	if err != nil {
+		t.Fatalf("issueMFAChallenge: %v", err)
+	}
+	if !containsString(challenge.Methods, mfaMethodWebAuthn) {
+		t.Errorf("challenge methods = %v, want %s", challenge.Methods, mfaMethodWebAuthn)
+	}
+
+	// The MFA ceremony only allows the passkeys of the challenged user
+	options, err := ts.BeginWebAuthnMFA(&auth_dto.WebAuthnMFABeginDTO{ChallengeToken: challenge.ChallengeToken})
+	if err != nil {
+		t.Fatalf("BeginWebAuthnMFA: %v", err)
+	}
+	assertion := options.Options.(*protocol.CredentialAssertion)
+	if len(assertion.Response.AllowedCredentials) != 1 {
+		t.Errorf("MFA options allow %d passkeys, want 1", len(assertion.Response.AllowedCredentials))
+	}
+
+	tokens, err := ts.FinishWebAuthnMFA(&auth_dto.WebAuthnMFAFinishDTO{
+		ChallengeToken: challenge.ChallengeToken,
+		SessionID:      options.SessionID,
+		Credential:     authenticator.login(options),
+	}, &auth_dto.ClientInfo{})
+	if err != nil {
+		t.Fatalf("FinishWebAuthnMFA: %v", err)
+	}
+	if tokens.AccessToken == "" {
+		t.Errorf("got tokens %+v, want an access token", tokens)
+	}
+
+	// The challenge is consumed by the successful answer
+	if _, err := webAuthnMFA(t, ts, authenticator, challenge.ChallengeToken); !errors.Is(err, ErrInvalidMFAChallenge) {
+		t.Fatalf("reused challenge got error %v, want ErrInvalidMFAChallenge", err)
+	}
+}
+
+func TestWebAuthnMFARejectsPasskeyOfAnotherUser(t *testing.T) {
+	ts := newTestService(t, newTestConfig(), nil)
+	u := ts.addUser(t, &models.User{Email: "jane@example.com", MFAEnabled: true})
+	other := ts.addUser(t, &models.User{Email: "john@example.com"})
+	registerPasskey(t, ts, newSoftAuthenticator(t), u.ID)
+	otherAuthenticator := newSoftAuthenticator(t)
+	registerPasskey(t, ts, otherAuthenticator, other.ID)
+
+	challenge, err := ts.issueMFAChallenge(u)
+	if err != nil {
+		t.Fatalf("issueMFAChallenge: %v", err)
+	}
+	if _, err := webAuthnMFA(t, ts, otherAuthenticator, challenge.ChallengeToken); !errors.Is(err, ErrInvalidMFACode) {
+		t.Fatalf("got error %v, want ErrInvalidMFACode", err)
+	}
+	if len(ts.refreshTokens.tokens) != 0 {
+		t.Errorf("issued %d sessions, want none", len(ts.refreshTokens.tokens))
+	}
+}
+
+func TestWebAuthnMFAInvalidatesChallengeAfterMaxAttempts(t *testing.T) {
+	c := newTestConfig()
+	ts := newTestService(t, c, nil)
+	u := ts.addUser(t, &models.User{Email: "jane@example.com", MFAEnabled: true})
+	authenticator := newSoftAuthenticator(t)
+	registerPasskey(t, ts, authenticator, u.ID)
+
+	challenge, err := ts.issueMFAChallenge(u)
+	if err != nil {
+		t.Fatalf("issueMFAChallenge: %v", err)
+	}
+
+	forged := *authenticator
+	forged.key = newSoftAuthenticator(t).key
+	for i := 0; i < c.Auth.MFA.MaxAttempts; i++ {
+		if _, err := webAuthnMFA(t, ts, &forged, challenge.ChallengeToken); !errors.Is(err, ErrInvalidMFACode) {
+			t.Fatalf("attempt %d got error %v, want ErrInvalidMFACode", i+1, err)
+		}
+	}
+
+	// Even the right passkey cannot answer the challenge anymore
+	if _, err := webAuthnMFA(t, ts, authenticator, challenge.ChallengeToken); !errors.Is(err, ErrInvalidMFAChallenge) {
+		t.Fatalf("got error %v, want ErrInvalidMFAChallenge", err)
+	}
+	account, _ := ts.throttles.GetThrottle(models.LOGIN_THROTTLE_ACCOUNT, u.Email)
+	if account == nil || account.FailedAttempts != c.Auth.MFA.MaxAttempts {
+		t.Errorf("account throttle = %+v, want %d failures", account, c.Auth.MFA.MaxAttempts)
+	}
+}
+
+func TestWebAuthnMFARequiresRegisteredPasskey(t *testing.T) {
+	ts := newTestService(t, newTestConfig(), nil)
+	u := ts.addUser(t, &models.User{Email: "jane@example.com", MFAEnabled: true})
+
+	challenge, err := ts.issueMFAChallenge(u)
+	if err != nil {
+		t.Fatalf("issueMFAChallenge: %v", err)
+	}
+	_, err = ts.BeginWebAuthnMFA(&auth_dto.WebAuthnMFABeginDTO{ChallengeToken: challenge.ChallengeToken})
+	if !errors.Is(err, ErrWebAuthnNotRegistered) {
+		t.Fatalf("got error %v, want ErrWebAuthnNotRegistered", err)
+	}
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
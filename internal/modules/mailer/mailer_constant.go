@@ -4,9 +4,70 @@ const (
 	// EmailVerificationSubject is the subject of the email verification email
 	EmailVerificationSubject  = "Email Verification"
 	EmailVerificationTemplate = "send_confirm_email_code"
+
+	// PasswordResetSubject is the subject of the password reset email
+	PasswordResetSubject  = "Password Reset"
+	PasswordResetTemplate = "reset_password"
+
+	// PasswordChangedSubject is the subject of the password changed notification email
+	PasswordChangedSubject  = "Your Password Was Changed"
+	PasswordChangedTemplate = "password_changed"
+
+	// AccountLockedSubject is the subject of the account lockout notification email
+	AccountLockedSubject  = "Your Account Was Temporarily Locked"
+	AccountLockedTemplate = "account_locked"
+
+	// MagicLinkSubject is the subject of the passwordless login email
+	MagicLinkSubject  = "Your Login Link"
+	MagicLinkTemplate = "magic_link"
+
+	// EmailChangeConfirmSubject is the subject of the email sent to the new address of an email change
+	EmailChangeConfirmSubject  = "Confirm Your New Email Address"
+	EmailChangeConfirmTemplate = "email_change_confirm"
+
+	// EmailChangeNoticeSubject is the subject of the email sent to the old address of an email change
+	EmailChangeNoticeSubject  = "Your Email Address Is Being Changed"
+	EmailChangeNoticeTemplate = "email_change_notice"
 )
 
 type EmailVerificationData struct {
 	Name string
 	Code string
 }
+
+type PasswordResetData struct {
+	Name             string
+	Link             string
+	ExpiresInMinutes int
+}
+
+type PasswordChangedData struct {
+	Name      string
+	ChangedAt string
+}
+
+type AccountLockedData struct {
+	Name           string
+	LockoutMinutes int
+	IPAddress      string
+}
+
+type MagicLinkData struct {
+	Name             string
+	Link             string
+	ExpiresInMinutes int
+}
+
+type EmailChangeConfirmData struct {
+	Name             string
+	NewEmail         string
+	Link             string
+	ExpiresInMinutes int
+}
+
+type EmailChangeNoticeData struct {
+	Name           string
+	NewEmail       string
+	UndoLink       string
+	UndoExpiryDays int
+}
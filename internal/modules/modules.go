@@ -0,0 +1,48 @@
+package modules
+
+import (
+	"modular-fx-fiber/internal/modules/auth"
+	"modular-fx-fiber/internal/modules/mailer"
+	"modular-fx-fiber/internal/modules/oauth"
+	"modular-fx-fiber/internal/modules/role"
+	"modular-fx-fiber/internal/modules/service_account"
+	"modular-fx-fiber/internal/modules/sms"
+	"modular-fx-fiber/internal/modules/user"
+
+	"go.uber.org/fx"
+)
+
+// feature is a feature module and the permissions its routes check
+type feature struct {
+	module      fx.Option
+	permissions fx.Option // part of module too, nil when the routes check no permission
+}
+
+// features lists the feature modules of the server. A module declaring permissions lists them
+// here as well, so commands can build the permission catalog without starting the modules.
+var features = []feature{
+	{module: user.Module, permissions: user.Permissions},
+	{module: auth.Module},
+	{module: mailer.Module},
+	{module: sms.Module},
+	{module: oauth.Module},
+	{module: service_account.Module, permissions: service_account.Permissions},
+	{module: role.Module, permissions: role.Permissions},
+}
+
+// Module exports every feature module
+var Module = fx.Options(featureOptions(func(f feature) fx.Option { return f.module })...)
+
+// Permissions declares the permissions of every feature module, for the rbac catalog
+var Permissions = fx.Options(featureOptions(func(f feature) fx.Option { return f.permissions })...)
+
+// featureOptions collects one option of every feature, skipping the nil ones
+func featureOptions(option func(f feature) fx.Option) []fx.Option {
+	options := make([]fx.Option, 0, len(features))
+	for _, f := range features {
+		if o := option(f); o != nil {
+			options = append(options, o)
+		}
+	}
+	return options
+}
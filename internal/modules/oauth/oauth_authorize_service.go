@@ -0,0 +1,236 @@
+package oauth
+
+import (
+	"modular-fx-fiber/internal/shared/dto/oauth_dto"
+	"modular-fx-fiber/internal/shared/models"
+	"modular-fx-fiber/internal/shared/util"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Authorization checks an authorization request for the consent page.
+// When the user already consented to every requested scope, a code is issued right away.
+// Requests with an unknown client or redirect URI fail with an *Error, other invalid
+// requests are answered with a redirect carrying the error to the client.
+func (s *service) Authorization(dto *oauth_dto.AuthorizeDTO, userId uint64) (*oauth_dto.AuthorizationDTO, error) {
+	client, err := s.authorizationClient(dto)
+	if err != nil {
+		return nil, err
+	}
+
+	scopes, oauthErr := validateAuthorizationRequest(dto, client)
+	if oauthErr != nil {
+		return authorizationError(dto, client, oauthErr), nil
+	}
+
+	consent, err := s.consentRepo.GetConsent(userId, client.ClientID)
+	if err != nil {
+		s.logger.Error("Failed to fetch oauth consent",
+			zap.Uint64("user_id", userId),
+			zap.String("client_id", client.ClientID),
+			zap.Error(err))
+		return nil, err
+	}
+	if consent != nil && containsScopes(strings.Fields(consent.Scope), scopes) {
+		return s.grantAuthorization(dto, client, scopes, userId)
+	}
+
+	return &oauth_dto.AuthorizationDTO{
+		ClientID:        client.ClientID,
+		ClientName:      client.Name,
+		Scopes:          scopes,
+		ConsentRequired: true,
+	}, nil
+}
+
+// Consent records the decision of the user on an authorization request
+// and redirects back to the client with a code or an access_denied error
+func (s *service) Consent(dto *oauth_dto.ConsentDTO, userId uint64) (*oauth_dto.AuthorizationDTO, error) {
+	client, err := s.authorizationClient(&dto.AuthorizeDTO)
+	if err != nil {
+		return nil, err
+	}
+
+	scopes, oauthErr := validateAuthorizationRequest(&dto.AuthorizeDTO, client)
+	if oauthErr != nil {
+		return authorizationError(&dto.AuthorizeDTO, client, oauthErr), nil
+	}
+
+	if !dto.Approve {
+		s.logger.Info("User denied oauth authorization",
+			zap.Uint64("user_id", userId),
+			zap.String("client_id", client.ClientID))
+		return authorizationError(&dto.AuthorizeDTO, client, &Error{
+			Code:        oauth_dto.ErrorAccessDenied,
+			Description: "the user denied the request",
+		}), nil
+	}
+
+	// Remember the granted scopes, keeping the ones granted before
+	granted := slices.Clone(scopes)
+	consent, err := s.consentRepo.GetConsent(userId, client.ClientID)
+	if err != nil {
+		s.logger.Error("Failed to fetch oauth consent",
+			zap.Uint64("user_id", userId),
+			zap.String("client_id", client.ClientID),
+			zap.Error(err))
+		return nil, err
+	}
+	if consent != nil {
+		for _, scope := range strings.Fields(consent.Scope) {
+			if !slices.Contains(granted, scope) {
+				granted = append(granted, scope)
+			}
+		}
+	}
+
+	err = s.consentRepo.SaveConsent(&models.OAuthConsent{
+		UserID:   userId,
+		ClientID: client.ClientID,
+		Scope:    strings.Join(granted, " "),
+	})
+	if err != nil {
+		s.logger.Error("Failed to save oauth consent",
+			zap.Uint64("user_id", userId),
+			zap.String("client_id", client.ClientID),
+			zap.Error(err))
+		return nil, err
+	}
+
+	s.logger.Info("User granted oauth authorization",
+		zap.Uint64("user_id", userId),
+		zap.String("client_id", client.ClientID),
+		zap.Strings("scopes", scopes))
+	return s.grantAuthorization(&dto.AuthorizeDTO, client, scopes, userId)
+}
+
+// authorizationClient fetches the client of an authorization request and checks its redirect URI.
+// Errors here must not be redirected since the redirect URI cannot be trusted.
+func (s *service) authorizationClient(dto *oauth_dto.AuthorizeDTO) (*models.OAuthClient, error) {
+	client, err := s.oauthClientRepo.GetByClientID(dto.ClientID)
+	if err != nil {
+		s.logger.Error("Failed to fetch oauth client", zap.String("client_id", dto.ClientID), zap.Error(err))
+		return nil, err
+	}
+	if client == nil {
+		s.logger.Info("Authorization request for unknown client", zap.String("client_id", dto.ClientID))
+		return nil, &Error{Code: oauth_dto.ErrorInvalidRequest, Description: "unknown client"}
+	}
+
+	if !client.AllowsRedirectURI(dto.RedirectURI) {
+		s.logger.Warn("Authorization request with unregistered redirect uri",
+			zap.String("client_id", client.ClientID),
+			zap.String("redirect_uri", dto.RedirectURI))
+		return nil, &Error{Code: oauth_dto.ErrorInvalidRequest, Description: "redirect_uri is not registered for the client"}
+	}
+
+	return client, nil
+}
+
+// validateAuthorizationRequest checks the response type, scopes and PKCE parameters of a request
+func validateAuthorizationRequest(dto *oauth_dto.AuthorizeDTO, client *models.OAuthClient) ([]string, *Error) {
+	if dto.ResponseType != oauth_dto.ResponseTypeCode {
+		return nil, &Error{Code: oauth_dto.ErrorUnsupportedResponseType, Description: "only the code response type is supported"}
+	}
+
+	scopes := strings.Fields(dto.Scope)
+	if !client.AllowsScopes(scopes) {
+		return nil, &Error{Code: oauth_dto.ErrorInvalidScope, Description: "scope is not allowed for the client"}
+	}
+
+	if dto.CodeChallenge != "" && dto.CodeChallengeMethod != oauth_dto.CodeChallengeMethodS256 {
+		return nil, &Error{Code: oauth_dto.ErrorInvalidRequest, Description: "only the S256 code challenge method is supported"}
+	}
+	if dto.CodeChallenge == "" && client.Public {
+		return nil, &Error{Code: oauth_dto.ErrorInvalidRequest, Description: "public clients must use PKCE"}
+	}
+
+	return scopes, nil
+}
+
+// grantAuthorization issues an authorization code and redirects back to the client with it
+func (s *service) grantAuthorization(dto *oauth_dto.AuthorizeDTO, client *models.OAuthClient, scopes []string, userId uint64) (*oauth_dto.AuthorizationDTO, error) {
+	code, err := util.GenerateRandomToken(32)
+	if err != nil {
+		s.logger.Error("Failed to generate authorization code", zap.Error(err))
+		return nil, err
+	}
+
+	expiry := time.Duration(s.config.OAuth.AuthorizationCodeExpirySeconds) * time.Second
+	authorizationCode := models.OAuthAuthorizationCode{
+		CodeHash:      util.HashToken(code),
+		ClientID:      client.ClientID,
+		UserID:        userId,
+		RedirectURI:   dto.RedirectURI,
+		Scope:         strings.Join(scopes, " "),
+		Nonce:         nonEmpty(dto.Nonce),
+		CodeChallenge: nonEmpty(dto.CodeChallenge),
+		ExpiresAt:     time.Now().Add(expiry),
+	}
+	if err := s.authorizationCodeRepo.SaveAuthorizationCode(&authorizationCode); err != nil {
+		s.logger.Error("Failed to save authorization code",
+			zap.Uint64("user_id", userId),
+			zap.String("client_id", client.ClientID),
+			zap.Error(err))
+		return nil, err
+	}
+
+	params := url.Values{}
+	params.Set("code", code)
+	if dto.State != "" {
+		params.Set("state", dto.State)
+	}
+
+	return &oauth_dto.AuthorizationDTO{
+		ClientID:   client.ClientID,
+		ClientName: client.Name,
+		Scopes:     scopes,
+		RedirectTo: redirectURL(dto.RedirectURI, params),
+	}, nil
+}
+
+// authorizationError redirects back to the client with an error (RFC 6749 section 4.1.2.1)
+func authorizationError(dto *oauth_dto.AuthorizeDTO, client *models.OAuthClient, oauthErr *Error) *oauth_dto.AuthorizationDTO {
+	params := url.Values{}
+	params.Set("error", oauthErr.Code)
+	params.Set("error_description", oauthErr.Description)
+	if dto.State != "" {
+		params.Set("state", dto.State)
+	}
+
+	return &oauth_dto.AuthorizationDTO{
+		ClientID:   client.ClientID,
+		ClientName: client.Name,
+		Scopes:     []string{},
+		RedirectTo: redirectURL(dto.RedirectURI, params),
+	}
+}
+
+// redirectURL adds the parameters to the query of a registered redirect URI
+func redirectURL(redirectURI string, params url.Values) string {
+	u, err := url.Parse(redirectURI)
+	if err != nil {
+		return redirectURI
+	}
+
+	query := u.Query()
+	for key, values := range params {
+		query[key] = values
+	}
+	u.RawQuery = query.Encode()
+	return u.String()
+}
+
+// containsScopes reports whether every requested scope was granted
+func containsScopes(granted []string, requested []string) bool {
+	for _, scope := range requested {
+		if !slices.Contains(granted, scope) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,158 @@
+package oauth
+
+import (
+	"modular-fx-fiber/internal/shared/dto/oauth_dto"
+	"modular-fx-fiber/internal/shared/logger"
+	"modular-fx-fiber/internal/shared/models"
+	"modular-fx-fiber/internal/shared/util"
+	"modular-fx-fiber/internal/shared/validator"
+
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+)
+
+type (
+	Handlers interface {
+		AuthenticateClient(c *fiber.Ctx) error
+		Introspect(c *fiber.Ctx) error
+		Revoke(c *fiber.Ctx) error
+		Authorize(c *fiber.Ctx) error
+		Consent(c *fiber.Ctx) error
+		Token(c *fiber.Ctx) error
+		UserInfo(c *fiber.Ctx) error
+		Discovery(c *fiber.Ctx) error
+	}
+
+	handlers struct {
+		service   Service
+		validator *validator.Validator
+		logger    *logger.ZapLogger
+	}
+)
+
+// NewHandlers creates a new oauth handlers instance
+func NewHandlers(s Service, l *logger.ZapLogger, v *validator.Validator) Handlers {
+	return &handlers{
+		service:   s,
+		validator: v,
+		logger:    l,
+	}
+}
+
+// AuthenticateClient authenticates the calling client with HTTP Basic
+// (client_secret_basic) or form parameters (client_secret_post)
+func (h *handlers) AuthenticateClient(c *fiber.Ctx) error {
+	clientID, clientSecret, ok := util.BasicCredentials(c.Get(fiber.HeaderAuthorization))
+	if !ok {
+		clientID = c.FormValue("client_id")
+		clientSecret = c.FormValue("client_secret")
+	}
+
+	client, err := h.service.AuthenticateClient(clientID, clientSecret)
+	if err != nil {
+		if err == ErrInvalidClient {
+			return invalidClient(c, err)
+		}
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	c.Locals("oauth_client", client)
+	return c.Next()
+}
+
+// Introspect handles token introspection
+// @Summary Token introspection
+// @Description Describe an access or refresh token (RFC 7662). The caller must authenticate as a registered client
+// @Tags oauth
+// @Accept x-www-form-urlencoded
+// @Produce json
+// @Security BasicAuth
+// @Param token formData string true "Token to introspect"
+// @Param token_type_hint formData string false "access_token or refresh_token"
+// @Success 200 {object} oauth_dto.IntrospectionResponseDTO
+// @Failure 400 {object} oauth_dto.ErrorResponseDTO
+// @Failure 401 {object} oauth_dto.ErrorResponseDTO
+// @Router /oauth/introspect [post]
+func (h *handlers) Introspect(c *fiber.Ctx) error {
+	var introspectDto oauth_dto.IntrospectDTO
+
+	// Parse request body
+	if err := c.BodyParser(&introspectDto); err != nil {
+		return invalidRequest(c, err.Error())
+	}
+
+	// Validate request body
+	errs := h.validator.Validate(&introspectDto)
+	if errs != nil {
+		return invalidRequest(c, h.validator.ParseErrorToString(errs))
+	}
+
+	resp, err := h.service.Introspect(&introspectDto)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	client := c.Locals("oauth_client").(*models.OAuthClient)
+	h.logger.Debug("Token introspected",
+		zap.String("client_id", client.ClientID),
+		zap.Bool("active", resp.Active))
+
+	// Return response
+	c.Set(fiber.HeaderCacheControl, "no-store")
+	return c.JSON(resp)
+}
+
+// Revoke handles token revocation
+// @Summary Token revocation
+// @Description Revoke an access or refresh token (RFC 7009). Revoking a refresh token logs out its session. The caller must authenticate as a registered client and can only revoke the tokens issued to it, other tokens are ignored
+// @Tags oauth
+// @Accept x-www-form-urlencoded
+// @Produce json
+// @Security BasicAuth
+// @Param token formData string true "Token to revoke"
+// @Param token_type_hint formData string false "access_token or refresh_token"
+// @Success 200
+// @Failure 400 {object} oauth_dto.ErrorResponseDTO
+// @Failure 401 {object} oauth_dto.ErrorResponseDTO
+// @Router /oauth/revoke [post]
+func (h *handlers) Revoke(c *fiber.Ctx) error {
+	var revokeDto oauth_dto.RevokeDTO
+
+	// Parse request body
+	if err := c.BodyParser(&revokeDto); err != nil {
+		return invalidRequest(c, err.Error())
+	}
+
+	// Validate request body
+	errs := h.validator.Validate(&revokeDto)
+	if errs != nil {
+		return invalidRequest(c, h.validator.ParseErrorToString(errs))
+	}
+
+	client := c.Locals("oauth_client").(*models.OAuthClient)
+	if err := h.service.Revoke(&revokeDto, client); err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	h.logger.Info("Token revocation requested", zap.String("client_id", client.ClientID))
+
+	// Return response
+	return c.SendStatus(fiber.StatusOK)
+}
+
+// invalidRequest responds with an RFC 6749 invalid_request error
+func invalidRequest(c *fiber.Ctx, description string) error {
+	return c.Status(fiber.StatusBadRequest).JSON(&oauth_dto.ErrorResponseDTO{
+		Error:            oauth_dto.ErrorInvalidRequest,
+		ErrorDescription: description,
+	})
+}
+
+// invalidClient responds with an RFC 6749 invalid_client error
+func invalidClient(c *fiber.Ctx, err error) error {
+	c.Set(fiber.HeaderWWWAuthenticate, `Basic realm="oauth"`)
+	return c.Status(fiber.StatusUnauthorized).JSON(&oauth_dto.ErrorResponseDTO{
+		Error:            oauth_dto.ErrorInvalidClient,
+		ErrorDescription: err.Error(),
+	})
+}
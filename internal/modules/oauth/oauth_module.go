@@ -0,0 +1,15 @@
+package oauth
+
+import (
+	"go.uber.org/fx"
+)
+
+// Module exports the oauth module dependencies
+var Module = fx.Options(
+	fx.Provide(
+		NewRoutes,
+		NewHandlers,
+		NewService,
+	),
+	fx.Invoke(Register),
+)
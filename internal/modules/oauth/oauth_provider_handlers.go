@@ -0,0 +1,245 @@
+package oauth
+
+import (
+	"errors"
+	"fmt"
+	"modular-fx-fiber/internal/shared/dto/auth_dto"
+	"modular-fx-fiber/internal/shared/dto/oauth_dto"
+	"modular-fx-fiber/internal/shared/util"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// maxUserAgentLength matches the size of the user_agent column of refresh tokens
+const maxUserAgentLength = 512
+
+// Authorize handles an authorization request forwarded by the consent page
+// @Summary Authorization request
+// @Description Validate an authorization request (authorization code flow with optional PKCE) for the logged in user. When the user already consented to the requested scopes, redirect_to holds the client callback with a code, otherwise consent_required is set and the page must ask the user
+// @Tags oauth
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param response_type query string true "Must be code"
+// @Param client_id query string true "Client ID"
+// @Param redirect_uri query string true "Registered redirect URI"
+// @Param scope query string false "Space separated scopes"
+// @Param state query string false "Opaque value returned to the client"
+// @Param nonce query string false "Nonce copied to the id token"
+// @Param code_challenge query string false "PKCE code challenge, required for public clients"
+// @Param code_challenge_method query string false "Must be S256"
+// @Success 200 {object} oauth_dto.AuthorizationSuccessResponseDTO
+// @Failure 400 {object} oauth_dto.ErrorResponseDTO
+// @Failure 401 {object} map[string]string
+// @Router /oauth/authorize [get]
+func (h *handlers) Authorize(c *fiber.Ctx) error {
+	var authorizeDto oauth_dto.AuthorizeDTO
+
+	// Parse query parameters
+	if err := c.QueryParser(&authorizeDto); err != nil {
+		return invalidRequest(c, err.Error())
+	}
+
+	// Validate query parameters
+	errs := h.validator.Validate(&authorizeDto)
+	if errs != nil {
+		return invalidRequest(c, h.validator.ParseErrorToString(errs))
+	}
+
+	userId := c.Locals("user_id").(uint64)
+	authorization, err := h.service.Authorization(&authorizeDto, userId)
+	if err != nil {
+		return oauthError(c, err)
+	}
+
+	// Return response
+	return c.JSON(&oauth_dto.AuthorizationSuccessResponseDTO{
+		Success: true,
+		Data:    authorization,
+	})
+}
+
+// Consent handles the decision of the user on an authorization request
+// @Summary Authorization consent
+// @Description Approve or deny an authorization request. The response always holds redirect_to, the client callback with a code or an access_denied error
+// @Tags oauth
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body oauth_dto.ConsentDTO true "Authorization request and decision"
+// @Success 200 {object} oauth_dto.AuthorizationSuccessResponseDTO
+// @Failure 400 {object} oauth_dto.ErrorResponseDTO
+// @Failure 401 {object} map[string]string
+// @Router /oauth/authorize [post]
+func (h *handlers) Consent(c *fiber.Ctx) error {
+	var consentDto oauth_dto.ConsentDTO
+
+	// Parse request body
+	if err := c.BodyParser(&consentDto); err != nil {
+		return invalidRequest(c, err.Error())
+	}
+
+	// Validate request body
+	errs := h.validator.Validate(&consentDto)
+	if errs != nil {
+		return invalidRequest(c, h.validator.ParseErrorToString(errs))
+	}
+
+	userId := c.Locals("user_id").(uint64)
+	authorization, err := h.service.Consent(&consentDto, userId)
+	if err != nil {
+		return oauthError(c, err)
+	}
+
+	// Return response
+	return c.JSON(&oauth_dto.AuthorizationSuccessResponseDTO{
+		Success: true,
+		Data:    authorization,
+	})
+}
+
+// Token handles token requests
+// @Summary Token endpoint
+// @Description Issue tokens with the authorization_code, refresh_token or client_credentials grant (RFC 6749). Confidential clients authenticate with HTTP Basic or client_id and client_secret, public clients send client_id only. An id token is returned when the openid scope was granted
+// @Tags oauth
+// @Accept x-www-form-urlencoded
+// @Produce json
+// @Security BasicAuth
+// @Param grant_type formData string true "authorization_code, refresh_token or client_credentials"
+// @Param code formData string false "Authorization code"
+// @Param redirect_uri formData string false "Redirect URI of the authorization request"
+// @Param code_verifier formData string false "PKCE code verifier"
+// @Param refresh_token formData string false "Refresh token"
+// @Param scope formData string false "Scopes of a client_credentials token"
+// @Param client_id formData string false "Client ID, when not using HTTP Basic"
+// @Param client_secret formData string false "Client secret, when not using HTTP Basic"
+// @Success 200 {object} oauth_dto.TokenResponseDTO
+// @Failure 400 {object} oauth_dto.ErrorResponseDTO
+// @Failure 401 {object} oauth_dto.ErrorResponseDTO
+// @Router /oauth/token [post]
+func (h *handlers) Token(c *fiber.Ctx) error {
+	var tokenDto oauth_dto.TokenDTO
+
+	// Parse request body
+	if err := c.BodyParser(&tokenDto); err != nil {
+		return invalidRequest(c, err.Error())
+	}
+
+	// Validate request body
+	errs := h.validator.Validate(&tokenDto)
+	if errs != nil {
+		return invalidRequest(c, h.validator.ParseErrorToString(errs))
+	}
+
+	clientID, clientSecret, ok := util.BasicCredentials(c.Get(fiber.HeaderAuthorization))
+	if !ok {
+		clientID = c.FormValue("client_id")
+		clientSecret = c.FormValue("client_secret")
+	}
+
+	client, err := h.service.IdentifyClient(clientID, clientSecret)
+	if err != nil {
+		if err == ErrInvalidClient {
+			return invalidClient(c, err)
+		}
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	tokens, err := h.service.Token(&tokenDto, client, clientInfo(c, client.Name))
+	if err != nil {
+		return oauthError(c, err)
+	}
+
+	// Return response
+	c.Set(fiber.HeaderCacheControl, "no-store")
+	c.Set(fiber.HeaderPragma, "no-cache")
+	return c.JSON(tokens)
+}
+
+// UserInfo handles userinfo requests
+// @Summary UserInfo endpoint
+// @Description Return the claims about the user of an access token issued to an oauth client with the openid scope (OpenID Connect Core 5.3)
+// @Tags oauth
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{}
+// @Failure 401 {object} oauth_dto.ErrorResponseDTO
+// @Failure 403 {object} oauth_dto.ErrorResponseDTO
+// @Router /oauth/userinfo [get]
+func (h *handlers) UserInfo(c *fiber.Ctx) error {
+	accessToken, found := strings.CutPrefix(c.Get(fiber.HeaderAuthorization), "Bearer ")
+	if !found || accessToken == "" {
+		return bearerError(c, &Error{Code: oauth_dto.ErrorInvalidToken, Description: "missing bearer token"})
+	}
+
+	claims, err := h.service.UserInfo(accessToken)
+	if err != nil {
+		var oauthErr *Error
+		if errors.As(err, &oauthErr) {
+			return bearerError(c, oauthErr)
+		}
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	// Return response
+	c.Set(fiber.HeaderCacheControl, "no-store")
+	return c.JSON(claims)
+}
+
+// Discovery handles the OpenID Provider metadata request
+// @Summary OpenID Connect discovery
+// @Description OpenID Provider metadata (OpenID Connect Discovery 1.0)
+// @Tags oauth
+// @Produce json
+// @Success 200 {object} oauth_dto.DiscoveryDTO
+// @Router /.well-known/openid-configuration [get]
+func (h *handlers) Discovery(c *fiber.Ctx) error {
+	c.Set(fiber.HeaderCacheControl, "public, max-age=300")
+	return c.JSON(h.service.Discovery())
+}
+
+// oauthError responds with an OAuth error, invalid_client is reported as 401 and every other error as 400
+func oauthError(c *fiber.Ctx, err error) error {
+	var oauthErr *Error
+	if !errors.As(err, &oauthErr) {
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	status := fiber.StatusBadRequest
+	if oauthErr.Code == oauth_dto.ErrorInvalidClient {
+		status = fiber.StatusUnauthorized
+	}
+	return c.Status(status).JSON(&oauth_dto.ErrorResponseDTO{
+		Error:            oauthErr.Code,
+		ErrorDescription: oauthErr.Description,
+	})
+}
+
+// bearerError responds with an RFC 6750 error of a protected resource
+func bearerError(c *fiber.Ctx, oauthErr *Error) error {
+	status := fiber.StatusUnauthorized
+	if oauthErr.Code == oauth_dto.ErrorInsufficientScope {
+		status = fiber.StatusForbidden
+	}
+
+	c.Set(fiber.HeaderWWWAuthenticate, fmt.Sprintf(`Bearer error="%s", error_description="%s"`, oauthErr.Code, oauthErr.Description))
+	return c.Status(status).JSON(&oauth_dto.ErrorResponseDTO{
+		Error:            oauthErr.Code,
+		ErrorDescription: oauthErr.Description,
+	})
+}
+
+// clientInfo collects the device information of a token request, the session is labeled with the client name
+func clientInfo(c *fiber.Ctx, deviceLabel string) *auth_dto.ClientInfo {
+	userAgent := c.Get(fiber.HeaderUserAgent)
+	if len(userAgent) > maxUserAgentLength {
+		userAgent = userAgent[:maxUserAgentLength]
+	}
+
+	return &auth_dto.ClientInfo{
+		UserAgent:   userAgent,
+		IPAddress:   c.IP(),
+		DeviceLabel: deviceLabel,
+	}
+}
@@ -0,0 +1,125 @@
+package oauth
+
+import (
+	"modular-fx-fiber/internal/shared/dto/oauth_dto"
+	"modular-fx-fiber/internal/shared/middleware"
+	"modular-fx-fiber/internal/shared/models"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+	"go.uber.org/zap"
+)
+
+// UserInfo returns the claims about the user of an access token that its scopes allow (OpenID Connect Core 5.3)
+func (s *service) UserInfo(accessToken string) (map[string]any, error) {
+	claims, err := s.parseToken(accessToken, middleware.TokenTypeAccess)
+	if err != nil {
+		return nil, err
+	}
+	if claims == nil {
+		return nil, &Error{Code: oauth_dto.ErrorInvalidToken, Description: "invalid or expired access token"}
+	}
+
+	scopes := strings.Fields(claims.Scope)
+	if !slices.Contains(scopes, oauth_dto.ScopeOpenID) {
+		return nil, &Error{Code: oauth_dto.ErrorInsufficientScope, Description: "the openid scope is required"}
+	}
+
+	return s.userClaims(claims.UserID, scopes)
+}
+
+// userClaims builds the standard claims of a user for the granted scopes
+func (s *service) userClaims(userId uint64, scopes []string) (map[string]any, error) {
+	u, err := s.userService.GetMe(userId)
+	if err != nil {
+		s.logger.Error("Failed to fetch user for claims", zap.Uint64("user_id", userId), zap.Error(err))
+		return nil, err
+	}
+
+	claims := map[string]any{
+		"sub": strconv.FormatUint(u.ID, 10),
+	}
+
+	if slices.Contains(scopes, oauth_dto.ScopeEmail) {
+		claims["email"] = u.Email
+		claims["email_verified"] = u.EmailVerified
+	}
+
+	if slices.Contains(scopes, oauth_dto.ScopeProfile) {
+		claims["name"] = u.FullName
+		claims["given_name"] = u.FirstName
+		claims["family_name"] = u.LastName
+		claims["updated_at"] = u.UpdatedAt.Unix()
+		if u.AvatarURL != nil {
+			claims["picture"] = *u.AvatarURL
+		}
+		if u.DateOfBirth != nil {
+			claims["birthdate"] = u.DateOfBirth.Format("2006-01-02")
+		}
+		if u.Gender != nil {
+			switch *u.Gender {
+			case models.GENDER_MALE:
+				claims["gender"] = "male"
+			case models.GENDER_FEMALE:
+				claims["gender"] = "female"
+			}
+		}
+	}
+
+	if slices.Contains(scopes, oauth_dto.ScopePhone) && u.PhoneNumber != nil {
+		claims["phone_number"] = *u.PhoneNumber
+		claims["phone_number_verified"] = u.PhoneVerified
+	}
+
+	return claims, nil
+}
+
+// Discovery returns the OpenID Provider metadata of this API
+func (s *service) Discovery() *oauth_dto.DiscoveryDTO {
+	issuer := strings.TrimSuffix(s.config.OAuth.Issuer, "/")
+
+	// Id tokens are signed like every other token, HS256 when no asymmetric key is configured
+	algorithms := []string{}
+	for _, key := range s.keyManager.PublicKeySet().Keys {
+		if !slices.Contains(algorithms, key.Algorithm) {
+			algorithms = append(algorithms, key.Algorithm)
+		}
+	}
+	if len(algorithms) == 0 {
+		algorithms = append(algorithms, jwt.SigningMethodHS256.Alg())
+	}
+
+	return &oauth_dto.DiscoveryDTO{
+		Issuer:                issuer,
+		AuthorizationEndpoint: s.config.OAuth.AuthorizationEndpoint,
+		TokenEndpoint:         issuer + "/api/oauth/token",
+		UserInfoEndpoint:      issuer + "/api/oauth/userinfo",
+		JWKSURI:               issuer + "/.well-known/jwks.json",
+		IntrospectionEndpoint: issuer + "/api/oauth/introspect",
+		RevocationEndpoint:    issuer + "/api/oauth/revoke",
+		ScopesSupported: []string{
+			oauth_dto.ScopeOpenID,
+			oauth_dto.ScopeEmail,
+			oauth_dto.ScopeProfile,
+			oauth_dto.ScopePhone,
+		},
+		ResponseTypesSupported: []string{oauth_dto.ResponseTypeCode},
+		GrantTypesSupported: []string{
+			oauth_dto.GrantTypeAuthorizationCode,
+			oauth_dto.GrantTypeRefreshToken,
+			oauth_dto.GrantTypeClientCredentials,
+		},
+		SubjectTypesSupported:             []string{"public"},
+		IDTokenSigningAlgValuesSupported:  algorithms,
+		TokenEndpointAuthMethodsSupported: []string{"client_secret_basic", "client_secret_post", "none"},
+		CodeChallengeMethodsSupported:     []string{oauth_dto.CodeChallengeMethodS256},
+		ClaimsSupported: []string{
+			"sub", "iss", "aud", "exp", "iat", "nonce",
+			"email", "email_verified",
+			"name", "given_name", "family_name", "picture", "birthdate", "gender", "updated_at",
+			"phone_number", "phone_number_verified",
+		},
+	}
+}
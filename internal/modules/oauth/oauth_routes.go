@@ -0,0 +1,42 @@
+package oauth
+
+import (
+	"modular-fx-fiber/internal/core/server"
+	"modular-fx-fiber/internal/shared/middleware"
+)
+
+type (
+	Routes interface{}
+
+	routes struct {
+		handlers   Handlers
+		middleware middleware.Middleware
+	}
+)
+
+// NewRoutes creates new oauth routes
+func NewRoutes(h Handlers, m middleware.Middleware) Routes {
+	return &routes{
+		handlers:   h,
+		middleware: m,
+	}
+}
+
+// Register registers oauth routes
+func Register(s server.Server, h Handlers, m middleware.Middleware) {
+	a := s.GetApp()
+	a.Get("/.well-known/openid-configuration", h.Discovery)
+
+	group := a.Group("api/oauth")
+
+	// Client authenticated routes
+	group.Post("/introspect", h.AuthenticateClient, h.Introspect)
+	group.Post("/revoke", h.AuthenticateClient, h.Revoke)
+	// Token and userinfo authenticate the client or token themselves
+	group.Post("/token", h.Token)
+	group.Get("/userinfo", h.UserInfo)
+	group.Post("/userinfo", h.UserInfo)
+	// Routes used by the consent page on behalf of the logged in user
+	group.Get("/authorize", m.JWT(), h.Authorize)
+	group.Post("/authorize", m.JWT(), h.Consent)
+}
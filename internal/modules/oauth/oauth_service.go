@@ -0,0 +1,366 @@
+package oauth
+
+import (
+	"crypto/subtle"
+	"errors"
+	"modular-fx-fiber/internal/core/config"
+	"modular-fx-fiber/internal/modules/auth"
+	"modular-fx-fiber/internal/modules/user"
+	"modular-fx-fiber/internal/shared/dto/auth_dto"
+	"modular-fx-fiber/internal/shared/dto/oauth_dto"
+	"modular-fx-fiber/internal/shared/jwks"
+	"modular-fx-fiber/internal/shared/logger"
+	"modular-fx-fiber/internal/shared/middleware"
+	"modular-fx-fiber/internal/shared/models"
+	"modular-fx-fiber/internal/shared/repositories"
+	"modular-fx-fiber/internal/shared/revocation"
+	"modular-fx-fiber/internal/shared/util"
+	"strconv"
+
+	"go.uber.org/zap"
+)
+
+var (
+	ErrInvalidClient = errors.New("client authentication failed")
+)
+
+type (
+	Service interface {
+		AuthenticateClient(clientID string, clientSecret string) (*models.OAuthClient, error)
+		IdentifyClient(clientID string, clientSecret string) (*models.OAuthClient, error)
+		Introspect(dto *oauth_dto.IntrospectDTO) (*oauth_dto.IntrospectionResponseDTO, error)
+		Revoke(dto *oauth_dto.RevokeDTO, client *models.OAuthClient) error
+		Authorization(dto *oauth_dto.AuthorizeDTO, userId uint64) (*oauth_dto.AuthorizationDTO, error)
+		Consent(dto *oauth_dto.ConsentDTO, userId uint64) (*oauth_dto.AuthorizationDTO, error)
+		Token(dto *oauth_dto.TokenDTO, client *models.OAuthClient, clientInfo *auth_dto.ClientInfo) (*oauth_dto.TokenResponseDTO, error)
+		UserInfo(accessToken string) (map[string]any, error)
+		Discovery() *oauth_dto.DiscoveryDTO
+	}
+
+	service struct {
+		config     *config.Config
+		logger     *logger.ZapLogger
+		keyManager jwks.KeyManager
+		middleware middleware.Middleware
+		revocation revocation.Store
+
+		authService auth.Service
+		userService user.Service
+
+		oauthClientRepo       repositories.OAuthClientRepository
+		refreshTokenRepo      repositories.RefreshTokenRepository
+		authorizationCodeRepo repositories.OAuthAuthorizationCodeRepository
+		consentRepo           repositories.OAuthConsentRepository
+	}
+
+	// Error is an OAuth error (RFC 6749 section 5.2) returned to the client as is
+	Error struct {
+		Code        string
+		Description string
+	}
+)
+
+// NewService creates a new oauth service
+func NewService(
+	config *config.Config,
+	logger *logger.ZapLogger,
+	keyManager jwks.KeyManager,
+	middleware middleware.Middleware,
+	revocationStore revocation.Store,
+	authService auth.Service,
+	userService user.Service,
+	oauthClientRepo repositories.OAuthClientRepository,
+	refreshTokenRepo repositories.RefreshTokenRepository,
+	authorizationCodeRepo repositories.OAuthAuthorizationCodeRepository,
+	consentRepo repositories.OAuthConsentRepository,
+) Service {
+	return &service{
+		config:                config,
+		logger:                logger,
+		keyManager:            keyManager,
+		middleware:            middleware,
+		revocation:            revocationStore,
+		authService:           authService,
+		userService:           userService,
+		oauthClientRepo:       oauthClientRepo,
+		refreshTokenRepo:      refreshTokenRepo,
+		authorizationCodeRepo: authorizationCodeRepo,
+		consentRepo:           consentRepo,
+	}
+}
+
+func (e *Error) Error() string {
+	return e.Description
+}
+
+// AuthenticateClient checks the credentials of a confidential client
+func (s *service) AuthenticateClient(clientID string, clientSecret string) (*models.OAuthClient, error) {
+	if clientID == "" || clientSecret == "" {
+		return nil, ErrInvalidClient
+	}
+
+	client, err := s.oauthClientRepo.GetByClientID(clientID)
+	if err != nil {
+		s.logger.Error("Failed to fetch oauth client", zap.String("client_id", clientID), zap.Error(err))
+		return nil, err
+	}
+	if client == nil {
+		s.logger.Info("Authentication attempt with unknown client", zap.String("client_id", clientID))
+		return nil, ErrInvalidClient
+	}
+
+	// Public clients have no secret and cannot authenticate
+	secretHash := util.HashToken(clientSecret)
+	if subtle.ConstantTimeCompare([]byte(secretHash), []byte(client.ClientSecretHash)) != 1 {
+		s.logger.Info("Failed client secret verification", zap.String("client_id", clientID))
+		return nil, ErrInvalidClient
+	}
+
+	return client, nil
+}
+
+// IdentifyClient identifies the client of a token request. Confidential clients must
+// authenticate, public clients are identified by their client ID alone.
+func (s *service) IdentifyClient(clientID string, clientSecret string) (*models.OAuthClient, error) {
+	if clientSecret != "" {
+		return s.AuthenticateClient(clientID, clientSecret)
+	}
+	if clientID == "" {
+		return nil, ErrInvalidClient
+	}
+
+	client, err := s.oauthClientRepo.GetByClientID(clientID)
+	if err != nil {
+		s.logger.Error("Failed to fetch oauth client", zap.String("client_id", clientID), zap.Error(err))
+		return nil, err
+	}
+	if client == nil || !client.Public {
+		s.logger.Info("Token request without client authentication", zap.String("client_id", clientID))
+		return nil, ErrInvalidClient
+	}
+
+	return client, nil
+}
+
+// Introspect describes an access or refresh token.
+// Tokens that are invalid, expired or revoked are reported as inactive.
+func (s *service) Introspect(dto *oauth_dto.IntrospectDTO) (*oauth_dto.IntrospectionResponseDTO, error) {
+	// The hint only decides which token type is tried first
+	if dto.TokenTypeHint == oauth_dto.TokenTypeHintRefreshToken {
+		resp, err := s.introspectRefreshToken(dto.Token)
+		if err != nil || resp.Active {
+			return resp, err
+		}
+		return s.introspectAccessToken(dto.Token)
+	}
+
+	resp, err := s.introspectAccessToken(dto.Token)
+	if err != nil || resp.Active {
+		return resp, err
+	}
+	resp, err = s.introspectRefreshToken(dto.Token)
+	if err != nil || resp.Active {
+		return resp, err
+	}
+	return s.introspectClientToken(dto.Token)
+}
+
+// introspectAccessToken describes an access token, including its revocation state
+func (s *service) introspectAccessToken(token string) (*oauth_dto.IntrospectionResponseDTO, error) {
+	claims, err := s.parseToken(token, middleware.TokenTypeAccess)
+	if err != nil || claims == nil {
+		return &oauth_dto.IntrospectionResponseDTO{Active: false}, err
+	}
+
+	if claims.SubjectType == middleware.SubjectTypeServiceAccount {
+		return &oauth_dto.IntrospectionResponseDTO{
+			Active:      true,
+			TokenType:   oauth_dto.TokenTypeHintAccessToken,
+			Subject:     strconv.FormatUint(claims.ServiceAccountID, 10),
+			SubjectType: claims.SubjectType,
+			ExpiresAt:   claims.ExpiresAt.Unix(),
+			IssuedAt:    claims.IssuedAt.Unix(),
+			TokenID:     claims.ID,
+		}, nil
+	}
+
+	return &oauth_dto.IntrospectionResponseDTO{
+		Active:    true,
+		TokenType: oauth_dto.TokenTypeHintAccessToken,
+		Subject:   strconv.FormatUint(claims.UserID, 10),
+		Username:  claims.Email,
+		ExpiresAt: claims.ExpiresAt.Unix(),
+		IssuedAt:  claims.IssuedAt.Unix(),
+		TokenID:   claims.ID,
+		SessionID: claims.SessionID,
+		ClientID:  claims.ClientID,
+		Scope:     claims.Scope,
+	}, nil
+}
+
+// introspectClientToken describes an access token issued by the client_credentials grant
+func (s *service) introspectClientToken(token string) (*oauth_dto.IntrospectionResponseDTO, error) {
+	claims, err := s.parseToken(token, middleware.TokenTypeClient)
+	if err != nil || claims == nil {
+		return &oauth_dto.IntrospectionResponseDTO{Active: false}, err
+	}
+
+	return &oauth_dto.IntrospectionResponseDTO{
+		Active:    true,
+		TokenType: oauth_dto.TokenTypeHintAccessToken,
+		Subject:   claims.ClientID,
+		ExpiresAt: claims.ExpiresAt.Unix(),
+		IssuedAt:  claims.IssuedAt.Unix(),
+		TokenID:   claims.ID,
+		ClientID:  claims.ClientID,
+		Scope:     claims.Scope,
+	}, nil
+}
+
+// introspectRefreshToken describes a refresh token, which is only active while it is stored and not rotated
+func (s *service) introspectRefreshToken(token string) (*oauth_dto.IntrospectionResponseDTO, error) {
+	claims, savedToken, err := s.lookupRefreshToken(token)
+	if err != nil || savedToken == nil || savedToken.RevokedAt != nil {
+		return &oauth_dto.IntrospectionResponseDTO{Active: false}, err
+	}
+
+	return &oauth_dto.IntrospectionResponseDTO{
+		Active:    true,
+		TokenType: oauth_dto.TokenTypeHintRefreshToken,
+		Subject:   strconv.FormatUint(savedToken.UserID, 10),
+		Username:  claims.Email,
+		ExpiresAt: savedToken.ExpiresAt.Unix(),
+		IssuedAt:  savedToken.CreatedAt.Unix(),
+		TokenID:   claims.ID,
+		SessionID: savedToken.FamilyID,
+		ClientID:  stringValue(savedToken.ClientID),
+		Scope:     stringValue(savedToken.Scope),
+	}, nil
+}
+
+// Revoke revokes an access or refresh token issued to the calling client.
+// Revoking a refresh token logs out its whole session. Unknown tokens and tokens of other clients are ignored
+// as required by RFC 7009, client_credentials and service account tokens are short-lived and cannot be revoked.
+func (s *service) Revoke(dto *oauth_dto.RevokeDTO, client *models.OAuthClient) error {
+	if dto.TokenTypeHint == oauth_dto.TokenTypeHintRefreshToken {
+		found, err := s.revokeRefreshToken(dto.Token, client)
+		if err != nil || found {
+			return err
+		}
+		_, err = s.revokeAccessToken(dto.Token, client)
+		return err
+	}
+
+	found, err := s.revokeAccessToken(dto.Token, client)
+	if err != nil || found {
+		return err
+	}
+	_, err = s.revokeRefreshToken(dto.Token, client)
+	return err
+}
+
+// revokeAccessToken adds an access token to the revocation store until it expires.
+// It reports whether the token is a valid access token, revoked or not because it belongs to another client.
+func (s *service) revokeAccessToken(token string, client *models.OAuthClient) (bool, error) {
+	claims, err := s.parseToken(token, middleware.TokenTypeAccess)
+	if err != nil || claims == nil || claims.UserID == 0 {
+		return false, err
+	}
+
+	// Clients may only revoke the tokens issued to them
+	if claims.ClientID != client.ClientID {
+		s.logger.Warn("Client tried to revoke an access token of another client",
+			zap.String("client_id", client.ClientID),
+			zap.Uint64("user_id", claims.UserID))
+		return true, nil
+	}
+
+	if err := s.revocation.RevokeToken(claims.ID, claims.UserID, claims.ExpiresAt.Time); err != nil {
+		s.logger.Error("Failed to revoke access token",
+			zap.Uint64("user_id", claims.UserID),
+			zap.String("jti", claims.ID),
+			zap.Error(err))
+		return false, err
+	}
+
+	s.logger.Info("Access token revoked", zap.Uint64("user_id", claims.UserID), zap.String("jti", claims.ID))
+	return true, nil
+}
+
+// revokeRefreshToken deletes the token family (session) of a refresh token.
+// It reports whether the token is a stored refresh token, revoked or not because it belongs to another client.
+func (s *service) revokeRefreshToken(token string, client *models.OAuthClient) (bool, error) {
+	_, savedToken, err := s.lookupRefreshToken(token)
+	if err != nil || savedToken == nil {
+		return false, err
+	}
+
+	// Clients may only revoke the tokens issued to them
+	if stringValue(savedToken.ClientID) != client.ClientID {
+		s.logger.Warn("Client tried to revoke a refresh token of another client",
+			zap.String("client_id", client.ClientID),
+			zap.Uint64("user_id", savedToken.UserID))
+		return true, nil
+	}
+
+	if _, err := s.refreshTokenRepo.DeleteUserRefreshTokenFamily(savedToken.UserID, savedToken.FamilyID); err != nil {
+		s.logger.Error("Failed to delete refresh token family",
+			zap.Uint64("user_id", savedToken.UserID),
+			zap.String("family_id", savedToken.FamilyID),
+			zap.Error(err))
+		return false, err
+	}
+
+	s.logger.Info("Refresh token revoked",
+		zap.Uint64("user_id", savedToken.UserID),
+		zap.String("family_id", savedToken.FamilyID))
+	return true, nil
+}
+
+// lookupRefreshToken parses a refresh token and fetches its stored row by hash
+func (s *service) lookupRefreshToken(token string) (*middleware.UserClaims, *models.RefreshToken, error) {
+	claims, err := s.parseToken(token, middleware.TokenTypeRefresh)
+	if err != nil || claims == nil {
+		return nil, nil, err
+	}
+
+	savedToken, err := s.refreshTokenRepo.GetRefreshToken(util.HashToken(token))
+	if err != nil {
+		s.logger.Error("Failed to retrieve refresh token", zap.Error(err))
+		return nil, nil, err
+	}
+	if savedToken == nil || savedToken.UserID != claims.UserID {
+		return nil, nil, nil
+	}
+
+	return claims, savedToken, nil
+}
+
+// parseToken parses a token of the given type.
+// Tokens that are rejected yield nil claims, only failures to check them are returned as errors.
+func (s *service) parseToken(token string, tokenType string) (*middleware.UserClaims, error) {
+	claims, err := s.middleware.ParseToken(token, tokenType)
+	if err != nil {
+		if middleware.IsTokenRejected(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return claims, nil
+}
+
+// stringValue dereferences an optional string column
+func stringValue(value *string) string {
+	if value == nil {
+		return ""
+	}
+	return *value
+}
+
+// nonEmpty returns a pointer to the value, or nil for an empty string
+func nonEmpty(value string) *string {
+	if value == "" {
+		return nil
+	}
+	return &value
+}
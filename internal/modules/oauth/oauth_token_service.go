@@ -0,0 +1,212 @@
+package oauth
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"modular-fx-fiber/internal/modules/auth"
+	"modular-fx-fiber/internal/shared/dto/auth_dto"
+	"modular-fx-fiber/internal/shared/dto/oauth_dto"
+	"modular-fx-fiber/internal/shared/middleware"
+	"modular-fx-fiber/internal/shared/models"
+	"modular-fx-fiber/internal/shared/util"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// Token issues tokens for one of the supported grants.
+// Requests the client made wrong fail with an *Error to be returned to the client.
+func (s *service) Token(dto *oauth_dto.TokenDTO, client *models.OAuthClient, clientInfo *auth_dto.ClientInfo) (*oauth_dto.TokenResponseDTO, error) {
+	switch dto.GrantType {
+	case oauth_dto.GrantTypeAuthorizationCode:
+		return s.exchangeAuthorizationCode(dto, client, clientInfo)
+	case oauth_dto.GrantTypeRefreshToken:
+		return s.refreshClientToken(dto, client, clientInfo)
+	case oauth_dto.GrantTypeClientCredentials:
+		return s.issueClientToken(dto, client)
+	default:
+		return nil, &Error{Code: oauth_dto.ErrorUnsupportedGrantType, Description: "grant_type is not supported"}
+	}
+}
+
+// exchangeAuthorizationCode redeems an authorization code for a new session of its user
+func (s *service) exchangeAuthorizationCode(dto *oauth_dto.TokenDTO, client *models.OAuthClient, clientInfo *auth_dto.ClientInfo) (*oauth_dto.TokenResponseDTO, error) {
+	if dto.Code == "" || dto.RedirectURI == "" {
+		return nil, &Error{Code: oauth_dto.ErrorInvalidRequest, Description: "code and redirect_uri are required"}
+	}
+
+	code, err := s.authorizationCodeRepo.ConsumeAuthorizationCode(util.HashToken(dto.Code))
+	if err != nil {
+		s.logger.Error("Failed to consume authorization code", zap.String("client_id", client.ClientID), zap.Error(err))
+		return nil, err
+	}
+	if code == nil {
+		s.logger.Warn("Invalid, expired or reused authorization code", zap.String("client_id", client.ClientID))
+		return nil, &Error{Code: oauth_dto.ErrorInvalidGrant, Description: "invalid or expired authorization code"}
+	}
+
+	// The code must be redeemed by the client it was issued to, with the same redirect URI
+	if code.ClientID != client.ClientID || code.RedirectURI != dto.RedirectURI {
+		s.logger.Warn("Authorization code redeemed by another client or redirect uri",
+			zap.String("client_id", client.ClientID),
+			zap.String("code_client_id", code.ClientID))
+		return nil, &Error{Code: oauth_dto.ErrorInvalidGrant, Description: "invalid or expired authorization code"}
+	}
+
+	if code.CodeChallenge != nil && !verifyCodeChallenge(*code.CodeChallenge, dto.CodeVerifier) {
+		s.logger.Warn("Invalid PKCE code verifier", zap.String("client_id", client.ClientID))
+		return nil, &Error{Code: oauth_dto.ErrorInvalidGrant, Description: "invalid code_verifier"}
+	}
+
+	clientInfo.OAuthClientID = client.ClientID
+	clientInfo.Scope = code.Scope
+	tokens, err := s.authService.IssueTokens(code.UserID, clientInfo)
+	if err != nil {
+		if errors.Is(err, auth.ErrUserNotFound) || errors.Is(err, auth.ErrUserNotActive) {
+			return nil, &Error{Code: oauth_dto.ErrorInvalidGrant, Description: err.Error()}
+		}
+		return nil, err
+	}
+
+	s.logger.Info("Authorization code exchanged",
+		zap.Uint64("user_id", code.UserID),
+		zap.String("client_id", client.ClientID))
+	return s.tokenResponse(tokens, client, code.UserID, code.Scope, stringValue(code.Nonce))
+}
+
+// refreshClientToken rotates a refresh token previously issued to the client
+func (s *service) refreshClientToken(dto *oauth_dto.TokenDTO, client *models.OAuthClient, clientInfo *auth_dto.ClientInfo) (*oauth_dto.TokenResponseDTO, error) {
+	if dto.RefreshToken == "" {
+		return nil, &Error{Code: oauth_dto.ErrorInvalidRequest, Description: "refresh_token is required"}
+	}
+
+	savedToken, err := s.refreshTokenRepo.GetRefreshToken(util.HashToken(dto.RefreshToken))
+	if err != nil {
+		s.logger.Error("Failed to retrieve refresh token", zap.Error(err))
+		return nil, err
+	}
+	if savedToken == nil || stringValue(savedToken.ClientID) != client.ClientID {
+		s.logger.Warn("Refresh token not issued to the client", zap.String("client_id", client.ClientID))
+		return nil, &Error{Code: oauth_dto.ErrorInvalidGrant, Description: auth.ErrInvalidRefreshToken.Error()}
+	}
+
+	// Rotation, reuse detection and the client binding are handled by the auth service
+	clientInfo.OAuthClientID = client.ClientID
+	tokens, err := s.authService.RefreshToken(&auth_dto.RefreshTokenDTO{RefreshToken: dto.RefreshToken}, clientInfo)
+	if err != nil {
+		if errors.Is(err, auth.ErrInvalidRefreshToken) || errors.Is(err, auth.ErrUserNotActive) {
+			return nil, &Error{Code: oauth_dto.ErrorInvalidGrant, Description: err.Error()}
+		}
+		return nil, err
+	}
+
+	return s.tokenResponse(tokens, client, savedToken.UserID, stringValue(savedToken.Scope), "")
+}
+
+// issueClientToken issues an access token to a confidential client acting on its own behalf
+func (s *service) issueClientToken(dto *oauth_dto.TokenDTO, client *models.OAuthClient) (*oauth_dto.TokenResponseDTO, error) {
+	if client.Public {
+		return nil, &Error{Code: oauth_dto.ErrorUnauthorizedClient, Description: "public clients cannot use the client_credentials grant"}
+	}
+
+	// OpenID Connect scopes describe a user, a client token has none
+	scopes := strings.Fields(dto.Scope)
+	if !client.AllowsScopes(scopes) || slices.Contains(scopes, oauth_dto.ScopeOpenID) {
+		return nil, &Error{Code: oauth_dto.ErrorInvalidScope, Description: "scope is not allowed for the client"}
+	}
+	scope := strings.Join(scopes, " ")
+
+	expiry := time.Duration(s.config.OAuth.ClientTokenExpiryMinutes) * time.Minute
+	now := time.Now()
+	claims := jwt.MapClaims{}
+	claims["sub"] = client.ClientID
+	claims["client_id"] = client.ClientID
+	claims["scope"] = scope
+	claims["typ"] = middleware.TokenTypeClient
+	claims["jti"] = uuid.NewString()
+	claims["iat"] = now.Unix()
+	claims["exp"] = now.Add(expiry).Unix()
+
+	accessToken, err := s.keyManager.Sign(claims)
+	if err != nil {
+		s.logger.Error("Failed to sign client token", zap.String("client_id", client.ClientID), zap.Error(err))
+		return nil, err
+	}
+
+	s.logger.Info("Client token issued", zap.String("client_id", client.ClientID), zap.String("scope", scope))
+	return &oauth_dto.TokenResponseDTO{
+		AccessToken: accessToken,
+		TokenType:   "Bearer",
+		ExpiresIn:   uint(expiry.Seconds()),
+		Scope:       scope,
+	}, nil
+}
+
+// tokenResponse converts the tokens of a session to a token response,
+// adding an id token when the openid scope was granted
+func (s *service) tokenResponse(tokens *auth_dto.TokenResponseDTO, client *models.OAuthClient, userId uint64, scope string, nonce string) (*oauth_dto.TokenResponseDTO, error) {
+	resp := &oauth_dto.TokenResponseDTO{
+		AccessToken:  tokens.AccessToken,
+		TokenType:    tokens.TokenType,
+		ExpiresIn:    tokens.ExpiresIn,
+		RefreshToken: tokens.RefreshToken,
+		Scope:        scope,
+	}
+
+	scopes := strings.Fields(scope)
+	if !slices.Contains(scopes, oauth_dto.ScopeOpenID) {
+		return resp, nil
+	}
+
+	idToken, err := s.generateIDToken(client, userId, scopes, nonce)
+	if err != nil {
+		s.logger.Error("Failed to generate id token",
+			zap.Uint64("user_id", userId),
+			zap.String("client_id", client.ClientID),
+			zap.Error(err))
+		return nil, err
+	}
+	resp.IDToken = idToken
+
+	return resp, nil
+}
+
+// generateIDToken signs an OpenID Connect id token for the client with the claims of the granted scopes
+func (s *service) generateIDToken(client *models.OAuthClient, userId uint64, scopes []string, nonce string) (string, error) {
+	userClaims, err := s.userClaims(userId, scopes)
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	claims := jwt.MapClaims(userClaims)
+	claims["iss"] = strings.TrimSuffix(s.config.OAuth.Issuer, "/")
+	claims["sub"] = strconv.FormatUint(userId, 10)
+	claims["aud"] = client.ClientID
+	claims["typ"] = middleware.TokenTypeID
+	claims["iat"] = now.Unix()
+	claims["exp"] = now.Add(time.Duration(s.config.JWT.AccessExpiryMinutes) * time.Minute).Unix()
+	if nonce != "" {
+		claims["nonce"] = nonce
+	}
+
+	return s.keyManager.Sign(claims)
+}
+
+// verifyCodeChallenge checks a PKCE code verifier against the S256 challenge of the code
+func verifyCodeChallenge(challenge string, verifier string) bool {
+	if verifier == "" {
+		return false
+	}
+
+	sum := sha256.Sum256([]byte(verifier))
+	expected := base64.RawURLEncoding.EncodeToString(sum[:])
+	return subtle.ConstantTimeCompare([]byte(expected), []byte(challenge)) == 1
+}
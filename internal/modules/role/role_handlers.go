@@ -0,0 +1,436 @@
+package role
+
+import (
+	"modular-fx-fiber/internal/shared/dto/role_dto"
+	"modular-fx-fiber/internal/shared/logger"
+	"modular-fx-fiber/internal/shared/validator"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type (
+	// Handlers defines the HTTP handlers for role, permission and assignment management
+	Handlers interface {
+		CreateRole(c *fiber.Ctx) error
+		ListRoles(c *fiber.Ctx) error
+		GetRole(c *fiber.Ctx) error
+		UpdateRole(c *fiber.Ctx) error
+		DeleteRole(c *fiber.Ctx) error
+		AssignPermissions(c *fiber.Ctx) error
+		RemovePermissions(c *fiber.Ctx) error
+
+		CreatePermission(c *fiber.Ctx) error
+		ListPermissions(c *fiber.Ctx) error
+		GetPermission(c *fiber.Ctx) error
+		UpdatePermission(c *fiber.Ctx) error
+		DeletePermission(c *fiber.Ctx) error
+
+		GetUserRoles(c *fiber.Ctx) error
+		AssignUserRoles(c *fiber.Ctx) error
+		RemoveUserRoles(c *fiber.Ctx) error
+	}
+
+	handlers struct {
+		service   Service
+		validator *validator.Validator
+		logger    *logger.ZapLogger
+	}
+)
+
+// NewHandlers creates a new role handlers instance
+func NewHandlers(l *logger.ZapLogger, v *validator.Validator, s Service) Handlers {
+	return &handlers{
+		service:   s,
+		validator: v,
+		logger:    l,
+	}
+}
+
+// CreateRole handles role creation
+// @Summary Create role
+// @Description Create a role without permissions of its own, grant them with POST /roles/{id}/permissions. A role with parent_id inherits the permissions of the parent and its ancestors. Requires the roles:create permission
+// @Tags roles
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body role_dto.CreateRoleDTO true "Role name, description and parent role"
+// @Success 201 {object} role_dto.RoleSuccessResponseDTO
+// @Router /roles [post]
+func (h *handlers) CreateRole(c *fiber.Ctx) error {
+	var createRoleDto role_dto.CreateRoleDTO
+
+	// Parse request body
+	if err := c.BodyParser(&createRoleDto); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	// Validate request body
+	errs := h.validator.Validate(&createRoleDto)
+	if errs != nil {
+		err := h.validator.ParseErrorToString(errs)
+		return fiber.NewError(fiber.StatusBadRequest, err)
+	}
+
+	role, err := h.service.CreateRole(&createRoleDto)
+	if err != nil {
+		return roleError(err)
+	}
+
+	// Return response
+	return c.Status(fiber.StatusCreated).JSON(&role_dto.RoleSuccessResponseDTO{
+		Success: true,
+		Data:    role,
+	})
+}
+
+// ListRoles handles listing roles
+// @Summary List roles
+// @Description List roles with their own and inherited permissions with pagination. Requires the roles:read permission
+// @Tags roles
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param page query int false "Page number"
+// @Param page_size query int false "Page size"
+// @Success 200 {object} role_dto.ListRolesSuccessResponseDTO
+// @Router /roles [get]
+func (h *handlers) ListRoles(c *fiber.Ctx) error {
+	page, err := strconv.Atoi(c.Query("page", "1"))
+	if err != nil || page < 1 {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid page")
+	}
+
+	pageSize, err := strconv.Atoi(c.Query("page_size", "10"))
+	if err != nil || pageSize < 1 {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid page size")
+	}
+
+	// Limit page size to 100
+	if pageSize > 100 {
+		pageSize = 100
+	}
+
+	roles, total, err := h.service.ListRoles(page, pageSize)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	// Return response
+	return c.JSON(&role_dto.ListRolesSuccessResponseDTO{
+		Success: true,
+		Data: &role_dto.PaginatedRolesDTO{
+			Items:      roles,
+			TotalCount: total,
+			Page:       page,
+			PageSize:   pageSize,
+			TotalPages: (total + int64(pageSize) - 1) / int64(pageSize),
+		},
+	})
+}
+
+// GetRole handles getting a role
+// @Summary Get role
+// @Description Get a role with its own and inherited permissions, effective_permissions tells which role granted each one. Requires the roles:read permission
+// @Tags roles
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Role ID"
+// @Success 200 {object} role_dto.RoleSuccessResponseDTO
+// @Router /roles/{id} [get]
+func (h *handlers) GetRole(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid role id")
+	}
+
+	role, err := h.service.GetRole(id)
+	if err != nil {
+		return roleError(err)
+	}
+
+	// Return response
+	return c.JSON(&role_dto.RoleSuccessResponseDTO{
+		Success: true,
+		Data:    role,
+	})
+}
+
+// UpdateRole handles updating a role
+// @Summary Update role
+// @Description Rename a role, change its description or the parent it inherits from. A parent that is the role itself or inherits from it is refused. Requires the roles:update permission
+// @Tags roles
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Role ID"
+// @Param request body role_dto.UpdateRoleDTO true "Role name, description and parent role"
+// @Success 200 {object} role_dto.RoleSuccessResponseDTO
+// @Router /roles/{id} [put]
+func (h *handlers) UpdateRole(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid role id")
+	}
+
+	var updateRoleDto role_dto.UpdateRoleDTO
+
+	// Parse request body
+	if err := c.BodyParser(&updateRoleDto); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	// Validate request body
+	errs := h.validator.Validate(&updateRoleDto)
+	if errs != nil {
+		err := h.validator.ParseErrorToString(errs)
+		return fiber.NewError(fiber.StatusBadRequest, err)
+	}
+
+	role, err := h.service.UpdateRole(id, &updateRoleDto)
+	if err != nil {
+		return roleError(err)
+	}
+
+	// Return response
+	return c.JSON(&role_dto.RoleSuccessResponseDTO{
+		Success: true,
+		Data:    role,
+	})
+}
+
+// DeleteRole handles deleting a role
+// @Summary Delete role
+// @Description Delete a role, the users and service accounts holding it lose its permissions. Requires the roles:delete permission
+// @Tags roles
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Role ID"
+// @Success 200 {object} role_dto.DeleteRoleSuccessResponseDTO
+// @Router /roles/{id} [delete]
+func (h *handlers) DeleteRole(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid role id")
+	}
+
+	if err := h.service.DeleteRole(id); err != nil {
+		return roleError(err)
+	}
+
+	// Return response
+	return c.JSON(&role_dto.DeleteRoleSuccessResponseDTO{
+		Success: true,
+	})
+}
+
+// AssignPermissions handles granting permissions to a role
+// @Summary Assign role permissions
+// @Description Grant permissions to a role, permissions it already has are kept. Requires the roles:update permission
+// @Tags roles
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Role ID"
+// @Param request body role_dto.RolePermissionsDTO true "Permission IDs"
+// @Success 200 {object} role_dto.RoleSuccessResponseDTO
+// @Router /roles/{id}/permissions [post]
+func (h *handlers) AssignPermissions(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid role id")
+	}
+
+	var permissionsDto role_dto.RolePermissionsDTO
+
+	// Parse request body
+	if err := c.BodyParser(&permissionsDto); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	// Validate request body
+	errs := h.validator.Validate(&permissionsDto)
+	if errs != nil {
+		err := h.validator.ParseErrorToString(errs)
+		return fiber.NewError(fiber.StatusBadRequest, err)
+	}
+
+	role, err := h.service.AssignPermissions(id, &permissionsDto)
+	if err != nil {
+		return roleError(err)
+	}
+
+	// Return response
+	return c.JSON(&role_dto.RoleSuccessResponseDTO{
+		Success: true,
+		Data:    role,
+	})
+}
+
+// RemovePermissions handles taking permissions away from a role
+// @Summary Unassign role permissions
+// @Description Take permissions away from a role, permissions it does not have are ignored. Requires the roles:update permission
+// @Tags roles
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Role ID"
+// @Param request body role_dto.RolePermissionsDTO true "Permission IDs"
+// @Success 200 {object} role_dto.RoleSuccessResponseDTO
+// @Router /roles/{id}/permissions [delete]
+func (h *handlers) RemovePermissions(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid role id")
+	}
+
+	var permissionsDto role_dto.RolePermissionsDTO
+
+	// Parse request body
+	if err := c.BodyParser(&permissionsDto); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	// Validate request body
+	errs := h.validator.Validate(&permissionsDto)
+	if errs != nil {
+		err := h.validator.ParseErrorToString(errs)
+		return fiber.NewError(fiber.StatusBadRequest, err)
+	}
+
+	role, err := h.service.RemovePermissions(id, &permissionsDto)
+	if err != nil {
+		return roleError(err)
+	}
+
+	// Return response
+	return c.JSON(&role_dto.RoleSuccessResponseDTO{
+		Success: true,
+		Data:    role,
+	})
+}
+
+// GetUserRoles handles listing the roles of a user
+// @Summary Get user roles
+// @Description Get the roles of a user and the permissions the user holds through them, inherited ones included. Requires the roles:read permission
+// @Tags roles
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "User ID"
+// @Success 200 {object} role_dto.UserRolesSuccessResponseDTO
+// @Router /users/{id}/roles [get]
+func (h *handlers) GetUserRoles(c *fiber.Ctx) error {
+	userId, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid user id")
+	}
+
+	roles, err := h.service.GetUserRoles(userId)
+	if err != nil {
+		return roleError(err)
+	}
+
+	// Return response
+	return c.JSON(&role_dto.UserRolesSuccessResponseDTO{
+		Success: true,
+		Data:    roles,
+	})
+}
+
+// AssignUserRoles handles giving roles to a user
+// @Summary Assign user roles
+// @Description Give roles to a user, roles the user already has are kept. Requires the roles:update permission
+// @Tags roles
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "User ID"
+// @Param request body role_dto.UpdateUserRolesDTO true "Role IDs"
+// @Success 200 {object} role_dto.UserRolesSuccessResponseDTO
+// @Router /users/{id}/roles [post]
+func (h *handlers) AssignUserRoles(c *fiber.Ctx) error {
+	userId, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid user id")
+	}
+
+	var userRolesDto role_dto.UpdateUserRolesDTO
+
+	// Parse request body
+	if err := c.BodyParser(&userRolesDto); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	// Validate request body
+	errs := h.validator.Validate(&userRolesDto)
+	if errs != nil {
+		err := h.validator.ParseErrorToString(errs)
+		return fiber.NewError(fiber.StatusBadRequest, err)
+	}
+
+	roles, err := h.service.AssignUserRoles(userId, &userRolesDto)
+	if err != nil {
+		return roleError(err)
+	}
+
+	// Return response
+	return c.JSON(&role_dto.UserRolesSuccessResponseDTO{
+		Success: true,
+		Data:    roles,
+	})
+}
+
+// RemoveUserRoles handles taking roles away from a user
+// @Summary Unassign user roles
+// @Description Take roles away from a user, roles the user does not have are ignored. Requires the roles:update permission
+// @Tags roles
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "User ID"
+// @Param request body role_dto.UpdateUserRolesDTO true "Role IDs"
+// @Success 200 {object} role_dto.UserRolesSuccessResponseDTO
+// @Router /users/{id}/roles [delete]
+func (h *handlers) RemoveUserRoles(c *fiber.Ctx) error {
+	userId, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid user id")
+	}
+
+	var userRolesDto role_dto.UpdateUserRolesDTO
+
+	// Parse request body
+	if err := c.BodyParser(&userRolesDto); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	// Validate request body
+	errs := h.validator.Validate(&userRolesDto)
+	if errs != nil {
+		err := h.validator.ParseErrorToString(errs)
+		return fiber.NewError(fiber.StatusBadRequest, err)
+	}
+
+	roles, err := h.service.RemoveUserRoles(userId, &userRolesDto)
+	if err != nil {
+		return roleError(err)
+	}
+
+	// Return response
+	return c.JSON(&role_dto.UserRolesSuccessResponseDTO{
+		Success: true,
+		Data:    roles,
+	})
+}
+
+// roleError maps a service error to an HTTP error
+func roleError(err error) error {
+	switch err {
+	case ErrRoleNotFound, ErrPermissionNotFound, ErrUserNotFound:
+		return fiber.NewError(fiber.StatusNotFound, err.Error())
+	}
+	return fiber.NewError(fiber.StatusBadRequest, err.Error())
+}
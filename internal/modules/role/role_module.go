@@ -0,0 +1,31 @@
+package role
+
+import (
+	"modular-fx-fiber/internal/shared/models"
+	"modular-fx-fiber/internal/shared/rbac"
+
+	"go.uber.org/fx"
+)
+
+// Permissions declares the permissions checked by the role, permission and assignment routes
+var Permissions = rbac.Declare(
+	rbac.Permission{ResourceName: models.PERMISSION_RESOURCE_ROLES, Action: models.PERMISSION_ACTION_CREATE, Description: "Create roles"},
+	rbac.Permission{ResourceName: models.PERMISSION_RESOURCE_ROLES, Action: models.PERMISSION_ACTION_READ, Description: "List roles and the roles of users"},
+	rbac.Permission{ResourceName: models.PERMISSION_RESOURCE_ROLES, Action: models.PERMISSION_ACTION_UPDATE, Description: "Change roles and assign them to users"},
+	rbac.Permission{ResourceName: models.PERMISSION_RESOURCE_ROLES, Action: models.PERMISSION_ACTION_DELETE, Description: "Delete roles"},
+	rbac.Permission{ResourceName: models.PERMISSION_RESOURCE_PERMISSIONS, Action: models.PERMISSION_ACTION_CREATE, Description: "Create permissions"},
+	rbac.Permission{ResourceName: models.PERMISSION_RESOURCE_PERMISSIONS, Action: models.PERMISSION_ACTION_READ, Description: "List permissions"},
+	rbac.Permission{ResourceName: models.PERMISSION_RESOURCE_PERMISSIONS, Action: models.PERMISSION_ACTION_UPDATE, Description: "Change permissions"},
+	rbac.Permission{ResourceName: models.PERMISSION_RESOURCE_PERMISSIONS, Action: models.PERMISSION_ACTION_DELETE, Description: "Delete permissions"},
+)
+
+// Module exports the role module dependencies
+var Module = fx.Options(
+	fx.Provide(
+		NewRoutes,
+		NewHandlers,
+		NewService,
+	),
+	Permissions,
+	fx.Invoke(Register),
+)
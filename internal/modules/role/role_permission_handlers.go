@@ -0,0 +1,189 @@
+package role
+
+import (
+	"modular-fx-fiber/internal/shared/dto/role_dto"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// CreatePermission handles permission creation
+// @Summary Create permission
+// @Description Create a permission to perform an action on a resource, it has no effect until it is assigned to a role. Requires the permissions:create permission
+// @Tags permissions
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body role_dto.CreatePermissionDTO true "Permission name, description, resource and action"
+// @Success 201 {object} role_dto.PermissionSuccessResponseDTO
+// @Router /permissions [post]
+func (h *handlers) CreatePermission(c *fiber.Ctx) error {
+	var createPermissionDto role_dto.CreatePermissionDTO
+
+	// Parse request body
+	if err := c.BodyParser(&createPermissionDto); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	// Validate request body
+	errs := h.validator.Validate(&createPermissionDto)
+	if errs != nil {
+		err := h.validator.ParseErrorToString(errs)
+		return fiber.NewError(fiber.StatusBadRequest, err)
+	}
+
+	permission, err := h.service.CreatePermission(&createPermissionDto)
+	if err != nil {
+		return roleError(err)
+	}
+
+	// Return response
+	return c.Status(fiber.StatusCreated).JSON(&role_dto.PermissionSuccessResponseDTO{
+		Success: true,
+		Data:    permission,
+	})
+}
+
+// ListPermissions handles listing permissions
+// @Summary List permissions
+// @Description List permissions with pagination, ordered by resource and action. Requires the permissions:read permission
+// @Tags permissions
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param resource_name query string false "Only permissions on this resource"
+// @Param action query string false "Only permissions for this action"
+// @Param page query int false "Page number"
+// @Param page_size query int false "Page size"
+// @Success 200 {object} role_dto.ListPermissionsSuccessResponseDTO
+// @Router /permissions [get]
+func (h *handlers) ListPermissions(c *fiber.Ctx) error {
+	page, err := strconv.Atoi(c.Query("page", "1"))
+	if err != nil || page < 1 {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid page")
+	}
+
+	pageSize, err := strconv.Atoi(c.Query("page_size", "10"))
+	if err != nil || pageSize < 1 {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid page size")
+	}
+
+	// Limit page size to 100
+	if pageSize > 100 {
+		pageSize = 100
+	}
+
+	permissions, total, err := h.service.ListPermissions(c.Query("resource_name"), c.Query("action"), page, pageSize)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	// Return response
+	return c.JSON(&role_dto.ListPermissionsSuccessResponseDTO{
+		Success: true,
+		Data: &role_dto.PaginatedPermissionsDTO{
+			Items:      permissions,
+			TotalCount: total,
+			Page:       page,
+			PageSize:   pageSize,
+			TotalPages: (total + int64(pageSize) - 1) / int64(pageSize),
+		},
+	})
+}
+
+// GetPermission handles getting a permission
+// @Summary Get permission
+// @Description Get a permission. Requires the permissions:read permission
+// @Tags permissions
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Permission ID"
+// @Success 200 {object} role_dto.PermissionSuccessResponseDTO
+// @Router /permissions/{id} [get]
+func (h *handlers) GetPermission(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid permission id")
+	}
+
+	permission, err := h.service.GetPermission(id)
+	if err != nil {
+		return roleError(err)
+	}
+
+	// Return response
+	return c.JSON(&role_dto.PermissionSuccessResponseDTO{
+		Success: true,
+		Data:    permission,
+	})
+}
+
+// UpdatePermission handles updating a permission
+// @Summary Update permission
+// @Description Change a permission, the roles it is assigned to keep it. Requires the permissions:update permission
+// @Tags permissions
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Permission ID"
+// @Param request body role_dto.UpdatePermissionDTO true "Permission name, description, resource and action"
+// @Success 200 {object} role_dto.PermissionSuccessResponseDTO
+// @Router /permissions/{id} [put]
+func (h *handlers) UpdatePermission(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid permission id")
+	}
+
+	var updatePermissionDto role_dto.UpdatePermissionDTO
+
+	// Parse request body
+	if err := c.BodyParser(&updatePermissionDto); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	// Validate request body
+	errs := h.validator.Validate(&updatePermissionDto)
+	if errs != nil {
+		err := h.validator.ParseErrorToString(errs)
+		return fiber.NewError(fiber.StatusBadRequest, err)
+	}
+
+	permission, err := h.service.UpdatePermission(id, &updatePermissionDto)
+	if err != nil {
+		return roleError(err)
+	}
+
+	// Return response
+	return c.JSON(&role_dto.PermissionSuccessResponseDTO{
+		Success: true,
+		Data:    permission,
+	})
+}
+
+// DeletePermission handles deleting a permission
+// @Summary Delete permission
+// @Description Delete a permission and take it away from every role. Requires the permissions:delete permission
+// @Tags permissions
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Permission ID"
+// @Success 200 {object} role_dto.DeletePermissionSuccessResponseDTO
+// @Router /permissions/{id} [delete]
+func (h *handlers) DeletePermission(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid permission id")
+	}
+
+	if err := h.service.DeletePermission(id); err != nil {
+		return roleError(err)
+	}
+
+	// Return response
+	return c.JSON(&role_dto.DeletePermissionSuccessResponseDTO{
+		Success: true,
+	})
+}
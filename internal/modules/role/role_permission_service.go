@@ -0,0 +1,173 @@
+package role
+
+import (
+	"modular-fx-fiber/internal/shared/dto/role_dto"
+	"modular-fx-fiber/internal/shared/models"
+
+	"go.uber.org/zap"
+)
+
+// CreatePermission creates a permission, it has no effect until it is assigned to a role
+func (s *service) CreatePermission(dto *role_dto.CreatePermissionDTO) (*role_dto.PermissionDTO, error) {
+	if err := s.checkPermission(dto.Name, dto.ResourceName, dto.Action, 0); err != nil {
+		return nil, err
+	}
+
+	permission := models.Permission{
+		Name:         dto.Name,
+		Description:  dto.Description,
+		ResourceName: dto.ResourceName,
+		Action:       dto.Action,
+	}
+	if err := s.permissionRepo.Create(&permission); err != nil {
+		s.logger.Error("Failed to save permission", zap.String("name", dto.Name), zap.Error(err))
+		return nil, err
+	}
+
+	s.logger.Info("Permission created",
+		zap.Uint64("permission_id", permission.ID),
+		zap.String("resource_name", permission.ResourceName),
+		zap.String("action", permission.Action))
+	return toPermissionDTO(&permission), nil
+}
+
+// ListPermissions lists the permissions with pagination, optionally only those of a resource or action
+func (s *service) ListPermissions(resourceName string, action string, page int, pageSize int) ([]*role_dto.PermissionDTO, int64, error) {
+	var permissions []models.Permission
+	var total int64
+	var err error
+
+	switch {
+	case resourceName != "" && action != "":
+		// There is at most one permission per resource and action
+		var permission *models.Permission
+		permission, err = s.permissionRepo.GetByResourceAndAction(resourceName, action)
+		if permission != nil {
+			total = 1
+			if page == 1 {
+				permissions = []models.Permission{*permission}
+			}
+		}
+	case resourceName != "":
+		permissions, total, err = s.permissionRepo.ListByResourceName(resourceName, page, pageSize)
+	case action != "":
+		permissions, total, err = s.permissionRepo.ListByAction(action, page, pageSize)
+	default:
+		permissions, total, err = s.permissionRepo.List(page, pageSize)
+	}
+	if err != nil {
+		s.logger.Error("Failed to list permissions", zap.Error(err))
+		return nil, 0, err
+	}
+
+	items := make([]*role_dto.PermissionDTO, 0, len(permissions))
+	for i := range permissions {
+		items = append(items, toPermissionDTO(&permissions[i]))
+	}
+	return items, total, nil
+}
+
+// GetPermission returns a permission
+func (s *service) GetPermission(id uint64) (*role_dto.PermissionDTO, error) {
+	permission, err := s.getPermission(id)
+	if err != nil {
+		return nil, err
+	}
+	return toPermissionDTO(permission), nil
+}
+
+// UpdatePermission changes a permission, the roles it is assigned to keep it
+func (s *service) UpdatePermission(id uint64, dto *role_dto.UpdatePermissionDTO) (*role_dto.PermissionDTO, error) {
+	permission, err := s.getPermission(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkPermission(dto.Name, dto.ResourceName, dto.Action, permission.ID); err != nil {
+		return nil, err
+	}
+
+	permission.Name = dto.Name
+	permission.Description = dto.Description
+	permission.ResourceName = dto.ResourceName
+	permission.Action = dto.Action
+	if err := s.permissionRepo.Update(permission); err != nil {
+		s.logger.Error("Failed to update permission", zap.Uint64("permission_id", permission.ID), zap.Error(err))
+		return nil, err
+	}
+	s.permissionCache.InvalidateAll()
+
+	s.logger.Info("Permission updated",
+		zap.Uint64("permission_id", permission.ID),
+		zap.String("resource_name", permission.ResourceName),
+		zap.String("action", permission.Action))
+	return toPermissionDTO(permission), nil
+}
+
+// DeletePermission deletes a permission and takes it away from every role
+func (s *service) DeletePermission(id uint64) error {
+	permission, err := s.getPermission(id)
+	if err != nil {
+		return err
+	}
+
+	if err := s.permissionRepo.Delete(permission.ID); err != nil {
+		s.logger.Error("Failed to delete permission", zap.Uint64("permission_id", permission.ID), zap.Error(err))
+		return err
+	}
+	s.permissionCache.InvalidateAll()
+
+	s.logger.Info("Permission deleted", zap.Uint64("permission_id", permission.ID), zap.String("name", permission.Name))
+	return nil
+}
+
+// getPermission fetches a permission, failing with ErrPermissionNotFound when it does not exist
+func (s *service) getPermission(id uint64) (*models.Permission, error) {
+	permission, err := s.permissionRepo.GetByID(id)
+	if err != nil {
+		s.logger.Error("Failed to fetch permission", zap.Uint64("permission_id", id), zap.Error(err))
+		return nil, err
+	}
+	if permission == nil {
+		return nil, ErrPermissionNotFound
+	}
+	return permission, nil
+}
+
+// checkPermission fails with ErrPermissionAlreadyExists when another permission than the given one
+// has the name or grants the same action on the same resource
+func (s *service) checkPermission(name, resourceName, action string, permissionId uint64) error {
+	existing, err := s.permissionRepo.GetByName(name)
+	if err != nil {
+		s.logger.Error("Failed to fetch permission", zap.String("name", name), zap.Error(err))
+		return err
+	}
+	if existing != nil && existing.ID != permissionId {
+		return ErrPermissionAlreadyExists
+	}
+
+	existing, err = s.permissionRepo.GetByResourceAndAction(resourceName, action)
+	if err != nil {
+		s.logger.Error("Failed to fetch permission",
+			zap.String("resource_name", resourceName),
+			zap.String("action", action),
+			zap.Error(err))
+		return err
+	}
+	if existing != nil && existing.ID != permissionId {
+		return ErrPermissionAlreadyExists
+	}
+	return nil
+}
+
+// toPermissionDTO converts a permission to its response representation
+func toPermissionDTO(permission *models.Permission) *role_dto.PermissionDTO {
+	return &role_dto.PermissionDTO{
+		ID:           permission.ID,
+		Name:         permission.Name,
+		Description:  permission.Description,
+		ResourceName: permission.ResourceName,
+		Action:       permission.Action,
+		CreatedAt:    permission.CreatedAt,
+		UpdatedAt:    permission.UpdatedAt,
+	}
+}
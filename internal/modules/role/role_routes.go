@@ -0,0 +1,50 @@
+package role
+
+import (
+	"modular-fx-fiber/internal/core/server"
+	"modular-fx-fiber/internal/shared/middleware"
+	"modular-fx-fiber/internal/shared/models"
+)
+
+type (
+	Routes interface{}
+
+	routes struct {
+		handlers Handlers
+	}
+)
+
+// NewRoutes creates new role routes
+func NewRoutes(h Handlers) Routes {
+	return &routes{
+		handlers: h,
+	}
+}
+
+// Register registers role, permission and assignment routes, they are managed by logged in users
+// whose roles grant the matching permission
+func Register(s server.Server, m middleware.Middleware, h Handlers) {
+	app := s.GetApp()
+
+	roles := app.Group("api/roles", m.JWT())
+	roles.Post("/", m.RequirePermission(models.PERMISSION_RESOURCE_ROLES, models.PERMISSION_ACTION_CREATE), h.CreateRole)
+	roles.Get("/", m.RequirePermission(models.PERMISSION_RESOURCE_ROLES, models.PERMISSION_ACTION_READ), h.ListRoles)
+	roles.Get("/:id", m.RequirePermission(models.PERMISSION_RESOURCE_ROLES, models.PERMISSION_ACTION_READ), h.GetRole)
+	roles.Put("/:id", m.RequirePermission(models.PERMISSION_RESOURCE_ROLES, models.PERMISSION_ACTION_UPDATE), h.UpdateRole)
+	roles.Delete("/:id", m.RequirePermission(models.PERMISSION_RESOURCE_ROLES, models.PERMISSION_ACTION_DELETE), h.DeleteRole)
+	roles.Post("/:id/permissions", m.RequirePermission(models.PERMISSION_RESOURCE_ROLES, models.PERMISSION_ACTION_UPDATE), h.AssignPermissions)
+	roles.Delete("/:id/permissions", m.RequirePermission(models.PERMISSION_RESOURCE_ROLES, models.PERMISSION_ACTION_UPDATE), h.RemovePermissions)
+
+	permissions := app.Group("api/permissions", m.JWT())
+	permissions.Post("/", m.RequirePermission(models.PERMISSION_RESOURCE_PERMISSIONS, models.PERMISSION_ACTION_CREATE), h.CreatePermission)
+	permissions.Get("/", m.RequirePermission(models.PERMISSION_RESOURCE_PERMISSIONS, models.PERMISSION_ACTION_READ), h.ListPermissions)
+	permissions.Get("/:id", m.RequirePermission(models.PERMISSION_RESOURCE_PERMISSIONS, models.PERMISSION_ACTION_READ), h.GetPermission)
+	permissions.Put("/:id", m.RequirePermission(models.PERMISSION_RESOURCE_PERMISSIONS, models.PERMISSION_ACTION_UPDATE), h.UpdatePermission)
+	permissions.Delete("/:id", m.RequirePermission(models.PERMISSION_RESOURCE_PERMISSIONS, models.PERMISSION_ACTION_DELETE), h.DeletePermission)
+
+	// The users group has public routes, so JWT() is added per route here
+	users := app.Group("api/users")
+	users.Get("/:id/roles", m.JWT(), m.RequirePermission(models.PERMISSION_RESOURCE_ROLES, models.PERMISSION_ACTION_READ), h.GetUserRoles)
+	users.Post("/:id/roles", m.JWT(), m.RequirePermission(models.PERMISSION_RESOURCE_ROLES, models.PERMISSION_ACTION_UPDATE), h.AssignUserRoles)
+	users.Delete("/:id/roles", m.JWT(), m.RequirePermission(models.PERMISSION_RESOURCE_ROLES, models.PERMISSION_ACTION_UPDATE), h.RemoveUserRoles)
+}
@@ -0,0 +1,377 @@
+package role
+
+import (
+	"errors"
+	"modular-fx-fiber/internal/shared/dto/role_dto"
+	"modular-fx-fiber/internal/shared/logger"
+	"modular-fx-fiber/internal/shared/middleware"
+	"modular-fx-fiber/internal/shared/models"
+	"modular-fx-fiber/internal/shared/repositories"
+	"slices"
+
+	"go.uber.org/zap"
+)
+
+var (
+	ErrRoleNotFound            = errors.New("role not found")
+	ErrRoleAlreadyExists       = errors.New("role already exists")
+	ErrParentRoleNotFound      = errors.New("parent role not found")
+	ErrRoleCycle               = errors.New("role would inherit from itself")
+	ErrPermissionNotFound      = errors.New("permission not found")
+	ErrPermissionAlreadyExists = errors.New("permission already exists")
+	ErrUserNotFound            = errors.New("user not found")
+	ErrUnknownPermissions      = errors.New("one or more permissions do not exist")
+	ErrUnknownRoles            = errors.New("one or more roles do not exist")
+)
+
+type (
+	Service interface {
+		CreateRole(dto *role_dto.CreateRoleDTO) (*role_dto.RoleDTO, error)
+		ListRoles(page int, pageSize int) ([]*role_dto.RoleDTO, int64, error)
+		GetRole(id uint64) (*role_dto.RoleDTO, error)
+		UpdateRole(id uint64, dto *role_dto.UpdateRoleDTO) (*role_dto.RoleDTO, error)
+		DeleteRole(id uint64) error
+		AssignPermissions(id uint64, dto *role_dto.RolePermissionsDTO) (*role_dto.RoleDTO, error)
+		RemovePermissions(id uint64, dto *role_dto.RolePermissionsDTO) (*role_dto.RoleDTO, error)
+
+		CreatePermission(dto *role_dto.CreatePermissionDTO) (*role_dto.PermissionDTO, error)
+		ListPermissions(resourceName string, action string, page int, pageSize int) ([]*role_dto.PermissionDTO, int64, error)
+		GetPermission(id uint64) (*role_dto.PermissionDTO, error)
+		UpdatePermission(id uint64, dto *role_dto.UpdatePermissionDTO) (*role_dto.PermissionDTO, error)
+		DeletePermission(id uint64) error
+
+		GetUserRoles(userId uint64) (*role_dto.UserRolesDTO, error)
+		AssignUserRoles(userId uint64, dto *role_dto.UpdateUserRolesDTO) (*role_dto.UserRolesDTO, error)
+		RemoveUserRoles(userId uint64, dto *role_dto.UpdateUserRolesDTO) (*role_dto.UserRolesDTO, error)
+	}
+
+	service struct {
+		logger *logger.ZapLogger
+
+		userRepo       repositories.UserRepository
+		roleRepo       repositories.RoleRepository
+		permissionRepo repositories.PermissionRepository
+		userRoleRepo   repositories.UserRoleRepository
+
+		permissionCache middleware.PermissionCache
+	}
+)
+
+// NewService creates a new role service
+func NewService(
+	logger *logger.ZapLogger,
+	userRepo repositories.UserRepository,
+	roleRepo repositories.RoleRepository,
+	permissionRepo repositories.PermissionRepository,
+	userRoleRepo repositories.UserRoleRepository,
+	permissionCache middleware.PermissionCache,
+) Service {
+	return &service{
+		logger:          logger,
+		userRepo:        userRepo,
+		roleRepo:        roleRepo,
+		permissionRepo:  permissionRepo,
+		userRoleRepo:    userRoleRepo,
+		permissionCache: permissionCache,
+	}
+}
+
+// CreateRole creates a role without permissions of its own, they are granted with AssignPermissions.
+// With a parent the role starts with the permissions of the parent and its ancestors.
+func (s *service) CreateRole(dto *role_dto.CreateRoleDTO) (*role_dto.RoleDTO, error) {
+	if err := s.checkRoleName(dto.Name, 0); err != nil {
+		return nil, err
+	}
+	if dto.ParentID != nil {
+		if _, err := s.getRole(*dto.ParentID); err != nil {
+			if err == ErrRoleNotFound {
+				return nil, ErrParentRoleNotFound
+			}
+			return nil, err
+		}
+	}
+
+	// A new role has no children yet, so its parent cannot be part of a cycle
+	role := models.Role{
+		Name:        dto.Name,
+		Description: dto.Description,
+		ParentID:    dto.ParentID,
+	}
+	if err := s.roleRepo.Create(&role); err != nil {
+		s.logger.Error("Failed to save role", zap.String("name", dto.Name), zap.Error(err))
+		return nil, err
+	}
+
+	s.logger.Info("Role created", zap.Uint64("role_id", role.ID), zap.String("name", role.Name))
+	return s.GetRole(role.ID)
+}
+
+// ListRoles lists the roles and their permissions with pagination
+func (s *service) ListRoles(page int, pageSize int) ([]*role_dto.RoleDTO, int64, error) {
+	roles, total, err := s.roleRepo.List(page, pageSize)
+	if err != nil {
+		s.logger.Error("Failed to list roles", zap.Error(err))
+		return nil, 0, err
+	}
+
+	items := make([]*role_dto.RoleDTO, 0, len(roles))
+	for i := range roles {
+		items = append(items, toRoleDTO(&roles[i]))
+	}
+	return items, total, nil
+}
+
+// GetRole returns a role with its permissions
+func (s *service) GetRole(id uint64) (*role_dto.RoleDTO, error) {
+	role, err := s.getRole(id)
+	if err != nil {
+		return nil, err
+	}
+	return toRoleDTO(role), nil
+}
+
+// UpdateRole renames a role, changes its description or the parent it inherits from.
+// A parent that is the role itself or inherits from it is refused with ErrRoleCycle.
+func (s *service) UpdateRole(id uint64, dto *role_dto.UpdateRoleDTO) (*role_dto.RoleDTO, error) {
+	role, err := s.getRole(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkRoleName(dto.Name, role.ID); err != nil {
+		return nil, err
+	}
+
+	// The parent is checked and saved in the same transaction as the name and description
+	role.Name = dto.Name
+	role.Description = dto.Description
+	role.ParentID = dto.ParentID
+	if err := s.roleRepo.Update(role); err != nil {
+		switch {
+		case errors.Is(err, repositories.ErrRoleCycle):
+			return nil, ErrRoleCycle
+		case errors.Is(err, repositories.ErrRoleNotFound):
+			return nil, ErrParentRoleNotFound
+		}
+		s.logger.Error("Failed to update role", zap.Uint64("role_id", role.ID), zap.Error(err))
+		return nil, err
+	}
+	// The name is checked by RequireAnyRole and the parent changes the permissions of every holder
+	s.permissionCache.InvalidateAll()
+
+	s.logger.Info("Role updated", zap.Uint64("role_id", role.ID), zap.String("name", role.Name))
+	return s.GetRole(role.ID)
+}
+
+// DeleteRole deletes a role, the users and service accounts holding it lose its permissions
+// and the roles inheriting from it no longer have a parent
+func (s *service) DeleteRole(id uint64) error {
+	role, err := s.getRole(id)
+	if err != nil {
+		return err
+	}
+
+	if err := s.roleRepo.Delete(role.ID); err != nil {
+		s.logger.Error("Failed to delete role", zap.Uint64("role_id", role.ID), zap.Error(err))
+		return err
+	}
+	s.permissionCache.InvalidateAll()
+
+	s.logger.Info("Role deleted", zap.Uint64("role_id", role.ID), zap.String("name", role.Name))
+	return nil
+}
+
+// AssignPermissions grants permissions to a role, permissions it already has are kept
+func (s *service) AssignPermissions(id uint64, dto *role_dto.RolePermissionsDTO) (*role_dto.RoleDTO, error) {
+	if err := s.roleRepo.AssignPermissions(id, dto.PermissionIDs); err != nil {
+		switch {
+		case errors.Is(err, repositories.ErrRoleNotFound):
+			return nil, ErrRoleNotFound
+		case errors.Is(err, repositories.ErrPermissionNotFound):
+			return nil, ErrUnknownPermissions
+		}
+		s.logger.Error("Failed to assign role permissions", zap.Uint64("role_id", id), zap.Error(err))
+		return nil, err
+	}
+	s.permissionCache.InvalidateAll()
+
+	s.logger.Info("Role permissions assigned",
+		zap.Uint64("role_id", id),
+		zap.Uint64s("permission_ids", dto.PermissionIDs))
+	return s.GetRole(id)
+}
+
+// RemovePermissions takes permissions away from a role, permissions it does not have are ignored
+func (s *service) RemovePermissions(id uint64, dto *role_dto.RolePermissionsDTO) (*role_dto.RoleDTO, error) {
+	role, err := s.getRole(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.roleRepo.RemovePermissions(role.ID, dto.PermissionIDs); err != nil {
+		s.logger.Error("Failed to remove role permissions", zap.Uint64("role_id", role.ID), zap.Error(err))
+		return nil, err
+	}
+	s.permissionCache.InvalidateAll()
+
+	s.logger.Info("Role permissions removed",
+		zap.Uint64("role_id", role.ID),
+		zap.Uint64s("permission_ids", dto.PermissionIDs))
+	return s.GetRole(role.ID)
+}
+
+// GetUserRoles returns the roles of a user with their permissions, and the permissions the user
+// holds through them. A permission granted to one of the user's roles is reported from that role
+// rather than from an ancestor.
+func (s *service) GetUserRoles(userId uint64) (*role_dto.UserRolesDTO, error) {
+	u, err := s.userRepo.GetByIDWithPermissions(userId)
+	if err != nil {
+		s.logger.Error("Failed to fetch user roles", zap.Uint64("user_id", userId), zap.Error(err))
+		return nil, err
+	}
+	if u == nil {
+		return nil, ErrUserNotFound
+	}
+
+	roles := make([]*role_dto.RoleDTO, 0, len(u.Roles))
+	assigned := make([]*models.Role, 0, len(u.Roles))
+	for i := range u.Roles {
+		roles = append(roles, toRoleDTO(&u.Roles[i]))
+		assigned = append(assigned, &u.Roles[i])
+	}
+
+	// Go through the assigned roles first, then through their ancestors
+	effective := []models.EffectivePermission{}
+	for _, role := range assigned {
+		for _, permission := range role.Permissions {
+			effective = append(effective, models.EffectivePermission{Permission: permission, GrantedBy: role})
+		}
+	}
+	for _, role := range assigned {
+		effective = append(effective, role.EffectivePermissions()...)
+	}
+
+	permissions := []*role_dto.EffectivePermissionDTO{}
+	seen := map[uint64]bool{}
+	for _, permission := range effective {
+		if !seen[permission.ID] {
+			seen[permission.ID] = true
+			inherited := !slices.ContainsFunc(assigned, func(role *models.Role) bool { return role.ID == permission.GrantedBy.ID })
+			permissions = append(permissions, toEffectivePermissionDTO(permission, inherited))
+		}
+	}
+
+	return &role_dto.UserRolesDTO{
+		UserID:               u.ID,
+		Roles:                roles,
+		EffectivePermissions: permissions,
+	}, nil
+}
+
+// AssignUserRoles gives roles to a user, roles the user already has are kept
+func (s *service) AssignUserRoles(userId uint64, dto *role_dto.UpdateUserRolesDTO) (*role_dto.UserRolesDTO, error) {
+	if err := s.userRoleRepo.AssignRolesToUser(userId, dto.RoleIDs); err != nil {
+		switch {
+		case errors.Is(err, repositories.ErrUserNotFound):
+			return nil, ErrUserNotFound
+		case errors.Is(err, repositories.ErrRoleNotFound):
+			return nil, ErrUnknownRoles
+		}
+		s.logger.Error("Failed to assign user roles", zap.Uint64("user_id", userId), zap.Error(err))
+		return nil, err
+	}
+	s.permissionCache.InvalidateUser(userId)
+
+	s.logger.Info("User roles assigned",
+		zap.Uint64("user_id", userId),
+		zap.Uint64s("role_ids", dto.RoleIDs))
+	return s.GetUserRoles(userId)
+}
+
+// RemoveUserRoles takes roles away from a user, roles the user does not have are ignored
+func (s *service) RemoveUserRoles(userId uint64, dto *role_dto.UpdateUserRolesDTO) (*role_dto.UserRolesDTO, error) {
+	u, err := s.userRepo.GetByID(userId)
+	if err != nil {
+		s.logger.Error("Failed to fetch user", zap.Uint64("user_id", userId), zap.Error(err))
+		return nil, err
+	}
+	if u == nil {
+		return nil, ErrUserNotFound
+	}
+
+	if err := s.userRoleRepo.RemoveRolesFromUser(u.ID, dto.RoleIDs); err != nil {
+		s.logger.Error("Failed to remove user roles", zap.Uint64("user_id", u.ID), zap.Error(err))
+		return nil, err
+	}
+	s.permissionCache.InvalidateUser(u.ID)
+
+	s.logger.Info("User roles removed",
+		zap.Uint64("user_id", u.ID),
+		zap.Uint64s("role_ids", dto.RoleIDs))
+	return s.GetUserRoles(u.ID)
+}
+
+// getRole fetches a role, failing with ErrRoleNotFound when it does not exist
+func (s *service) getRole(id uint64) (*models.Role, error) {
+	role, err := s.roleRepo.GetByID(id)
+	if err != nil {
+		s.logger.Error("Failed to fetch role", zap.Uint64("role_id", id), zap.Error(err))
+		return nil, err
+	}
+	if role == nil {
+		return nil, ErrRoleNotFound
+	}
+	return role, nil
+}
+
+// checkRoleName fails with ErrRoleAlreadyExists when another role than the given one has the name
+func (s *service) checkRoleName(name string, roleId uint64) error {
+	existing, err := s.roleRepo.GetByName(name)
+	if err != nil {
+		s.logger.Error("Failed to fetch role", zap.String("name", name), zap.Error(err))
+		return err
+	}
+	if existing != nil && existing.ID != roleId {
+		return ErrRoleAlreadyExists
+	}
+	return nil
+}
+
+// toRoleDTO converts a role to its response representation
+func toRoleDTO(role *models.Role) *role_dto.RoleDTO {
+	permissions := make([]*role_dto.PermissionDTO, 0, len(role.Permissions))
+	for i := range role.Permissions {
+		permissions = append(permissions, toPermissionDTO(&role.Permissions[i]))
+	}
+
+	ancestors := []string{}
+	for _, ancestor := range role.Ancestors() {
+		ancestors = append(ancestors, ancestor.Name)
+	}
+
+	effectivePermissions := []*role_dto.EffectivePermissionDTO{}
+	for _, permission := range role.EffectivePermissions() {
+		effectivePermissions = append(effectivePermissions, toEffectivePermissionDTO(permission, permission.GrantedBy.ID != role.ID))
+	}
+
+	return &role_dto.RoleDTO{
+		ID:                   role.ID,
+		Name:                 role.Name,
+		Description:          role.Description,
+		ParentID:             role.ParentID,
+		Ancestors:            ancestors,
+		Permissions:          permissions,
+		EffectivePermissions: effectivePermissions,
+		CreatedAt:            role.CreatedAt,
+		UpdatedAt:            role.UpdatedAt,
+	}
+}
+
+// toEffectivePermissionDTO converts a permission held through a role to its response representation
+func toEffectivePermissionDTO(permission models.EffectivePermission, inherited bool) *role_dto.EffectivePermissionDTO {
+	return &role_dto.EffectivePermissionDTO{
+		PermissionDTO:   *toPermissionDTO(&permission.Permission),
+		GrantedByRoleID: permission.GrantedBy.ID,
+		GrantedByRole:   permission.GrantedBy.Name,
+		Inherited:       inherited,
+	}
+}
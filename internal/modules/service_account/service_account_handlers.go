@@ -0,0 +1,250 @@
+package service_account
+
+import (
+	"modular-fx-fiber/internal/shared/dto/service_account_dto"
+	"modular-fx-fiber/internal/shared/logger"
+	"modular-fx-fiber/internal/shared/validator"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type (
+	// Handlers defines the HTTP handlers for service account management
+	Handlers interface {
+		Create(c *fiber.Ctx) error
+		List(c *fiber.Ctx) error
+		Get(c *fiber.Ctx) error
+		RotateSecret(c *fiber.Ctx) error
+		UpdateRoles(c *fiber.Ctx) error
+		Delete(c *fiber.Ctx) error
+	}
+
+	handlers struct {
+		service   Service
+		validator *validator.Validator
+		logger    *logger.ZapLogger
+	}
+)
+
+// NewHandlers creates a new service account handlers instance
+func NewHandlers(l *logger.ZapLogger, v *validator.Validator, s Service) Handlers {
+	return &handlers{
+		service:   s,
+		validator: v,
+		logger:    l,
+	}
+}
+
+// Create handles service account creation
+// @Summary Create service account
+// @Description Create a service account for backend-to-backend calls. The client secret is only shown in this response, the account exchanges it for tokens at /auth/token. The roles may only grant permissions the caller has
+// @Tags service-accounts
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body service_account_dto.CreateServiceAccountDTO true "Service account name, description and roles"
+// @Success 201 {object} service_account_dto.CreateServiceAccountSuccessResponseDTO
+// @Router /service-accounts [post]
+func (h *handlers) Create(c *fiber.Ctx) error {
+	var createServiceAccountDto service_account_dto.CreateServiceAccountDTO
+
+	// Parse request body
+	if err := c.BodyParser(&createServiceAccountDto); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	// Validate request body
+	errs := h.validator.Validate(&createServiceAccountDto)
+	if errs != nil {
+		err := h.validator.ParseErrorToString(errs)
+		return fiber.NewError(fiber.StatusBadRequest, err)
+	}
+
+	userId := c.Locals("user_id").(uint64)
+	account, err := h.service.Create(userId, &createServiceAccountDto)
+	if err != nil {
+		return serviceAccountError(err)
+	}
+
+	// Return response
+	return c.Status(fiber.StatusCreated).JSON(&service_account_dto.CreateServiceAccountSuccessResponseDTO{
+		Success: true,
+		Data:    account,
+	})
+}
+
+// List handles listing service accounts
+// @Summary List service accounts
+// @Description List service accounts with pagination
+// @Tags service-accounts
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param page query int false "Page number"
+// @Param page_size query int false "Page size"
+// @Success 200 {object} service_account_dto.ListServiceAccountsSuccessResponseDTO
+// @Router /service-accounts [get]
+func (h *handlers) List(c *fiber.Ctx) error {
+	page, err := strconv.Atoi(c.Query("page", "1"))
+	if err != nil || page < 1 {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid page")
+	}
+
+	pageSize, err := strconv.Atoi(c.Query("page_size", "10"))
+	if err != nil || pageSize < 1 {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid page size")
+	}
+
+	// Limit page size to 100
+	if pageSize > 100 {
+		pageSize = 100
+	}
+
+	accounts, total, err := h.service.List(page, pageSize)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	// Return response
+	return c.JSON(&service_account_dto.ListServiceAccountsSuccessResponseDTO{
+		Success: true,
+		Data: &service_account_dto.PaginatedServiceAccountsDTO{
+			Items:      accounts,
+			TotalCount: total,
+			Page:       page,
+			PageSize:   pageSize,
+			TotalPages: (total + int64(pageSize) - 1) / int64(pageSize),
+		},
+	})
+}
+
+// Get handles getting a service account
+// @Summary Get service account
+// @Description Get a service account with its roles
+// @Tags service-accounts
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Service account ID"
+// @Success 200 {object} service_account_dto.ServiceAccountSuccessResponseDTO
+// @Router /service-accounts/{id} [get]
+func (h *handlers) Get(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid service account id")
+	}
+
+	account, err := h.service.Get(id)
+	if err != nil {
+		return serviceAccountError(err)
+	}
+
+	// Return response
+	return c.JSON(&service_account_dto.ServiceAccountSuccessResponseDTO{
+		Success: true,
+		Data:    account,
+	})
+}
+
+// RotateSecret handles rotating the client secret of a service account
+// @Summary Rotate service account secret
+// @Description Issue a new client secret. The previous secrets keep working until previous_secrets_expire_at. The new secret is only shown in this response
+// @Tags service-accounts
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Service account ID"
+// @Success 201 {object} service_account_dto.RotateSecretSuccessResponseDTO
+// @Router /service-accounts/{id}/secrets [post]
+func (h *handlers) RotateSecret(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid service account id")
+	}
+
+	secret, err := h.service.RotateSecret(id)
+	if err != nil {
+		return serviceAccountError(err)
+	}
+
+	// Return response
+	return c.Status(fiber.StatusCreated).JSON(&service_account_dto.RotateSecretSuccessResponseDTO{
+		Success: true,
+		Data:    secret,
+	})
+}
+
+// UpdateRoles handles replacing the roles of a service account
+// @Summary Update service account roles
+// @Description Replace the roles of a service account. They apply to the tokens issued before too. The roles may only grant permissions the caller has
+// @Tags service-accounts
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Service account ID"
+// @Param request body service_account_dto.UpdateServiceAccountRolesDTO true "Role IDs"
+// @Success 200 {object} service_account_dto.ServiceAccountSuccessResponseDTO
+// @Router /service-accounts/{id}/roles [put]
+func (h *handlers) UpdateRoles(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid service account id")
+	}
+
+	var updateRolesDto service_account_dto.UpdateServiceAccountRolesDTO
+
+	// Parse request body
+	if err := c.BodyParser(&updateRolesDto); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	userId := c.Locals("user_id").(uint64)
+	account, err := h.service.UpdateRoles(userId, id, &updateRolesDto)
+	if err != nil {
+		return serviceAccountError(err)
+	}
+
+	// Return response
+	return c.JSON(&service_account_dto.ServiceAccountSuccessResponseDTO{
+		Success: true,
+		Data:    account,
+	})
+}
+
+// Delete handles deleting a service account
+// @Summary Delete service account
+// @Description Delete a service account, it can no longer get tokens and its issued tokens expire on their own
+// @Tags service-accounts
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Service account ID"
+// @Success 200 {object} service_account_dto.DeleteServiceAccountSuccessResponseDTO
+// @Router /service-accounts/{id} [delete]
+func (h *handlers) Delete(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid service account id")
+	}
+
+	if err := h.service.Delete(id); err != nil {
+		return serviceAccountError(err)
+	}
+
+	// Return response
+	return c.JSON(&service_account_dto.DeleteServiceAccountSuccessResponseDTO{
+		Success: true,
+	})
+}
+
+// serviceAccountError maps a service error to an HTTP error
+func serviceAccountError(err error) error {
+	switch err {
+	case ErrServiceAccountNotFound:
+		return fiber.NewError(fiber.StatusNotFound, err.Error())
+	case ErrRoleNotGrantable:
+		return fiber.NewError(fiber.StatusForbidden, err.Error())
+	}
+	return fiber.NewError(fiber.StatusBadRequest, err.Error())
+}
@@ -0,0 +1,27 @@
+package service_account
+
+import (
+	"modular-fx-fiber/internal/shared/models"
+	"modular-fx-fiber/internal/shared/rbac"
+
+	"go.uber.org/fx"
+)
+
+// Permissions declares the permissions checked by the service account routes
+var Permissions = rbac.Declare(
+	rbac.Permission{ResourceName: models.PERMISSION_RESOURCE_SERVICE_ACCOUNTS, Action: models.PERMISSION_ACTION_READ, Description: "List service accounts"},
+	rbac.Permission{ResourceName: models.PERMISSION_RESOURCE_SERVICE_ACCOUNTS, Action: models.PERMISSION_ACTION_CREATE, Description: "Create service accounts"},
+	rbac.Permission{ResourceName: models.PERMISSION_RESOURCE_SERVICE_ACCOUNTS, Action: models.PERMISSION_ACTION_UPDATE, Description: "Rotate secrets and replace roles of service accounts"},
+	rbac.Permission{ResourceName: models.PERMISSION_RESOURCE_SERVICE_ACCOUNTS, Action: models.PERMISSION_ACTION_DELETE, Description: "Delete service accounts"},
+)
+
+// Module exports the service account module dependencies
+var Module = fx.Options(
+	fx.Provide(
+		NewRoutes,
+		NewHandlers,
+		NewService,
+	),
+	Permissions,
+	fx.Invoke(Register),
+)
@@ -0,0 +1,35 @@
+package service_account
+
+import (
+	"modular-fx-fiber/internal/core/server"
+	"modular-fx-fiber/internal/shared/middleware"
+	"modular-fx-fiber/internal/shared/models"
+)
+
+type (
+	Routes interface{}
+
+	routes struct {
+		handlers Handlers
+	}
+)
+
+// NewRoutes creates new service account routes
+func NewRoutes(h Handlers) Routes {
+	return &routes{
+		handlers: h,
+	}
+}
+
+// Register registers service account routes, they are managed by logged in users
+// whose roles grant the matching permission
+func Register(s server.Server, m middleware.Middleware, h Handlers) {
+	group := s.GetApp().Group("api/service-accounts", m.JWT())
+
+	group.Post("/", m.RequirePermission(models.PERMISSION_RESOURCE_SERVICE_ACCOUNTS, models.PERMISSION_ACTION_CREATE), h.Create)
+	group.Get("/", m.RequirePermission(models.PERMISSION_RESOURCE_SERVICE_ACCOUNTS, models.PERMISSION_ACTION_READ), h.List)
+	group.Get("/:id", m.RequirePermission(models.PERMISSION_RESOURCE_SERVICE_ACCOUNTS, models.PERMISSION_ACTION_READ), h.Get)
+	group.Post("/:id/secrets", m.RequirePermission(models.PERMISSION_RESOURCE_SERVICE_ACCOUNTS, models.PERMISSION_ACTION_UPDATE), h.RotateSecret)
+	group.Put("/:id/roles", m.RequirePermission(models.PERMISSION_RESOURCE_SERVICE_ACCOUNTS, models.PERMISSION_ACTION_UPDATE), h.UpdateRoles)
+	group.Delete("/:id", m.RequirePermission(models.PERMISSION_RESOURCE_SERVICE_ACCOUNTS, models.PERMISSION_ACTION_DELETE), h.Delete)
+}
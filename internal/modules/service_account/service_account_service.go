@@ -0,0 +1,279 @@
+package service_account
+
+import (
+	"errors"
+	"modular-fx-fiber/internal/core/config"
+	"modular-fx-fiber/internal/shared/dto/service_account_dto"
+	"modular-fx-fiber/internal/shared/logger"
+	"modular-fx-fiber/internal/shared/middleware"
+	"modular-fx-fiber/internal/shared/models"
+	"modular-fx-fiber/internal/shared/repositories"
+	"modular-fx-fiber/internal/shared/util"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+var (
+	ErrServiceAccountNotFound = errors.New("service account not found")
+	ErrRoleNotFound           = errors.New("role not found")
+	ErrRoleNotGrantable       = errors.New("role grants permissions you do not have")
+)
+
+type (
+	Service interface {
+		Create(callerId uint64, dto *service_account_dto.CreateServiceAccountDTO) (*service_account_dto.CreatedServiceAccountDTO, error)
+		List(page int, pageSize int) ([]*service_account_dto.ServiceAccountDTO, int64, error)
+		Get(id uint64) (*service_account_dto.ServiceAccountDTO, error)
+		RotateSecret(id uint64) (*service_account_dto.ServiceAccountSecretDTO, error)
+		UpdateRoles(callerId uint64, id uint64, dto *service_account_dto.UpdateServiceAccountRolesDTO) (*service_account_dto.ServiceAccountDTO, error)
+		Delete(id uint64) error
+	}
+
+	service struct {
+		config *config.Config
+		logger *logger.ZapLogger
+
+		serviceAccountRepo repositories.ServiceAccountRepository
+		userRepo           repositories.UserRepository
+		roleRepo           repositories.RoleRepository
+		permissionCache    middleware.PermissionCache
+	}
+)
+
+// NewService creates a new service account service
+func NewService(
+	config *config.Config,
+	logger *logger.ZapLogger,
+	serviceAccountRepo repositories.ServiceAccountRepository,
+	userRepo repositories.UserRepository,
+	roleRepo repositories.RoleRepository,
+	permissionCache middleware.PermissionCache,
+) Service {
+	return &service{
+		config:             config,
+		logger:             logger,
+		serviceAccountRepo: serviceAccountRepo,
+		userRepo:           userRepo,
+		roleRepo:           roleRepo,
+		permissionCache:    permissionCache,
+	}
+}
+
+// Create creates a service account with its first client secret.
+// The plaintext secret is only returned here, the database keeps its hash.
+func (s *service) Create(callerId uint64, dto *service_account_dto.CreateServiceAccountDTO) (*service_account_dto.CreatedServiceAccountDTO, error) {
+	if err := s.checkGrantableRoles(callerId, dto.RoleIDs); err != nil {
+		return nil, err
+	}
+
+	clientID, err := util.GenerateRandomToken(16)
+	if err != nil {
+		s.logger.Error("Failed to generate client id", zap.Error(err))
+		return nil, err
+	}
+	clientSecret, err := util.GenerateRandomToken(32)
+	if err != nil {
+		s.logger.Error("Failed to generate client secret", zap.Error(err))
+		return nil, err
+	}
+
+	account := models.ServiceAccount{
+		Name:        dto.Name,
+		Description: dto.Description,
+		ClientID:    clientID,
+		Active:      true,
+		Secrets:     []models.ServiceAccountSecret{{SecretHash: util.HashToken(clientSecret)}},
+	}
+	found, err := s.serviceAccountRepo.Create(&account, dto.RoleIDs)
+	if err != nil {
+		s.logger.Error("Failed to save service account", zap.String("name", dto.Name), zap.Error(err))
+		return nil, err
+	}
+	if !found {
+		return nil, ErrRoleNotFound
+	}
+
+	s.logger.Info("Service account created",
+		zap.Uint64("service_account_id", account.ID),
+		zap.String("client_id", account.ClientID))
+	return &service_account_dto.CreatedServiceAccountDTO{
+		ServiceAccountDTO: *toServiceAccountDTO(&account),
+		ClientSecret:      clientSecret,
+	}, nil
+}
+
+// List lists the service accounts with pagination
+func (s *service) List(page int, pageSize int) ([]*service_account_dto.ServiceAccountDTO, int64, error) {
+	accounts, total, err := s.serviceAccountRepo.List(page, pageSize)
+	if err != nil {
+		s.logger.Error("Failed to list service accounts", zap.Error(err))
+		return nil, 0, err
+	}
+
+	items := make([]*service_account_dto.ServiceAccountDTO, 0, len(accounts))
+	for i := range accounts {
+		items = append(items, toServiceAccountDTO(&accounts[i]))
+	}
+	return items, total, nil
+}
+
+// Get returns a service account
+func (s *service) Get(id uint64) (*service_account_dto.ServiceAccountDTO, error) {
+	account, err := s.getServiceAccount(id)
+	if err != nil {
+		return nil, err
+	}
+	return toServiceAccountDTO(account), nil
+}
+
+// RotateSecret adds a new client secret to a service account. The previous secrets keep
+// working for the configured grace period so callers can switch over without downtime.
+func (s *service) RotateSecret(id uint64) (*service_account_dto.ServiceAccountSecretDTO, error) {
+	account, err := s.getServiceAccount(id)
+	if err != nil {
+		return nil, err
+	}
+
+	clientSecret, err := util.GenerateRandomToken(32)
+	if err != nil {
+		s.logger.Error("Failed to generate client secret", zap.Error(err))
+		return nil, err
+	}
+
+	expiresAt := time.Now().Add(time.Duration(s.config.Auth.ServiceAccount.SecretGraceMinutes) * time.Minute)
+	if err := s.serviceAccountRepo.ExpireSecrets(account.ID, expiresAt); err != nil {
+		s.logger.Error("Failed to expire service account secrets", zap.Uint64("service_account_id", account.ID), zap.Error(err))
+		return nil, err
+	}
+
+	secret := models.ServiceAccountSecret{
+		ServiceAccountID: account.ID,
+		SecretHash:       util.HashToken(clientSecret),
+	}
+	if err := s.serviceAccountRepo.AddSecret(&secret); err != nil {
+		s.logger.Error("Failed to save service account secret", zap.Uint64("service_account_id", account.ID), zap.Error(err))
+		return nil, err
+	}
+
+	s.logger.Info("Service account secret rotated",
+		zap.Uint64("service_account_id", account.ID),
+		zap.Time("previous_secrets_expire_at", expiresAt))
+	return &service_account_dto.ServiceAccountSecretDTO{
+		ClientID:                account.ClientID,
+		ClientSecret:            clientSecret,
+		PreviousSecretsExpireAt: expiresAt,
+	}, nil
+}
+
+// UpdateRoles replaces the roles of a service account, they apply to the tokens issued before too
+func (s *service) UpdateRoles(callerId uint64, id uint64, dto *service_account_dto.UpdateServiceAccountRolesDTO) (*service_account_dto.ServiceAccountDTO, error) {
+	account, err := s.getServiceAccount(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkGrantableRoles(callerId, dto.RoleIDs); err != nil {
+		return nil, err
+	}
+
+	found, err := s.serviceAccountRepo.ReplaceRoles(account, dto.RoleIDs)
+	if err != nil {
+		s.logger.Error("Failed to replace service account roles", zap.Uint64("service_account_id", account.ID), zap.Error(err))
+		return nil, err
+	}
+	if !found {
+		return nil, ErrRoleNotFound
+	}
+	s.permissionCache.InvalidateServiceAccount(account.ID)
+
+	s.logger.Info("Service account roles updated",
+		zap.Uint64("service_account_id", account.ID),
+		zap.Uint64s("role_ids", dto.RoleIDs))
+	return toServiceAccountDTO(account), nil
+}
+
+// Delete deletes a service account, its tokens lose every permission at once and stop working once they expire
+func (s *service) Delete(id uint64) error {
+	deleted, err := s.serviceAccountRepo.Delete(id)
+	if err != nil {
+		s.logger.Error("Failed to delete service account", zap.Uint64("service_account_id", id), zap.Error(err))
+		return err
+	}
+	if !deleted {
+		return ErrServiceAccountNotFound
+	}
+	s.permissionCache.InvalidateServiceAccount(id)
+
+	s.logger.Info("Service account deleted", zap.Uint64("service_account_id", id))
+	return nil
+}
+
+// checkGrantableRoles fails with ErrRoleNotGrantable when one of the roles, or a role it inherits
+// from, holds a permission the caller does not have. The client secret would otherwise let the
+// caller act with more permissions than their own roles grant.
+func (s *service) checkGrantableRoles(callerId uint64, roleIDs []uint64) error {
+	if len(roleIDs) == 0 {
+		return nil
+	}
+
+	caller, err := s.userRepo.GetByIDWithPermissions(callerId)
+	if err != nil {
+		s.logger.Error("Failed to fetch user permissions", zap.Uint64("user_id", callerId), zap.Error(err))
+		return err
+	}
+	if caller == nil {
+		return ErrRoleNotGrantable
+	}
+
+	for _, roleID := range roleIDs {
+		role, err := s.roleRepo.GetByID(roleID)
+		if err != nil {
+			s.logger.Error("Failed to fetch role", zap.Uint64("role_id", roleID), zap.Error(err))
+			return err
+		}
+		if role == nil {
+			return ErrRoleNotFound
+		}
+		for _, permission := range role.EffectivePermissions() {
+			if !caller.HasPermission(permission.ResourceName, permission.Action) {
+				s.logger.Warn("Refused to grant a role exceeding the caller permissions",
+					zap.Uint64("user_id", callerId),
+					zap.Uint64("role_id", roleID),
+					zap.String("permission", permission.ResourceName+":"+permission.Action))
+				return ErrRoleNotGrantable
+			}
+		}
+	}
+	return nil
+}
+
+// getServiceAccount fetches a service account, failing with ErrServiceAccountNotFound when it does not exist
+func (s *service) getServiceAccount(id uint64) (*models.ServiceAccount, error) {
+	account, err := s.serviceAccountRepo.GetByID(id)
+	if err != nil {
+		s.logger.Error("Failed to fetch service account", zap.Uint64("service_account_id", id), zap.Error(err))
+		return nil, err
+	}
+	if account == nil {
+		return nil, ErrServiceAccountNotFound
+	}
+	return account, nil
+}
+
+// toServiceAccountDTO converts a service account to its response representation
+func toServiceAccountDTO(account *models.ServiceAccount) *service_account_dto.ServiceAccountDTO {
+	roles := make([]string, 0, len(account.Roles))
+	for _, role := range account.Roles {
+		roles = append(roles, role.Name)
+	}
+
+	return &service_account_dto.ServiceAccountDTO{
+		ID:          account.ID,
+		Name:        account.Name,
+		Description: account.Description,
+		ClientID:    account.ClientID,
+		Active:      account.Active,
+		Roles:       roles,
+		CreatedAt:   account.CreatedAt,
+	}
+}
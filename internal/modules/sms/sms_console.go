@@ -0,0 +1,51 @@
+package sms
+
+import (
+	"context"
+	"fmt"
+	"modular-fx-fiber/internal/shared/logger"
+	"os"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// consoleSender writes messages to the log, or appends them to a file, instead of sending them.
+// It is meant for local development, where codes are read from the output.
+type consoleSender struct {
+	logger *logger.ZapLogger
+	file   string
+	mu     sync.Mutex
+}
+
+func newConsoleSender(l *logger.ZapLogger, file string) SMSSender {
+	return &consoleSender{
+		logger: l,
+		file:   file,
+	}
+}
+
+// Send logs the message, or appends it to the file when one is configured
+func (s *consoleSender) Send(_ context.Context, to string, message string) error {
+	if s.file == "" {
+		s.logger.Info("SMS message", zap.String("to", to), zap.String("message", message))
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f, err := os.OpenFile(s.file, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open sms file: %w", err)
+	}
+	defer f.Close()
+
+	if _, err := fmt.Fprintf(f, "%s\t%s\t%s\n", time.Now().Format(time.RFC3339), to, message); err != nil {
+		return fmt.Errorf("failed to write sms file: %w", err)
+	}
+
+	s.logger.Debug("SMS message written to file", zap.String("to", to), zap.String("file", s.file))
+	return nil
+}
@@ -0,0 +1,10 @@
+package sms
+
+// Messages are written without diacritics so they fit in a single GSM-7 encoded SMS
+const (
+	// PhoneVerificationMessage is the text of the SMS verifying a phone number, with the code and its expiry in minutes
+	PhoneVerificationMessage = "Ma xac minh so dien thoai cua ban la %s. Ma co hieu luc trong %d phut. Khong chia se ma nay voi bat ky ai."
+
+	// MFACodeMessage is the text of the SMS carrying a login code, with the code and its expiry in minutes
+	MFACodeMessage = "Ma dang nhap cua ban la %s. Ma co hieu luc trong %d phut. Neu ban khong dang nhap, hay doi mat khau ngay."
+)
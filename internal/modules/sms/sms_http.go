@@ -0,0 +1,92 @@
+package sms
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"modular-fx-fiber/internal/core/config"
+	"modular-fx-fiber/internal/shared/logger"
+	"net/http"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// httpSender posts messages as JSON to the API of an SMS gateway.
+// Gateways with another request format are plugged in with a small proxy or another SMSSender.
+type httpSender struct {
+	logger *logger.ZapLogger
+	client *http.Client
+	url    string
+	apiKey string
+	from   string
+}
+
+// httpMessage is the request body sent to the gateway
+type httpMessage struct {
+	From    string `json:"from,omitempty"`
+	To      string `json:"to"`
+	Message string `json:"message"`
+}
+
+func newHTTPSender(l *logger.ZapLogger, c config.SMSHTTPConfig) (SMSSender, error) {
+	if c.URL == "" {
+		return nil, errors.New("sms.http.url is required with the http sms provider")
+	}
+
+	timeout := time.Duration(c.TimeoutSeconds) * time.Second
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+
+	return &httpSender{
+		logger: l,
+		client: &http.Client{Timeout: timeout},
+		url:    c.URL,
+		apiKey: c.APIKey,
+		from:   c.From,
+	}, nil
+}
+
+// Send posts the message to the gateway, any status other than 2xx is an error
+func (s *httpSender) Send(ctx context.Context, to string, message string) error {
+	body, err := json.Marshal(&httpMessage{
+		From:    s.from,
+		To:      to,
+		Message: message,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if s.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+s.apiKey)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		s.logger.Error("Failed to reach sms gateway", zap.String("to", to), zap.Error(err))
+		return fmt.Errorf("failed to send sms: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		s.logger.Error("SMS gateway rejected message",
+			zap.String("to", to),
+			zap.Int("status", resp.StatusCode),
+			zap.String("body", string(respBody)))
+		return fmt.Errorf("sms gateway responded with status %d", resp.StatusCode)
+	}
+
+	s.logger.Info("SMS sent successfully", zap.String("to", to))
+	return nil
+}
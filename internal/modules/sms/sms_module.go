@@ -0,0 +1,7 @@
+package sms
+
+import "go.uber.org/fx"
+
+var Module = fx.Options(
+	fx.Provide(NewSMSSender),
+)
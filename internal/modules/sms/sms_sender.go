@@ -0,0 +1,31 @@
+package sms
+
+import (
+	"context"
+	"fmt"
+	"modular-fx-fiber/internal/core/config"
+	"modular-fx-fiber/internal/shared/logger"
+)
+
+// Providers of the SMS sender
+const (
+	PROVIDER_CONSOLE = "console"
+	PROVIDER_HTTP    = "http"
+)
+
+// SMSSender sends text messages to phone numbers in E.164 format
+type SMSSender interface {
+	Send(ctx context.Context, to string, message string) error
+}
+
+// NewSMSSender creates the sender of the configured provider
+func NewSMSSender(l *logger.ZapLogger, c *config.Config) (SMSSender, error) {
+	switch c.SMS.Provider {
+	case PROVIDER_CONSOLE, "":
+		return newConsoleSender(l, c.SMS.ConsoleFile), nil
+	case PROVIDER_HTTP:
+		return newHTTPSender(l, c.SMS.HTTP)
+	default:
+		return nil, fmt.Errorf("unknown sms provider %q", c.SMS.Provider)
+	}
+}
@@ -0,0 +1,115 @@
+package user
+
+import (
+	"modular-fx-fiber/internal/shared/dto/user_dto"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// RequestEmailChange handles requests to change the current user's email address
+// @Summary Request email change
+// @Description Start changing the current user's email address. A confirmation link is sent to the new address and a notice with an undo link to the old one, the address only changes once the link is opened
+// @Tags users
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param email body user_dto.ChangeEmailDTO true "New email and current password"
+// @Success 200 {object} user_dto.ChangeEmailSuccessResponseDTO
+// @Router /users/me/email [post]
+func (h *handlers) RequestEmailChange(c *fiber.Ctx) error {
+	changeEmailDto := &user_dto.ChangeEmailDTO{}
+
+	// Parse request body
+	if err := c.BodyParser(changeEmailDto); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	// Validate request body
+	errs := h.validator.Validate(changeEmailDto)
+	if errs != nil {
+		err := h.validator.ParseErrorToString(errs)
+		return fiber.NewError(fiber.StatusBadRequest, err)
+	}
+
+	userId := c.Locals("user_id").(uint64)
+
+	// Request email change
+	if err := h.service.RequestEmailChange(userId, changeEmailDto); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	// Return response
+	return c.JSON(&user_dto.ChangeEmailSuccessResponseDTO{
+		Success: true,
+	})
+}
+
+// ConfirmEmailChange handles confirming an email change from the new address
+// @Summary Confirm email change
+// @Description Apply an email change with the token sent to the new address and log out all sessions
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param token body user_dto.EmailChangeTokenDTO true "Confirmation token"
+// @Success 200 {object} user_dto.ConfirmEmailChangeSuccessResponseDTO
+// @Router /users/email/confirm [post]
+func (h *handlers) ConfirmEmailChange(c *fiber.Ctx) error {
+	tokenDto := &user_dto.EmailChangeTokenDTO{}
+
+	// Parse request body
+	if err := c.BodyParser(tokenDto); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	// Validate request body
+	errs := h.validator.Validate(tokenDto)
+	if errs != nil {
+		err := h.validator.ParseErrorToString(errs)
+		return fiber.NewError(fiber.StatusBadRequest, err)
+	}
+
+	// Confirm email change
+	if err := h.service.ConfirmEmailChange(tokenDto); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	// Return response
+	return c.JSON(&user_dto.ConfirmEmailChangeSuccessResponseDTO{
+		Success: true,
+	})
+}
+
+// UndoEmailChange handles undoing an email change from the old address
+// @Summary Undo email change
+// @Description Cancel a pending email change, or restore the old address of a confirmed one, with the token sent to the old address. All sessions are logged out when the address is restored
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param token body user_dto.EmailChangeTokenDTO true "Undo token"
+// @Success 200 {object} user_dto.UndoEmailChangeSuccessResponseDTO
+// @Router /users/email/undo [post]
+func (h *handlers) UndoEmailChange(c *fiber.Ctx) error {
+	tokenDto := &user_dto.EmailChangeTokenDTO{}
+
+	// Parse request body
+	if err := c.BodyParser(tokenDto); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	// Validate request body
+	errs := h.validator.Validate(tokenDto)
+	if errs != nil {
+		err := h.validator.ParseErrorToString(errs)
+		return fiber.NewError(fiber.StatusBadRequest, err)
+	}
+
+	// Undo email change
+	if err := h.service.UndoEmailChange(tokenDto); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	// Return response
+	return c.JSON(&user_dto.UndoEmailChangeSuccessResponseDTO{
+		Success: true,
+	})
+}
@@ -0,0 +1,272 @@
+package user
+
+import (
+	"errors"
+	"fmt"
+	"modular-fx-fiber/internal/modules/mailer"
+	"modular-fx-fiber/internal/shared/dto/user_dto"
+	"modular-fx-fiber/internal/shared/models"
+	"modular-fx-fiber/internal/shared/util"
+	"net/url"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+var (
+	ErrSameEmail                = errors.New("new email is the same as the current one")
+	ErrInvalidEmailChangeToken  = errors.New("invalid or expired email change link")
+	ErrEmailChangeNoLongerValid = errors.New("the email address of the account changed since the request")
+)
+
+// RequestEmailChange starts a change of the user's email address. Nothing changes until the new
+// address confirms with the link sent to it, the old address gets a notice with a link to undo.
+func (s *service) RequestEmailChange(userID uint64, dto *user_dto.ChangeEmailDTO) error {
+	u, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		s.logger.Error("Failed to fetch user by ID", zap.Uint64("user_id", userID), zap.Error(err))
+		return err
+	}
+	if u == nil {
+		return ErrUserNotFound
+	}
+
+	// Verify current password
+	match, _, err := s.passwordHasher.Verify(u.Password, dto.CurrentPassword)
+	if err != nil {
+		s.logger.Error("Failed to verify current password", zap.Uint64("user_id", userID), zap.Error(err))
+		return err
+	}
+	if !match {
+		s.logger.Info("Failed current password verification", zap.Uint64("user_id", userID))
+		return ErrInvalidCurrentPassword
+	}
+
+	if strings.EqualFold(dto.NewEmail, u.Email) {
+		return ErrSameEmail
+	}
+
+	// Checked again when the change is confirmed
+	existingUser, err := s.userRepo.GetByEmail(dto.NewEmail)
+	if err != nil {
+		return err
+	}
+	if existingUser != nil {
+		return ErrEmailAlreadyExists
+	}
+
+	confirmToken, err := util.GenerateRandomToken(32)
+	if err != nil {
+		s.logger.Error("Failed to generate email change token", zap.Error(err))
+		return err
+	}
+	undoToken, err := util.GenerateRandomToken(32)
+	if err != nil {
+		s.logger.Error("Failed to generate email change token", zap.Error(err))
+		return err
+	}
+
+	emailChangeConfig := s.config.Auth.EmailChange
+	now := time.Now()
+	request := models.EmailChangeRequest{
+		UserID:           u.ID,
+		OldEmail:         u.Email,
+		NewEmail:         dto.NewEmail,
+		ConfirmTokenHash: util.HashToken(confirmToken),
+		UndoTokenHash:    util.HashToken(undoToken),
+		ExpiresAt:        now.Add(time.Duration(emailChangeConfig.ConfirmExpiryMinutes) * time.Minute),
+		UndoExpiresAt:    now.AddDate(0, 0, emailChangeConfig.UndoExpiryDays),
+	}
+	if err := s.emailChangeRepo.SaveRequest(&request); err != nil {
+		s.logger.Error("Failed to save email change request", zap.Uint64("user_id", u.ID), zap.Error(err))
+		return err
+	}
+
+	go func() {
+		if err := s.sendEmailChangeEmails(u, &request, confirmToken, undoToken); err != nil {
+			s.logger.Error("Failed to send email change emails",
+				zap.Uint64("user_id", u.ID),
+				zap.Error(err))
+		}
+	}()
+
+	s.logger.Info("Email change requested", zap.Uint64("user_id", u.ID), zap.Uint64("request_id", request.ID))
+	return nil
+}
+
+// ConfirmEmailChange applies an email change confirmed from the new address and logs out every session
+func (s *service) ConfirmEmailChange(dto *user_dto.EmailChangeTokenDTO) error {
+	request, err := s.emailChangeRepo.GetPendingRequest(util.HashToken(dto.Token))
+	if err != nil {
+		s.logger.Error("Failed to retrieve email change request", zap.Error(err))
+		return err
+	}
+	if request == nil {
+		s.logger.Warn("Invalid or expired email change confirmation")
+		return ErrInvalidEmailChangeToken
+	}
+
+	u, err := s.userRepo.GetByID(request.UserID)
+	if err != nil {
+		s.logger.Error("Failed to fetch user by ID", zap.Uint64("user_id", request.UserID), zap.Error(err))
+		return err
+	}
+	if u == nil {
+		return ErrInvalidEmailChangeToken
+	}
+	if u.Email != request.OldEmail {
+		return ErrEmailChangeNoLongerValid
+	}
+
+	// The address may have been taken since the request was made
+	existingUser, err := s.userRepo.GetByEmail(request.NewEmail)
+	if err != nil {
+		return err
+	}
+	if existingUser != nil {
+		return ErrEmailAlreadyExists
+	}
+
+	// Consume the request before changing anything so it can only be applied once
+	confirmed, err := s.emailChangeRepo.MarkRequestConfirmed(request.ID)
+	if err != nil {
+		s.logger.Error("Failed to mark email change request as confirmed", zap.Uint64("request_id", request.ID), zap.Error(err))
+		return err
+	}
+	if !confirmed {
+		return ErrInvalidEmailChangeToken
+	}
+
+	// Opening the link proves the user owns the new inbox
+	u.Email = request.NewEmail
+	u.EmailVerified = true
+	if err := s.userRepo.Update(u); err != nil {
+		s.logger.Error("Failed to update user email", zap.Uint64("user_id", u.ID), zap.Error(err))
+		return err
+	}
+
+	if err := s.revokeAllSessions(u.ID); err != nil {
+		return err
+	}
+
+	s.logger.Info("User email changed", zap.Uint64("user_id", u.ID), zap.Uint64("request_id", request.ID))
+	return nil
+}
+
+// UndoEmailChange cancels a pending email change, or restores the old address of a confirmed one,
+// from the link sent to the old address. Every session is logged out when the address is restored.
+func (s *service) UndoEmailChange(dto *user_dto.EmailChangeTokenDTO) error {
+	request, err := s.emailChangeRepo.GetUndoableRequest(util.HashToken(dto.Token))
+	if err != nil {
+		s.logger.Error("Failed to retrieve email change request", zap.Error(err))
+		return err
+	}
+	if request == nil {
+		s.logger.Warn("Invalid or expired email change undo")
+		return ErrInvalidEmailChangeToken
+	}
+
+	cancelled, err := s.emailChangeRepo.MarkRequestCancelled(request.ID)
+	if err != nil {
+		s.logger.Error("Failed to cancel email change request", zap.Uint64("request_id", request.ID), zap.Error(err))
+		return err
+	}
+	if !cancelled {
+		return ErrInvalidEmailChangeToken
+	}
+
+	// A request that was not confirmed yet changed nothing
+	if request.ConfirmedAt == nil {
+		s.logger.Info("Email change cancelled", zap.Uint64("user_id", request.UserID), zap.Uint64("request_id", request.ID))
+		return nil
+	}
+
+	u, err := s.userRepo.GetByID(request.UserID)
+	if err != nil {
+		s.logger.Error("Failed to fetch user by ID", zap.Uint64("user_id", request.UserID), zap.Error(err))
+		return err
+	}
+	if u == nil {
+		return ErrInvalidEmailChangeToken
+	}
+
+	// Restore the old address, unless it was taken by another account in the meantime
+	if u.Email != request.OldEmail {
+		existingUser, err := s.userRepo.GetByEmail(request.OldEmail)
+		if err != nil {
+			return err
+		}
+		if existingUser != nil {
+			return ErrEmailAlreadyExists
+		}
+
+		u.Email = request.OldEmail
+		u.EmailVerified = true
+		if err := s.userRepo.Update(u); err != nil {
+			s.logger.Error("Failed to restore user email", zap.Uint64("user_id", u.ID), zap.Error(err))
+			return err
+		}
+	}
+
+	if err := s.revokeAllSessions(u.ID); err != nil {
+		return err
+	}
+
+	s.logger.Warn("Email change undone", zap.Uint64("user_id", u.ID), zap.Uint64("request_id", request.ID))
+	return nil
+}
+
+// revokeAllSessions logs out every session of the user and invalidates issued access tokens
+func (s *service) revokeAllSessions(userID uint64) error {
+	if err := s.refreshTokenRepo.DeleteUserRefreshTokens(userID); err != nil {
+		s.logger.Error("Failed to delete refresh tokens for user", zap.Uint64("user_id", userID), zap.Error(err))
+		return err
+	}
+	if err := s.revocation.RevokeUserTokens(userID, time.Now()); err != nil {
+		s.logger.Error("Failed to revoke access tokens for user", zap.Uint64("user_id", userID), zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// sendEmailChangeEmails sends the confirmation link to the new address and the notice with the undo link to the old one
+func (s *service) sendEmailChangeEmails(u *models.User, request *models.EmailChangeRequest, confirmToken string, undoToken string) error {
+	emailChangeConfig := s.config.Auth.EmailChange
+
+	confirmData, err := util.StructToMap(&mailer.EmailChangeConfirmData{
+		Name:             u.FullName(),
+		NewEmail:         request.NewEmail,
+		Link:             fmt.Sprintf("%s/email-change/confirm?token=%s", s.config.App.FrontendURL, url.QueryEscape(confirmToken)),
+		ExpiresInMinutes: emailChangeConfig.ConfirmExpiryMinutes,
+	})
+	if err != nil {
+		s.logger.Error("[sendEmailChangeEmails] Failed to convert struct to map", zap.Error(err))
+		return err
+	}
+	if err := s.gmailMailer.SendTemplatedEmail(
+		request.NewEmail,
+		mailer.EmailChangeConfirmSubject,
+		mailer.EmailChangeConfirmTemplate,
+		confirmData,
+	); err != nil {
+		return err
+	}
+
+	noticeData, err := util.StructToMap(&mailer.EmailChangeNoticeData{
+		Name:           u.FullName(),
+		NewEmail:       request.NewEmail,
+		UndoLink:       fmt.Sprintf("%s/email-change/undo?token=%s", s.config.App.FrontendURL, url.QueryEscape(undoToken)),
+		UndoExpiryDays: emailChangeConfig.UndoExpiryDays,
+	})
+	if err != nil {
+		s.logger.Error("[sendEmailChangeEmails] Failed to convert struct to map", zap.Error(err))
+		return err
+	}
+	return s.gmailMailer.SendTemplatedEmail(
+		request.OldEmail,
+		mailer.EmailChangeNoticeSubject,
+		mailer.EmailChangeNoticeTemplate,
+		noticeData,
+	)
+}
@@ -1,6 +1,7 @@
 package user
 
 import (
+	"modular-fx-fiber/internal/core/server"
 	"modular-fx-fiber/internal/shared/dto/user_dto"
 	"modular-fx-fiber/internal/shared/logger"
 	"modular-fx-fiber/internal/shared/validator"
@@ -15,6 +16,13 @@ type (
 		Create(c *fiber.Ctx) error
 		ListUsers(c *fiber.Ctx) error
 		GetMe(c *fiber.Ctx) error
+		ChangePassword(c *fiber.Ctx) error
+		Unlock(c *fiber.Ctx) error
+		Deactivate(c *fiber.Ctx) error
+		Activate(c *fiber.Ctx) error
+		RequestEmailChange(c *fiber.Ctx) error
+		ConfirmEmailChange(c *fiber.Ctx) error
+		UndoEmailChange(c *fiber.Ctx) error
 	}
 
 	handlers struct {
@@ -41,6 +49,9 @@ func NewHandlers(l *logger.ZapLogger, v *validator.Validator, s Service) Handler
 // @Produce json
 // @Param   user body user_dto.CreateUserDTO true "User details"
 // @Success 201 {object} user_dto.CreateUserSuccessResponseDTO
+// @Failure 400 {object} password.PolicyErrorResponse
+// @Security BearerAuth
+// @Security ApiKeyAuth
 // @Router /users [post]
 func (h *handlers) Create(c *fiber.Ctx) error {
 	createUserDto := &user_dto.CreateUserDTO{}
@@ -61,7 +72,7 @@ func (h *handlers) Create(c *fiber.Ctx) error {
 	// Create user
 	user, err := h.service.CreateUser(createUserDto)
 	if err != nil {
-		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+		return server.BadRequest(err)
 	}
 
 	// Response with created user
@@ -80,6 +91,8 @@ func (h *handlers) Create(c *fiber.Ctx) error {
 // @Param page query int false "Page number"
 // @Param page_size query int false "Page size"
 // @Success 200 {object} ListUsersSuccessResponseDTO
+// @Security BearerAuth
+// @Security ApiKeyAuth
 // @Router /users [get]
 func (h *handlers) ListUsers(c *fiber.Ctx) error {
 	page := c.Query("page", "1")
@@ -125,9 +138,15 @@ func (h *handlers) ListUsers(c *fiber.Ctx) error {
 // @Accept json
 // @Produce json
 // @Success 200 {object} GetMeSuccessResponseDTO
+// @Security BearerAuth
+// @Security ApiKeyAuth
 // @Router /users/me [get]
 func (h *handlers) GetMe(c *fiber.Ctx) error {
-	userId := c.Locals("user_id").(uint64)
+	// Service accounts are not users
+	userId, ok := c.Locals("user_id").(uint64)
+	if !ok {
+		return fiber.NewError(fiber.StatusForbidden, "route is only available to users")
+	}
 
 	user, err := h.service.GetMe(userId)
 	if err != nil {
@@ -139,3 +158,129 @@ func (h *handlers) GetMe(c *fiber.Ctx) error {
 		Data:    user,
 	})
 }
+
+// ChangePassword handles changing the current user's password
+// @Summary Change password
+// @Description Change the current user's password and log out all other sessions. Every issued access token is revoked, including the one of this request: the current session keeps its refresh token and must call POST /auth/refresh-token before its next request
+// @Tags users
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param password body user_dto.ChangePasswordDTO true "Current and new password"
+// @Success 200 {object} user_dto.ChangePasswordSuccessResponseDTO
+// @Failure 400 {object} password.PolicyErrorResponse
+// @Router /users/me/password [put]
+func (h *handlers) ChangePassword(c *fiber.Ctx) error {
+	changePasswordDto := &user_dto.ChangePasswordDTO{}
+
+	// Parse request body
+	if err := c.BodyParser(changePasswordDto); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	// Validate request body
+	errs := h.validator.Validate(changePasswordDto)
+	if errs != nil {
+		err := h.validator.ParseErrorToString(errs)
+		return fiber.NewError(fiber.StatusBadRequest, err)
+	}
+
+	userId := c.Locals("user_id").(uint64)
+	sessionId := c.Locals("session_id").(string)
+
+	// Change password
+	if err := h.service.ChangePassword(userId, sessionId, changePasswordDto); err != nil {
+		return server.BadRequest(err)
+	}
+
+	return c.JSON(&user_dto.ChangePasswordSuccessResponseDTO{
+		Success: true,
+	})
+}
+
+// Unlock handles lifting the login lockout of a user
+// @Summary Unlock user
+// @Description Clear the failed login attempts and lockout of a user before the lockout expires
+// @Tags users
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param id path int true "User ID"
+// @Success 200 {object} user_dto.UnlockUserSuccessResponseDTO
+// @Router /users/{id}/unlock [post]
+func (h *handlers) Unlock(c *fiber.Ctx) error {
+	userId, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid user id")
+	}
+
+	if err := h.service.UnlockUser(userId); err != nil {
+		if err == ErrUserNotFound {
+			return fiber.NewError(fiber.StatusNotFound, err.Error())
+		}
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	return c.JSON(&user_dto.UnlockUserSuccessResponseDTO{
+		Success: true,
+	})
+}
+
+// Deactivate handles deactivating a user
+// @Summary Deactivate user
+// @Description Keep a user from logging in, their sessions are logged out and every access token they were issued is revoked
+// @Tags users
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param id path int true "User ID"
+// @Success 200 {object} user_dto.DeactivateUserSuccessResponseDTO
+// @Router /users/{id}/deactivate [post]
+func (h *handlers) Deactivate(c *fiber.Ctx) error {
+	userId, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid user id")
+	}
+
+	if err := h.service.DeactivateUser(userId); err != nil {
+		if err == ErrUserNotFound {
+			return fiber.NewError(fiber.StatusNotFound, err.Error())
+		}
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	return c.JSON(&user_dto.DeactivateUserSuccessResponseDTO{
+		Success: true,
+	})
+}
+
+// Activate handles activating a user
+// @Summary Activate user
+// @Description Let a deactivated user log in again
+// @Tags users
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param id path int true "User ID"
+// @Success 200 {object} user_dto.ActivateUserSuccessResponseDTO
+// @Router /users/{id}/activate [post]
+func (h *handlers) Activate(c *fiber.Ctx) error {
+	userId, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid user id")
+	}
+
+	if err := h.service.ActivateUser(userId); err != nil {
+		if err == ErrUserNotFound {
+			return fiber.NewError(fiber.StatusNotFound, err.Error())
+		}
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	return c.JSON(&user_dto.ActivateUserSuccessResponseDTO{
+		Success: true,
+	})
+}
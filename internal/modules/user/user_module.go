@@ -1,9 +1,19 @@
 package user
 
 import (
+	"modular-fx-fiber/internal/shared/models"
+	"modular-fx-fiber/internal/shared/rbac"
+
 	"go.uber.org/fx"
 )
 
+// Permissions declares the permissions checked by the user routes
+var Permissions = rbac.Declare(
+	rbac.Permission{ResourceName: models.PERMISSION_RESOURCE_USERS, Action: models.PERMISSION_ACTION_READ, Description: "List users"},
+	rbac.Permission{ResourceName: models.PERMISSION_RESOURCE_USERS, Action: models.PERMISSION_ACTION_CREATE, Description: "Create users"},
+	rbac.Permission{ResourceName: models.PERMISSION_RESOURCE_USERS, Action: models.PERMISSION_ACTION_UPDATE, Description: "Unlock, deactivate and activate users"},
+)
+
 // Module exports the user module dependencies
 var Module = fx.Options(
 	fx.Provide(
@@ -11,5 +21,6 @@ var Module = fx.Options(
 		NewHandlers,
 		NewService,
 	),
+	Permissions,
 	fx.Invoke(Register),
 )
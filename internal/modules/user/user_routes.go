@@ -3,6 +3,7 @@ package user
 import (
 	"modular-fx-fiber/internal/core/server"
 	"modular-fx-fiber/internal/shared/middleware"
+	"modular-fx-fiber/internal/shared/models"
 )
 
 type (
@@ -21,8 +22,25 @@ func NewRoutes(h *Handlers) Routes {
 }
 
 func Register(s server.Server, m middleware.Middleware, h Handlers) {
-	group := s.GetApp().Group("api/users", m.JWT())
-	group.Get("/", h.ListUsers)
-	group.Post("/", h.Create)
-	group.Get("/me", h.GetMe)
+	group := s.GetApp().Group("api/users")
+
+	// Routes scripts may call with an API key of the matching scope
+	group.Get("/me", m.Authenticate(), m.RequireScope(models.API_KEY_SCOPE_USERS_READ), h.GetMe)
+	// Admin routes, the caller's roles must also grant the permission
+	group.Get("/", m.Authenticate(), m.RequireScope(models.API_KEY_SCOPE_USERS_READ),
+		m.RequirePermission(models.PERMISSION_RESOURCE_USERS, models.PERMISSION_ACTION_READ), h.ListUsers)
+	group.Post("/", m.Authenticate(), m.RequireScope(models.API_KEY_SCOPE_USERS_WRITE),
+		m.RequirePermission(models.PERMISSION_RESOURCE_USERS, models.PERMISSION_ACTION_CREATE), h.Create)
+	group.Post("/:id/unlock", m.Authenticate(), m.RequireScope(models.API_KEY_SCOPE_USERS_WRITE),
+		m.RequirePermission(models.PERMISSION_RESOURCE_USERS, models.PERMISSION_ACTION_UPDATE), h.Unlock)
+	group.Post("/:id/deactivate", m.Authenticate(), m.RequireScope(models.API_KEY_SCOPE_USERS_WRITE),
+		m.RequirePermission(models.PERMISSION_RESOURCE_USERS, models.PERMISSION_ACTION_UPDATE), h.Deactivate)
+	group.Post("/:id/activate", m.Authenticate(), m.RequireScope(models.API_KEY_SCOPE_USERS_WRITE),
+		m.RequirePermission(models.PERMISSION_RESOURCE_USERS, models.PERMISSION_ACTION_UPDATE), h.Activate)
+	// Routes that require a logged in session
+	group.Put("/me/password", m.JWT(), h.ChangePassword)
+	group.Post("/me/email", m.JWT(), h.RequestEmailChange)
+	// Routes opened from the links of the email change emails
+	group.Post("/email/confirm", h.ConfirmEmailChange)
+	group.Post("/email/undo", h.UndoEmailChange)
 }
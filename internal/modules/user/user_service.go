@@ -3,15 +3,23 @@ package user
 import (
 	"errors"
 	"go.uber.org/zap"
-	"golang.org/x/crypto/bcrypt"
+	"modular-fx-fiber/internal/core/config"
+	"modular-fx-fiber/internal/modules/mailer"
 	"modular-fx-fiber/internal/shared/dto/user_dto"
 	"modular-fx-fiber/internal/shared/logger"
 	"modular-fx-fiber/internal/shared/models"
+	"modular-fx-fiber/internal/shared/password"
 	"modular-fx-fiber/internal/shared/repositories"
+	"modular-fx-fiber/internal/shared/revocation"
+	"modular-fx-fiber/internal/shared/util"
+	"strings"
+	"time"
 )
 
 var (
-	ErrEmailAlreadyExists = errors.New("email already exists")
+	ErrEmailAlreadyExists     = errors.New("email already exists")
+	ErrUserNotFound           = errors.New("user not found")
+	ErrInvalidCurrentPassword = errors.New("current password is incorrect")
 )
 
 type (
@@ -19,22 +27,54 @@ type (
 		CreateUser(dto *user_dto.CreateUserDTO) (*models.UserResponseDTO, error)
 		ListUsers(page int, pageSize int) ([]*models.UserResponseDTO, int64, error)
 		GetMe(userID uint64) (*models.UserResponseDTO, error)
+		ChangePassword(userID uint64, sessionID string, dto *user_dto.ChangePasswordDTO) error
+		UnlockUser(userID uint64) error
+		DeactivateUser(userID uint64) error
+		ActivateUser(userID uint64) error
+		RequestEmailChange(userID uint64, dto *user_dto.ChangeEmailDTO) error
+		ConfirmEmailChange(dto *user_dto.EmailChangeTokenDTO) error
+		UndoEmailChange(dto *user_dto.EmailChangeTokenDTO) error
 	}
 
 	service struct {
-		logger   *logger.ZapLogger
-		userRepo repositories.UserRepository
+		config         *config.Config
+		logger         *logger.ZapLogger
+		gmailMailer    mailer.GmailMailer
+		revocation     revocation.Store
+		passwordHasher password.Hasher
+		passwordPolicy password.Policy
+
+		userRepo          repositories.UserRepository
+		refreshTokenRepo  repositories.RefreshTokenRepository
+		loginThrottleRepo repositories.LoginThrottleRepository
+		emailChangeRepo   repositories.EmailChangeRequestRepository
 	}
 )
 
 // NewService creates a new user service
 func NewService(
+	config *config.Config,
 	logger *logger.ZapLogger,
+	gmailMailer mailer.GmailMailer,
+	revocationStore revocation.Store,
+	passwordHasher password.Hasher,
+	passwordPolicy password.Policy,
 	userRepo repositories.UserRepository,
+	refreshTokenRepo repositories.RefreshTokenRepository,
+	loginThrottleRepo repositories.LoginThrottleRepository,
+	emailChangeRepo repositories.EmailChangeRequestRepository,
 ) Service {
 	return &service{
-		logger:   logger,
-		userRepo: userRepo,
+		config:            config,
+		logger:            logger,
+		gmailMailer:       gmailMailer,
+		revocation:        revocationStore,
+		passwordHasher:    passwordHasher,
+		passwordPolicy:    passwordPolicy,
+		userRepo:          userRepo,
+		refreshTokenRepo:  refreshTokenRepo,
+		loginThrottleRepo: loginThrottleRepo,
+		emailChangeRepo:   emailChangeRepo,
 	}
 }
 
@@ -49,8 +89,13 @@ func (s *service) CreateUser(dto *user_dto.CreateUserDTO) (*models.UserResponseD
 		return nil, ErrEmailAlreadyExists
 	}
 
+	// Check password policy
+	if err := s.passwordPolicy.Check(dto.Password, dto.Email, dto.FirstName, dto.LastName); err != nil {
+		return nil, err
+	}
+
 	// Hash password
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(dto.Password), bcrypt.DefaultCost)
+	hashedPassword, err := s.passwordHasher.Hash(dto.Password)
 	if err != nil {
 		return nil, err
 	}
@@ -58,7 +103,7 @@ func (s *service) CreateUser(dto *user_dto.CreateUserDTO) (*models.UserResponseD
 	// Create user
 	user := &models.User{
 		Email:       dto.Email,
-		Password:    string(hashedPassword),
+		Password:    hashedPassword,
 		PhoneNumber: dto.PhoneNumber,
 		FirstName:   dto.FirstName,
 		LastName:    dto.LastName,
@@ -96,7 +141,173 @@ func (s *service) GetMe(userID uint64) (*models.UserResponseDTO, error) {
 	if err != nil {
 		return nil, err
 	}
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
 
 	userResponse := user.ToResponseDTO()
 	return userResponse, nil
 }
+
+// ChangePassword verifies the current password, stores the new one and logs out every session
+// except the caller's. Every access token is revoked, the caller's too: its session keeps the
+// refresh token and has to refresh before the next request.
+func (s *service) ChangePassword(userID uint64, sessionID string, dto *user_dto.ChangePasswordDTO) error {
+	u, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		s.logger.Error("Failed to fetch user by ID", zap.Uint64("user_id", userID), zap.Error(err))
+		return err
+	}
+	if u == nil {
+		return ErrUserNotFound
+	}
+
+	// Verify current password
+	match, _, err := s.passwordHasher.Verify(u.Password, dto.CurrentPassword)
+	if err != nil {
+		s.logger.Error("Failed to verify current password", zap.Uint64("user_id", userID), zap.Error(err))
+		return err
+	}
+	if !match {
+		s.logger.Info("Failed current password verification", zap.Uint64("user_id", userID))
+		return ErrInvalidCurrentPassword
+	}
+
+	// Check password policy
+	if err := s.passwordPolicy.Check(dto.NewPassword, u.Email, u.FirstName, u.LastName); err != nil {
+		return err
+	}
+
+	// Hash new password
+	hashedPassword, err := s.passwordHasher.Hash(dto.NewPassword)
+	if err != nil {
+		return err
+	}
+
+	u.Password = hashedPassword
+	if err := s.userRepo.Update(u); err != nil {
+		s.logger.Error("Failed to update user password", zap.Uint64("user_id", userID), zap.Error(err))
+		return err
+	}
+
+	// Revoke the refresh tokens of all other sessions
+	if err := s.refreshTokenRepo.DeleteUserRefreshTokensExcept(userID, sessionID); err != nil {
+		s.logger.Error("Failed to delete refresh tokens for other sessions",
+			zap.Uint64("user_id", userID),
+			zap.Error(err))
+		return err
+	}
+
+	// Invalidate issued access tokens including the caller's, the current session gets a new one by refreshing
+	if err := s.revocation.RevokeUserTokens(userID, time.Now()); err != nil {
+		s.logger.Error("Failed to revoke access tokens for user",
+			zap.Uint64("user_id", userID),
+			zap.Error(err))
+		return err
+	}
+
+	go func() {
+		// Send password changed notification
+		err := s.sendPasswordChangedEmail(u)
+		if err != nil {
+			s.logger.Error("Failed to send password changed email",
+				zap.String("email", u.Email),
+				zap.Error(err))
+		}
+	}()
+
+	s.logger.Info("User password changed", zap.Uint64("user_id", userID))
+	return nil
+}
+
+// UnlockUser lifts the login lockout of a user before it expires
+func (s *service) UnlockUser(userID uint64) error {
+	u, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		s.logger.Error("Failed to fetch user by ID", zap.Uint64("user_id", userID), zap.Error(err))
+		return err
+	}
+	if u == nil {
+		return ErrUserNotFound
+	}
+
+	if err := s.loginThrottleRepo.DeleteThrottle(models.LOGIN_THROTTLE_ACCOUNT, strings.ToLower(u.Email)); err != nil {
+		s.logger.Error("Failed to clear account login throttle", zap.Uint64("user_id", userID), zap.Error(err))
+		return err
+	}
+
+	s.logger.Info("User unlocked", zap.Uint64("user_id", userID))
+	return nil
+}
+
+// DeactivateUser keeps a user from logging in and logs them out of every session.
+// Their refresh tokens are deleted and every access token issued so far is revoked.
+func (s *service) DeactivateUser(userID uint64) error {
+	u, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		s.logger.Error("Failed to fetch user by ID", zap.Uint64("user_id", userID), zap.Error(err))
+		return err
+	}
+	if u == nil {
+		return ErrUserNotFound
+	}
+
+	u.Status = models.USER_STATUS_INACTIVE
+	if err := s.userRepo.Update(u); err != nil {
+		s.logger.Error("Failed to update user status", zap.Uint64("user_id", userID), zap.Error(err))
+		return err
+	}
+
+	// Revoke all sessions
+	if err := s.refreshTokenRepo.DeleteUserRefreshTokens(userID); err != nil {
+		s.logger.Error("Failed to delete refresh tokens for user", zap.Uint64("user_id", userID), zap.Error(err))
+		return err
+	}
+	if err := s.revocation.RevokeUserTokens(userID, time.Now()); err != nil {
+		s.logger.Error("Failed to revoke access tokens for user", zap.Uint64("user_id", userID), zap.Error(err))
+		return err
+	}
+
+	s.logger.Info("User deactivated", zap.Uint64("user_id", userID))
+	return nil
+}
+
+// ActivateUser lets a deactivated user log in again
+func (s *service) ActivateUser(userID uint64) error {
+	u, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		s.logger.Error("Failed to fetch user by ID", zap.Uint64("user_id", userID), zap.Error(err))
+		return err
+	}
+	if u == nil {
+		return ErrUserNotFound
+	}
+
+	u.Status = models.USER_STATUS_ACTIVE
+	if err := s.userRepo.Update(u); err != nil {
+		s.logger.Error("Failed to update user status", zap.Uint64("user_id", userID), zap.Error(err))
+		return err
+	}
+
+	s.logger.Info("User activated", zap.Uint64("user_id", userID))
+	return nil
+}
+
+// sendPasswordChangedEmail notifies the user that their password was changed
+func (s *service) sendPasswordChangedEmail(u *models.User) error {
+	mailData, err := util.StructToMap(&mailer.PasswordChangedData{
+		Name:      u.FullName(),
+		ChangedAt: time.Now().Format(time.RFC1123),
+	})
+	if err != nil {
+		s.logger.Error("[sendPasswordChangedEmail] Failed to convert struct to map", zap.Error(err))
+		return err
+	}
+
+	return s.gmailMailer.SendTemplatedEmail(
+		u.Email,
+		mailer.PasswordChangedSubject,
+		mailer.PasswordChangedTemplate,
+		mailData,
+	)
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"log"
 	"modular-fx-fiber/internal/core/config"
+	"modular-fx-fiber/internal/shared/models"
 	"os"
 	"time"
 
@@ -55,6 +56,11 @@ func NewDatabase(config *config.Config) (Database, error) {
 		return nil, err
 	}
 
+	// Load role assignments through their join models so soft-deleted ones are ignored
+	if err := setupJoinTables(db); err != nil {
+		return nil, err
+	}
+
 	// Configure connection pool
 	sqlDB, err := db.DB()
 	if err != nil {
@@ -71,6 +77,14 @@ func NewDatabase(config *config.Config) (Database, error) {
 	}, nil
 }
 
+// setupJoinTables registers the join models of many-to-many relationships whose join tables have extra columns
+func setupJoinTables(db *gorm.DB) error {
+	if err := db.SetupJoinTable(&models.User{}, "Roles", &models.UserRole{}); err != nil {
+		return err
+	}
+	return db.SetupJoinTable(&models.Role{}, "Permissions", &models.RolePermission{})
+}
+
 // GetDB returns the GORM database instance
 func (d *database) GetDB() *gorm.DB {
 	return d.DB
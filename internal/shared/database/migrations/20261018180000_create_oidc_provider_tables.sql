@@ -0,0 +1,51 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE oauth_clients ADD COLUMN redirect_uris JSONB NOT NULL DEFAULT '[]';
+ALTER TABLE oauth_clients ADD COLUMN scopes JSONB NOT NULL DEFAULT '[]';
+ALTER TABLE oauth_clients ADD COLUMN is_public BOOLEAN NOT NULL DEFAULT false;
+
+ALTER TABLE refresh_tokens ADD COLUMN client_id VARCHAR(64);
+ALTER TABLE refresh_tokens ADD COLUMN scope VARCHAR(255);
+
+CREATE TABLE oauth_authorization_codes (
+    id BIGSERIAL PRIMARY KEY,
+    code_hash VARCHAR(64) NOT NULL,
+    client_id VARCHAR(64) NOT NULL,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    redirect_uri VARCHAR(512) NOT NULL,
+    scope VARCHAR(255) NOT NULL,
+    nonce VARCHAR(255),
+    code_challenge VARCHAR(128),
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    used_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX idx_oauth_authorization_codes_code_hash ON oauth_authorization_codes(code_hash);
+CREATE INDEX idx_oauth_authorization_codes_user_id ON oauth_authorization_codes(user_id);
+CREATE INDEX idx_oauth_authorization_codes_expires_at ON oauth_authorization_codes(expires_at);
+
+CREATE TABLE oauth_consents (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    client_id VARCHAR(64) NOT NULL,
+    scope VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX idx_oauth_consents_user_id_client_id ON oauth_consents(user_id, client_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS oauth_consents;
+DROP TABLE IF EXISTS oauth_authorization_codes;
+
+ALTER TABLE refresh_tokens DROP COLUMN IF EXISTS scope;
+ALTER TABLE refresh_tokens DROP COLUMN IF EXISTS client_id;
+
+ALTER TABLE oauth_clients DROP COLUMN IF EXISTS is_public;
+ALTER TABLE oauth_clients DROP COLUMN IF EXISTS scopes;
+ALTER TABLE oauth_clients DROP COLUMN IF EXISTS redirect_uris;
+-- +goose StatementEnd
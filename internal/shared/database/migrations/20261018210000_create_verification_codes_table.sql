@@ -0,0 +1,26 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE verification_codes (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    purpose VARCHAR(32) NOT NULL,
+    code_hash VARCHAR(64) NOT NULL,
+    attempts INT NOT NULL DEFAULT 0,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    used_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_verification_codes_user_id_purpose ON verification_codes(user_id, purpose);
+CREATE INDEX idx_verification_codes_expires_at ON verification_codes(expires_at);
+
+-- Codes stored on the user had no expiry, users still unverified can request a new one
+ALTER TABLE users DROP COLUMN verify_email_code;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE users ADD COLUMN verify_email_code VARCHAR(6);
+
+DROP TABLE IF EXISTS verification_codes;
+-- +goose StatementEnd
@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE users ADD COLUMN phone_verified BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE users ADD COLUMN sms_mfa_enabled BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Address a code was sent to, used to rate limit the codes sent to a phone number
+ALTER TABLE verification_codes ADD COLUMN target VARCHAR(255);
+
+CREATE INDEX idx_verification_codes_target_created_at ON verification_codes(target, created_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_verification_codes_target_created_at;
+
+ALTER TABLE verification_codes DROP COLUMN target;
+
+ALTER TABLE users DROP COLUMN sms_mfa_enabled;
+ALTER TABLE users DROP COLUMN phone_verified;
+-- +goose StatementEnd
@@ -0,0 +1,50 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Random user handle given to authenticators, so passkeys do not carry the user ID
+ALTER TABLE users ADD COLUMN webauthn_handle BYTEA;
+
+CREATE UNIQUE INDEX idx_users_webauthn_handle ON users(webauthn_handle);
+
+CREATE TABLE webauthn_credentials (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    credential_id BYTEA NOT NULL,
+    public_key BYTEA NOT NULL,
+    attestation_type VARCHAR(50) NOT NULL DEFAULT '',
+    aaguid BYTEA,
+    sign_count BIGINT NOT NULL DEFAULT 0,
+    clone_warning BOOLEAN NOT NULL DEFAULT FALSE,
+    transports VARCHAR(255) NOT NULL DEFAULT '',
+    user_verified BOOLEAN NOT NULL DEFAULT FALSE,
+    backup_eligible BOOLEAN NOT NULL DEFAULT FALSE,
+    backup_state BOOLEAN NOT NULL DEFAULT FALSE,
+    last_used_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX idx_webauthn_credentials_credential_id ON webauthn_credentials(credential_id);
+CREATE INDEX idx_webauthn_credentials_user_id ON webauthn_credentials(user_id);
+
+CREATE TABLE webauthn_sessions (
+    id BIGSERIAL PRIMARY KEY,
+    session_hash VARCHAR(64) NOT NULL,
+    user_id BIGINT REFERENCES users(id) ON DELETE CASCADE,
+    ceremony VARCHAR(20) NOT NULL,
+    data TEXT NOT NULL,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX idx_webauthn_sessions_session_hash ON webauthn_sessions(session_hash);
+CREATE INDEX idx_webauthn_sessions_expires_at ON webauthn_sessions(expires_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS webauthn_sessions;
+DROP TABLE IF EXISTS webauthn_credentials;
+
+DROP INDEX IF EXISTS idx_users_webauthn_handle;
+ALTER TABLE users DROP COLUMN webauthn_handle;
+-- +goose StatementEnd
@@ -0,0 +1,14 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Roles inherit the permissions of their parent role and its ancestors
+ALTER TABLE roles ADD COLUMN parent_id BIGINT REFERENCES roles(id) ON DELETE SET NULL;
+
+CREATE INDEX idx_roles_parent_id ON roles(parent_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_roles_parent_id;
+
+ALTER TABLE roles DROP COLUMN parent_id;
+-- +goose StatementEnd
@@ -1,28 +1,35 @@
 package auth_dto
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+)
 
 // LoginDTO represents login credentials
 // @Description Login credentials
 type LoginDTO struct {
-	Email    string `json:"email" validate:"required,email" example:"user@example.com"`
-	Password string `json:"password" validate:"required,min=8" example:"secureP@ssw0rd"`
+	Email       string `json:"email" validate:"required,email" example:"user@example.com"`
+	Password    string `json:"password" validate:"required,min=8" example:"secureP@ssw0rd"`
+	DeviceLabel string `json:"device_label,omitempty" validate:"omitempty,max=100" example:"Work laptop"`
 }
 
 // RegisterDTO represents registration data
 // @Description Registration data
 type RegisterDTO struct {
 	Email       string     `json:"email" validate:"required,email" example:"user@example.com"`
-	Password    string     `json:"password" validate:"required,password" example:"secureP@ssw0rd"`
+	Password    string     `json:"password" validate:"required" example:"secureP@ssw0rd"`
 	PhoneNumber *string    `json:"phone_number,omitempty" validate:"omitempty,vn_phone" example:"0912345678"`
 	FirstName   string     `json:"first_name" validate:"required" example:"John"`
 	LastName    string     `json:"last_name" validate:"required" example:"Doe"`
 	DateOfBirth *time.Time `json:"date_of_birth,omitempty" validate:"omitempty,datetime=1990-01-01T00:00:00Z" example:"1990-01-01T00:00:00Z"`
 	Gender      *uint8     `json:"gender,omitempty" validate:"omitempty,oneof=1 2" example:"1"`
+	DeviceLabel string     `json:"device_label,omitempty" validate:"omitempty,max=100" example:"Work laptop"`
 }
 
+// VerifyEmailDTO represents an email verification code
+// @Description Email verification code
 type VerifyEmailDTO struct {
-	Code *string `json:"code" validate:"required,min=6,max=6,alphanum" example:"123456"`
+	Code string `json:"code" validate:"required,len=6,numeric" example:"123456"`
 }
 
 // RefreshTokenDTO represents refresh token request data
@@ -34,3 +41,137 @@ type RefreshTokenDTO struct {
 type LogoutDTO struct {
 	UserId uint64
 }
+
+// ForgotPasswordDTO represents a request for a password reset email
+// @Description Forgot password request data
+type ForgotPasswordDTO struct {
+	Email string `json:"email" validate:"required,email" example:"user@example.com"`
+}
+
+// ResetPasswordDTO represents the data needed to reset a password with a reset token
+// @Description Reset password request data
+type ResetPasswordDTO struct {
+	Token       string `json:"token" validate:"required" example:"Jx3b4G2m0uQ4c1aT9k8n7p6r5s4t3u2v1w0x9y8z7A6"`
+	NewPassword string `json:"new_password" validate:"required" example:"newSecureP@ssw0rd"`
+}
+
+// TOTPCodeDTO represents a TOTP code from an authenticator app
+// @Description TOTP code data
+type TOTPCodeDTO struct {
+	Code string `json:"code" validate:"required,len=6,numeric" example:"123456"`
+}
+
+// MFAVerifyDTO represents the second step of a login with MFA enabled.
+// Either a TOTP code, a code received by SMS or a recovery code must be provided.
+// @Description MFA verification data
+type MFAVerifyDTO struct {
+	ChallengeToken string `json:"challenge_token" validate:"required" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."`
+	Code           string `json:"code,omitempty" validate:"required_without_all=SMSCode RecoveryCode,omitempty,len=6,numeric" example:"123456"`
+	SMSCode        string `json:"sms_code,omitempty" validate:"omitempty,len=6,numeric" example:"123456"`
+	RecoveryCode   string `json:"recovery_code,omitempty" validate:"required_without_all=Code SMSCode" example:"abcde-12345"`
+	DeviceLabel    string `json:"device_label,omitempty" validate:"omitempty,max=100" example:"Work laptop"`
+}
+
+// MFASMSCodeDTO represents a request for a login code by SMS during an MFA challenge
+// @Description MFA SMS code request data
+type MFASMSCodeDTO struct {
+	ChallengeToken string `json:"challenge_token" validate:"required" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."`
+}
+
+// WebAuthnMFABeginDTO represents a request for a WebAuthn challenge during an MFA challenge
+// @Description WebAuthn MFA begin data
+type WebAuthnMFABeginDTO struct {
+	ChallengeToken string `json:"challenge_token" validate:"required" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."`
+}
+
+// WebAuthnMFAFinishDTO represents the answer of an authenticator to a WebAuthn challenge during an MFA challenge
+// @Description WebAuthn MFA finish data
+type WebAuthnMFAFinishDTO struct {
+	ChallengeToken string          `json:"challenge_token" validate:"required" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."`
+	SessionID      string          `json:"session_id" validate:"required" example:"Jx3b4G2m0uQ4c1aT9k8n7p6r5s4t3u2v1w0x9y8z7A6"`
+	Credential     json.RawMessage `json:"credential" swaggertype:"object"` // PublicKeyCredential returned by navigator.credentials.get()
+	DeviceLabel    string          `json:"device_label,omitempty" validate:"omitempty,max=100" example:"Work laptop"`
+}
+
+// DisableSMSMFADTO represents a request to stop using SMS as a second factor
+// @Description Disable SMS MFA data
+type DisableSMSMFADTO struct {
+	Password string `json:"password" validate:"required" example:"secureP@ssw0rd"`
+}
+
+// SendPhoneCodeDTO represents a request for a phone verification code.
+// The code is sent to PhoneNumber, or to the number of the user when it is empty.
+// @Description Phone verification code request data
+type SendPhoneCodeDTO struct {
+	PhoneNumber string `json:"phone_number,omitempty" validate:"omitempty,e164|vn_phone" example:"+84912345678"`
+}
+
+// VerifyPhoneDTO represents a phone verification code received by SMS
+// @Description Phone verification data
+type VerifyPhoneDTO struct {
+	Code string `json:"code" validate:"required,len=6,numeric" example:"123456"`
+}
+
+// ClientInfo describes the device a session is started or refreshed from.
+// It is filled in by the handlers from the request, not parsed from the body.
+type ClientInfo struct {
+	UserAgent   string
+	IPAddress   string
+	DeviceLabel string
+
+	// OAuthClientID and Scope are set when the session is granted to an oauth client
+	OAuthClientID string
+	Scope         string
+}
+
+// MagicLinkDTO represents a request for a passwordless login link
+// @Description Magic link request data
+type MagicLinkDTO struct {
+	Email string `json:"email" validate:"required,email" example:"user@example.com"`
+}
+
+// ConsumeMagicLinkDTO represents the token of a magic link exchanged for tokens
+// @Description Magic link consume request data
+type ConsumeMagicLinkDTO struct {
+	Token       string `json:"token" validate:"required" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."`
+	DeviceLabel string `json:"device_label,omitempty" validate:"omitempty,max=100" example:"Work laptop"`
+}
+
+// SocialCallbackDTO represents the parameters an identity provider redirected back with
+// @Description Social login callback data
+type SocialCallbackDTO struct {
+	Code        string `json:"code" validate:"required" example:"4/0AX4XfWh..."`
+	State       string `json:"state" validate:"required" example:"Jx3b4G2m0uQ4c1aT9k8n7p6r5s4t3u2v1w0x9y8z7A6"`
+	DeviceLabel string `json:"device_label,omitempty" validate:"omitempty,max=100" example:"Work laptop"`
+}
+
+// WebAuthnRegisterFinishDTO represents the answer of an authenticator to a WebAuthn registration challenge
+// @Description WebAuthn registration finish data
+type WebAuthnRegisterFinishDTO struct {
+	SessionID  string          `json:"session_id" validate:"required" example:"Jx3b4G2m0uQ4c1aT9k8n7p6r5s4t3u2v1w0x9y8z7A6"`
+	Name       string          `json:"name,omitempty" validate:"omitempty,max=100" example:"MacBook Touch ID"`
+	Credential json.RawMessage `json:"credential" swaggertype:"object"` // PublicKeyCredential returned by navigator.credentials.create()
+}
+
+// WebAuthnLoginFinishDTO represents the answer of an authenticator to a passkey login challenge
+// @Description Passkey login finish data
+type WebAuthnLoginFinishDTO struct {
+	SessionID   string          `json:"session_id" validate:"required" example:"Jx3b4G2m0uQ4c1aT9k8n7p6r5s4t3u2v1w0x9y8z7A6"`
+	Credential  json.RawMessage `json:"credential" swaggertype:"object"` // PublicKeyCredential returned by navigator.credentials.get()
+	DeviceLabel string          `json:"device_label,omitempty" validate:"omitempty,max=100" example:"Work laptop"`
+}
+
+// CreateAPIKeyDTO represents a request to create a personal API key
+// @Description Create API key request data
+type CreateAPIKeyDTO struct {
+	Name      string     `json:"name"                 validate:"required,max=100"                       example:"CI deploy"`
+	Scopes    []string   `json:"scopes"               validate:"dive,oneof=users:read users:write"      example:"users:read"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"                                                   example:"2027-01-01T00:00:00Z"`
+}
+
+// ServiceAccountTokenRequestDTO represents a client_credentials token request of a service account.
+// The credentials are sent with HTTP Basic or as client_id and client_secret form fields.
+// @Description Service account token request data
+type ServiceAccountTokenRequestDTO struct {
+	GrantType string `form:"grant_type" validate:"required" example:"client_credentials"`
+}
@@ -1,5 +1,7 @@
 package auth_dto
 
+import "time"
+
 // TokenResponseDTO represents token response data
 // @Description Token response data
 type TokenResponseDTO struct {
@@ -41,3 +43,249 @@ type VerifySuccessResponseDTO struct {
 type LogoutSuccessResponseDTO struct {
 	Success bool `json:"success"`
 }
+
+// ForgotPasswordSuccessResponseDTO represents a successful forgot password response
+// @Description Response structure for forgot password requests
+type ForgotPasswordSuccessResponseDTO struct {
+	Success bool `json:"success"`
+}
+
+// ResetPasswordSuccessResponseDTO represents a successful password reset response
+// @Description Response structure for successful password reset requests
+type ResetPasswordSuccessResponseDTO struct {
+	Success bool `json:"success"`
+}
+
+// MFAChallengeDTO represents the challenge returned by login when MFA is enabled
+// @Description MFA challenge data
+type MFAChallengeDTO struct {
+	Status         string   `json:"status"          example:"mfa_required"`
+	ChallengeToken string   `json:"challenge_token" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."`
+	ExpiresIn      uint     `json:"expires_in"      example:"300"` // in seconds
+	Methods        []string `json:"methods"         example:"totp,sms,webauthn,recovery_code"`
+}
+
+// LoginMFARequiredResponseDTO represents a login response that requires a second factor
+// @Description Response structure for login requests of users with MFA enabled
+type LoginMFARequiredResponseDTO struct {
+	Success bool             `json:"success"`
+	Data    *MFAChallengeDTO `json:"data"`
+}
+
+// TOTPEnrollmentDTO represents a newly generated TOTP secret
+// @Description TOTP enrollment data
+type TOTPEnrollmentDTO struct {
+	Secret     string `json:"secret"      example:"JBSWY3DPEHPK3PXP"`
+	OTPAuthURI string `json:"otpauth_uri" example:"otpauth://totp/Modular%20Fiber%20API:user@example.com?issuer=Modular%20Fiber%20API&secret=JBSWY3DPEHPK3PXP"`
+}
+
+// TOTPEnrollSuccessResponseDTO represents a successful TOTP enrollment response
+// @Description Response structure for successful TOTP enrollment requests
+type TOTPEnrollSuccessResponseDTO struct {
+	Success bool               `json:"success"`
+	Data    *TOTPEnrollmentDTO `json:"data"`
+}
+
+// RecoveryCodesDTO represents one-time MFA recovery codes, shown only once
+// @Description MFA recovery codes
+type RecoveryCodesDTO struct {
+	RecoveryCodes []string `json:"recovery_codes" example:"abcde-12345,fghij-67890"`
+}
+
+// TOTPConfirmSuccessResponseDTO represents a successful TOTP confirmation response
+// @Description Response structure for successful TOTP confirmation requests
+type TOTPConfirmSuccessResponseDTO struct {
+	Success bool              `json:"success"`
+	Data    *RecoveryCodesDTO `json:"data"`
+}
+
+// TOTPDisableSuccessResponseDTO represents a successful TOTP disable response
+// @Description Response structure for successful TOTP disable requests
+type TOTPDisableSuccessResponseDTO struct {
+	Success bool `json:"success"`
+}
+
+// MFASMSCodeSuccessResponseDTO represents a successful MFA SMS code request response
+// @Description Response structure for successful MFA SMS code requests
+type MFASMSCodeSuccessResponseDTO struct {
+	Success bool `json:"success"`
+}
+
+// SMSMFAEnableSuccessResponseDTO represents a successful SMS MFA enable response
+// @Description Response structure for successful SMS MFA enable requests
+type SMSMFAEnableSuccessResponseDTO struct {
+	Success bool              `json:"success"`
+	Data    *RecoveryCodesDTO `json:"data"`
+}
+
+// SMSMFADisableSuccessResponseDTO represents a successful SMS MFA disable response
+// @Description Response structure for successful SMS MFA disable requests
+type SMSMFADisableSuccessResponseDTO struct {
+	Success bool `json:"success"`
+}
+
+// SendPhoneCodeSuccessResponseDTO represents a successful phone verification code request response
+// @Description Response structure for successful phone verification code requests
+type SendPhoneCodeSuccessResponseDTO struct {
+	Success bool `json:"success"`
+}
+
+// VerifyPhoneSuccessResponseDTO represents a successful phone verification response
+// @Description Response structure for successful phone verification requests
+type VerifyPhoneSuccessResponseDTO struct {
+	Success bool `json:"success"`
+}
+
+// MFAVerifySuccessResponseDTO represents a successful MFA verification response
+// @Description Response structure for successful MFA verification requests
+type MFAVerifySuccessResponseDTO struct {
+	Success bool              `json:"success"`
+	Data    *TokenResponseDTO `json:"data"`
+}
+
+// SessionDTO represents a logged in device
+// @Description Session information
+type SessionDTO struct {
+	ID          string     `json:"id"                     example:"3f1c7a4e-5b2d-4e8a-9c6f-1a2b3c4d5e6f"`
+	DeviceLabel *string    `json:"device_label,omitempty" example:"Work laptop"`
+	UserAgent   *string    `json:"user_agent,omitempty"   example:"Mozilla/5.0 (X11; Linux x86_64)"`
+	IPAddress   *string    `json:"ip_address,omitempty"   example:"203.0.113.7"`
+	LastUsedAt  *time.Time `json:"last_used_at,omitempty" example:"2023-01-01T12:00:00Z"`
+	ExpiresAt   time.Time  `json:"expires_at"             example:"2023-01-08T12:00:00Z"`
+	Current     bool       `json:"current"                example:"true"`
+}
+
+// ListSessionsSuccessResponseDTO represents a successful list sessions response
+// @Description Response structure for successful list sessions requests
+type ListSessionsSuccessResponseDTO struct {
+	Success bool          `json:"success"`
+	Data    []*SessionDTO `json:"data"`
+}
+
+// RevokeSessionSuccessResponseDTO represents a successful session revocation response
+// @Description Response structure for successful session revocation requests
+type RevokeSessionSuccessResponseDTO struct {
+	Success bool `json:"success"`
+}
+
+// MagicLinkSuccessResponseDTO represents a magic link request response
+// @Description Response structure for magic link requests
+type MagicLinkSuccessResponseDTO struct {
+	Success bool `json:"success"`
+}
+
+// SocialAuthorizationDTO represents where to send the user to log in with an identity provider
+// @Description Social login authorization data
+type SocialAuthorizationDTO struct {
+	AuthorizationURL string `json:"authorization_url" example:"https://accounts.google.com/o/oauth2/v2/auth?client_id=..."`
+	State            string `json:"state"             example:"Jx3b4G2m0uQ4c1aT9k8n7p6r5s4t3u2v1w0x9y8z7A6"`
+	ExpiresIn        uint   `json:"expires_in"        example:"600"` // in seconds
+}
+
+// SocialAuthorizationSuccessResponseDTO represents a successful social login start response
+// @Description Response structure for successful social login start requests
+type SocialAuthorizationSuccessResponseDTO struct {
+	Success bool                    `json:"success"`
+	Data    *SocialAuthorizationDTO `json:"data"`
+}
+
+// APIKeyDTO represents a personal API key, without its secret part
+// @Description API key information
+type APIKeyDTO struct {
+	ID         uint64     `json:"id"                     example:"1"`
+	Name       string     `json:"name"                   example:"CI deploy"`
+	KeyPrefix  string     `json:"key_prefix"             example:"mff_Xk3vQ9aB"`
+	Scopes     []string   `json:"scopes"                 example:"users:read"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"   example:"2027-01-01T00:00:00Z"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty" example:"2026-01-01T12:00:00Z"`
+	CreatedAt  time.Time  `json:"created_at"             example:"2026-01-01T00:00:00Z"`
+}
+
+// CreatedAPIKeyDTO represents a newly created API key. The key is only shown once.
+// @Description Created API key data
+type CreatedAPIKeyDTO struct {
+	APIKeyDTO
+	Key string `json:"key" example:"mff_Xk3vQ9aBcD7eF1gH2iJ3kL4mN5oP6qR7sT8uV9wX0yZ"`
+}
+
+// CreateAPIKeySuccessResponseDTO represents a successful API key creation response
+// @Description Response structure for successful API key creation requests
+type CreateAPIKeySuccessResponseDTO struct {
+	Success bool              `json:"success"`
+	Data    *CreatedAPIKeyDTO `json:"data"`
+}
+
+// ListAPIKeysSuccessResponseDTO represents a successful list API keys response
+// @Description Response structure for successful list API keys requests
+type ListAPIKeysSuccessResponseDTO struct {
+	Success bool         `json:"success"`
+	Data    []*APIKeyDTO `json:"data"`
+}
+
+// RevokeAPIKeySuccessResponseDTO represents a successful API key revocation response
+// @Description Response structure for successful API key revocation requests
+type RevokeAPIKeySuccessResponseDTO struct {
+	Success bool `json:"success"`
+}
+
+// WebAuthnOptionsDTO represents the options of a WebAuthn ceremony, to pass to
+// navigator.credentials.create() or navigator.credentials.get(). The session ID is sent back with the answer.
+// @Description WebAuthn ceremony options
+type WebAuthnOptionsDTO struct {
+	SessionID string      `json:"session_id" example:"Jx3b4G2m0uQ4c1aT9k8n7p6r5s4t3u2v1w0x9y8z7A6"`
+	Options   interface{} `json:"options" swaggertype:"object"`
+}
+
+// WebAuthnOptionsSuccessResponseDTO represents a successful WebAuthn ceremony start response
+// @Description Response structure for successful WebAuthn ceremony start requests
+type WebAuthnOptionsSuccessResponseDTO struct {
+	Success bool                `json:"success"`
+	Data    *WebAuthnOptionsDTO `json:"data"`
+}
+
+// WebAuthnCredentialDTO represents a passkey registered by a user, without its key material
+// @Description Passkey information
+type WebAuthnCredentialDTO struct {
+	ID             uint64     `json:"id"                     example:"1"`
+	Name           string     `json:"name"                   example:"MacBook Touch ID"`
+	Transports     []string   `json:"transports"             example:"internal,hybrid"`
+	BackupEligible bool       `json:"backup_eligible"        example:"true"` // synced passkey
+	BackupState    bool       `json:"backup_state"           example:"true"`
+	LastUsedAt     *time.Time `json:"last_used_at,omitempty" example:"2026-01-01T12:00:00Z"`
+	CreatedAt      time.Time  `json:"created_at"             example:"2026-01-01T00:00:00Z"`
+}
+
+// WebAuthnRegisterSuccessResponseDTO represents a successful passkey registration response
+// @Description Response structure for successful passkey registration requests
+type WebAuthnRegisterSuccessResponseDTO struct {
+	Success bool                   `json:"success"`
+	Data    *WebAuthnCredentialDTO `json:"data"`
+}
+
+// ListWebAuthnCredentialsSuccessResponseDTO represents a successful list passkeys response
+// @Description Response structure for successful list passkeys requests
+type ListWebAuthnCredentialsSuccessResponseDTO struct {
+	Success bool                     `json:"success"`
+	Data    []*WebAuthnCredentialDTO `json:"data"`
+}
+
+// DeleteWebAuthnCredentialSuccessResponseDTO represents a successful passkey deletion response
+// @Description Response structure for successful passkey deletion requests
+type DeleteWebAuthnCredentialSuccessResponseDTO struct {
+	Success bool `json:"success"`
+}
+
+// ServiceAccountTokenDTO represents an access token issued to a service account, it has no refresh token
+// @Description Service account token data
+type ServiceAccountTokenDTO struct {
+	AccessToken string `json:"access_token" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."`
+	TokenType   string `json:"token_type"   example:"Bearer"`
+	ExpiresIn   uint   `json:"expires_in"   example:"900"` // in seconds
+	Scope       string `json:"scope"        example:"users:read"`
+}
+
+// ResendVerificationSuccessResponseDTO represents a successful resend verification code response
+// @Description Response structure for successful resend verification code requests
+type ResendVerificationSuccessResponseDTO struct {
+	Success bool `json:"success"`
+}
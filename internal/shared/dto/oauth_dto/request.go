@@ -0,0 +1,73 @@
+package oauth_dto
+
+// Token type hints defined by RFC 7009
+const (
+	TokenTypeHintAccessToken  = "access_token"
+	TokenTypeHintRefreshToken = "refresh_token"
+)
+
+// IntrospectDTO represents a token introspection request (RFC 7662)
+// @Description Token introspection request
+type IntrospectDTO struct {
+	Token         string `form:"token" validate:"required" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."`
+	TokenTypeHint string `form:"token_type_hint" example:"access_token"`
+}
+
+// RevokeDTO represents a token revocation request (RFC 7009)
+// @Description Token revocation request
+type RevokeDTO struct {
+	Token         string `form:"token" validate:"required" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."`
+	TokenTypeHint string `form:"token_type_hint" example:"refresh_token"`
+}
+
+// Grant types supported by the token endpoint
+const (
+	GrantTypeAuthorizationCode = "authorization_code"
+	GrantTypeRefreshToken      = "refresh_token"
+	GrantTypeClientCredentials = "client_credentials"
+)
+
+// Scopes defined by OpenID Connect, a client may also be granted other scopes
+const (
+	ScopeOpenID  = "openid"
+	ScopeEmail   = "email"
+	ScopeProfile = "profile"
+	ScopePhone   = "phone"
+)
+
+// ResponseTypeCode is the only response type of the authorize endpoint
+const ResponseTypeCode = "code"
+
+// CodeChallengeMethodS256 is the only supported PKCE method (RFC 7636)
+const CodeChallengeMethodS256 = "S256"
+
+// AuthorizeDTO represents an authorization request forwarded by the consent page
+// @Description Authorization request
+type AuthorizeDTO struct {
+	ResponseType        string `query:"response_type"         json:"response_type"         validate:"required" example:"code"`
+	ClientID            string `query:"client_id"             json:"client_id"             validate:"required" example:"3q2-7wEAAAAAAAAA"`
+	RedirectURI         string `query:"redirect_uri"          json:"redirect_uri"          validate:"required" example:"https://app.example.com/callback"`
+	Scope               string `query:"scope"                 json:"scope"                                     example:"openid email profile"`
+	State               string `query:"state"                 json:"state"                                     example:"af0ifjsldkj"`
+	Nonce               string `query:"nonce"                 json:"nonce"                                     example:"n-0S6_WzA2Mj"`
+	CodeChallenge       string `query:"code_challenge"        json:"code_challenge"                            example:"E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM"`
+	CodeChallengeMethod string `query:"code_challenge_method" json:"code_challenge_method"                     example:"S256"`
+}
+
+// ConsentDTO represents the decision of the user on an authorization request
+// @Description Consent decision
+type ConsentDTO struct {
+	AuthorizeDTO
+	Approve bool `json:"approve" example:"true"`
+}
+
+// TokenDTO represents a token request (RFC 6749 section 4)
+// @Description Token request
+type TokenDTO struct {
+	GrantType    string `form:"grant_type" validate:"required" example:"authorization_code"`
+	Code         string `form:"code" example:"SplxlOBeZQQYbYS6WxSbIA"`
+	RedirectURI  string `form:"redirect_uri" example:"https://app.example.com/callback"`
+	CodeVerifier string `form:"code_verifier" example:"dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"`
+	RefreshToken string `form:"refresh_token" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."`
+	Scope        string `form:"scope" example:"reports:read"`
+}
@@ -0,0 +1,89 @@
+package oauth_dto
+
+// Error codes defined by RFC 6749
+const (
+	ErrorInvalidRequest          = "invalid_request"
+	ErrorInvalidClient           = "invalid_client"
+	ErrorInvalidGrant            = "invalid_grant"
+	ErrorInvalidScope            = "invalid_scope"
+	ErrorUnauthorizedClient      = "unauthorized_client"
+	ErrorUnsupportedGrantType    = "unsupported_grant_type"
+	ErrorUnsupportedResponseType = "unsupported_response_type"
+	ErrorAccessDenied            = "access_denied"
+	ErrorInvalidToken            = "invalid_token"      // RFC 6750
+	ErrorInsufficientScope       = "insufficient_scope" // RFC 6750
+)
+
+// IntrospectionResponseDTO represents a token introspection response (RFC 7662).
+// Only Active is set for tokens that are not active.
+// @Description Token introspection response
+type IntrospectionResponseDTO struct {
+	Active      bool   `json:"active"               example:"true"`
+	TokenType   string `json:"token_type,omitempty" example:"access_token"`
+	Subject     string `json:"sub,omitempty"        example:"1"`
+	SubjectType string `json:"sub_typ,omitempty"    example:"service_account"` // only set for service account tokens
+	Username    string `json:"username,omitempty"   example:"user@example.com"`
+	ExpiresAt   int64  `json:"exp,omitempty"        example:"1767225600"`
+	IssuedAt    int64  `json:"iat,omitempty"        example:"1767222000"`
+	TokenID     string `json:"jti,omitempty"        example:"5f0c6a3e-7d4b-4a7e-9a53-0c2b1f7e8d11"`
+	SessionID   string `json:"sid,omitempty"        example:"9b2e4f4a-3f1d-4c55-8f0e-6a1d2c3b4e5f"`
+	ClientID    string `json:"client_id,omitempty"  example:"3q2-7wEAAAAAAAAA"`
+	Scope       string `json:"scope,omitempty"      example:"openid email"`
+}
+
+// ErrorResponseDTO represents an OAuth error response (RFC 6749 section 5.2)
+// @Description OAuth error response
+type ErrorResponseDTO struct {
+	Error            string `json:"error"                       example:"invalid_client"`
+	ErrorDescription string `json:"error_description,omitempty" example:"client authentication failed"`
+}
+
+// TokenResponseDTO represents a token response (RFC 6749 section 5.1).
+// IDToken is only set when the openid scope was granted.
+// @Description Token response
+type TokenResponseDTO struct {
+	AccessToken  string `json:"access_token"            example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."`
+	TokenType    string `json:"token_type"              example:"Bearer"`
+	ExpiresIn    uint   `json:"expires_in"              example:"3600"` // in seconds
+	RefreshToken string `json:"refresh_token,omitempty" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."`
+	Scope        string `json:"scope,omitempty"         example:"openid email"`
+	IDToken      string `json:"id_token,omitempty"      example:"eyJhbGciOiJSUzI1NiIsInR5cCI6IkpXVCJ9..."`
+}
+
+// AuthorizationDTO describes an authorization request to the consent page.
+// RedirectTo is set once the request is decided, the page must then send the user there.
+// @Description Authorization request details
+type AuthorizationDTO struct {
+	ClientID        string   `json:"client_id"             example:"3q2-7wEAAAAAAAAA"`
+	ClientName      string   `json:"client_name"           example:"Reporting"`
+	Scopes          []string `json:"scopes"                example:"openid,email"`
+	ConsentRequired bool     `json:"consent_required"      example:"true"`
+	RedirectTo      string   `json:"redirect_to,omitempty" example:"https://app.example.com/callback?code=SplxlOBeZQQYbYS6WxSbIA&state=af0ifjsldkj"`
+}
+
+// AuthorizationSuccessResponseDTO represents a successful authorization response
+// @Description Response structure for authorization requests
+type AuthorizationSuccessResponseDTO struct {
+	Success bool              `json:"success"`
+	Data    *AuthorizationDTO `json:"data"`
+}
+
+// DiscoveryDTO represents the OpenID Provider metadata (OpenID Connect Discovery 1.0)
+// @Description OpenID Provider metadata
+type DiscoveryDTO struct {
+	Issuer                            string   `json:"issuer"                                example:"http://localhost:8000"`
+	AuthorizationEndpoint             string   `json:"authorization_endpoint"                example:"http://localhost:3000/oauth/authorize"`
+	TokenEndpoint                     string   `json:"token_endpoint"                        example:"http://localhost:8000/api/oauth/token"`
+	UserInfoEndpoint                  string   `json:"userinfo_endpoint"                     example:"http://localhost:8000/api/oauth/userinfo"`
+	JWKSURI                           string   `json:"jwks_uri"                              example:"http://localhost:8000/.well-known/jwks.json"`
+	IntrospectionEndpoint             string   `json:"introspection_endpoint"                example:"http://localhost:8000/api/oauth/introspect"`
+	RevocationEndpoint                string   `json:"revocation_endpoint"                   example:"http://localhost:8000/api/oauth/revoke"`
+	ScopesSupported                   []string `json:"scopes_supported"                      example:"openid,email,profile,phone"`
+	ResponseTypesSupported            []string `json:"response_types_supported"              example:"code"`
+	GrantTypesSupported               []string `json:"grant_types_supported"                 example:"authorization_code,refresh_token,client_credentials"`
+	SubjectTypesSupported             []string `json:"subject_types_supported"               example:"public"`
+	IDTokenSigningAlgValuesSupported  []string `json:"id_token_signing_alg_values_supported" example:"RS256"`
+	TokenEndpointAuthMethodsSupported []string `json:"token_endpoint_auth_methods_supported" example:"client_secret_basic,client_secret_post,none"`
+	CodeChallengeMethodsSupported     []string `json:"code_challenge_methods_supported"      example:"S256"`
+	ClaimsSupported                   []string `json:"claims_supported"                      example:"sub,email,email_verified,name"`
+}
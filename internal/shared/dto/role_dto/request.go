@@ -0,0 +1,47 @@
+package role_dto
+
+// CreateRoleDTO represents a request to create a role
+// @Description Create role request data
+type CreateRoleDTO struct {
+	Name        string  `json:"name"        validate:"required,max=100" example:"support"`
+	Description string  `json:"description" validate:"max=255"          example:"Customer support agents"`
+	ParentID    *uint64 `json:"parent_id"                               example:"2"`
+}
+
+// UpdateRoleDTO represents a request to update a role. A missing parent_id removes the parent.
+// @Description Update role request data
+type UpdateRoleDTO struct {
+	Name        string  `json:"name"        validate:"required,max=100" example:"support"`
+	Description string  `json:"description" validate:"max=255"          example:"Customer support agents"`
+	ParentID    *uint64 `json:"parent_id"                               example:"2"`
+}
+
+// RolePermissionsDTO represents a request to assign or unassign permissions of a role
+// @Description Role permissions request data
+type RolePermissionsDTO struct {
+	PermissionIDs []uint64 `json:"permission_ids" validate:"required,min=1" example:"1,2"`
+}
+
+// CreatePermissionDTO represents a request to create a permission
+// @Description Create permission request data
+type CreatePermissionDTO struct {
+	Name         string `json:"name"          validate:"required,max=100" example:"users.read"`
+	Description  string `json:"description"   validate:"max=255"          example:"List and view users"`
+	ResourceName string `json:"resource_name" validate:"required,max=100" example:"users"`
+	Action       string `json:"action"        validate:"required,max=50"  example:"read"`
+}
+
+// UpdatePermissionDTO represents a request to update a permission
+// @Description Update permission request data
+type UpdatePermissionDTO struct {
+	Name         string `json:"name"          validate:"required,max=100" example:"users.read"`
+	Description  string `json:"description"   validate:"max=255"          example:"List and view users"`
+	ResourceName string `json:"resource_name" validate:"required,max=100" example:"users"`
+	Action       string `json:"action"        validate:"required,max=50"  example:"read"`
+}
+
+// UpdateUserRolesDTO represents a request to assign or unassign roles of a user
+// @Description User roles request data
+type UpdateUserRolesDTO struct {
+	RoleIDs []uint64 `json:"role_ids" validate:"required,min=1" example:"1,2"`
+}
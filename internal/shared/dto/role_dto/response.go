@@ -0,0 +1,114 @@
+package role_dto
+
+import "time"
+
+// PermissionDTO represents a permission
+// @Description Permission information
+type PermissionDTO struct {
+	ID           uint64    `json:"id"            example:"1"`
+	Name         string    `json:"name"          example:"users.read"`
+	Description  string    `json:"description"   example:"List and view users"`
+	ResourceName string    `json:"resource_name" example:"users"`
+	Action       string    `json:"action"        example:"read"`
+	CreatedAt    time.Time `json:"created_at"    example:"2026-01-01T00:00:00Z"`
+	UpdatedAt    time.Time `json:"updated_at"    example:"2026-01-01T00:00:00Z"`
+}
+
+// EffectivePermissionDTO represents a permission held through a role, with the role it was granted to
+// @Description Effective permission information
+type EffectivePermissionDTO struct {
+	PermissionDTO
+	GrantedByRoleID uint64 `json:"granted_by_role_id" example:"2"`
+	GrantedByRole   string `json:"granted_by_role"    example:"viewer"`
+	Inherited       bool   `json:"inherited"          example:"true"` // granted to an ancestor role
+}
+
+// RoleDTO represents a role with its permissions. Permissions lists the permissions granted to the role,
+// effective_permissions adds the ones inherited from its ancestors.
+// @Description Role information
+type RoleDTO struct {
+	ID                   uint64                    `json:"id"          example:"1"`
+	Name                 string                    `json:"name"        example:"support"`
+	Description          string                    `json:"description" example:"Customer support agents"`
+	ParentID             *uint64                   `json:"parent_id"   example:"2"`
+	Ancestors            []string                  `json:"ancestors"   example:"editor,viewer"`
+	Permissions          []*PermissionDTO          `json:"permissions"`
+	EffectivePermissions []*EffectivePermissionDTO `json:"effective_permissions"`
+	CreatedAt            time.Time                 `json:"created_at"  example:"2026-01-01T00:00:00Z"`
+	UpdatedAt            time.Time                 `json:"updated_at"  example:"2026-01-01T00:00:00Z"`
+}
+
+// UserRolesDTO represents the roles assigned to a user and the permissions the user holds through them
+// @Description User roles information
+type UserRolesDTO struct {
+	UserID               uint64                    `json:"user_id" example:"1"`
+	Roles                []*RoleDTO                `json:"roles"`
+	EffectivePermissions []*EffectivePermissionDTO `json:"effective_permissions"`
+}
+
+// PaginatedRolesDTO represents a paginated list of roles
+// @Description Paginated list of roles
+type PaginatedRolesDTO struct {
+	Items      []*RoleDTO `json:"items"`
+	TotalCount int64      `json:"total_count" example:"42"`
+	Page       int        `json:"page" example:"1"`
+	PageSize   int        `json:"page_size" example:"10"`
+	TotalPages int64      `json:"total_pages" example:"5"`
+}
+
+// PaginatedPermissionsDTO represents a paginated list of permissions
+// @Description Paginated list of permissions
+type PaginatedPermissionsDTO struct {
+	Items      []*PermissionDTO `json:"items"`
+	TotalCount int64            `json:"total_count" example:"42"`
+	Page       int              `json:"page" example:"1"`
+	PageSize   int              `json:"page_size" example:"10"`
+	TotalPages int64            `json:"total_pages" example:"5"`
+}
+
+// RoleSuccessResponseDTO represents a successful create, get or update role response
+// @Description Response structure for successful create, get or update role requests
+type RoleSuccessResponseDTO struct {
+	Success bool     `json:"success"`
+	Data    *RoleDTO `json:"data"`
+}
+
+// ListRolesSuccessResponseDTO represents a successful list roles response
+// @Description Response structure for successful list roles requests
+type ListRolesSuccessResponseDTO struct {
+	Success bool               `json:"success"`
+	Data    *PaginatedRolesDTO `json:"data"`
+}
+
+// DeleteRoleSuccessResponseDTO represents a successful role deletion response
+// @Description Response structure for successful role deletion requests
+type DeleteRoleSuccessResponseDTO struct {
+	Success bool `json:"success"`
+}
+
+// PermissionSuccessResponseDTO represents a successful create, get or update permission response
+// @Description Response structure for successful create, get or update permission requests
+type PermissionSuccessResponseDTO struct {
+	Success bool           `json:"success"`
+	Data    *PermissionDTO `json:"data"`
+}
+
+// ListPermissionsSuccessResponseDTO represents a successful list permissions response
+// @Description Response structure for successful list permissions requests
+type ListPermissionsSuccessResponseDTO struct {
+	Success bool                     `json:"success"`
+	Data    *PaginatedPermissionsDTO `json:"data"`
+}
+
+// DeletePermissionSuccessResponseDTO represents a successful permission deletion response
+// @Description Response structure for successful permission deletion requests
+type DeletePermissionSuccessResponseDTO struct {
+	Success bool `json:"success"`
+}
+
+// UserRolesSuccessResponseDTO represents a successful get, assign or unassign user roles response
+// @Description Response structure for successful user roles requests
+type UserRolesSuccessResponseDTO struct {
+	Success bool          `json:"success"`
+	Data    *UserRolesDTO `json:"data"`
+}
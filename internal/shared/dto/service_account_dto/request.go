@@ -0,0 +1,15 @@
+package service_account_dto
+
+// CreateServiceAccountDTO represents a request to create a service account
+// @Description Create service account request data
+type CreateServiceAccountDTO struct {
+	Name        string   `json:"name"        validate:"required,max=100" example:"billing-worker"`
+	Description string   `json:"description" validate:"max=255"          example:"Nightly invoice export"`
+	RoleIDs     []uint64 `json:"role_ids"                                example:"1,2"`
+}
+
+// UpdateServiceAccountRolesDTO represents a request to replace the roles of a service account
+// @Description Replace service account roles request data
+type UpdateServiceAccountRolesDTO struct {
+	RoleIDs []uint64 `json:"role_ids" example:"1,2"`
+}
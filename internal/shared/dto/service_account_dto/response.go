@@ -0,0 +1,74 @@
+package service_account_dto
+
+import "time"
+
+// ServiceAccountDTO represents a service account, without its secrets
+// @Description Service account information
+type ServiceAccountDTO struct {
+	ID          uint64    `json:"id"          example:"1"`
+	Name        string    `json:"name"        example:"billing-worker"`
+	Description string    `json:"description" example:"Nightly invoice export"`
+	ClientID    string    `json:"client_id"   example:"3q2-7wEAAAAAAAAA"`
+	Active      bool      `json:"active"      example:"true"`
+	Roles       []string  `json:"roles"       example:"admin"`
+	CreatedAt   time.Time `json:"created_at"  example:"2026-01-01T00:00:00Z"`
+}
+
+// CreatedServiceAccountDTO represents a newly created service account. The client secret is only shown once.
+// @Description Created service account data
+type CreatedServiceAccountDTO struct {
+	ServiceAccountDTO
+	ClientSecret string `json:"client_secret" example:"Xk3vQ9aBcD7eF1gH2iJ3kL4mN5oP6qR7sT8uV9wX0yZ"`
+}
+
+// ServiceAccountSecretDTO represents a rotated client secret. The client secret is only shown once.
+// @Description Rotated service account secret data
+type ServiceAccountSecretDTO struct {
+	ClientID                string    `json:"client_id"                  example:"3q2-7wEAAAAAAAAA"`
+	ClientSecret            string    `json:"client_secret"              example:"Xk3vQ9aBcD7eF1gH2iJ3kL4mN5oP6qR7sT8uV9wX0yZ"`
+	PreviousSecretsExpireAt time.Time `json:"previous_secrets_expire_at" example:"2026-01-01T01:00:00Z"`
+}
+
+// PaginatedServiceAccountsDTO represents a paginated list of service accounts
+// @Description Paginated list of service accounts
+type PaginatedServiceAccountsDTO struct {
+	Items      []*ServiceAccountDTO `json:"items"`
+	TotalCount int64                `json:"total_count" example:"42"`
+	Page       int                  `json:"page" example:"1"`
+	PageSize   int                  `json:"page_size" example:"10"`
+	TotalPages int64                `json:"total_pages" example:"5"`
+}
+
+// CreateServiceAccountSuccessResponseDTO represents a successful service account creation response
+// @Description Response structure for successful service account creation requests
+type CreateServiceAccountSuccessResponseDTO struct {
+	Success bool                      `json:"success"`
+	Data    *CreatedServiceAccountDTO `json:"data"`
+}
+
+// ListServiceAccountsSuccessResponseDTO represents a successful list service accounts response
+// @Description Response structure for successful list service accounts requests
+type ListServiceAccountsSuccessResponseDTO struct {
+	Success bool                         `json:"success"`
+	Data    *PaginatedServiceAccountsDTO `json:"data"`
+}
+
+// ServiceAccountSuccessResponseDTO represents a successful get or update service account response
+// @Description Response structure for successful get or update service account requests
+type ServiceAccountSuccessResponseDTO struct {
+	Success bool               `json:"success"`
+	Data    *ServiceAccountDTO `json:"data"`
+}
+
+// RotateSecretSuccessResponseDTO represents a successful secret rotation response
+// @Description Response structure for successful secret rotation requests
+type RotateSecretSuccessResponseDTO struct {
+	Success bool                     `json:"success"`
+	Data    *ServiceAccountSecretDTO `json:"data"`
+}
+
+// DeleteServiceAccountSuccessResponseDTO represents a successful service account deletion response
+// @Description Response structure for successful service account deletion requests
+type DeleteServiceAccountSuccessResponseDTO struct {
+	Success bool `json:"success"`
+}
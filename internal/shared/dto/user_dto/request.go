@@ -6,7 +6,7 @@ import "time"
 // @Description Data for creating a new user
 type CreateUserDTO struct {
 	Email       string     `json:"email" validate:"required,email" example:"user@example.com"`
-	Password    string     `json:"password" validate:"required,min=8" example:"secureP@ssw0rd"`
+	Password    string     `json:"password" validate:"required" example:"secureP@ssw0rd"`
 	PhoneNumber *string    `json:"phone_number,omitempty" validate:"omitempty,e164" example:"+12125551234"`
 	FirstName   string     `json:"first_name" validate:"required" example:"John"`
 	LastName    string     `json:"last_name" validate:"required" example:"Doe"`
@@ -30,5 +30,18 @@ type UpdateUserDTO struct {
 // @Description Data for changing a user's password
 type ChangePasswordDTO struct {
 	CurrentPassword string `json:"current_password" validate:"required" example:"oldP@ssw0rd"`
-	NewPassword     string `json:"new_password" validate:"required,min=8,nefield=CurrentPassword" example:"newSecureP@ssw0rd"`
+	NewPassword     string `json:"new_password" validate:"required,nefield=CurrentPassword" example:"newSecureP@ssw0rd"`
+}
+
+// ChangeEmailDTO represents a request to change the current user's email address
+// @Description Data for changing a user's email address
+type ChangeEmailDTO struct {
+	NewEmail        string `json:"new_email" validate:"required,email,max=255" example:"new@example.com"`
+	CurrentPassword string `json:"current_password" validate:"required" example:"secureP@ssw0rd"`
+}
+
+// EmailChangeTokenDTO represents the token of an email change confirmation or undo link
+// @Description Token of an email change link
+type EmailChangeTokenDTO struct {
+	Token string `json:"token" validate:"required" example:"Xk3vQ9aBcD7eF1gH2iJ3kL4mN5oP6qR7sT8uV9wX0yZ"`
 }
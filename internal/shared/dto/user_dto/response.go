@@ -30,3 +30,45 @@ type GetMeSuccessResponseDTO struct {
 	Success bool                    `json:"success"`
 	Data    *models.UserResponseDTO `json:"data"`
 }
+
+// ChangePasswordSuccessResponseDTO represents a successful change password response
+// @Description Response structure for successful change password requests
+type ChangePasswordSuccessResponseDTO struct {
+	Success bool `json:"success"`
+}
+
+// UnlockUserSuccessResponseDTO represents a successful account unlock response
+// @Description Response structure for successful account unlock requests
+type UnlockUserSuccessResponseDTO struct {
+	Success bool `json:"success"`
+}
+
+// DeactivateUserSuccessResponseDTO represents a successful account deactivation response
+// @Description Response structure for successful account deactivation requests
+type DeactivateUserSuccessResponseDTO struct {
+	Success bool `json:"success"`
+}
+
+// ActivateUserSuccessResponseDTO represents a successful account activation response
+// @Description Response structure for successful account activation requests
+type ActivateUserSuccessResponseDTO struct {
+	Success bool `json:"success"`
+}
+
+// ChangeEmailSuccessResponseDTO represents a successful email change request response
+// @Description Response structure for successful email change requests
+type ChangeEmailSuccessResponseDTO struct {
+	Success bool `json:"success"`
+}
+
+// ConfirmEmailChangeSuccessResponseDTO represents a successful email change confirmation response
+// @Description Response structure for successful email change confirmation requests
+type ConfirmEmailChangeSuccessResponseDTO struct {
+	Success bool `json:"success"`
+}
+
+// UndoEmailChangeSuccessResponseDTO represents a successful email change undo response
+// @Description Response structure for successful email change undo requests
+type UndoEmailChangeSuccessResponseDTO struct {
+	Success bool `json:"success"`
+}
@@ -14,4 +14,5 @@ type PermissionRepository interface {
 	List(page, pageSize int) ([]models.Permission, int64, error)
 	ListByResourceName(resourceName string, page, pageSize int) ([]models.Permission, int64, error)
 	ListByAction(action string, page, pageSize int) ([]models.Permission, int64, error)
+	ListAll() ([]models.Permission, error)
 }
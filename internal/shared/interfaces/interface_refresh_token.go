@@ -4,7 +4,12 @@ import "modular-fx-fiber/internal/shared/models"
 
 type RefreshTokenRepository interface {
 	SaveRefreshToken(token *models.RefreshToken) error
-	GetRefreshToken(token string) (*models.RefreshToken, error)
-	DeleteRefreshToken(token string) error
+	GetRefreshToken(tokenHash string) (*models.RefreshToken, error)
+	RevokeRefreshToken(id uint64) (bool, error)
+	RevokeRefreshTokenFamily(familyID string) error
+	DeleteRefreshToken(tokenHash string) error
 	DeleteUserRefreshTokens(userID uint64) error
+	DeleteUserRefreshTokensExcept(userID uint64, keepFamilyID string) error
+	DeleteUserRefreshTokenFamily(userID uint64, familyID string) (bool, error)
+	ListActiveUserRefreshTokens(userID uint64) ([]models.RefreshToken, error)
 }
@@ -13,4 +13,5 @@ type RoleRepository interface {
 	List(page int, pageSize int) ([]models.Role, int64, error)
 	AssignPermissions(roleID uint64, permissionIDs []uint64) error
 	RemovePermissions(roleID uint64, permissionIDs []uint64) error
+	SetParent(roleID uint64, parentID *uint64) error
 }
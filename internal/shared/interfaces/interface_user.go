@@ -9,6 +9,8 @@ type UserRepository interface {
 	Update(user *models.User) error
 	GetByEmail(email string) (*models.User, error)
 	GetByID(id uint64) (*models.User, error)
+	GetByWebAuthnHandle(handle []byte) (*models.User, error)
+	GetByIDWithPermissions(id uint64) (*models.User, error)
 	List(page int, pageSize int) ([]models.User, int64, error)
 	Delete(id uint64) error
 }
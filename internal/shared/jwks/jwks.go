@@ -0,0 +1,74 @@
+package jwks
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"math/big"
+	"modular-fx-fiber/internal/core/server"
+	"sort"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type (
+	// KeySet is a JSON Web Key Set as defined in RFC 7517
+	KeySet struct {
+		Keys []JSONWebKey `json:"keys"`
+	}
+
+	// JSONWebKey is the public part of a signing key
+	JSONWebKey struct {
+		KeyType   string `json:"kty"`
+		KeyID     string `json:"kid"`
+		Use       string `json:"use"`
+		Algorithm string `json:"alg"`
+		Curve     string `json:"crv,omitempty"`
+		X         string `json:"x,omitempty"`
+		N         string `json:"n,omitempty"`
+		E         string `json:"e,omitempty"`
+	}
+)
+
+// PublicKeySet returns the public keys of every configured key
+func (km *keyManager) PublicKeySet() *KeySet {
+	set := &KeySet{Keys: make([]JSONWebKey, 0, len(km.keys))}
+
+	for _, key := range km.keys {
+		jwk := JSONWebKey{
+			KeyID:     key.id,
+			Use:       "sig",
+			Algorithm: key.method.Alg(),
+		}
+
+		switch k := key.publicKey.(type) {
+		case *rsa.PublicKey:
+			jwk.KeyType = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(k.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(k.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.KeyType = "OKP"
+			jwk.Curve = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(k)
+		default:
+			continue
+		}
+
+		set.Keys = append(set.Keys, jwk)
+	}
+
+	// Keep the output stable
+	sort.Slice(set.Keys, func(i, j int) bool {
+		return set.Keys[i].KeyID < set.Keys[j].KeyID
+	})
+
+	return set
+}
+
+// Register registers the JWKS route
+func Register(s server.Server, km KeyManager) {
+	s.GetApp().Get("/.well-known/jwks.json", func(c *fiber.Ctx) error {
+		c.Set(fiber.HeaderCacheControl, "public, max-age=300")
+		return c.JSON(km.PublicKeySet())
+	})
+}
@@ -0,0 +1,255 @@
+package jwks
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"modular-fx-fiber/internal/core/config"
+	"modular-fx-fiber/internal/shared/logger"
+	"os"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"go.uber.org/zap"
+)
+
+// Supported asymmetric signing algorithms
+const (
+	AlgorithmRS256 = "RS256"
+	AlgorithmEdDSA = "EdDSA"
+)
+
+var (
+	ErrUnknownKeyID        = errors.New("unknown key id")
+	ErrUnexpectedAlgorithm = errors.New("unexpected signing algorithm")
+	ErrNoSigningKey        = errors.New("no signing key configured")
+)
+
+type (
+	// KeyManager signs and verifies JWTs with the configured keys.
+	// Tokens are signed with the newest key and verified with any configured key,
+	// so keys can be rotated without invalidating issued tokens.
+	KeyManager interface {
+		Sign(claims jwt.Claims) (string, error)
+		Parse(tokenString string, claims jwt.Claims) (*jwt.Token, error)
+		PublicKeySet() *KeySet
+	}
+
+	keyManager struct {
+		keys       map[string]*signingKey
+		signingKey *signingKey
+
+		// secret signs and verifies tokens without a kid. With asymmetric keys it is nil,
+		// unless legacy HS256 tokens are accepted until secretUntil.
+		secret      []byte
+		secretUntil time.Time
+	}
+
+	signingKey struct {
+		id         string
+		method     jwt.SigningMethod
+		privateKey crypto.PrivateKey
+		publicKey  crypto.PublicKey
+	}
+)
+
+// NewKeyManager loads the signing keys from the JWT configuration.
+// Keys are listed oldest first and the last key holding a private key signs new tokens.
+// When no keys are configured, tokens are signed with HS256 and the shared secret.
+// Once keys are configured the secret only verifies tokens when jwt.accept_legacy_hs256 is set.
+func NewKeyManager(c *config.Config, l *logger.ZapLogger) (KeyManager, error) {
+	km := &keyManager{
+		keys: make(map[string]*signingKey),
+	}
+	if c.JWT.Secret != "" {
+		km.secret = []byte(c.JWT.Secret)
+	}
+
+	for _, keyConfig := range c.JWT.Keys {
+		key, err := loadKey(keyConfig)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load jwt key %q: %w", keyConfig.ID, err)
+		}
+		if _, exists := km.keys[key.id]; exists {
+			return nil, fmt.Errorf("duplicate jwt key id %q", key.id)
+		}
+
+		km.keys[key.id] = key
+		if key.privateKey != nil {
+			km.signingKey = key
+		}
+	}
+
+	if len(c.JWT.Keys) > 0 {
+		if km.signingKey == nil {
+			return nil, ErrNoSigningKey
+		}
+
+		// Anyone knowing the secret could otherwise forge tokens without a kid
+		if !c.JWT.AcceptLegacyHS256 {
+			km.secret = nil
+		} else {
+			until, err := time.Parse(time.RFC3339, c.JWT.AcceptLegacyHS256Until)
+			if err != nil {
+				return nil, fmt.Errorf("jwt.accept_legacy_hs256_until must be an RFC 3339 time: %w", err)
+			}
+			km.secretUntil = until
+			l.Warn("Accepting HS256 tokens signed with the shared secret", zap.Time("until", until))
+		}
+	}
+	if km.signingKey == nil && km.secret == nil {
+		return nil, ErrNoSigningKey
+	}
+
+	if km.signingKey != nil {
+		l.Info("JWT signing key loaded",
+			zap.String("kid", km.signingKey.id),
+			zap.String("alg", km.signingKey.method.Alg()),
+			zap.Int("verification_keys", len(km.keys)))
+	} else {
+		l.Warn("No asymmetric JWT keys configured, signing with HS256 shared secret")
+	}
+
+	return km, nil
+}
+
+// Sign signs the claims with the current signing key and sets the kid header
+func (km *keyManager) Sign(claims jwt.Claims) (string, error) {
+	if km.signingKey == nil {
+		return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(km.secret)
+	}
+
+	token := jwt.NewWithClaims(km.signingKey.method, claims)
+	token.Header["kid"] = km.signingKey.id
+	return token.SignedString(km.signingKey.privateKey)
+}
+
+// Parse parses and validates a token signed by any of the configured keys
+func (km *keyManager) Parse(tokenString string, claims jwt.Claims) (*jwt.Token, error) {
+	return jwt.ParseWithClaims(tokenString, claims, km.keyFunc)
+}
+
+// keyFunc selects the verification key from the kid header and makes sure the
+// token algorithm matches the algorithm of that key
+func (km *keyManager) keyFunc(token *jwt.Token) (any, error) {
+	kid, _ := token.Header["kid"].(string)
+
+	// Tokens without a kid are HS256 tokens signed with the shared secret
+	if kid == "" {
+		if km.secret == nil || (!km.secretUntil.IsZero() && time.Now().After(km.secretUntil)) {
+			return nil, ErrUnknownKeyID
+		}
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("%w: %v", ErrUnexpectedAlgorithm, token.Header["alg"])
+		}
+		return km.secret, nil
+	}
+
+	key, ok := km.keys[kid]
+	if !ok {
+		return nil, ErrUnknownKeyID
+	}
+	if token.Method.Alg() != key.method.Alg() {
+		return nil, fmt.Errorf("%w: %v", ErrUnexpectedAlgorithm, token.Header["alg"])
+	}
+	return key.publicKey, nil
+}
+
+// loadKey reads a key pair, or a public key only, from PEM files
+func loadKey(keyConfig config.JWTKeyConfig) (*signingKey, error) {
+	if keyConfig.ID == "" {
+		return nil, errors.New("key id is required")
+	}
+
+	key := &signingKey{id: keyConfig.ID}
+
+	switch {
+	case keyConfig.PrivateKeyFile != "":
+		privateKey, err := readPrivateKey(keyConfig.PrivateKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		key.privateKey = privateKey
+
+		switch k := privateKey.(type) {
+		case *rsa.PrivateKey:
+			key.publicKey = &k.PublicKey
+		case ed25519.PrivateKey:
+			key.publicKey = k.Public()
+		default:
+			return nil, fmt.Errorf("unsupported private key type %T", privateKey)
+		}
+
+	case keyConfig.PublicKeyFile != "":
+		publicKey, err := readPublicKey(keyConfig.PublicKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		key.publicKey = publicKey
+
+	default:
+		return nil, errors.New("private_key_file or public_key_file is required")
+	}
+
+	// Pick the signing method from the key type and check it against the configured algorithm
+	switch key.publicKey.(type) {
+	case *rsa.PublicKey:
+		key.method = jwt.SigningMethodRS256
+	case ed25519.PublicKey:
+		key.method = jwt.SigningMethodEdDSA
+	default:
+		return nil, fmt.Errorf("unsupported public key type %T", key.publicKey)
+	}
+	if keyConfig.Algorithm != "" && keyConfig.Algorithm != key.method.Alg() {
+		return nil, fmt.Errorf("algorithm %s does not match key type %s", keyConfig.Algorithm, key.method.Alg())
+	}
+
+	return key, nil
+}
+
+// readPrivateKey reads a PKCS#8 or PKCS#1 private key from a PEM file
+func readPrivateKey(path string) (crypto.PrivateKey, error) {
+	block, err := readPEM(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if key, err := x509.ParsePKCS8PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	return nil, fmt.Errorf("failed to parse private key in %s", path)
+}
+
+// readPublicKey reads a PKIX public key from a PEM file
+func readPublicKey(path string) (crypto.PublicKey, error) {
+	block, err := readPEM(path)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse public key in %s: %w", path, err)
+	}
+	return key, nil
+}
+
+func readPEM(path string) (*pem.Block, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM data found in %s", path)
+	}
+	return block, nil
+}
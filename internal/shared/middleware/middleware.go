@@ -4,7 +4,13 @@ import (
 	"errors"
 	"fmt"
 	"modular-fx-fiber/internal/core/config"
+	"modular-fx-fiber/internal/shared/jwks"
 	"modular-fx-fiber/internal/shared/logger"
+	"modular-fx-fiber/internal/shared/models"
+	"modular-fx-fiber/internal/shared/repositories"
+	"modular-fx-fiber/internal/shared/revocation"
+	"modular-fx-fiber/internal/shared/util"
+	"slices"
 	"strings"
 
 	"github.com/gofiber/fiber/v2"
@@ -12,6 +18,28 @@ import (
 	"go.uber.org/zap"
 )
 
+// Token types carried in the "typ" claim
+const (
+	TokenTypeAccess  = "access"
+	TokenTypeRefresh = "refresh"
+	TokenTypeClient  = "client" // client_credentials access tokens, they have no user
+	TokenTypeID      = "id"     // OpenID Connect id tokens
+)
+
+// Subject types carried in the "sub_typ" claim of access tokens
+const (
+	SubjectTypeUser           = "user"
+	SubjectTypeServiceAccount = "service_account"
+)
+
+const (
+	// APIKeyHeader carries the API key of requests made by scripts
+	APIKeyHeader = "X-API-Key"
+
+	// APIKeyPrefixLength is the number of leading characters of an API key kept to identify it
+	APIKeyPrefixLength = 12
+)
+
 // Define standard error types
 var (
 	ErrMissingAuthHeader = errors.New("missing authorization header")
@@ -19,111 +47,341 @@ var (
 	ErrInvalidToken      = errors.New("invalid token")
 	ErrTokenExpired      = errors.New("token expired")
 	ErrInvalidClaims     = errors.New("invalid token claims")
+	ErrTokenRevoked      = errors.New("token has been revoked")
+	ErrClientBoundToken  = errors.New("token was issued to an oauth client")
+	ErrInvalidAPIKey     = errors.New("invalid or expired api key")
+	ErrServiceAccount    = errors.New("route is not available to service accounts")
 )
 
 type (
 	Middleware interface {
 		JWT() fiber.Handler
+		Authenticate() fiber.Handler
+		RequireScope(scope string) fiber.Handler
+		RequirePermission(resourceName, action string) fiber.Handler
+		RequireAllPermissions(permissions ...string) fiber.Handler
+		RequireAnyRole(roleNames ...string) fiber.Handler
+		ParseToken(tokenString string, tokenType string) (*UserClaims, error)
 	}
 
 	middleware struct {
-		config *config.Config
-		logger *logger.ZapLogger
+		config     *config.Config
+		logger     *logger.ZapLogger
+		keyManager jwks.KeyManager
+		revocation revocation.Store
+		apiKeyRepo repositories.APIKeyRepository
+
+		userRepo           repositories.UserRepository
+		serviceAccountRepo repositories.ServiceAccountRepository
+		permissionCache    *permissionCache
 	}
 
 	// UserClaims defines the structure for JWT claims
 	UserClaims struct {
-		UserID uint64 `json:"user_id"`
-		Email  string `json:"email"`
+		UserID    uint64 `json:"user_id"`
+		Email     string `json:"email"`
+		SessionID string `json:"sid"`
+		TokenType string `json:"typ"`
+		ClientID  string `json:"client_id,omitempty"` // set when the token was issued to an oauth client
+		Scope     string `json:"scope,omitempty"`
+
+		// Service account tokens carry the service_account subject type instead of a user
+		SubjectType      string `json:"sub_typ,omitempty"`
+		ServiceAccountID uint64 `json:"service_account_id,omitempty"`
 		jwt.RegisteredClaims
 	}
 )
 
 // NewMiddleware creates a new middleware instance
-func NewMiddleware(config *config.Config, logger *logger.ZapLogger) Middleware {
+func NewMiddleware(config *config.Config, logger *logger.ZapLogger, keyManager jwks.KeyManager, revocationStore revocation.Store, apiKeyRepo repositories.APIKeyRepository, userRepo repositories.UserRepository, serviceAccountRepo repositories.ServiceAccountRepository, permissionCache *permissionCache) Middleware {
 	return &middleware{
-		config: config,
-		logger: logger,
+		config:     config,
+		logger:     logger,
+		keyManager: keyManager,
+		revocation: revocationStore,
+		apiKeyRepo: apiKeyRepo,
+
+		userRepo:           userRepo,
+		serviceAccountRepo: serviceAccountRepo,
+		permissionCache:    permissionCache,
 	}
 }
 
-// JWT middleware for protecting routes
-// JWT middleware for protecting routes
+// JWT middleware for protecting routes, only tokens of users are accepted
 func (m *middleware) JWT() fiber.Handler {
 	return func(c *fiber.Ctx) error {
-		// Get authorization header
-		authHeader := c.Get("Authorization")
-		if authHeader == "" {
-			return fiber.NewError(fiber.StatusUnauthorized, ErrMissingAuthHeader.Error())
+		if err := m.authenticateBearer(c, false); err != nil {
+			return err
 		}
 
-		// Check if the header has the right format
-		parts := strings.Split(authHeader, " ")
-		if len(parts) != 2 || parts[0] != "Bearer" {
-			return fiber.NewError(fiber.StatusUnauthorized, ErrInvalidAuthFormat.Error())
-		}
+		// Continue
+		return c.Next()
+	}
+}
 
-		// Get the token
-		tokenString := parts[1]
-
-		// Parse and validate the token
-		token, err := jwt.ParseWithClaims(
-			tokenString,
-			&UserClaims{},
-			func(token *jwt.Token) (any, error) {
-				// Validate signing method
-				if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-					return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-				}
-
-				// Return the secret key
-				return []byte(m.config.JWT.Secret), nil
-			},
-		)
-
-		// Handle parsing errors
-		if err != nil {
-			// Check for specific error types
-			if errors.Is(err, jwt.ErrTokenExpired) {
-				m.logger.Debug("Token expired", zap.Error(err))
-				return fiber.NewError(fiber.StatusUnauthorized, ErrTokenExpired.Error())
+// Authenticate middleware for routes that scripts and other backends may call. It accepts a Bearer JWT
+// of a user or service account, or an API key in the X-API-Key header, and stores the identity in the context.
+// The "subject_type" local tells users and service accounts apart, service accounts have a
+// "service_account_id" instead of a "user_id".
+// Requests made with an API key also get the "scopes" of the key, see RequireScope.
+func (m *middleware) Authenticate() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if key := c.Get(APIKeyHeader); key != "" {
+			if err := m.authenticateAPIKey(c, key); err != nil {
+				return err
 			}
+			return c.Next()
+		}
 
-			m.logger.Error("JWT parsing error", zap.Error(err))
-			return fiber.NewError(fiber.StatusUnauthorized, ErrInvalidToken.Error())
+		if err := m.authenticateBearer(c, true); err != nil {
+			return err
 		}
 
-		// Check if token is valid
-		if !token.Valid {
-			return fiber.NewError(fiber.StatusUnauthorized, ErrInvalidToken.Error())
+		// Continue
+		return c.Next()
+	}
+}
+
+// RequireScope middleware rejects requests made with an API key that was not granted the scope.
+// Requests authenticated with a user or service account JWT always pass, their roles are checked
+// by RequirePermission.
+func (m *middleware) RequireScope(scope string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		scopes, ok := c.Locals("scopes").([]string)
+		if ok && !slices.Contains(scopes, scope) {
+			return fiber.NewError(fiber.StatusForbidden, fmt.Sprintf("api key is missing the %s scope", scope))
 		}
+		return c.Next()
+	}
+}
+
+// authenticateBearer validates the Bearer access token of the request and stores its subject in the context
+func (m *middleware) authenticateBearer(c *fiber.Ctx, allowServiceAccount bool) error {
+	// Get authorization header
+	authHeader := c.Get("Authorization")
+	if authHeader == "" {
+		return fiber.NewError(fiber.StatusUnauthorized, ErrMissingAuthHeader.Error())
+	}
+
+	// Check if the header has the right format
+	parts := strings.Split(authHeader, " ")
+	if len(parts) != 2 || parts[0] != "Bearer" {
+		return fiber.NewError(fiber.StatusUnauthorized, ErrInvalidAuthFormat.Error())
+	}
+
+	// Get the token
+	tokenString := parts[1]
 
-		// Extract claims with proper type assertion
-		claims, ok := token.Claims.(*UserClaims)
-		if !ok {
-			m.logger.Error("Failed to assert token claims",
-				zap.String("claims_type", fmt.Sprintf("%T", token.Claims)))
-			return fiber.NewError(fiber.StatusUnauthorized, ErrInvalidClaims.Error())
+	// Parse and validate the token
+	claims, err := m.ParseToken(tokenString, TokenTypeAccess)
+	if err != nil {
+		if IsTokenRejected(err) {
+			return fiber.NewError(fiber.StatusUnauthorized, err.Error())
 		}
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
 
-		// Validate required claims
-		if claims.UserID == 0 || claims.Email == "" {
-			m.logger.Error("Missing required claims",
-				zap.Any("user_id", claims.UserID),
-				zap.String("email", claims.Email))
-			return fiber.NewError(fiber.StatusUnauthorized, "Invalid token claims")
+	// Tokens granted to other applications are only accepted by the oauth endpoints
+	if claims.ClientID != "" {
+		return fiber.NewError(fiber.StatusUnauthorized, ErrClientBoundToken.Error())
+	}
+
+	if claims.SubjectType == SubjectTypeServiceAccount {
+		if !allowServiceAccount {
+			return fiber.NewError(fiber.StatusForbidden, ErrServiceAccount.Error())
 		}
 
-		// Store user info in context with proper types
-		c.Locals("user_id", claims.UserID)
-		c.Locals("email", claims.Email)
+		c.Locals("subject_type", SubjectTypeServiceAccount)
+		c.Locals("service_account_id", claims.ServiceAccountID)
 
-		m.logger.Debug("JWT successfully validated",
-			zap.Uint64("user_id", claims.UserID),
-			zap.String("email", claims.Email),
+		m.logger.Debug("Service account JWT successfully validated",
+			zap.Uint64("service_account_id", claims.ServiceAccountID),
 			zap.Time("expires", claims.ExpiresAt.Time))
+		return nil
+	}
 
-		// Continue
-		return c.Next()
+	// Store user info in context with proper types
+	c.Locals("subject_type", SubjectTypeUser)
+	c.Locals("user_id", claims.UserID)
+	c.Locals("email", claims.Email)
+	c.Locals("session_id", claims.SessionID)
+
+	m.logger.Debug("JWT successfully validated",
+		zap.Uint64("user_id", claims.UserID),
+		zap.String("email", claims.Email),
+		zap.Time("expires", claims.ExpiresAt.Time))
+	return nil
+}
+
+// authenticateAPIKey validates an API key and stores its owner in the context.
+// API keys belong to no session, so the session ID is empty.
+func (m *middleware) authenticateAPIKey(c *fiber.Ctx, key string) error {
+	if !strings.HasPrefix(key, models.API_KEY_PREFIX) {
+		return fiber.NewError(fiber.StatusUnauthorized, ErrInvalidAPIKey.Error())
 	}
+
+	apiKey, err := m.apiKeyRepo.GetActiveAPIKey(util.HashToken(key))
+	if err != nil {
+		m.logger.Error("Failed to fetch api key", zap.Error(err))
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+	if apiKey == nil || apiKey.User == nil {
+		m.logger.Info("Invalid or expired api key used", zap.String("key_prefix", keyPrefix(key)))
+		return fiber.NewError(fiber.StatusUnauthorized, ErrInvalidAPIKey.Error())
+	}
+	if apiKey.User.Status != models.USER_STATUS_ACTIVE {
+		m.logger.Info("Api key of inactive user used", zap.Uint64("user_id", apiKey.UserID))
+		return fiber.NewError(fiber.StatusUnauthorized, ErrInvalidAPIKey.Error())
+	}
+
+	// Failing to record the use must not fail the request
+	if err := m.apiKeyRepo.TouchAPIKey(apiKey.ID); err != nil {
+		m.logger.Error("Failed to update api key last use", zap.Uint64("api_key_id", apiKey.ID), zap.Error(err))
+	}
+
+	// Store user info in context, the same way as for a JWT
+	c.Locals("subject_type", SubjectTypeUser)
+	c.Locals("user_id", apiKey.UserID)
+	c.Locals("email", apiKey.User.Email)
+	c.Locals("session_id", "")
+	c.Locals("api_key_id", apiKey.ID)
+	c.Locals("scopes", apiKey.Scopes)
+
+	m.logger.Debug("API key successfully validated",
+		zap.Uint64("user_id", apiKey.UserID),
+		zap.Uint64("api_key_id", apiKey.ID))
+	return nil
+}
+
+// keyPrefix returns the part of an API key that is shown in listings and safe to log
+func keyPrefix(key string) string {
+	if len(key) > APIKeyPrefixLength {
+		return key[:APIKeyPrefixLength]
+	}
+	return key
+}
+
+// ParseToken parses and validates a token of the given type and returns its claims.
+// Access tokens are also checked against the revocation store, client tokens are short-lived and are not.
+// Tokens that are not valid are reported with one of the Err* values of this package.
+func (m *middleware) ParseToken(tokenString string, tokenType string) (*UserClaims, error) {
+	// The key manager picks the verification key from the kid header
+	token, err := m.keyManager.Parse(tokenString, &UserClaims{})
+
+	// Handle parsing errors
+	if err != nil {
+		// Check for specific error types
+		if errors.Is(err, jwt.ErrTokenExpired) {
+			m.logger.Debug("Token expired", zap.Error(err))
+			return nil, ErrTokenExpired
+		}
+
+		m.logger.Error("JWT parsing error", zap.Error(err))
+		return nil, ErrInvalidToken
+	}
+
+	// Check if token is valid
+	if !token.Valid {
+		return nil, ErrInvalidToken
+	}
+
+	// Extract claims with proper type assertion
+	claims, ok := token.Claims.(*UserClaims)
+	if !ok {
+		m.logger.Error("Failed to assert token claims",
+			zap.String("claims_type", fmt.Sprintf("%T", token.Claims)))
+		return nil, ErrInvalidClaims
+	}
+
+	// Refresh tokens and MFA challenges must not be accepted as access tokens and vice versa
+	if claims.TokenType != tokenType {
+		m.logger.Warn("Token of wrong type used",
+			zap.String("typ", claims.TokenType),
+			zap.String("expected", tokenType))
+		return nil, ErrInvalidToken
+	}
+
+	// Client tokens identify a client instead of a user
+	if tokenType == TokenTypeClient {
+		if claims.ClientID == "" || claims.ID == "" {
+			m.logger.Error("Missing required claims in client token", zap.String("client_id", claims.ClientID))
+			return nil, ErrInvalidClaims
+		}
+		return claims, nil
+	}
+
+	// Validate required claims
+	if claims.SubjectType == SubjectTypeServiceAccount {
+		if claims.ServiceAccountID == 0 {
+			m.logger.Error("Missing service account id claim")
+			return nil, ErrInvalidClaims
+		}
+	} else if claims.UserID == 0 || claims.Email == "" {
+		m.logger.Error("Missing required claims",
+			zap.Any("user_id", claims.UserID),
+			zap.String("email", claims.Email))
+		return nil, ErrInvalidClaims
+	}
+
+	if tokenType != TokenTypeAccess {
+		return claims, nil
+	}
+
+	// Tokens must be identifiable to be checked against the revocation store
+	if claims.ID == "" || claims.IssuedAt == nil {
+		m.logger.Warn("Access token without jti or iat claim",
+			zap.Uint64("user_id", claims.UserID),
+			zap.Uint64("service_account_id", claims.ServiceAccountID))
+		return nil, ErrInvalidClaims
+	}
+
+	// Reject tokens revoked before their expiry
+	revoked, err := m.isRevoked(claims)
+	if err != nil {
+		m.logger.Error("Failed to check token revocation",
+			zap.Uint64("user_id", claims.UserID),
+			zap.Error(err))
+		return nil, err
+	}
+	if revoked {
+		m.logger.Info("Revoked token used",
+			zap.Uint64("user_id", claims.UserID),
+			zap.String("jti", claims.ID))
+		return nil, ErrTokenRevoked
+	}
+
+	return claims, nil
+}
+
+// isRevoked checks the token against the revoked jti list, the revoked sessions and the
+// "issued before" watermark of its user. Service account tokens have no session nor watermark.
+func (m *middleware) isRevoked(claims *UserClaims) (bool, error) {
+	revoked, err := m.revocation.IsTokenRevoked(claims.ID)
+	if err != nil || revoked || claims.UserID == 0 {
+		return revoked, err
+	}
+
+	if claims.SessionID != "" {
+		revoked, err := m.revocation.IsSessionRevoked(claims.SessionID)
+		if err != nil || revoked {
+			return revoked, err
+		}
+	}
+
+	revokedBefore, err := m.revocation.UserTokensRevokedBefore(claims.UserID)
+	if err != nil {
+		return false, err
+	}
+	// iat is truncated to the second, a token issued in the second of the revocation may predate it
+	return revokedBefore != nil && !claims.IssuedAt.Time.After(*revokedBefore), nil
+}
+
+// IsTokenRejected reports whether an error of ParseToken means the token was rejected,
+// as opposed to a failure while checking it
+func IsTokenRejected(err error) bool {
+	return errors.Is(err, ErrInvalidToken) ||
+		errors.Is(err, ErrTokenExpired) ||
+		errors.Is(err, ErrInvalidClaims) ||
+		errors.Is(err, ErrTokenRevoked) ||
+		errors.Is(err, ErrClientBoundToken)
 }
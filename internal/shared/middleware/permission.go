@@ -0,0 +1,207 @@
+package middleware
+
+import (
+	"fmt"
+	"modular-fx-fiber/internal/core/config"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+)
+
+type (
+	// permissionHolder is a caller whose roles and permissions were loaded, a user or a service account
+	permissionHolder interface {
+		HasPermission(resourceName, action string) bool
+		HasRole(roleName string) bool
+	}
+
+	// PermissionCache keeps the roles and permissions of recent callers for auth.rbac.permission_cache_seconds,
+	// so permission checks do not query the database on every request. Services changing role assignments,
+	// roles or permissions invalidate it, so the change applies on the next request of this instance.
+	PermissionCache interface {
+		InvalidateUser(userId uint64)
+		InvalidateServiceAccount(serviceAccountId uint64)
+		InvalidateAll()
+	}
+
+	// permissionCache is the PermissionCache, the middleware reads and fills it through get and set
+	permissionCache struct {
+		mu      sync.Mutex
+		ttl     time.Duration
+		entries map[string]permissionCacheEntry
+	}
+
+	permissionCacheEntry struct {
+		holder    permissionHolder
+		expiresAt time.Time
+	}
+
+	// noPermissions is the holder of callers that no longer exist
+	noPermissions struct{}
+)
+
+func (noPermissions) HasPermission(string, string) bool { return false }
+func (noPermissions) HasRole(string) bool               { return false }
+
+// NewPermissionCache creates the permission cache used by RequirePermission and RequireAnyRole.
+// It is provided as itself for the middleware and as PermissionCache for the services invalidating it.
+func NewPermissionCache(config *config.Config) *permissionCache {
+	return &permissionCache{
+		ttl:     time.Duration(config.Auth.RBAC.PermissionCacheSeconds) * time.Second,
+		entries: make(map[string]permissionCacheEntry),
+	}
+}
+
+// permissionCacheKey returns the cache key of a caller
+func permissionCacheKey(subjectType string, id uint64) string {
+	return fmt.Sprintf("%s:%d", subjectType, id)
+}
+
+// get returns the cached holder of a caller, nil when it is missing or expired
+func (pc *permissionCache) get(key string) permissionHolder {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+
+	entry, ok := pc.entries[key]
+	if !ok || time.Now().After(entry.expiresAt) {
+		return nil
+	}
+	return entry.holder
+}
+
+// set caches the holder of a caller
+func (pc *permissionCache) set(key string, holder permissionHolder) {
+	if pc.ttl <= 0 {
+		return
+	}
+
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+
+	// Drop entries that expired in the meantime
+	now := time.Now()
+	for k, entry := range pc.entries {
+		if now.After(entry.expiresAt) {
+			delete(pc.entries, k)
+		}
+	}
+
+	pc.entries[key] = permissionCacheEntry{
+		holder:    holder,
+		expiresAt: now.Add(pc.ttl),
+	}
+}
+
+// InvalidateUser drops the cached roles and permissions of a user
+func (pc *permissionCache) InvalidateUser(userId uint64) {
+	pc.invalidate(permissionCacheKey(SubjectTypeUser, userId))
+}
+
+// InvalidateServiceAccount drops the cached roles and permissions of a service account
+func (pc *permissionCache) InvalidateServiceAccount(serviceAccountId uint64) {
+	pc.invalidate(permissionCacheKey(SubjectTypeServiceAccount, serviceAccountId))
+}
+
+// InvalidateAll drops every cached caller, for changes to roles or permissions held by many callers
+func (pc *permissionCache) InvalidateAll() {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+
+	clear(pc.entries)
+}
+
+func (pc *permissionCache) invalidate(key string) {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+
+	delete(pc.entries, key)
+}
+
+// RequirePermission middleware rejects callers whose roles do not grant the permission to perform
+// the action on the resource. It must come after JWT() or Authenticate().
+func (m *middleware) RequirePermission(resourceName, action string) fiber.Handler {
+	return m.RequireAllPermissions(resourceName + ":" + action)
+}
+
+// RequireAllPermissions middleware rejects callers whose roles do not grant every one of the
+// permissions, given as "resource:action". It must come after JWT() or Authenticate().
+func (m *middleware) RequireAllPermissions(permissions ...string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		holder, err := m.permissionHolder(c)
+		if err != nil {
+			return err
+		}
+
+		for _, permission := range permissions {
+			resourceName, action, _ := strings.Cut(permission, ":")
+			if !holder.HasPermission(resourceName, action) {
+				return fiber.NewError(fiber.StatusForbidden, fmt.Sprintf("missing the %s permission", permission))
+			}
+		}
+		return c.Next()
+	}
+}
+
+// RequireAnyRole middleware rejects callers that have none of the roles. It must come after JWT() or Authenticate().
+func (m *middleware) RequireAnyRole(roleNames ...string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		holder, err := m.permissionHolder(c)
+		if err != nil {
+			return err
+		}
+
+		for _, roleName := range roleNames {
+			if holder.HasRole(roleName) {
+				return c.Next()
+			}
+		}
+		return fiber.NewError(fiber.StatusForbidden, fmt.Sprintf("missing one of the roles %s", strings.Join(roleNames, ", ")))
+	}
+}
+
+// permissionHolder loads the roles and permissions of the authenticated caller, from the cache when possible
+func (m *middleware) permissionHolder(c *fiber.Ctx) (permissionHolder, error) {
+	var key string
+	var load func() (permissionHolder, error)
+
+	switch c.Locals("subject_type") {
+	case SubjectTypeUser:
+		userId := c.Locals("user_id").(uint64)
+		key = permissionCacheKey(SubjectTypeUser, userId)
+		load = func() (permissionHolder, error) {
+			u, err := m.userRepo.GetByIDWithPermissions(userId)
+			if err != nil || u == nil {
+				return noPermissions{}, err
+			}
+			return u, nil
+		}
+	case SubjectTypeServiceAccount:
+		accountId := c.Locals("service_account_id").(uint64)
+		key = permissionCacheKey(SubjectTypeServiceAccount, accountId)
+		load = func() (permissionHolder, error) {
+			account, err := m.serviceAccountRepo.GetByIDWithPermissions(accountId)
+			if err != nil || account == nil || !account.Active {
+				return noPermissions{}, err
+			}
+			return account, nil
+		}
+	default:
+		return nil, fiber.NewError(fiber.StatusUnauthorized, ErrMissingAuthHeader.Error())
+	}
+
+	if holder := m.permissionCache.get(key); holder != nil {
+		return holder, nil
+	}
+
+	holder, err := load()
+	if err != nil {
+		m.logger.Error("Failed to load caller permissions", zap.String("subject", key), zap.Error(err))
+		return nil, fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	m.permissionCache.set(key, holder)
+	return holder, nil
+}
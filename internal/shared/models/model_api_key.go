@@ -0,0 +1,36 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// API_KEY_PREFIX starts every API key so leaked keys are easy to recognize
+const API_KEY_PREFIX = "mff_"
+
+// Scopes that can be granted to an API key
+const (
+	API_KEY_SCOPE_USERS_READ  = "users:read"
+	API_KEY_SCOPE_USERS_WRITE = "users:write"
+)
+
+// APIKey represents a personal access token for scripts and CI jobs.
+// Only the SHA-256 hash of the key is stored, the prefix identifies it in listings.
+// Revoked keys are soft deleted.
+type APIKey struct {
+	ID         uint64         `json:"id" gorm:"primaryKey;autoIncrement"`
+	UserID     uint64         `json:"user_id" gorm:"index;not null;OnDelete:CASCADE"`
+	Name       string         `json:"name" gorm:"size:100;not null"`
+	KeyPrefix  string         `json:"key_prefix" gorm:"size:16;not null"`
+	KeyHash    string         `json:"-" gorm:"uniqueIndex;size:64;not null"`
+	Scopes     []string       `json:"scopes" gorm:"type:jsonb;serializer:json;not null"`
+	ExpiresAt  *time.Time     `json:"expires_at" gorm:"type:timestamp with time zone"` // nil never expires
+	LastUsedAt *time.Time     `json:"last_used_at" gorm:"type:timestamp with time zone"`
+	CreatedAt  time.Time      `json:"created_at" gorm:"type:timestamp with time zone;not null;autoCreateTime"`
+	UpdatedAt  time.Time      `json:"updated_at" gorm:"type:timestamp with time zone;not null;autoUpdateTime"`
+	DeletedAt  gorm.DeletedAt `json:"deleted_at" gorm:"type:timestamp with time zone;index"`
+
+	// Many-to-One relationship with User
+	User *User `json:"-" gorm:"foreignKey:UserID;references:ID"`
+}
@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// EmailChangeRequest is a pending or applied change of a user's email address.
+// The new address confirms the change with one link, the old address can undo it with another.
+// Only the SHA-256 hashes of both link tokens are stored.
+type EmailChangeRequest struct {
+	ID               uint64     `json:"id" gorm:"primaryKey;autoIncrement"`
+	UserID           uint64     `json:"user_id" gorm:"index;not null;OnDelete:CASCADE"`
+	OldEmail         string     `json:"old_email" gorm:"type:varchar(255);not null"`
+	NewEmail         string     `json:"new_email" gorm:"type:varchar(255);not null"`
+	ConfirmTokenHash string     `json:"-" gorm:"uniqueIndex;size:64;not null"`
+	UndoTokenHash    string     `json:"-" gorm:"uniqueIndex;size:64;not null"`
+	ExpiresAt        time.Time  `json:"expires_at" gorm:"type:timestamp with time zone;not null"`      // until the new address can confirm
+	UndoExpiresAt    time.Time  `json:"undo_expires_at" gorm:"type:timestamp with time zone;not null"` // until the old address can undo
+	ConfirmedAt      *time.Time `json:"confirmed_at" gorm:"type:timestamp with time zone"`
+	CancelledAt      *time.Time `json:"cancelled_at" gorm:"type:timestamp with time zone"` // set when undone or replaced by a newer request
+	CreatedAt        time.Time  `json:"created_at" gorm:"type:timestamp with time zone;not null;autoCreateTime"`
+	UpdatedAt        time.Time  `json:"updated_at" gorm:"type:timestamp with time zone;not null;autoUpdateTime"`
+
+	// Many-to-One relationship with User
+	User *User `json:"-" gorm:"foreignKey:UserID;references:ID"`
+}
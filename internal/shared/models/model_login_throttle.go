@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// Kinds of login throttles
+const (
+	LOGIN_THROTTLE_ACCOUNT       = "account"       // keyed by the lowercased email
+	LOGIN_THROTTLE_IP            = "ip"            // keyed by the source IP address
+	LOGIN_THROTTLE_MFA_CHALLENGE = "mfa_challenge" // keyed by the jti of an MFA challenge token
+)
+
+// LoginThrottle tracks failed login attempts for an account or a source IP.
+// Attempts older than the configured window are forgotten on the next failure.
+type LoginThrottle struct {
+	ID             uint64     `json:"id" gorm:"primaryKey;autoIncrement"`
+	KeyType        string     `json:"key_type" gorm:"size:16;not null;uniqueIndex:idx_login_throttles_key_type_key"`
+	Key            string     `json:"key" gorm:"size:255;not null;uniqueIndex:idx_login_throttles_key_type_key"`
+	FailedAttempts int        `json:"failed_attempts" gorm:"not null;default:0"`
+	LastFailedAt   *time.Time `json:"last_failed_at" gorm:"type:timestamp with time zone"`
+	LockedUntil    *time.Time `json:"locked_until" gorm:"type:timestamp with time zone"`
+	CreatedAt      time.Time  `json:"created_at" gorm:"type:timestamp with time zone;not null;autoCreateTime"`
+	UpdatedAt      time.Time  `json:"updated_at" gorm:"type:timestamp with time zone;not null;autoUpdateTime"`
+}
@@ -0,0 +1,23 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// MagicLinkToken records a passwordless login link so it can only be used once.
+// The link itself is a signed token, only its jti is stored.
+type MagicLinkToken struct {
+	ID        uint64         `json:"id" gorm:"primaryKey;autoIncrement"`
+	UserID    uint64         `json:"user_id" gorm:"index;not null;OnDelete:CASCADE"`
+	JTI       string         `json:"jti" gorm:"uniqueIndex;size:36;not null"`
+	ExpiresAt time.Time      `json:"expires_at" gorm:"type:timestamp with time zone;not null"`
+	UsedAt    *time.Time     `json:"used_at" gorm:"type:timestamp with time zone"`
+	CreatedAt time.Time      `json:"created_at" gorm:"type:timestamp with time zone;not null;autoCreateTime"`
+	UpdatedAt time.Time      `json:"updated_at" gorm:"type:timestamp with time zone;not null;autoUpdateTime"`
+	DeletedAt gorm.DeletedAt `json:"deleted_at" gorm:"type:timestamp with time zone;index"`
+
+	// Many-to-One relationship with User
+	User *User `json:"-" gorm:"foreignKey:UserID;references:ID"`
+}
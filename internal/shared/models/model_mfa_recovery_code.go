@@ -0,0 +1,21 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// MFARecoveryCode represents a one-time MFA recovery code, stored hashed
+type MFARecoveryCode struct {
+	ID        uint64         `json:"id" gorm:"primaryKey;autoIncrement"`
+	UserID    uint64         `json:"user_id" gorm:"index;not null;OnDelete:CASCADE"`
+	CodeHash  string         `json:"-" gorm:"size:64;index;not null"`
+	UsedAt    *time.Time     `json:"used_at" gorm:"type:timestamp with time zone"`
+	CreatedAt time.Time      `json:"created_at" gorm:"type:timestamp with time zone;not null;autoCreateTime"`
+	UpdatedAt time.Time      `json:"updated_at" gorm:"type:timestamp with time zone;not null;autoUpdateTime"`
+	DeletedAt gorm.DeletedAt `json:"deleted_at" gorm:"type:timestamp with time zone;index"`
+
+	// Many-to-One relationship with User
+	User *User `json:"-" gorm:"foreignKey:UserID;references:ID"`
+}
@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// OAuthAuthorizationCode represents a single-use authorization code issued by the
+// authorize endpoint. Only the SHA-256 hash of the code is stored.
+type OAuthAuthorizationCode struct {
+	ID            uint64     `json:"id" gorm:"primaryKey;autoIncrement"`
+	CodeHash      string     `json:"-" gorm:"uniqueIndex;size:64;not null"`
+	ClientID      string     `json:"client_id" gorm:"size:64;not null"`
+	UserID        uint64     `json:"user_id" gorm:"index;not null;OnDelete:CASCADE"`
+	RedirectURI   string     `json:"redirect_uri" gorm:"size:512;not null"`
+	Scope         string     `json:"scope" gorm:"size:255;not null"`
+	Nonce         *string    `json:"-" gorm:"size:255"`
+	CodeChallenge *string    `json:"-" gorm:"size:128"` // S256 PKCE challenge
+	ExpiresAt     time.Time  `json:"expires_at" gorm:"type:timestamp with time zone;not null;index"`
+	UsedAt        *time.Time `json:"used_at" gorm:"type:timestamp with time zone"`
+	CreatedAt     time.Time  `json:"created_at" gorm:"type:timestamp with time zone;not null;autoCreateTime"`
+
+	// Many-to-One relationship with User
+	User *User `json:"-" gorm:"foreignKey:UserID;references:ID"`
+}
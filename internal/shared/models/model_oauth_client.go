@@ -0,0 +1,39 @@
+package models
+
+import (
+	"slices"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// OAuthClient represents a registered client of the OAuth and OpenID Connect endpoints.
+// Only the SHA-256 hash of the client secret is stored, public clients have no secret
+// and must use PKCE.
+type OAuthClient struct {
+	ID               uint64         `json:"id" gorm:"primaryKey;autoIncrement"`
+	ClientID         string         `json:"client_id" gorm:"uniqueIndex;size:64;not null"`
+	ClientSecretHash string         `json:"-" gorm:"size:64;not null"`
+	Name             string         `json:"name" gorm:"size:100;not null"`
+	RedirectURIs     []string       `json:"redirect_uris" gorm:"type:jsonb;serializer:json;not null"` // exact match allowlist
+	Scopes           []string       `json:"scopes" gorm:"type:jsonb;serializer:json;not null"`        // scopes the client may request
+	Public           bool           `json:"public" gorm:"column:is_public;not null;default:false"`
+	CreatedAt        time.Time      `json:"created_at" gorm:"type:timestamp with time zone;not null;autoCreateTime"`
+	UpdatedAt        time.Time      `json:"updated_at" gorm:"type:timestamp with time zone;not null;autoUpdateTime"`
+	DeletedAt        gorm.DeletedAt `json:"deleted_at" gorm:"type:timestamp with time zone;index"`
+}
+
+// AllowsRedirectURI checks the redirect URI against the allowlist of the client
+func (c *OAuthClient) AllowsRedirectURI(redirectURI string) bool {
+	return slices.Contains(c.RedirectURIs, redirectURI)
+}
+
+// AllowsScopes checks that every scope was granted to the client
+func (c *OAuthClient) AllowsScopes(scopes []string) bool {
+	for _, scope := range scopes {
+		if !slices.Contains(c.Scopes, scope) {
+			return false
+		}
+	}
+	return true
+}
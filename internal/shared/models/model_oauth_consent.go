@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// OAuthConsent records the scopes a user granted to a client, so they are not asked again
+type OAuthConsent struct {
+	ID        uint64    `json:"id" gorm:"primaryKey;autoIncrement"`
+	UserID    uint64    `json:"user_id" gorm:"not null;uniqueIndex:idx_oauth_consents_user_id_client_id;OnDelete:CASCADE"`
+	ClientID  string    `json:"client_id" gorm:"size:64;not null;uniqueIndex:idx_oauth_consents_user_id_client_id"`
+	Scope     string    `json:"scope" gorm:"size:255;not null"` // space separated
+	CreatedAt time.Time `json:"created_at" gorm:"type:timestamp with time zone;not null;autoCreateTime"`
+	UpdatedAt time.Time `json:"updated_at" gorm:"type:timestamp with time zone;not null;autoUpdateTime"`
+
+	// Many-to-One relationship with User
+	User *User `json:"-" gorm:"foreignKey:UserID;references:ID"`
+}
@@ -0,0 +1,23 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// PasswordResetToken represents a single-use password reset token in the database.
+// Only the SHA-256 hash of the token is stored, the plain token is sent by email.
+type PasswordResetToken struct {
+	ID        uint64         `json:"id" gorm:"primaryKey;autoIncrement"`
+	UserID    uint64         `json:"user_id" gorm:"index;not null;OnDelete:CASCADE"`
+	TokenHash string         `json:"-" gorm:"uniqueIndex;size:64;not null"`
+	ExpiresAt time.Time      `json:"expires_at" gorm:"type:timestamp with time zone;not null"`
+	UsedAt    *time.Time     `json:"used_at" gorm:"type:timestamp with time zone"`
+	CreatedAt time.Time      `json:"created_at" gorm:"type:timestamp with time zone;not null;autoCreateTime"`
+	UpdatedAt time.Time      `json:"updated_at" gorm:"type:timestamp with time zone;not null;autoUpdateTime"`
+	DeletedAt gorm.DeletedAt `json:"deleted_at" gorm:"type:timestamp with time zone;index"`
+
+	// Many-to-One relationship with User
+	User *User `json:"-" gorm:"foreignKey:UserID;references:ID"`
+}
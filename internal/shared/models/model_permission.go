@@ -6,6 +6,22 @@ import (
 	"gorm.io/gorm"
 )
 
+// Resources protected by permissions
+const (
+	PERMISSION_RESOURCE_USERS            = "users"
+	PERMISSION_RESOURCE_ROLES            = "roles"
+	PERMISSION_RESOURCE_PERMISSIONS      = "permissions"
+	PERMISSION_RESOURCE_SERVICE_ACCOUNTS = "service_accounts"
+)
+
+// Actions of permissions
+const (
+	PERMISSION_ACTION_CREATE = "create"
+	PERMISSION_ACTION_READ   = "read"
+	PERMISSION_ACTION_UPDATE = "update"
+	PERMISSION_ACTION_DELETE = "delete"
+)
+
 // Permission defines an action that can be performed on a resource
 type Permission struct {
 	ID           uint64         `json:"id" gorm:"primaryKey;autoIncrement"`
@@ -6,15 +6,27 @@ import (
 	"gorm.io/gorm"
 )
 
-// RefreshToken represents a refresh token in the database
+// RefreshToken represents a refresh token in the database.
+// Only the SHA-256 hash of the token is stored. Tokens rotated from the same
+// login share a FamilyID and point to the token they replaced through ParentID.
+// A family is what users see as a session, described by the device info of its latest token.
 type RefreshToken struct {
-	ID        uint64         `json:"id" gorm:"primaryKey;autoIncrement"`
-	UserID    uint64         `json:"user_id" gorm:"index;not null;OnDelete:CASCADE"`
-	Token     string         `json:"token" gorm:"uniqueIndex;size:255;not null"`
-	ExpiresAt time.Time      `json:"expires_at" gorm:"type:timestamp with time zone;not null"`
-	CreatedAt time.Time      `json:"created_at" gorm:"type:timestamp with time zone;not null;autoCreateTime"`
-	UpdatedAt time.Time      `json:"updated_at" gorm:"type:timestamp with time zone;not null;autoUpdateTime"`
-	DeletedAt gorm.DeletedAt `json:"deleted_at" gorm:"type:timestamp with time zone;index"`
+	ID          uint64         `json:"id" gorm:"primaryKey;autoIncrement"`
+	UserID      uint64         `json:"user_id" gorm:"index;not null;OnDelete:CASCADE"`
+	TokenHash   string         `json:"-" gorm:"uniqueIndex;size:64;not null"`
+	FamilyID    string         `json:"family_id" gorm:"index;size:36;not null"`
+	ParentID    *uint64        `json:"parent_id"`
+	UserAgent   *string        `json:"user_agent" gorm:"size:512"`
+	IPAddress   *string        `json:"ip_address" gorm:"size:45"`
+	DeviceLabel *string        `json:"device_label" gorm:"size:100"`
+	LastUsedAt  *time.Time     `json:"last_used_at" gorm:"type:timestamp with time zone"`
+	ClientID    *string        `json:"client_id" gorm:"size:64"` // OAuth client the session was granted to, nil for first-party logins
+	Scope       *string        `json:"scope" gorm:"size:255"`
+	ExpiresAt   time.Time      `json:"expires_at" gorm:"type:timestamp with time zone;not null"`
+	RevokedAt   *time.Time     `json:"revoked_at" gorm:"type:timestamp with time zone"`
+	CreatedAt   time.Time      `json:"created_at" gorm:"type:timestamp with time zone;not null;autoCreateTime"`
+	UpdatedAt   time.Time      `json:"updated_at" gorm:"type:timestamp with time zone;not null;autoUpdateTime"`
+	DeletedAt   gorm.DeletedAt `json:"deleted_at" gorm:"type:timestamp with time zone;index"`
 
 	// Many-to-One relationship with User
 	User *User `json:"-" gorm:"foreignKey:UserID;references:ID"`
@@ -0,0 +1,37 @@
+package models
+
+import "time"
+
+// RevokedAccessToken represents an access token revoked before its expiry, identified by its jti claim.
+// Rows can be removed once ExpiresAt has passed since the token is rejected as expired anyway.
+type RevokedAccessToken struct {
+	JTI       string    `json:"jti" gorm:"primaryKey;size:36"`
+	UserID    uint64    `json:"user_id" gorm:"index;not null;OnDelete:CASCADE"`
+	ExpiresAt time.Time `json:"expires_at" gorm:"type:timestamp with time zone;not null;index"`
+	CreatedAt time.Time `json:"created_at" gorm:"type:timestamp with time zone;not null;autoCreateTime"`
+
+	// Many-to-One relationship with User
+	User *User `json:"-" gorm:"foreignKey:UserID;references:ID"`
+}
+
+// RevokedSession invalidates every access token carrying SessionID in its sid claim.
+// Rows can be removed once ExpiresAt has passed since the tokens of the session expired by then.
+type RevokedSession struct {
+	SessionID string    `json:"session_id" gorm:"primaryKey;size:36"`
+	UserID    uint64    `json:"user_id" gorm:"index;not null;OnDelete:CASCADE"`
+	ExpiresAt time.Time `json:"expires_at" gorm:"type:timestamp with time zone;not null;index"`
+	CreatedAt time.Time `json:"created_at" gorm:"type:timestamp with time zone;not null;autoCreateTime"`
+
+	// Many-to-One relationship with User
+	User *User `json:"-" gorm:"foreignKey:UserID;references:ID"`
+}
+
+// UserTokenWatermark invalidates every access token of a user issued before RevokedBefore
+type UserTokenWatermark struct {
+	UserID        uint64    `json:"user_id" gorm:"primaryKey"`
+	RevokedBefore time.Time `json:"revoked_before" gorm:"type:timestamp with time zone;not null"`
+	UpdatedAt     time.Time `json:"updated_at" gorm:"type:timestamp with time zone;not null;autoUpdateTime"`
+
+	// One-to-One relationship with User
+	User *User `json:"-" gorm:"foreignKey:UserID;references:ID"`
+}
@@ -11,8 +11,68 @@ type Role struct {
 	ID          uint64         `json:"id" gorm:"primaryKey;autoIncrement"`
 	Name        string         `json:"name" gorm:"size:100;uniqueIndex;not null"`
 	Description string         `json:"description" gorm:"size:255"`
+	ParentID    *uint64        `json:"parent_id" gorm:"index"` // the role inherits the permissions of its parent
+	Parent      *Role          `json:"-" gorm:"foreignKey:ParentID"`
 	Permissions []Permission   `json:"permissions" gorm:"many2many:role_permissions;"`
 	CreatedAt   time.Time      `json:"created_at" gorm:"type:timestamp with time zone;not null;autoCreateTime"`
 	UpdatedAt   time.Time      `json:"updated_at" gorm:"type:timestamp with time zone;not null;autoUpdateTime"`
 	DeletedAt   gorm.DeletedAt `json:"deleted_at" gorm:"type:timestamp with time zone;index"`
 }
+
+// EffectivePermission is a permission of a role together with the role it was granted to,
+// the role itself or the ancestor it is inherited from
+type EffectivePermission struct {
+	Permission
+	GrantedBy *Role
+}
+
+// Ancestors returns the parent of the role, the parent of the parent and so on.
+// Parents must be loaded, repositories returning roles with permissions load them.
+func (r *Role) Ancestors() []*Role {
+	ancestors := []*Role{}
+	visited := map[uint64]bool{r.ID: true}
+	for parent := r.Parent; parent != nil && !visited[parent.ID]; parent = parent.Parent {
+		visited[parent.ID] = true
+		ancestors = append(ancestors, parent)
+	}
+	return ancestors
+}
+
+// EffectivePermissions returns the permissions granted to the role and inherited from its ancestors.
+// A permission granted at several levels is reported once, from the closest role.
+func (r *Role) EffectivePermissions() []EffectivePermission {
+	permissions := []EffectivePermission{}
+	seen := map[uint64]bool{}
+	for _, role := range append([]*Role{r}, r.Ancestors()...) {
+		for _, permission := range role.Permissions {
+			if !seen[permission.ID] {
+				seen[permission.ID] = true
+				permissions = append(permissions, EffectivePermission{Permission: permission, GrantedBy: role})
+			}
+		}
+	}
+	return permissions
+}
+
+// HasPermission checks if the role or one of its ancestors has the specified permission
+func (r *Role) HasPermission(resourceName, action string) bool {
+	for _, permission := range r.EffectivePermissions() {
+		if permission.ResourceName == resourceName && permission.Action == action {
+			return true
+		}
+	}
+	return false
+}
+
+// IsOrInherits checks if the role has the specified name or inherits from a role with that name
+func (r *Role) IsOrInherits(roleName string) bool {
+	if r.Name == roleName {
+		return true
+	}
+	for _, ancestor := range r.Ancestors() {
+		if ancestor.Name == roleName {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,68 @@
+package models
+
+import (
+	"slices"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ServiceAccount is a non-human identity used for backend-to-backend calls.
+// It authenticates with the client_credentials grant and holds roles like a user.
+type ServiceAccount struct {
+	ID          uint64                 `json:"id" gorm:"primaryKey;autoIncrement"`
+	Name        string                 `json:"name" gorm:"size:100;not null"`
+	Description string                 `json:"description" gorm:"size:255"`
+	ClientID    string                 `json:"client_id" gorm:"uniqueIndex;size:64;not null"`
+	Active      bool                   `json:"active" gorm:"not null;default:true"`
+	Roles       []Role                 `json:"roles" gorm:"many2many:service_account_roles;"`
+	Secrets     []ServiceAccountSecret `json:"-" gorm:"foreignKey:ServiceAccountID"`
+	CreatedAt   time.Time              `json:"created_at" gorm:"type:timestamp with time zone;not null;autoCreateTime"`
+	UpdatedAt   time.Time              `json:"updated_at" gorm:"type:timestamp with time zone;not null;autoUpdateTime"`
+	DeletedAt   gorm.DeletedAt         `json:"deleted_at" gorm:"type:timestamp with time zone;index"`
+}
+
+// ServiceAccountSecret is a client secret of a service account, only its SHA-256 hash is stored.
+// Rotating the secret gives the previous ones an expiry so callers can switch over.
+type ServiceAccountSecret struct {
+	ID               uint64     `json:"id" gorm:"primaryKey;autoIncrement"`
+	ServiceAccountID uint64     `json:"service_account_id" gorm:"index;not null;OnDelete:CASCADE"`
+	SecretHash       string     `json:"-" gorm:"size:64;not null"`
+	ExpiresAt        *time.Time `json:"expires_at" gorm:"type:timestamp with time zone"` // nil until the secret is rotated
+	LastUsedAt       *time.Time `json:"last_used_at" gorm:"type:timestamp with time zone"`
+	CreatedAt        time.Time  `json:"created_at" gorm:"type:timestamp with time zone;not null;autoCreateTime"`
+}
+
+// HasPermission checks if the service account has the specified permission, directly or inherited by one of its roles
+func (sa *ServiceAccount) HasPermission(resourceName, action string) bool {
+	for i := range sa.Roles {
+		if sa.Roles[i].HasPermission(resourceName, action) {
+			return true
+		}
+	}
+	return false
+}
+
+// Scopes returns the effective permissions of the service account's roles as "resource:action" scopes
+func (sa *ServiceAccount) Scopes() []string {
+	scopes := []string{}
+	for i := range sa.Roles {
+		for _, permission := range sa.Roles[i].EffectivePermissions() {
+			scope := permission.ResourceName + ":" + permission.Action
+			if !slices.Contains(scopes, scope) {
+				scopes = append(scopes, scope)
+			}
+		}
+	}
+	return scopes
+}
+
+// HasRole checks if the service account has the specified role or a role inheriting from it
+func (sa *ServiceAccount) HasRole(roleName string) bool {
+	for i := range sa.Roles {
+		if sa.Roles[i].IsOrInherits(roleName) {
+			return true
+		}
+	}
+	return false
+}
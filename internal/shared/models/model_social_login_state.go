@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// SocialLoginState keeps the state, nonce and PKCE verifier of a social login until the
+// provider redirects back. Only the SHA-256 hash of the state is stored and rows are
+// deleted when consumed.
+type SocialLoginState struct {
+	ID           uint64    `json:"id" gorm:"primaryKey;autoIncrement"`
+	StateHash    string    `json:"-" gorm:"uniqueIndex;size:64;not null"`
+	Provider     string    `json:"provider" gorm:"size:50;not null"`
+	Nonce        string    `json:"-" gorm:"size:64;not null"`
+	CodeVerifier string    `json:"-" gorm:"size:128;not null"`
+	ExpiresAt    time.Time `json:"expires_at" gorm:"type:timestamp with time zone;not null;index"`
+	CreatedAt    time.Time `json:"created_at" gorm:"type:timestamp with time zone;not null;autoCreateTime"`
+}
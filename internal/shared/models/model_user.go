@@ -19,22 +19,27 @@ const (
 )
 
 type User struct {
-	ID              uint64         `json:"id" gorm:"type:bigserial;primaryKey;autoIncrement"`
-	Email           string         `json:"email" gorm:"type:varchar(255);uniqueIndex;not null"`
-	PhoneNumber     *string        `json:"phone_number" gorm:"type:varchar(20)"`
-	Password        string         `json:"password" gorm:"type:varchar(255);not null"`
-	FirstName       string         `json:"first_name" gorm:"type:varchar(100);not null"`
-	LastName        string         `json:"last_name" gorm:"type:varchar(100);not null"`
-	DateOfBirth     *time.Time     `json:"date_of_birth" gorm:"type:date"`
-	Gender          *uint8         `json:"gender" gorm:"type:smallint"` // References GENDER constants
-	AvatarURL       *string        `json:"avatar_url" gorm:"type:varchar(512)"`
-	EmailVerified   bool           `json:"email_verified" gorm:"type:boolean;default:false"`
-	VerifyEmailCode *string        `json:"verify_email_code" gorm:"type:varchar(6)"` // 6-digit code for email verification
-	Status          uint8          `json:"status" gorm:"type:smallint;default:1"`    // Default to USER_STATUS_ACTIVE (1)
-	LastLoginAt     *time.Time     `json:"last_login_at" gorm:"type:timestamp with time zone"`
-	CreatedAt       time.Time      `json:"created_at" gorm:"type:timestamp with time zone;not null;default:CURRENT_TIMESTAMP"`
-	UpdatedAt       time.Time      `json:"updated_at" gorm:"type:timestamp with time zone;not null;default:CURRENT_TIMESTAMP;autoUpdateTime"`
-	DeletedAt       gorm.DeletedAt `json:"deleted_at" gorm:"type:timestamp with time zone;index"`
+	ID            uint64         `json:"id" gorm:"type:bigserial;primaryKey;autoIncrement"`
+	Email         string         `json:"email" gorm:"type:varchar(255);uniqueIndex;not null"`
+	PhoneNumber   *string        `json:"phone_number" gorm:"type:varchar(20)"`
+	PhoneVerified bool           `json:"phone_verified" gorm:"type:boolean;default:false"`
+	Password      string         `json:"password" gorm:"type:varchar(255);not null"`
+	FirstName     string         `json:"first_name" gorm:"type:varchar(100);not null"`
+	LastName      string         `json:"last_name" gorm:"type:varchar(100);not null"`
+	DateOfBirth   *time.Time     `json:"date_of_birth" gorm:"type:date"`
+	Gender        *uint8         `json:"gender" gorm:"type:smallint"` // References GENDER constants
+	AvatarURL     *string        `json:"avatar_url" gorm:"type:varchar(512)"`
+	EmailVerified bool           `json:"email_verified" gorm:"type:boolean;default:false"`
+	Status        uint8          `json:"status" gorm:"type:smallint;default:1"` // Default to USER_STATUS_ACTIVE (1)
+	MFAEnabled    bool           `json:"mfa_enabled" gorm:"type:boolean;default:false"`
+	SMSMFAEnabled bool           `json:"sms_mfa_enabled" gorm:"type:boolean;default:false"`
+	LastLoginAt   *time.Time     `json:"last_login_at" gorm:"type:timestamp with time zone"`
+	CreatedAt     time.Time      `json:"created_at" gorm:"type:timestamp with time zone;not null;default:CURRENT_TIMESTAMP"`
+	UpdatedAt     time.Time      `json:"updated_at" gorm:"type:timestamp with time zone;not null;default:CURRENT_TIMESTAMP;autoUpdateTime"`
+	DeletedAt     gorm.DeletedAt `json:"deleted_at" gorm:"type:timestamp with time zone;index"`
+
+	// Random handle identifying the user to WebAuthn authenticators, set on the first passkey registration
+	WebAuthnHandle []byte `json:"-" gorm:"type:bytea;uniqueIndex"`
 
 	// One-to-Many relationship with RefreshTokens
 	RefreshTokens []RefreshToken `json:"-" gorm:"foreignKey:UserID;references:ID;constraint:OnDelete:CASCADE"`
@@ -49,22 +54,24 @@ func (u *User) FullName() string {
 // UserResponseDTO represents the user data to be returned in API responses
 // @Description User information returned in API responses
 type UserResponseDTO struct {
-	ID              uint64     `json:"id" example:"1"`
-	Email           string     `json:"email" example:"user@example.com"`
-	PhoneNumber     *string    `json:"phone_number,omitempty" example:"+12125551234"`
-	FirstName       string     `json:"first_name" example:"John"`
-	LastName        string     `json:"last_name" example:"Doe"`
-	FullName        string     `json:"full_name" example:"John Doe"`
-	DateOfBirth     *time.Time `json:"date_of_birth,omitempty" example:"1990-01-01"`
-	Gender          *uint8     `json:"gender,omitempty" example:"1"`
-	AvatarURL       *string    `json:"avatar_url,omitempty" example:"https://example.com/avatar.jpg"`
-	EmailVerified   bool       `json:"email_verified" example:"true"`
-	VerifyEmailCode string     `json:"verify_email_code,omitempty" example:"123456"`
-	Status          uint8      `json:"status" example:"1"`
-	LastLoginAt     *time.Time `json:"last_login_at,omitempty" example:"2023-01-01T12:00:00Z"`
-	CreatedAt       time.Time  `json:"created_at" example:"2023-01-01T00:00:00Z"`
-	UpdatedAt       time.Time  `json:"updated_at" example:"2023-01-01T12:34:56Z"`
-	DeletedAt       *time.Time `json:"deleted_at,omitempty" example:"2023-01-10T00:00:00Z"`
+	ID            uint64     `json:"id" example:"1"`
+	Email         string     `json:"email" example:"user@example.com"`
+	PhoneNumber   *string    `json:"phone_number,omitempty" example:"+12125551234"`
+	PhoneVerified bool       `json:"phone_verified" example:"false"`
+	FirstName     string     `json:"first_name" example:"John"`
+	LastName      string     `json:"last_name" example:"Doe"`
+	FullName      string     `json:"full_name" example:"John Doe"`
+	DateOfBirth   *time.Time `json:"date_of_birth,omitempty" example:"1990-01-01"`
+	Gender        *uint8     `json:"gender,omitempty" example:"1"`
+	AvatarURL     *string    `json:"avatar_url,omitempty" example:"https://example.com/avatar.jpg"`
+	EmailVerified bool       `json:"email_verified" example:"true"`
+	MFAEnabled    bool       `json:"mfa_enabled" example:"false"`
+	SMSMFAEnabled bool       `json:"sms_mfa_enabled" example:"false"`
+	Status        uint8      `json:"status" example:"1"`
+	LastLoginAt   *time.Time `json:"last_login_at,omitempty" example:"2023-01-01T12:00:00Z"`
+	CreatedAt     time.Time  `json:"created_at" example:"2023-01-01T00:00:00Z"`
+	UpdatedAt     time.Time  `json:"updated_at" example:"2023-01-01T12:34:56Z"`
+	DeletedAt     *time.Time `json:"deleted_at,omitempty" example:"2023-01-10T00:00:00Z"`
 }
 
 func (u *User) ToResponseDTO() *UserResponseDTO {
@@ -72,6 +79,7 @@ func (u *User) ToResponseDTO() *UserResponseDTO {
 		ID:            u.ID,
 		Email:         u.Email,
 		PhoneNumber:   u.PhoneNumber,
+		PhoneVerified: u.PhoneVerified,
 		FirstName:     u.FirstName,
 		LastName:      u.LastName,
 		FullName:      u.FullName(),
@@ -79,6 +87,8 @@ func (u *User) ToResponseDTO() *UserResponseDTO {
 		Gender:        u.Gender,
 		AvatarURL:     u.AvatarURL,
 		EmailVerified: u.EmailVerified,
+		MFAEnabled:    u.MFAEnabled,
+		SMSMFAEnabled: u.SMSMFAEnabled,
 		Status:        u.Status,
 		LastLoginAt:   u.LastLoginAt,
 		CreatedAt:     u.CreatedAt,
@@ -87,22 +97,20 @@ func (u *User) ToResponseDTO() *UserResponseDTO {
 	}
 }
 
-// HasPermission checks if the user has the specified permission
+// HasPermission checks if the user has the specified permission, directly or inherited by one of their roles
 func (u *User) HasPermission(resourceName, action string) bool {
-	for _, role := range u.Roles {
-		for _, permission := range role.Permissions {
-			if permission.ResourceName == resourceName && permission.Action == action {
-				return true
-			}
+	for i := range u.Roles {
+		if u.Roles[i].HasPermission(resourceName, action) {
+			return true
 		}
 	}
 	return false
 }
 
-// HasRole checks if the user has the specified role
+// HasRole checks if the user has the specified role or a role inheriting from it
 func (u *User) HasRole(roleName string) bool {
-	for _, role := range u.Roles {
-		if role.Name == roleName {
+	for i := range u.Roles {
+		if u.Roles[i].IsOrInherits(roleName) {
 			return true
 		}
 	}
@@ -0,0 +1,23 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// UserTOTPSecret holds a user's TOTP secret, encrypted at rest.
+// The secret is only active for MFA once ConfirmedAt is set.
+type UserTOTPSecret struct {
+	ID              uint64         `json:"id" gorm:"primaryKey;autoIncrement"`
+	UserID          uint64         `json:"user_id" gorm:"index;not null;OnDelete:CASCADE"`
+	SecretEncrypted string         `json:"-" gorm:"type:text;not null"`
+	LastUsedStep    int64          `json:"-" gorm:"not null;default:0"` // last accepted TOTP time step, prevents code replay
+	ConfirmedAt     *time.Time     `json:"confirmed_at" gorm:"type:timestamp with time zone"`
+	CreatedAt       time.Time      `json:"created_at" gorm:"type:timestamp with time zone;not null;autoCreateTime"`
+	UpdatedAt       time.Time      `json:"updated_at" gorm:"type:timestamp with time zone;not null;autoUpdateTime"`
+	DeletedAt       gorm.DeletedAt `json:"deleted_at" gorm:"type:timestamp with time zone;index"`
+
+	// Many-to-One relationship with User
+	User *User `json:"-" gorm:"foreignKey:UserID;references:ID"`
+}
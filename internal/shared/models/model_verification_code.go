@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+// Purposes of verification codes, a user has at most one active code per purpose
+const (
+	VERIFICATION_PURPOSE_EMAIL   = "email_verification"
+	VERIFICATION_PURPOSE_PHONE   = "phone_verification"
+	VERIFICATION_PURPOSE_MFA_SMS = "mfa_sms"
+)
+
+// VerificationCode is a short numeric code sent to a user to prove ownership of an address.
+// Only the SHA-256 hash of the code is stored, and it is locked after too many wrong guesses.
+type VerificationCode struct {
+	ID        uint64     `json:"id" gorm:"primaryKey;autoIncrement"`
+	UserID    uint64     `json:"user_id" gorm:"index:idx_verification_codes_user_id_purpose;not null;OnDelete:CASCADE"`
+	Purpose   string     `json:"purpose" gorm:"index:idx_verification_codes_user_id_purpose;size:32;not null"`
+	CodeHash  string     `json:"-" gorm:"size:64;not null"`
+	Target    *string    `json:"target" gorm:"size:255;index:idx_verification_codes_target_created_at"`
+	Attempts  int        `json:"attempts" gorm:"not null;default:0"` // wrong guesses so far
+	ExpiresAt time.Time  `json:"expires_at" gorm:"type:timestamp with time zone;not null;index"`
+	UsedAt    *time.Time `json:"used_at" gorm:"type:timestamp with time zone"`
+	CreatedAt time.Time  `json:"created_at" gorm:"type:timestamp with time zone;not null;autoCreateTime;index:idx_verification_codes_target_created_at"`
+
+	// Many-to-One relationship with User
+	User *User `json:"-" gorm:"foreignKey:UserID;references:ID"`
+}
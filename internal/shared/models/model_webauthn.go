@@ -0,0 +1,46 @@
+package models
+
+import "time"
+
+// Ceremonies a WebAuthn session is started for
+const (
+	WEBAUTHN_CEREMONY_REGISTRATION = "registration"
+	WEBAUTHN_CEREMONY_LOGIN        = "login"
+	WEBAUTHN_CEREMONY_MFA          = "mfa"
+)
+
+// WebAuthnCredential is a passkey or security key registered by a user.
+// The sign count and flags are updated on every login so cloned authenticators can be detected.
+type WebAuthnCredential struct {
+	ID              uint64     `json:"id" gorm:"primaryKey;autoIncrement"`
+	UserID          uint64     `json:"user_id" gorm:"index;not null;OnDelete:CASCADE"`
+	Name            string     `json:"name" gorm:"size:100;not null"`
+	CredentialID    []byte     `json:"-" gorm:"type:bytea;uniqueIndex;not null"`
+	PublicKey       []byte     `json:"-" gorm:"type:bytea;not null"`
+	AttestationType string     `json:"attestation_type" gorm:"size:50;not null;default:''"`
+	AAGUID          []byte     `json:"-" gorm:"column:aaguid;type:bytea"`
+	SignCount       uint32     `json:"sign_count" gorm:"type:bigint;not null;default:0"`
+	CloneWarning    bool       `json:"clone_warning" gorm:"not null;default:false"`
+	Transports      string     `json:"transports" gorm:"size:255;not null;default:''"` // comma separated
+	UserVerified    bool       `json:"user_verified" gorm:"not null;default:false"`
+	BackupEligible  bool       `json:"backup_eligible" gorm:"not null;default:false"`
+	BackupState     bool       `json:"backup_state" gorm:"not null;default:false"`
+	LastUsedAt      *time.Time `json:"last_used_at" gorm:"type:timestamp with time zone"`
+	CreatedAt       time.Time  `json:"created_at" gorm:"type:timestamp with time zone;not null;autoCreateTime"`
+
+	// Many-to-One relationship with User
+	User *User `json:"-" gorm:"foreignKey:UserID;references:ID"`
+}
+
+// WebAuthnSession keeps the challenge of a WebAuthn ceremony until the client answers it.
+// Only the SHA-256 hash of the session ID is stored and rows are deleted when consumed.
+// UserID is empty for usernameless logins.
+type WebAuthnSession struct {
+	ID          uint64    `json:"id" gorm:"primaryKey;autoIncrement"`
+	SessionHash string    `json:"-" gorm:"uniqueIndex;size:64;not null"`
+	UserID      *uint64   `json:"user_id" gorm:"OnDelete:CASCADE"`
+	Ceremony    string    `json:"ceremony" gorm:"size:20;not null"`
+	Data        string    `json:"-" gorm:"type:text;not null"` // webauthn.SessionData as JSON
+	ExpiresAt   time.Time `json:"expires_at" gorm:"type:timestamp with time zone;not null;index"`
+	CreatedAt   time.Time `json:"created_at" gorm:"type:timestamp with time zone;not null;autoCreateTime"`
+}
@@ -2,9 +2,13 @@ package shared
 
 import (
 	"modular-fx-fiber/internal/shared/database"
+	"modular-fx-fiber/internal/shared/jwks"
 	"modular-fx-fiber/internal/shared/logger"
 	"modular-fx-fiber/internal/shared/middleware"
+	"modular-fx-fiber/internal/shared/password"
+	"modular-fx-fiber/internal/shared/rbac"
 	"modular-fx-fiber/internal/shared/repositories"
+	"modular-fx-fiber/internal/shared/revocation"
 	"modular-fx-fiber/internal/shared/swagger"
 	"modular-fx-fiber/internal/shared/validator"
 
@@ -15,13 +19,42 @@ import (
 var Module = fx.Options(
 	fx.Provide(
 		database.NewDatabase,
+		jwks.NewKeyManager,
 		logger.NewZapLogger,
 		middleware.NewMiddleware,
+		fx.Annotate(middleware.NewPermissionCache, fx.As(fx.Self()), fx.As(new(middleware.PermissionCache))),
+		password.NewHasher,
+		password.NewPolicy,
+		rbac.NewCatalog,
+		rbac.NewSyncer,
+		revocation.NewStore,
 		swagger.NewSwagger,
 		validator.NewValidator,
 		// Repositories
 		repositories.NewUserRepository,
+		repositories.NewRoleRepository,
+		repositories.NewPermissionRepository,
+		repositories.NewRolePermissionRepository,
+		repositories.NewUserRoleRepository,
 		repositories.NewRefreshTokenRepository,
+		repositories.NewPasswordResetTokenRepository,
+		repositories.NewTOTPSecretRepository,
+		repositories.NewMFARecoveryCodeRepository,
+		repositories.NewOAuthClientRepository,
+		repositories.NewLoginThrottleRepository,
+		repositories.NewMagicLinkTokenRepository,
+		repositories.NewUserIdentityRepository,
+		repositories.NewSocialLoginStateRepository,
+		repositories.NewOAuthAuthorizationCodeRepository,
+		repositories.NewOAuthConsentRepository,
+		repositories.NewAPIKeyRepository,
+		repositories.NewServiceAccountRepository,
+		repositories.NewVerificationCodeRepository,
+		repositories.NewEmailChangeRequestRepository,
+		repositories.NewWebAuthnCredentialRepository,
+		repositories.NewWebAuthnSessionRepository,
 	),
 	fx.Invoke(swagger.Register),
+	fx.Invoke(jwks.Register),
+	fx.Invoke(rbac.Register),
 )
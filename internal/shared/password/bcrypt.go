@@ -0,0 +1,45 @@
+package password
+
+import (
+	"errors"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+type bcryptHasher struct {
+	cost int
+}
+
+// newBcrypt creates the bcrypt algorithm, kept to verify the hashes made before argon2id
+func newBcrypt(cost int) *bcryptHasher {
+	if cost == 0 {
+		cost = bcrypt.DefaultCost
+	}
+	return &bcryptHasher{cost: cost}
+}
+
+// hash returns the bcrypt hash of a password, the cost is encoded in it
+func (h *bcryptHasher) hash(password string) (string, error) {
+	hashed, err := bcrypt.GenerateFromPassword([]byte(password), h.cost)
+	if err != nil {
+		return "", err
+	}
+	return string(hashed), nil
+}
+
+// verify compares a password with a bcrypt hash, current reports whether it has the configured cost
+func (h *bcryptHasher) verify(encodedHash string, password string) (bool, bool, error) {
+	err := bcrypt.CompareHashAndPassword([]byte(encodedHash), []byte(password))
+	if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+		return false, false, nil
+	}
+	if err != nil {
+		return false, false, ErrUnsupportedHash
+	}
+
+	cost, err := bcrypt.Cost([]byte(encodedHash))
+	if err != nil {
+		return false, false, ErrUnsupportedHash
+	}
+	return true, cost == h.cost, nil
+}
@@ -0,0 +1,75 @@
+package password
+
+import (
+	"bufio"
+	"os"
+	"strings"
+)
+
+// blocklistPrefixLength is the length of the SHA-1 prefix the blocklist is keyed by, the same
+// 5 hex characters the Pwned Passwords range API uses
+const blocklistPrefixLength = 5
+
+// Blocklist holds the SHA-1 hashes of breached passwords
+type Blocklist interface {
+	// Contains reports whether the uppercase hex SHA-1 hash is on the list
+	Contains(sha1Hash string) bool
+}
+
+// prefixBlocklist groups the hash suffixes by their 5 character prefix
+type prefixBlocklist map[string]map[string]struct{}
+
+// LoadBlocklist reads a breached password file, one uppercase or lowercase hex SHA-1 hash per line.
+// A ":count" suffix as in the Pwned Passwords downloads, blank lines and lines starting with # are ignored.
+// An empty path disables the blocklist.
+func LoadBlocklist(path string) (Blocklist, error) {
+	if path == "" {
+		return nil, nil
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	blocklist := prefixBlocklist{}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		hash, _, _ := strings.Cut(line, ":")
+		hash = strings.ToUpper(hash)
+		if len(hash) != 40 {
+			continue
+		}
+
+		prefix, suffix := hash[:blocklistPrefixLength], hash[blocklistPrefixLength:]
+		if blocklist[prefix] == nil {
+			blocklist[prefix] = map[string]struct{}{}
+		}
+		blocklist[prefix][suffix] = struct{}{}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return blocklist, nil
+}
+
+// Contains reports whether the hash is on the list
+func (b prefixBlocklist) Contains(sha1Hash string) bool {
+	if len(sha1Hash) != 40 {
+		return false
+	}
+
+	suffixes, ok := b[sha1Hash[:blocklistPrefixLength]]
+	if !ok {
+		return false
+	}
+	_, ok = suffixes[sha1Hash[blocklistPrefixLength:]]
+	return ok
+}
@@ -0,0 +1,95 @@
+package password
+
+import (
+	"errors"
+	"fmt"
+	"modular-fx-fiber/internal/core/config"
+	"strings"
+)
+
+// Algorithms selectable with auth.password_hashing.algorithm
+const (
+	AlgorithmArgon2id = "argon2id"
+	AlgorithmBcrypt   = "bcrypt"
+)
+
+var (
+	ErrUnsupportedHash = errors.New("unsupported password hash format")
+)
+
+// Hasher hashes passwords with the configured algorithm and verifies hashes of every supported algorithm.
+// Hashes are self-describing: the algorithm and its parameters are encoded in the stored value,
+// "$argon2id$v=19$m=65536,t=3,p=2$<salt>$<key>" for argon2id and the usual "$2a$10$..." for bcrypt.
+type Hasher interface {
+	// Hash returns the encoded hash of a password
+	Hash(password string) (string, error)
+	// Verify checks a password against an encoded hash. needsRehash reports a matching hash
+	// that was made with another algorithm or other parameters than configured.
+	Verify(encodedHash string, password string) (match bool, needsRehash bool, err error)
+}
+
+// algorithm is a single hashing algorithm with its parameters
+type algorithm interface {
+	hash(password string) (string, error)
+	verify(encodedHash string, password string) (match bool, current bool, err error)
+}
+
+type hasher struct {
+	current    algorithm
+	algorithms map[string]algorithm
+}
+
+// NewHasher creates the password hasher configured in auth.password_hashing
+func NewHasher(c *config.Config) (Hasher, error) {
+	cfg := c.Auth.PasswordHashing
+
+	h := &hasher{
+		algorithms: map[string]algorithm{
+			AlgorithmArgon2id: newArgon2id(cfg.Argon2id),
+			AlgorithmBcrypt:   newBcrypt(cfg.BcryptCost),
+		},
+	}
+
+	name := cfg.Algorithm
+	if name == "" {
+		name = AlgorithmArgon2id
+	}
+	current, ok := h.algorithms[name]
+	if !ok {
+		return nil, fmt.Errorf("unsupported password hashing algorithm %q", cfg.Algorithm)
+	}
+	h.current = current
+
+	return h, nil
+}
+
+// Hash returns the encoded hash of a password made with the configured algorithm
+func (h *hasher) Hash(password string) (string, error) {
+	return h.current.hash(password)
+}
+
+// Verify checks a password against a hash of any supported algorithm
+func (h *hasher) Verify(encodedHash string, password string) (bool, bool, error) {
+	alg, ok := h.algorithms[algorithmOf(encodedHash)]
+	if !ok {
+		return false, false, ErrUnsupportedHash
+	}
+
+	match, current, err := alg.verify(encodedHash, password)
+	if err != nil || !match {
+		return false, false, err
+	}
+	return true, alg != h.current || !current, nil
+}
+
+// algorithmOf detects the algorithm of an encoded hash
+func algorithmOf(encodedHash string) string {
+	switch {
+	case strings.HasPrefix(encodedHash, "$argon2id$"):
+		return AlgorithmArgon2id
+	case strings.HasPrefix(encodedHash, "$2a$"), strings.HasPrefix(encodedHash, "$2b$"), strings.HasPrefix(encodedHash, "$2y$"):
+		return AlgorithmBcrypt
+	default:
+		return ""
+	}
+}
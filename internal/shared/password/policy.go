@@ -0,0 +1,166 @@
+package password
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"modular-fx-fiber/internal/core/config"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Violation codes of the password policy, returned to clients as is
+const (
+	ViolationTooShort         = "too_short"
+	ViolationTooLong          = "too_long"
+	ViolationMissingLowercase = "missing_lowercase"
+	ViolationMissingUppercase = "missing_uppercase"
+	ViolationMissingDigit     = "missing_digit"
+	ViolationMissingSymbol    = "missing_symbol"
+	ViolationPersonalInfo     = "contains_personal_info"
+	ViolationBreached         = "breached"
+)
+
+// minPersonalInfoLength ignores parts of the email and names too short to be meaningful, like "jo"
+const minPersonalInfoLength = 3
+
+type (
+	// Policy checks new passwords against the rules configured in auth.password_policy
+	Policy interface {
+		// Check returns a *PolicyError listing every rule the password breaks, or nil.
+		// personalInfo holds the email and names of the user, the password must not contain them.
+		Check(password string, personalInfo ...string) error
+	}
+
+	// Violation is a single broken rule of the password policy
+	Violation struct {
+		Code    string `json:"code"    example:"too_short"`
+		Message string `json:"message" example:"password must be at least 8 characters long"`
+	}
+
+	// PolicyError is returned when a password breaks the policy
+	PolicyError struct {
+		Violations []Violation
+	}
+
+	// PolicyErrorResponse is the response body of a password rejected by the policy
+	PolicyErrorResponse struct {
+		Success    bool        `json:"success" default:"false"`
+		Message    string      `json:"message" example:"password does not meet the password policy"`
+		Status     int         `json:"status"  example:"400"`
+		Violations []Violation `json:"violations"`
+	}
+
+	policy struct {
+		config    config.PasswordPolicyConfig
+		blocklist Blocklist
+	}
+)
+
+func (e *PolicyError) Error() string {
+	return "password does not meet the password policy"
+}
+
+// NewPolicy creates the password policy configured in auth.password_policy,
+// loading the breached password blocklist when a file is configured
+func NewPolicy(c *config.Config) (Policy, error) {
+	cfg := c.Auth.PasswordPolicy
+
+	blocklist, err := LoadBlocklist(cfg.BlocklistFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load password blocklist: %w", err)
+	}
+
+	return &policy{
+		config:    cfg,
+		blocklist: blocklist,
+	}, nil
+}
+
+// Check validates a password against every rule of the policy
+func (p *policy) Check(password string, personalInfo ...string) error {
+	var violations []Violation
+	add := func(code string, format string, args ...any) {
+		violations = append(violations, Violation{Code: code, Message: fmt.Sprintf(format, args...)})
+	}
+
+	length := utf8.RuneCountInString(password)
+	if length < p.config.MinLength {
+		add(ViolationTooShort, "password must be at least %d characters long", p.config.MinLength)
+	}
+	if p.config.MaxLength > 0 && length > p.config.MaxLength {
+		add(ViolationTooLong, "password must be at most %d characters long", p.config.MaxLength)
+	}
+
+	var lower, upper, digit, symbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsDigit(r):
+			digit = true
+		case unicode.IsPunct(r), unicode.IsSymbol(r), unicode.IsSpace(r):
+			symbol = true
+		}
+	}
+	if p.config.RequireLowercase && !lower {
+		add(ViolationMissingLowercase, "password must contain a lowercase letter")
+	}
+	if p.config.RequireUppercase && !upper {
+		add(ViolationMissingUppercase, "password must contain an uppercase letter")
+	}
+	if p.config.RequireDigit && !digit {
+		add(ViolationMissingDigit, "password must contain a digit")
+	}
+	if p.config.RequireSymbol && !symbol {
+		add(ViolationMissingSymbol, "password must contain a symbol")
+	}
+
+	if p.config.RejectPersonalInfo && containsPersonalInfo(password, personalInfo) {
+		add(ViolationPersonalInfo, "password must not contain your email address or name")
+	}
+
+	if p.blocklist != nil && p.blocklist.Contains(sha1Hex(password)) {
+		add(ViolationBreached, "password appeared in a data breach, choose another one")
+	}
+
+	if len(violations) == 0 {
+		return nil
+	}
+	return &PolicyError{Violations: violations}
+}
+
+// containsPersonalInfo reports whether the password contains a part of the local part of the
+// email address or of the names of the user, ignoring case. The email domain is left out, its
+// labels like "mail" or "com" are shared by many users and say nothing about this one.
+func containsPersonalInfo(password string, personalInfo []string) bool {
+	password = strings.ToLower(password)
+	for _, info := range personalInfo {
+		if at := strings.LastIndex(info, "@"); at >= 0 {
+			info = info[:at]
+		}
+
+		// Split "john.doe" into "john" and "doe"
+		parts := strings.FieldsFunc(strings.ToLower(info), func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+		})
+		for _, part := range parts {
+			if utf8.RuneCountInString(part) < minPersonalInfoLength {
+				continue
+			}
+			if strings.Contains(password, part) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// sha1Hex returns the uppercase hex SHA-1 of a password, the format of breached password lists
+func sha1Hex(password string) string {
+	sum := sha1.Sum([]byte(password))
+	return strings.ToUpper(hex.EncodeToString(sum[:]))
+}
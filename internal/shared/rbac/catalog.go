@@ -0,0 +1,64 @@
+package rbac
+
+import (
+	"sort"
+
+	"go.uber.org/fx"
+)
+
+type (
+	// Permission is a permission a module checks, see Declare
+	Permission struct {
+		ResourceName string
+		Action       string
+		Description  string
+	}
+
+	// Catalog holds the permissions declared by every module
+	Catalog struct {
+		permissions []Permission
+	}
+
+	// CatalogParams collects the declared permissions from the fx value group
+	CatalogParams struct {
+		fx.In
+
+		Permissions []Permission `group:"rbac_permissions"`
+	}
+)
+
+// Key returns the "resource:action" form of the permission, the form used by RequireAllPermissions and scopes
+func (p Permission) Key() string {
+	return p.ResourceName + ":" + p.Action
+}
+
+// Declare adds permissions to the catalog, modules include it in their fx options for the permissions their routes check
+func Declare(permissions ...Permission) fx.Option {
+	return fx.Provide(fx.Annotate(
+		func() []Permission { return permissions },
+		fx.ResultTags(`group:"rbac_permissions,flatten"`),
+	))
+}
+
+// NewCatalog creates the catalog of declared permissions. Permissions declared by several modules are kept once.
+func NewCatalog(p CatalogParams) *Catalog {
+	seen := make(map[string]bool, len(p.Permissions))
+	permissions := make([]Permission, 0, len(p.Permissions))
+	for _, permission := range p.Permissions {
+		if seen[permission.Key()] {
+			continue
+		}
+		seen[permission.Key()] = true
+		permissions = append(permissions, permission)
+	}
+
+	sort.Slice(permissions, func(i, j int) bool {
+		return permissions[i].Key() < permissions[j].Key()
+	})
+	return &Catalog{permissions: permissions}
+}
+
+// Permissions returns the declared permissions ordered by resource and action
+func (c *Catalog) Permissions() []Permission {
+	return c.permissions
+}
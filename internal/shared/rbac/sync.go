@@ -0,0 +1,243 @@
+package rbac
+
+import (
+	"context"
+	"errors"
+	"modular-fx-fiber/internal/core/config"
+	"modular-fx-fiber/internal/shared/logger"
+	"modular-fx-fiber/internal/shared/models"
+	"modular-fx-fiber/internal/shared/repositories"
+	"strings"
+
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+)
+
+type (
+	// Syncer brings the permissions table in line with the catalog and creates the default roles
+	Syncer interface {
+		Sync() (*SyncResult, error)
+	}
+
+	// SyncResult reports what a sync changed
+	SyncResult struct {
+		Created      []string // declared permissions that were missing
+		Updated      []string // declared permissions whose description changed
+		Orphaned     []string // permissions in the database that no module declares
+		RolesCreated []string
+	}
+
+	syncer struct {
+		config  *config.Config
+		logger  *logger.ZapLogger
+		catalog *Catalog
+
+		permissionRepo repositories.PermissionRepository
+		roleRepo       repositories.RoleRepository
+	}
+)
+
+// NewSyncer creates a new permission syncer
+func NewSyncer(
+	config *config.Config,
+	logger *logger.ZapLogger,
+	catalog *Catalog,
+	permissionRepo repositories.PermissionRepository,
+	roleRepo repositories.RoleRepository,
+) Syncer {
+	return &syncer{
+		config:         config,
+		logger:         logger,
+		catalog:        catalog,
+		permissionRepo: permissionRepo,
+		roleRepo:       roleRepo,
+	}
+}
+
+// Register syncs the permissions when the server starts, if auth.rbac.sync_on_startup is set
+func Register(lc fx.Lifecycle, c *config.Config, s Syncer) {
+	if !c.Auth.RBAC.SyncOnStartup {
+		return
+	}
+
+	lc.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			_, err := s.Sync()
+			return err
+		},
+	})
+}
+
+// Sync creates the declared permissions that are missing and updates their descriptions.
+// Permissions no module declares are reported as orphaned but kept, they may be granted by hand.
+// Then the default roles are created when missing, given their permissions and their parent.
+func (s *syncer) Sync() (*SyncResult, error) {
+	result := &SyncResult{}
+
+	for _, declared := range s.catalog.Permissions() {
+		permission, err := s.permissionRepo.GetByResourceAndAction(declared.ResourceName, declared.Action)
+		if err != nil {
+			s.logger.Error("Failed to fetch permission", zap.String("permission", declared.Key()), zap.Error(err))
+			return nil, err
+		}
+
+		if permission == nil {
+			permission = &models.Permission{
+				Name:         declared.Key(),
+				Description:  declared.Description,
+				ResourceName: declared.ResourceName,
+				Action:       declared.Action,
+			}
+			if err := s.permissionRepo.Create(permission); err != nil {
+				s.logger.Error("Failed to save permission", zap.String("permission", declared.Key()), zap.Error(err))
+				return nil, err
+			}
+			result.Created = append(result.Created, declared.Key())
+			continue
+		}
+
+		if permission.Description != declared.Description {
+			permission.Description = declared.Description
+			if err := s.permissionRepo.Update(permission); err != nil {
+				s.logger.Error("Failed to update permission", zap.String("permission", declared.Key()), zap.Error(err))
+				return nil, err
+			}
+			result.Updated = append(result.Updated, declared.Key())
+		}
+	}
+
+	permissions, err := s.permissionRepo.ListAll()
+	if err != nil {
+		s.logger.Error("Failed to list permissions", zap.Error(err))
+		return nil, err
+	}
+
+	declared := make(map[string]bool, len(s.catalog.Permissions()))
+	for _, permission := range s.catalog.Permissions() {
+		declared[permission.Key()] = true
+	}
+	for _, permission := range permissions {
+		key := permission.ResourceName + ":" + permission.Action
+		if !declared[key] {
+			s.logger.Warn("Permission is not declared by any module", zap.String("permission", key))
+			result.Orphaned = append(result.Orphaned, key)
+		}
+	}
+
+	for _, roleConfig := range s.config.Auth.RBAC.DefaultRoles {
+		created, err := s.seedRole(roleConfig, permissions)
+		if err != nil {
+			return nil, err
+		}
+		if created {
+			result.RolesCreated = append(result.RolesCreated, roleConfig.Name)
+		}
+	}
+
+	// Parents are set once every default role exists
+	for _, roleConfig := range s.config.Auth.RBAC.DefaultRoles {
+		if roleConfig.Parent != "" {
+			if err := s.seedRoleParent(roleConfig); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	s.logger.Info("Permissions synced",
+		zap.Strings("created", result.Created),
+		zap.Strings("updated", result.Updated),
+		zap.Int("orphaned", len(result.Orphaned)),
+		zap.Strings("roles_created", result.RolesCreated))
+	return result, nil
+}
+
+// seedRole creates a default role when it is missing and grants it the permissions its patterns match.
+// Permissions the role already has are kept, so permissions granted by hand survive a sync.
+func (s *syncer) seedRole(roleConfig config.RoleSeedConfig, permissions []models.Permission) (bool, error) {
+	role, err := s.roleRepo.GetByName(roleConfig.Name)
+	if err != nil {
+		s.logger.Error("Failed to fetch role", zap.String("name", roleConfig.Name), zap.Error(err))
+		return false, err
+	}
+
+	created := false
+	if role == nil {
+		role = &models.Role{
+			Name:        roleConfig.Name,
+			Description: roleConfig.Description,
+		}
+		if err := s.roleRepo.Create(role); err != nil {
+			s.logger.Error("Failed to save role", zap.String("name", roleConfig.Name), zap.Error(err))
+			return false, err
+		}
+		created = true
+	}
+
+	permissionIDs := []uint64{}
+	for _, pattern := range roleConfig.Permissions {
+		matched := false
+		for _, permission := range permissions {
+			if matchPermission(pattern, permission) {
+				permissionIDs = append(permissionIDs, permission.ID)
+				matched = true
+			}
+		}
+		if !matched {
+			s.logger.Warn("Default role permission matches no permission",
+				zap.String("role", roleConfig.Name),
+				zap.String("permission", pattern))
+		}
+	}
+
+	if err := s.roleRepo.AssignPermissions(role.ID, permissionIDs); err != nil {
+		s.logger.Error("Failed to assign role permissions", zap.String("name", roleConfig.Name), zap.Error(err))
+		return false, err
+	}
+	return created, nil
+}
+
+// seedRoleParent makes a default role inherit from its configured parent, unless the role already has a parent
+func (s *syncer) seedRoleParent(roleConfig config.RoleSeedConfig) error {
+	role, err := s.roleRepo.GetByName(roleConfig.Name)
+	if err != nil {
+		s.logger.Error("Failed to fetch role", zap.String("name", roleConfig.Name), zap.Error(err))
+		return err
+	}
+	if role == nil || role.ParentID != nil {
+		return nil
+	}
+
+	parent, err := s.roleRepo.GetByName(roleConfig.Parent)
+	if err != nil {
+		s.logger.Error("Failed to fetch role", zap.String("name", roleConfig.Parent), zap.Error(err))
+		return err
+	}
+	if parent == nil {
+		s.logger.Warn("Default role parent does not exist",
+			zap.String("role", roleConfig.Name),
+			zap.String("parent", roleConfig.Parent))
+		return nil
+	}
+
+	if err := s.roleRepo.SetParent(role.ID, &parent.ID); err != nil {
+		if errors.Is(err, repositories.ErrRoleCycle) {
+			s.logger.Warn("Default role parent would make the role inherit from itself",
+				zap.String("role", roleConfig.Name),
+				zap.String("parent", roleConfig.Parent))
+			return nil
+		}
+		s.logger.Error("Failed to set role parent", zap.String("name", roleConfig.Name), zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// matchPermission reports whether a permission matches "*", "resource:*" or "resource:action"
+func matchPermission(pattern string, permission models.Permission) bool {
+	if pattern == "*" {
+		return true
+	}
+
+	resourceName, action, _ := strings.Cut(pattern, ":")
+	return resourceName == permission.ResourceName && (action == "*" || action == permission.Action)
+}
@@ -0,0 +1,73 @@
+package repositories
+
+import (
+	"errors"
+	"modular-fx-fiber/internal/shared/database"
+	"modular-fx-fiber/internal/shared/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type (
+	APIKeyRepository interface {
+		CreateAPIKey(apiKey *models.APIKey) error
+		GetActiveAPIKey(keyHash string) (*models.APIKey, error)
+		ListUserAPIKeys(userID uint64) ([]*models.APIKey, error)
+		DeleteUserAPIKey(userID uint64, id uint64) (bool, error)
+		TouchAPIKey(id uint64) error
+	}
+
+	apiKeyRepo struct {
+		db *gorm.DB
+	}
+)
+
+// NewAPIKeyRepository creates a new API key repository
+func NewAPIKeyRepository(db database.Database) APIKeyRepository {
+	return &apiKeyRepo{db: db.GetDB()}
+}
+
+// CreateAPIKey saves a new API key
+func (r *apiKeyRepo) CreateAPIKey(apiKey *models.APIKey) error {
+	return r.db.Create(apiKey).Error
+}
+
+// GetActiveAPIKey retrieves an unexpired API key by its hash, together with its user
+func (r *apiKeyRepo) GetActiveAPIKey(keyHash string) (*models.APIKey, error) {
+	var apiKey models.APIKey
+	err := r.db.Preload("User").
+		Where("key_hash = ? AND (expires_at IS NULL OR expires_at > NOW())", keyHash).
+		First(&apiKey).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &apiKey, nil
+}
+
+// ListUserAPIKeys lists the API keys of a user, newest first
+func (r *apiKeyRepo) ListUserAPIKeys(userID uint64) ([]*models.APIKey, error) {
+	var apiKeys []*models.APIKey
+	err := r.db.Where("user_id = ?", userID).Order("created_at DESC").Find(&apiKeys).Error
+	return apiKeys, err
+}
+
+// DeleteUserAPIKey revokes an API key of a user. It reports false when the user has no such key.
+func (r *apiKeyRepo) DeleteUserAPIKey(userID uint64, id uint64) (bool, error) {
+	result := r.db.Where("id = ? AND user_id = ?", id, userID).Delete(&models.APIKey{})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// TouchAPIKey records the use of an API key. It writes at most once a minute per key
+// so busy scripts do not cause a write for every request.
+func (r *apiKeyRepo) TouchAPIKey(id uint64) error {
+	return r.db.Model(&models.APIKey{}).
+		Where("id = ? AND (last_used_at IS NULL OR last_used_at < NOW() - INTERVAL '1 minute')", id).
+		UpdateColumn("last_used_at", time.Now()).Error
+}
@@ -0,0 +1,95 @@
+package repositories
+
+import (
+	"errors"
+	"modular-fx-fiber/internal/shared/database"
+	"modular-fx-fiber/internal/shared/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type (
+	EmailChangeRequestRepository interface {
+		SaveRequest(request *models.EmailChangeRequest) error
+		GetPendingRequest(confirmTokenHash string) (*models.EmailChangeRequest, error)
+		GetUndoableRequest(undoTokenHash string) (*models.EmailChangeRequest, error)
+		MarkRequestConfirmed(id uint64) (bool, error)
+		MarkRequestCancelled(id uint64) (bool, error)
+	}
+
+	emailChangeRequestRepo struct {
+		db *gorm.DB
+	}
+)
+
+// NewEmailChangeRequestRepository creates a new email change request repository
+func NewEmailChangeRequestRepository(db database.Database) EmailChangeRequestRepository {
+	return &emailChangeRequestRepo{db: db.GetDB()}
+}
+
+// SaveRequest saves a new email change request and cancels the unconfirmed requests of the same user
+func (r *emailChangeRequestRepo) SaveRequest(request *models.EmailChangeRequest) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&models.EmailChangeRequest{}).
+			Where("user_id = ? AND confirmed_at IS NULL AND cancelled_at IS NULL", request.UserID).
+			Update("cancelled_at", time.Now()).Error
+		if err != nil {
+			return err
+		}
+		return tx.Create(request).Error
+	})
+}
+
+// GetPendingRequest retrieves an unconfirmed, uncancelled and unexpired request by the hash of its confirm token
+func (r *emailChangeRequestRepo) GetPendingRequest(confirmTokenHash string) (*models.EmailChangeRequest, error) {
+	var request models.EmailChangeRequest
+	err := r.db.
+		Where("confirm_token_hash = ? AND confirmed_at IS NULL AND cancelled_at IS NULL AND expires_at > NOW()", confirmTokenHash).
+		First(&request).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &request, nil
+}
+
+// GetUndoableRequest retrieves an uncancelled request by the hash of its undo token while it can still be undone
+func (r *emailChangeRequestRepo) GetUndoableRequest(undoTokenHash string) (*models.EmailChangeRequest, error) {
+	var request models.EmailChangeRequest
+	err := r.db.
+		Where("undo_token_hash = ? AND cancelled_at IS NULL AND undo_expires_at > NOW()", undoTokenHash).
+		First(&request).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &request, nil
+}
+
+// MarkRequestConfirmed marks a request as confirmed. It reports false when the request was
+// already confirmed or cancelled, so concurrent requests cannot apply it twice.
+func (r *emailChangeRequestRepo) MarkRequestConfirmed(id uint64) (bool, error) {
+	result := r.db.Model(&models.EmailChangeRequest{}).
+		Where("id = ? AND confirmed_at IS NULL AND cancelled_at IS NULL", id).
+		Update("confirmed_at", time.Now())
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// MarkRequestCancelled marks a request as cancelled. It reports false when it was already cancelled.
+func (r *emailChangeRequestRepo) MarkRequestCancelled(id uint64) (bool, error) {
+	result := r.db.Model(&models.EmailChangeRequest{}).
+		Where("id = ? AND cancelled_at IS NULL", id).
+		Update("cancelled_at", time.Now())
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
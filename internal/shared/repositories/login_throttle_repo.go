@@ -0,0 +1,86 @@
+package repositories
+
+import (
+	"errors"
+	"modular-fx-fiber/internal/shared/database"
+	"modular-fx-fiber/internal/shared/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type (
+	LoginThrottleRepository interface {
+		GetThrottle(keyType string, key string) (*models.LoginThrottle, error)
+		RecordFailure(keyType string, key string, windowStart time.Time) (*models.LoginThrottle, error)
+		LockThrottle(id uint64, until time.Time) error
+		DeleteThrottle(keyType string, key string) error
+	}
+
+	loginThrottleRepo struct {
+		db *gorm.DB
+	}
+)
+
+// NewLoginThrottleRepository creates a new login throttle repository
+func NewLoginThrottleRepository(db database.Database) LoginThrottleRepository {
+	return &loginThrottleRepo{db: db.GetDB()}
+}
+
+// GetThrottle retrieves the throttle of an account or IP
+func (r *loginThrottleRepo) GetThrottle(keyType string, key string) (*models.LoginThrottle, error) {
+	var throttle models.LoginThrottle
+	if err := r.db.Where("key_type = ? AND key = ?", keyType, key).First(&throttle).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &throttle, nil
+}
+
+// RecordFailure atomically counts a failed attempt and returns the updated throttle.
+// The count restarts when the previous failure happened before windowStart.
+func (r *loginThrottleRepo) RecordFailure(keyType string, key string, windowStart time.Time) (*models.LoginThrottle, error) {
+	now := time.Now()
+	throttle := models.LoginThrottle{
+		KeyType:        keyType,
+		Key:            key,
+		FailedAttempts: 1,
+		LastFailedAt:   &now,
+	}
+
+	err := r.db.Clauses(
+		clause.OnConflict{
+			Columns: []clause.Column{{Name: "key_type"}, {Name: "key"}},
+			DoUpdates: clause.Assignments(map[string]any{
+				"failed_attempts": gorm.Expr(
+					"CASE WHEN login_throttles.last_failed_at IS NULL OR login_throttles.last_failed_at < ? THEN 1 ELSE login_throttles.failed_attempts + 1 END",
+					windowStart),
+				"last_failed_at": gorm.Expr("EXCLUDED.last_failed_at"),
+				"updated_at":     gorm.Expr("EXCLUDED.updated_at"),
+			}),
+		},
+		clause.Returning{},
+	).Create(&throttle).Error
+	if err != nil {
+		return nil, err
+	}
+	return &throttle, nil
+}
+
+// LockThrottle locks an account or IP until the given time and restarts its failure count
+func (r *loginThrottleRepo) LockThrottle(id uint64, until time.Time) error {
+	return r.db.Model(&models.LoginThrottle{}).
+		Where("id = ?", id).
+		Updates(map[string]any{
+			"locked_until":    until,
+			"failed_attempts": 0,
+		}).Error
+}
+
+// DeleteThrottle clears the failed attempts and lock of an account or IP
+func (r *loginThrottleRepo) DeleteThrottle(keyType string, key string) error {
+	return r.db.Where("key_type = ? AND key = ?", keyType, key).Delete(&models.LoginThrottle{}).Error
+}
@@ -0,0 +1,69 @@
+package repositories
+
+import (
+	"errors"
+	"modular-fx-fiber/internal/shared/database"
+	"modular-fx-fiber/internal/shared/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type (
+	MagicLinkTokenRepository interface {
+		SaveMagicLinkToken(token *models.MagicLinkToken) error
+		GetValidMagicLinkToken(jti string) (*models.MagicLinkToken, error)
+		MarkMagicLinkTokenUsed(id uint64) (bool, error)
+		CountUserMagicLinkTokensSince(userID uint64, since time.Time) (int64, error)
+	}
+
+	magicLinkTokenRepo struct {
+		db *gorm.DB
+	}
+)
+
+// NewMagicLinkTokenRepository creates a new magic link token repository
+func NewMagicLinkTokenRepository(db database.Database) MagicLinkTokenRepository {
+	return &magicLinkTokenRepo{db: db.GetDB()}
+}
+
+// SaveMagicLinkToken saves a magic link token to the database
+func (r *magicLinkTokenRepo) SaveMagicLinkToken(token *models.MagicLinkToken) error {
+	return r.db.Create(token).Error
+}
+
+// GetValidMagicLinkToken retrieves an unused, unexpired magic link token by its jti
+func (r *magicLinkTokenRepo) GetValidMagicLinkToken(jti string) (*models.MagicLinkToken, error) {
+	var token models.MagicLinkToken
+	err := r.db.
+		Where("jti = ? AND used_at IS NULL AND expires_at > NOW()", jti).
+		First(&token).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &token, nil
+}
+
+// MarkMagicLinkTokenUsed marks a magic link token as used. It reports false when the
+// token was already used, so concurrent requests cannot consume it twice.
+func (r *magicLinkTokenRepo) MarkMagicLinkTokenUsed(id uint64) (bool, error) {
+	result := r.db.Model(&models.MagicLinkToken{}).
+		Where("id = ? AND used_at IS NULL", id).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// CountUserMagicLinkTokensSince counts the magic links sent to a user since the given time
+func (r *magicLinkTokenRepo) CountUserMagicLinkTokensSince(userID uint64, since time.Time) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.MagicLinkToken{}).
+		Where("user_id = ? AND created_at >= ?", userID, since).
+		Count(&count).Error
+	return count, err
+}
@@ -0,0 +1,64 @@
+package repositories
+
+import (
+	"modular-fx-fiber/internal/shared/database"
+	"modular-fx-fiber/internal/shared/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type (
+	MFARecoveryCodeRepository interface {
+		ReplaceRecoveryCodes(userID uint64, codeHashes []string) error
+		UseRecoveryCode(userID uint64, codeHash string) (bool, error)
+		DeleteUserRecoveryCodes(userID uint64) error
+	}
+
+	mfaRecoveryCodeRepo struct {
+		db *gorm.DB
+	}
+)
+
+// NewMFARecoveryCodeRepository creates a new MFA recovery code repository
+func NewMFARecoveryCodeRepository(db database.Database) MFARecoveryCodeRepository {
+	return &mfaRecoveryCodeRepo{db: db.GetDB()}
+}
+
+// ReplaceRecoveryCodes deletes the existing recovery codes of a user and stores new ones
+func (r *mfaRecoveryCodeRepo) ReplaceRecoveryCodes(userID uint64, codeHashes []string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&models.MFARecoveryCode{}).Error; err != nil {
+			return err
+		}
+
+		codes := make([]models.MFARecoveryCode, 0, len(codeHashes))
+		for _, hash := range codeHashes {
+			codes = append(codes, models.MFARecoveryCode{
+				UserID:   userID,
+				CodeHash: hash,
+			})
+		}
+		if len(codes) == 0 {
+			return nil
+		}
+		return tx.Create(&codes).Error
+	})
+}
+
+// UseRecoveryCode consumes an unused recovery code. It reports false when no
+// matching unused code exists.
+func (r *mfaRecoveryCodeRepo) UseRecoveryCode(userID uint64, codeHash string) (bool, error) {
+	result := r.db.Model(&models.MFARecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, codeHash).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// DeleteUserRecoveryCodes deletes all recovery codes of a user
+func (r *mfaRecoveryCodeRepo) DeleteUserRecoveryCodes(userID uint64) error {
+	return r.db.Where("user_id = ?", userID).Delete(&models.MFARecoveryCode{}).Error
+}
@@ -0,0 +1,51 @@
+package repositories
+
+import (
+	"modular-fx-fiber/internal/shared/database"
+	"modular-fx-fiber/internal/shared/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type (
+	OAuthAuthorizationCodeRepository interface {
+		SaveAuthorizationCode(code *models.OAuthAuthorizationCode) error
+		ConsumeAuthorizationCode(codeHash string) (*models.OAuthAuthorizationCode, error)
+	}
+
+	oauthAuthorizationCodeRepo struct {
+		db *gorm.DB
+	}
+)
+
+// NewOAuthAuthorizationCodeRepository creates a new OAuth authorization code repository
+func NewOAuthAuthorizationCodeRepository(db database.Database) OAuthAuthorizationCodeRepository {
+	return &oauthAuthorizationCodeRepo{db: db.GetDB()}
+}
+
+// SaveAuthorizationCode saves an authorization code and drops expired ones
+func (r *oauthAuthorizationCodeRepo) SaveAuthorizationCode(code *models.OAuthAuthorizationCode) error {
+	if err := r.db.Where("expires_at < NOW()").Delete(&models.OAuthAuthorizationCode{}).Error; err != nil {
+		return err
+	}
+	return r.db.Create(code).Error
+}
+
+// ConsumeAuthorizationCode marks an unused, unexpired code as used and returns it, so
+// concurrent requests cannot redeem it twice. It returns nil when no such code exists.
+func (r *oauthAuthorizationCodeRepo) ConsumeAuthorizationCode(codeHash string) (*models.OAuthAuthorizationCode, error) {
+	var codes []models.OAuthAuthorizationCode
+	err := r.db.Model(&codes).
+		Clauses(clause.Returning{}).
+		Where("code_hash = ? AND used_at IS NULL AND expires_at > NOW()", codeHash).
+		Update("used_at", time.Now()).Error
+	if err != nil {
+		return nil, err
+	}
+	if len(codes) == 0 {
+		return nil, nil
+	}
+	return &codes[0], nil
+}
@@ -0,0 +1,42 @@
+package repositories
+
+import (
+	"errors"
+	"modular-fx-fiber/internal/shared/database"
+	"modular-fx-fiber/internal/shared/models"
+
+	"gorm.io/gorm"
+)
+
+type (
+	OAuthClientRepository interface {
+		CreateClient(client *models.OAuthClient) error
+		GetByClientID(clientID string) (*models.OAuthClient, error)
+	}
+
+	oauthClientRepo struct {
+		db *gorm.DB
+	}
+)
+
+// NewOAuthClientRepository creates a new OAuth client repository
+func NewOAuthClientRepository(db database.Database) OAuthClientRepository {
+	return &oauthClientRepo{db: db.GetDB()}
+}
+
+// CreateClient registers a new OAuth client
+func (r *oauthClientRepo) CreateClient(client *models.OAuthClient) error {
+	return r.db.Create(client).Error
+}
+
+// GetByClientID retrieves an OAuth client by its client ID
+func (r *oauthClientRepo) GetByClientID(clientID string) (*models.OAuthClient, error) {
+	var client models.OAuthClient
+	if err := r.db.Where("client_id = ?", clientID).First(&client).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &client, nil
+}
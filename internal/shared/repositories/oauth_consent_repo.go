@@ -0,0 +1,47 @@
+package repositories
+
+import (
+	"errors"
+	"modular-fx-fiber/internal/shared/database"
+	"modular-fx-fiber/internal/shared/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type (
+	OAuthConsentRepository interface {
+		GetConsent(userID uint64, clientID string) (*models.OAuthConsent, error)
+		SaveConsent(consent *models.OAuthConsent) error
+	}
+
+	oauthConsentRepo struct {
+		db *gorm.DB
+	}
+)
+
+// NewOAuthConsentRepository creates a new OAuth consent repository
+func NewOAuthConsentRepository(db database.Database) OAuthConsentRepository {
+	return &oauthConsentRepo{db: db.GetDB()}
+}
+
+// GetConsent retrieves the consent a user gave to a client
+func (r *oauthConsentRepo) GetConsent(userID uint64, clientID string) (*models.OAuthConsent, error) {
+	var consent models.OAuthConsent
+	err := r.db.Where("user_id = ? AND client_id = ?", userID, clientID).First(&consent).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &consent, nil
+}
+
+// SaveConsent creates the consent of a user for a client or replaces its scopes
+func (r *oauthConsentRepo) SaveConsent(consent *models.OAuthConsent) error {
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "client_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"scope", "updated_at"}),
+	}).Create(consent).Error
+}
@@ -0,0 +1,65 @@
+package repositories
+
+import (
+	"errors"
+	"modular-fx-fiber/internal/shared/database"
+	"modular-fx-fiber/internal/shared/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type (
+	PasswordResetTokenRepository interface {
+		SaveResetToken(token *models.PasswordResetToken) error
+		GetValidResetToken(tokenHash string) (*models.PasswordResetToken, error)
+		MarkResetTokenUsed(id uint64) (bool, error)
+		DeleteUserResetTokens(userID uint64) error
+	}
+
+	passwordResetTokenRepo struct {
+		db *gorm.DB
+	}
+)
+
+// NewPasswordResetTokenRepository creates a new password reset token repository
+func NewPasswordResetTokenRepository(db database.Database) PasswordResetTokenRepository {
+	return &passwordResetTokenRepo{db: db.GetDB()}
+}
+
+// SaveResetToken saves a password reset token to the database
+func (r *passwordResetTokenRepo) SaveResetToken(token *models.PasswordResetToken) error {
+	return r.db.Create(token).Error
+}
+
+// GetValidResetToken retrieves an unused, unexpired reset token by its hash
+func (r *passwordResetTokenRepo) GetValidResetToken(tokenHash string) (*models.PasswordResetToken, error) {
+	var resetToken models.PasswordResetToken
+	err := r.db.
+		Where("token_hash = ? AND used_at IS NULL AND expires_at > NOW()", tokenHash).
+		First(&resetToken).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &resetToken, nil
+}
+
+// MarkResetTokenUsed marks a reset token as used. It reports false when the
+// token was already used, so concurrent requests cannot consume it twice.
+func (r *passwordResetTokenRepo) MarkResetTokenUsed(id uint64) (bool, error) {
+	result := r.db.Model(&models.PasswordResetToken{}).
+		Where("id = ? AND used_at IS NULL", id).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// DeleteUserResetTokens deletes all outstanding reset tokens for a user
+func (r *passwordResetTokenRepo) DeleteUserResetTokens(userID uint64) error {
+	return r.db.Where("user_id = ? AND used_at IS NULL", userID).Delete(&models.PasswordResetToken{}).Error
+}
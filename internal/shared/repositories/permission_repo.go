@@ -0,0 +1,128 @@
+package repositories
+
+import (
+	"errors"
+	"modular-fx-fiber/internal/shared/database"
+	"modular-fx-fiber/internal/shared/models"
+
+	"gorm.io/gorm"
+)
+
+type (
+	PermissionRepository interface {
+		Create(permission *models.Permission) error
+		Update(permission *models.Permission) error
+		Delete(id uint64) error
+		GetByID(id uint64) (*models.Permission, error)
+		GetByName(name string) (*models.Permission, error)
+		GetByResourceAndAction(resourceName, action string) (*models.Permission, error)
+		List(page, pageSize int) ([]models.Permission, int64, error)
+		ListByResourceName(resourceName string, page, pageSize int) ([]models.Permission, int64, error)
+		ListByAction(action string, page, pageSize int) ([]models.Permission, int64, error)
+		ListAll() ([]models.Permission, error)
+	}
+
+	permissionRepo struct {
+		db *gorm.DB
+	}
+)
+
+// NewPermissionRepository creates a new permission repository
+func NewPermissionRepository(db database.Database) PermissionRepository {
+	return &permissionRepo{db: db.GetDB()}
+}
+
+// Create inserts a new permission. A soft-deleted permission with the same name is removed
+// for good first, so names of deleted permissions can be reused.
+func (r *permissionRepo) Create(permission *models.Permission) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("name = ? AND deleted_at IS NOT NULL", permission.Name).Delete(&models.Permission{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(permission).Error
+	})
+}
+
+// Update updates an existing permission
+func (r *permissionRepo) Update(permission *models.Permission) error {
+	return r.db.Save(permission).Error
+}
+
+// Delete soft-deletes a permission and takes it away from every role
+func (r *permissionRepo) Delete(id uint64) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("permission_id = ?", id).Delete(&models.RolePermission{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.Permission{}, "id = ?", id).Error
+	})
+}
+
+// GetByID retrieves a permission by ID
+func (r *permissionRepo) GetByID(id uint64) (*models.Permission, error) {
+	return r.first("id = ?", id)
+}
+
+// GetByName retrieves a permission by name
+func (r *permissionRepo) GetByName(name string) (*models.Permission, error) {
+	return r.first("name = ?", name)
+}
+
+// GetByResourceAndAction retrieves the permission to perform an action on a resource
+func (r *permissionRepo) GetByResourceAndAction(resourceName, action string) (*models.Permission, error) {
+	return r.first("resource_name = ? AND action = ?", resourceName, action)
+}
+
+// List retrieves a paginated list of permissions
+func (r *permissionRepo) List(page, pageSize int) ([]models.Permission, int64, error) {
+	return r.list(r.db, page, pageSize)
+}
+
+// ListByResourceName retrieves a paginated list of the permissions on a resource
+func (r *permissionRepo) ListByResourceName(resourceName string, page, pageSize int) ([]models.Permission, int64, error) {
+	return r.list(r.db.Where("resource_name = ?", resourceName), page, pageSize)
+}
+
+// ListByAction retrieves a paginated list of the permissions for an action, on any resource
+func (r *permissionRepo) ListByAction(action string, page, pageSize int) ([]models.Permission, int64, error) {
+	return r.list(r.db.Where("action = ?", action), page, pageSize)
+}
+
+// ListAll retrieves every permission, ordered by resource and action
+func (r *permissionRepo) ListAll() ([]models.Permission, error) {
+	permissions := []models.Permission{}
+	err := r.db.Order("resource_name, action").Find(&permissions).Error
+	return permissions, err
+}
+
+// first retrieves the first permission matching the conditions, or nil when there is none
+func (r *permissionRepo) first(query string, args ...interface{}) (*models.Permission, error) {
+	var permission models.Permission
+	if err := r.db.Where(query, args...).First(&permission).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &permission, nil
+}
+
+// list retrieves a page of the permissions matched by the query, ordered by resource and action
+func (r *permissionRepo) list(query *gorm.DB, page, pageSize int) ([]models.Permission, int64, error) {
+	var permissions []models.Permission
+	var totalCount int64
+
+	offset := (page - 1) * pageSize
+
+	// Get total count
+	if err := query.Session(&gorm.Session{}).Model(&models.Permission{}).Count(&totalCount).Error; err != nil {
+		return nil, 0, err
+	}
+
+	// Get paginated results
+	if err := query.Session(&gorm.Session{}).Order("resource_name, action").Offset(offset).Limit(pageSize).Find(&permissions).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return permissions, totalCount, nil
+}
@@ -5,14 +5,20 @@ import (
 	"gorm.io/gorm"
 	"modular-fx-fiber/internal/shared/database"
 	"modular-fx-fiber/internal/shared/models"
+	"time"
 )
 
 type (
 	RefreshTokenRepository interface {
 		SaveRefreshToken(token *models.RefreshToken) error
-		GetRefreshToken(token string) (*models.RefreshToken, error)
-		DeleteRefreshToken(token string) error
+		GetRefreshToken(tokenHash string) (*models.RefreshToken, error)
+		RevokeRefreshToken(id uint64) (bool, error)
+		RevokeRefreshTokenFamily(familyID string) error
+		DeleteRefreshToken(tokenHash string) error
 		DeleteUserRefreshTokens(userID uint64) error
+		DeleteUserRefreshTokensExcept(userID uint64, keepFamilyID string) error
+		DeleteUserRefreshTokenFamily(userID uint64, familyID string) (bool, error)
+		ListActiveUserRefreshTokens(userID uint64) ([]models.RefreshToken, error)
 	}
 
 	// refreshTokenRepository implements the Repository interface
@@ -31,10 +37,10 @@ func (r *refreshTokenRepo) SaveRefreshToken(token *models.RefreshToken) error {
 	return r.db.Create(token).Error
 }
 
-// GetRefreshToken retrieves a refresh token by its value
-func (r *refreshTokenRepo) GetRefreshToken(token string) (*models.RefreshToken, error) {
+// GetRefreshToken retrieves an unexpired refresh token by its hash, including revoked ones
+func (r *refreshTokenRepo) GetRefreshToken(tokenHash string) (*models.RefreshToken, error) {
 	var refreshToken models.RefreshToken
-	if err := r.db.Where("token = ? AND expires_at > NOW()", token).First(&refreshToken).Error; err != nil {
+	if err := r.db.Where("token_hash = ? AND expires_at > NOW()", tokenHash).First(&refreshToken).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, nil
 		}
@@ -43,12 +49,60 @@ func (r *refreshTokenRepo) GetRefreshToken(token string) (*models.RefreshToken,
 	return &refreshToken, nil
 }
 
+// RevokeRefreshToken marks a refresh token as revoked. It reports false when the
+// token was already revoked, so a token can only be rotated once.
+func (r *refreshTokenRepo) RevokeRefreshToken(id uint64) (bool, error) {
+	result := r.db.Model(&models.RefreshToken{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Update("revoked_at", time.Now())
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// RevokeRefreshTokenFamily revokes every token of a family
+func (r *refreshTokenRepo) RevokeRefreshTokenFamily(familyID string) error {
+	return r.db.Model(&models.RefreshToken{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyID).
+		Update("revoked_at", time.Now()).Error
+}
+
 // DeleteRefreshToken deletes a refresh token
-func (r *refreshTokenRepo) DeleteRefreshToken(token string) error {
-	return r.db.Where("token = ?", token).Delete(&models.RefreshToken{}).Error
+func (r *refreshTokenRepo) DeleteRefreshToken(tokenHash string) error {
+	return r.db.Where("token_hash = ?", tokenHash).Delete(&models.RefreshToken{}).Error
 }
 
 // DeleteUserRefreshTokens deletes all refresh tokens for a user
 func (r *refreshTokenRepo) DeleteUserRefreshTokens(userID uint64) error {
 	return r.db.Where("user_id = ?", userID).Delete(&models.RefreshToken{}).Error
 }
+
+// DeleteUserRefreshTokensExcept deletes all refresh tokens for a user except those of the kept family
+func (r *refreshTokenRepo) DeleteUserRefreshTokensExcept(userID uint64, keepFamilyID string) error {
+	return r.db.Where("user_id = ? AND family_id <> ?", userID, keepFamilyID).Delete(&models.RefreshToken{}).Error
+}
+
+// DeleteUserRefreshTokenFamily deletes every token of one of the user's token families.
+// It reports false when the user has no such family.
+func (r *refreshTokenRepo) DeleteUserRefreshTokenFamily(userID uint64, familyID string) (bool, error) {
+	result := r.db.Where("user_id = ? AND family_id = ?", userID, familyID).Delete(&models.RefreshToken{})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// ListActiveUserRefreshTokens lists the unrevoked, unexpired refresh tokens of a user,
+// which is one token per active token family
+func (r *refreshTokenRepo) ListActiveUserRefreshTokens(userID uint64) ([]models.RefreshToken, error) {
+	var refreshTokens []models.RefreshToken
+	err := r.db.
+		Where("user_id = ? AND revoked_at IS NULL AND expires_at > NOW()", userID).
+		Order("last_used_at DESC").
+		Find(&refreshTokens).Error
+	if err != nil {
+		return nil, err
+	}
+	return refreshTokens, nil
+}
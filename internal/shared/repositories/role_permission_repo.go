@@ -0,0 +1,121 @@
+package repositories
+
+import (
+	"modular-fx-fiber/internal/shared/database"
+	"modular-fx-fiber/internal/shared/models"
+	"slices"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type (
+	RolePermissionRepository interface {
+		AssignPermissionsToRole(roleID uint64, permissionIDs []uint64) error
+		RemovePermissionsFromRole(roleID uint64, permissionIDs []uint64) error
+		GetRolePermissions(roleID uint64) ([]uint64, error)
+		GetPermissionRoles(permissionID uint64) ([]uint64, error)
+	}
+
+	rolePermissionRepo struct {
+		db *gorm.DB
+	}
+)
+
+// NewRolePermissionRepository creates a new role permission repository
+func NewRolePermissionRepository(db database.Database) RolePermissionRepository {
+	return &rolePermissionRepo{db: db.GetDB()}
+}
+
+// AssignPermissionsToRole grants permissions to a role, permissions it already has are kept.
+// It returns ErrRoleNotFound or ErrPermissionNotFound, and changes nothing, when one of them does not exist.
+func (r *rolePermissionRepo) AssignPermissionsToRole(roleID uint64, permissionIDs []uint64) error {
+	return assignRolePermissions(r.db, roleID, permissionIDs)
+}
+
+// RemovePermissionsFromRole takes permissions away from a role, permissions it does not have are ignored
+func (r *rolePermissionRepo) RemovePermissionsFromRole(roleID uint64, permissionIDs []uint64) error {
+	return removeRolePermissions(r.db, roleID, permissionIDs)
+}
+
+// GetRolePermissions lists the IDs of the permissions granted to a role
+func (r *rolePermissionRepo) GetRolePermissions(roleID uint64) ([]uint64, error) {
+	permissionIDs := []uint64{}
+	err := r.db.Model(&models.RolePermission{}).
+		Where("role_id = ?", roleID).
+		Order("permission_id").
+		Pluck("permission_id", &permissionIDs).Error
+	return permissionIDs, err
+}
+
+// GetPermissionRoles lists the IDs of the roles a permission is granted to
+func (r *rolePermissionRepo) GetPermissionRoles(permissionID uint64) ([]uint64, error) {
+	roleIDs := []uint64{}
+	err := r.db.Model(&models.RolePermission{}).
+		Where("permission_id = ?", permissionID).
+		Order("role_id").
+		Pluck("role_id", &roleIDs).Error
+	return roleIDs, err
+}
+
+// assignRolePermissions grants permissions to a role in one transaction. Assignments that
+// were removed before are restored rather than inserted again.
+func assignRolePermissions(db *gorm.DB, roleID uint64, permissionIDs []uint64) error {
+	permissionIDs = slices.Compact(slices.Sorted(slices.Values(permissionIDs)))
+	if len(permissionIDs) == 0 {
+		return nil
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		var roleCount int64
+		if err := tx.Model(&models.Role{}).Where("id = ?", roleID).Count(&roleCount).Error; err != nil {
+			return err
+		}
+		if roleCount == 0 {
+			return ErrRoleNotFound
+		}
+
+		var permissionCount int64
+		if err := tx.Model(&models.Permission{}).Where("id IN ?", permissionIDs).Count(&permissionCount).Error; err != nil {
+			return err
+		}
+		if permissionCount != int64(len(permissionIDs)) {
+			return ErrPermissionNotFound
+		}
+
+		rolePermissions := make([]models.RolePermission, 0, len(permissionIDs))
+		for _, permissionID := range permissionIDs {
+			rolePermissions = append(rolePermissions, models.RolePermission{RoleID: roleID, PermissionID: permissionID})
+		}
+
+		return tx.Omit("Role", "Permission").
+			Clauses(restoreOnConflict("role_id", "permission_id")).
+			Create(&rolePermissions).Error
+	})
+}
+
+// removeRolePermissions soft-deletes the assignments of permissions to a role
+func removeRolePermissions(db *gorm.DB, roleID uint64, permissionIDs []uint64) error {
+	if len(permissionIDs) == 0 {
+		return nil
+	}
+	return db.Where("role_id = ? AND permission_id IN ?", roleID, permissionIDs).
+		Delete(&models.RolePermission{}).Error
+}
+
+// restoreOnConflict turns the insert of an assignment that already exists into restoring it,
+// so assignments that were soft-deleted do not hit the unique constraint of the join table
+func restoreOnConflict(columns ...string) clause.OnConflict {
+	conflictColumns := make([]clause.Column, 0, len(columns))
+	for _, column := range columns {
+		conflictColumns = append(conflictColumns, clause.Column{Name: column})
+	}
+
+	return clause.OnConflict{
+		Columns: conflictColumns,
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"deleted_at": nil,
+			"updated_at": gorm.Expr("NOW()"),
+		}),
+	}
+}
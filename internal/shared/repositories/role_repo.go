@@ -0,0 +1,237 @@
+package repositories
+
+import (
+	"errors"
+	"modular-fx-fiber/internal/shared/database"
+	"modular-fx-fiber/internal/shared/models"
+	"slices"
+
+	"gorm.io/gorm"
+)
+
+// Errors returned when an assignment refers to a record that does not exist
+var (
+	ErrUserNotFound       = errors.New("user not found")
+	ErrRoleNotFound       = errors.New("role not found")
+	ErrPermissionNotFound = errors.New("permission not found")
+	ErrRoleCycle          = errors.New("role would inherit from itself")
+)
+
+type (
+	RoleRepository interface {
+		Create(role *models.Role) error
+		Update(role *models.Role) error
+		Delete(id uint64) error
+		GetByID(id uint64) (*models.Role, error)
+		GetByName(name string) (*models.Role, error)
+		List(page int, pageSize int) ([]models.Role, int64, error)
+		AssignPermissions(roleID uint64, permissionIDs []uint64) error
+		RemovePermissions(roleID uint64, permissionIDs []uint64) error
+		SetParent(roleID uint64, parentID *uint64) error
+	}
+
+	roleRepo struct {
+		db *gorm.DB
+	}
+)
+
+// NewRoleRepository creates a new role repository
+func NewRoleRepository(db database.Database) RoleRepository {
+	return &roleRepo{db: db.GetDB()}
+}
+
+// Create inserts a new role. A soft-deleted role with the same name is removed
+// for good first, so names of deleted roles can be reused.
+func (r *roleRepo) Create(role *models.Role) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("name = ? AND deleted_at IS NOT NULL", role.Name).Delete(&models.Role{}).Error; err != nil {
+			return err
+		}
+		return tx.Omit("Permissions", "Parent").Create(role).Error
+	})
+}
+
+// Update saves the name, description and parent of a role in one transaction, its permissions are changed
+// with AssignPermissions and RemovePermissions. The parent is checked as by SetParent.
+func (r *roleRepo) Update(role *models.Role) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := setRoleParent(tx, role.ID, role.ParentID); err != nil {
+			return err
+		}
+		return tx.Omit("Permissions", "Parent", "ParentID").Save(role).Error
+	})
+}
+
+// Delete soft-deletes a role together with its permission and user assignments.
+// Roles inheriting from it no longer have a parent.
+func (r *roleRepo) Delete(id uint64) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Role{}).Where("parent_id = ?", id).Update("parent_id", nil).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("role_id = ?", id).Delete(&models.RolePermission{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("role_id = ?", id).Delete(&models.UserRole{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.Role{}, "id = ?", id).Error
+	})
+}
+
+// GetByID retrieves a role by ID together with its permissions and ancestors
+func (r *roleRepo) GetByID(id uint64) (*models.Role, error) {
+	return r.first("id = ?", id)
+}
+
+// GetByName retrieves a role by name together with its permissions and ancestors
+func (r *roleRepo) GetByName(name string) (*models.Role, error) {
+	return r.first("name = ?", name)
+}
+
+// List retrieves a paginated list of roles together with their permissions and ancestors
+func (r *roleRepo) List(page, pageSize int) ([]models.Role, int64, error) {
+	var roles []models.Role
+	var totalCount int64
+
+	offset := (page - 1) * pageSize
+
+	// Get total count
+	if err := r.db.Model(&models.Role{}).Count(&totalCount).Error; err != nil {
+		return nil, 0, err
+	}
+
+	// Get paginated results
+	if err := r.db.Preload("Permissions").Order("id").Offset(offset).Limit(pageSize).Find(&roles).Error; err != nil {
+		return nil, 0, err
+	}
+	if err := loadRoleAncestors(r.db, roles); err != nil {
+		return nil, 0, err
+	}
+
+	return roles, totalCount, nil
+}
+
+// AssignPermissions grants permissions to a role, permissions it already has are kept.
+// It returns ErrRoleNotFound or ErrPermissionNotFound, and changes nothing, when one of them does not exist.
+func (r *roleRepo) AssignPermissions(roleID uint64, permissionIDs []uint64) error {
+	return assignRolePermissions(r.db, roleID, permissionIDs)
+}
+
+// RemovePermissions takes permissions away from a role, permissions it does not have are ignored
+func (r *roleRepo) RemovePermissions(roleID uint64, permissionIDs []uint64) error {
+	return removeRolePermissions(r.db, roleID, permissionIDs)
+}
+
+// SetParent makes a role inherit the permissions of another role and its ancestors, or of none when parentID is nil.
+// It returns ErrRoleNotFound when one of the roles does not exist, and ErrRoleCycle when the parent is the role
+// itself or inherits from it.
+func (r *roleRepo) SetParent(roleID uint64, parentID *uint64) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		return setRoleParent(tx, roleID, parentID)
+	})
+}
+
+// setRoleParent sets the parent of a role within the transaction, see SetParent
+func setRoleParent(tx *gorm.DB, roleID uint64, parentID *uint64) error {
+	if parentID != nil {
+		// Serialize hierarchy changes, two of them running side by side could close a cycle together
+		if err := tx.Exec("LOCK TABLE roles IN SHARE ROW EXCLUSIVE MODE").Error; err != nil {
+			return err
+		}
+
+		var parentCount int64
+		if err := tx.Model(&models.Role{}).Where("id = ?", *parentID).Count(&parentCount).Error; err != nil {
+			return err
+		}
+		if parentCount == 0 {
+			return ErrRoleNotFound
+		}
+
+		// Walk up from the new parent, reaching the role means it would inherit from itself
+		var cycleCount int64
+		err := tx.Raw(`
+			WITH RECURSIVE ancestors AS (
+				SELECT id, parent_id FROM roles WHERE id = ? AND deleted_at IS NULL
+				UNION
+				SELECT roles.id, roles.parent_id FROM roles
+				JOIN ancestors ON roles.id = ancestors.parent_id
+				WHERE roles.deleted_at IS NULL
+			)
+			SELECT COUNT(*) FROM ancestors WHERE id = ?`, *parentID, roleID).
+			Scan(&cycleCount).Error
+		if err != nil {
+			return err
+		}
+		if cycleCount > 0 {
+			return ErrRoleCycle
+		}
+	}
+
+	result := tx.Model(&models.Role{}).Where("id = ?", roleID).Update("parent_id", parentID)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrRoleNotFound
+	}
+	return nil
+}
+
+// first retrieves the first role matching the conditions with its permissions and ancestors, or nil when there is none
+func (r *roleRepo) first(query string, args ...interface{}) (*models.Role, error) {
+	roles := []models.Role{}
+	if err := r.db.Preload("Permissions").Where(query, args...).Limit(1).Find(&roles).Error; err != nil {
+		return nil, err
+	}
+	if len(roles) == 0 {
+		return nil, nil
+	}
+	if err := loadRoleAncestors(r.db, roles); err != nil {
+		return nil, err
+	}
+	return &roles[0], nil
+}
+
+// loadRoleAncestors sets the Parent of roles, with its permissions, and the parents of the parents in turn,
+// as needed by Role.EffectivePermissions. Each role is loaded once, so a cycle in the data ends the walk.
+func loadRoleAncestors(db *gorm.DB, roles []models.Role) error {
+	loaded := make(map[uint64]*models.Role, len(roles))
+	pending := make([]*models.Role, 0, len(roles))
+	for i := range roles {
+		loaded[roles[i].ID] = &roles[i]
+		pending = append(pending, &roles[i])
+	}
+
+	for len(pending) > 0 {
+		parentIDs := []uint64{}
+		for _, role := range pending {
+			if role.ParentID != nil && loaded[*role.ParentID] == nil && !slices.Contains(parentIDs, *role.ParentID) {
+				parentIDs = append(parentIDs, *role.ParentID)
+			}
+		}
+
+		parents := []models.Role{}
+		if len(parentIDs) > 0 {
+			if err := db.Preload("Permissions").Find(&parents, "id IN ?", parentIDs).Error; err != nil {
+				return err
+			}
+		}
+		for i := range parents {
+			loaded[parents[i].ID] = &parents[i]
+		}
+
+		// Soft-deleted parents are not loaded, the role then inherits nothing
+		for _, role := range pending {
+			if role.ParentID != nil {
+				role.Parent = loaded[*role.ParentID]
+			}
+		}
+
+		pending = pending[:0]
+		for i := range parents {
+			pending = append(pending, &parents[i])
+		}
+	}
+	return nil
+}
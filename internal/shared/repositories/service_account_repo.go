@@ -0,0 +1,206 @@
+package repositories
+
+import (
+	"errors"
+	"modular-fx-fiber/internal/shared/database"
+	"modular-fx-fiber/internal/shared/models"
+	"slices"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type (
+	ServiceAccountRepository interface {
+		Create(account *models.ServiceAccount, roleIDs []uint64) (bool, error)
+		List(page, pageSize int) ([]models.ServiceAccount, int64, error)
+		GetByID(id uint64) (*models.ServiceAccount, error)
+		GetByClientID(clientID string) (*models.ServiceAccount, error)
+		GetByIDWithPermissions(id uint64) (*models.ServiceAccount, error)
+		Update(account *models.ServiceAccount) error
+		Delete(id uint64) (bool, error)
+		ReplaceRoles(account *models.ServiceAccount, roleIDs []uint64) (bool, error)
+		AddSecret(secret *models.ServiceAccountSecret) error
+		ExpireSecrets(accountID uint64, expiresAt time.Time) error
+		GetActiveSecrets(accountID uint64) ([]models.ServiceAccountSecret, error)
+		TouchSecret(id uint64) error
+	}
+
+	serviceAccountRepo struct {
+		db *gorm.DB
+	}
+)
+
+// NewServiceAccountRepository creates a new service account repository
+func NewServiceAccountRepository(db database.Database) ServiceAccountRepository {
+	return &serviceAccountRepo{db: db.GetDB()}
+}
+
+// Create inserts a new service account together with its secrets and roles. It reports false,
+// and creates nothing, when one of the roles does not exist.
+func (r *serviceAccountRepo) Create(account *models.ServiceAccount, roleIDs []uint64) (bool, error) {
+	found := true
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		roles, err := findRoles(tx, roleIDs)
+		if err != nil {
+			return err
+		}
+		if roles == nil {
+			found = false
+			return nil
+		}
+
+		account.Roles = roles
+		return tx.Create(account).Error
+	})
+	if err != nil {
+		return false, err
+	}
+	return found, nil
+}
+
+// List retrieves a paginated list of service accounts with their roles
+func (r *serviceAccountRepo) List(page, pageSize int) ([]models.ServiceAccount, int64, error) {
+	var accounts []models.ServiceAccount
+	var totalCount int64
+
+	offset := (page - 1) * pageSize
+
+	// Get total count
+	if err := r.db.Model(&models.ServiceAccount{}).Count(&totalCount).Error; err != nil {
+		return nil, 0, err
+	}
+
+	// Get paginated results
+	if err := r.db.Preload("Roles").Order("id").Offset(offset).Limit(pageSize).Find(&accounts).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return accounts, totalCount, nil
+}
+
+// GetByID retrieves a service account by ID with its roles
+func (r *serviceAccountRepo) GetByID(id uint64) (*models.ServiceAccount, error) {
+	var account models.ServiceAccount
+	if err := r.db.Preload("Roles").First(&account, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &account, nil
+}
+
+// GetByClientID retrieves a service account by its client ID with the permissions of its roles, inherited ones included
+func (r *serviceAccountRepo) GetByClientID(clientID string) (*models.ServiceAccount, error) {
+	var account models.ServiceAccount
+	if err := r.db.Preload("Roles.Permissions").First(&account, "client_id = ?", clientID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if err := loadRoleAncestors(r.db, account.Roles); err != nil {
+		return nil, err
+	}
+	return &account, nil
+}
+
+// GetByIDWithPermissions retrieves a service account by ID with the permissions of its roles, inherited ones included
+func (r *serviceAccountRepo) GetByIDWithPermissions(id uint64) (*models.ServiceAccount, error) {
+	var account models.ServiceAccount
+	if err := r.db.Preload("Roles.Permissions").First(&account, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if err := loadRoleAncestors(r.db, account.Roles); err != nil {
+		return nil, err
+	}
+	return &account, nil
+}
+
+// Update updates the fields of a service account, its roles are left untouched
+func (r *serviceAccountRepo) Update(account *models.ServiceAccount) error {
+	return r.db.Omit("Roles", "Secrets").Save(account).Error
+}
+
+// Delete soft-deletes a service account. It reports false when no such account exists.
+func (r *serviceAccountRepo) Delete(id uint64) (bool, error) {
+	result := r.db.Delete(&models.ServiceAccount{}, "id = ?", id)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// ReplaceRoles sets the roles of a service account. It reports false, and changes
+// nothing, when one of the roles does not exist.
+func (r *serviceAccountRepo) ReplaceRoles(account *models.ServiceAccount, roleIDs []uint64) (bool, error) {
+	found := true
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		roles, err := findRoles(tx, roleIDs)
+		if err != nil {
+			return err
+		}
+		if roles == nil {
+			found = false
+			return nil
+		}
+
+		if err := tx.Model(account).Association("Roles").Replace(roles); err != nil {
+			return err
+		}
+		account.Roles = roles
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+	return found, nil
+}
+
+// AddSecret saves a new secret of a service account
+func (r *serviceAccountRepo) AddSecret(secret *models.ServiceAccountSecret) error {
+	return r.db.Create(secret).Error
+}
+
+// ExpireSecrets makes every secret of a service account expire at the given time at the latest
+func (r *serviceAccountRepo) ExpireSecrets(accountID uint64, expiresAt time.Time) error {
+	return r.db.Model(&models.ServiceAccountSecret{}).
+		Where("service_account_id = ? AND (expires_at IS NULL OR expires_at > ?)", accountID, expiresAt).
+		Update("expires_at", expiresAt).Error
+}
+
+// GetActiveSecrets retrieves the unexpired secrets of a service account
+func (r *serviceAccountRepo) GetActiveSecrets(accountID uint64) ([]models.ServiceAccountSecret, error) {
+	var secrets []models.ServiceAccountSecret
+	err := r.db.
+		Where("service_account_id = ? AND (expires_at IS NULL OR expires_at > NOW())", accountID).
+		Find(&secrets).Error
+	return secrets, err
+}
+
+// TouchSecret records the use of a secret
+func (r *serviceAccountRepo) TouchSecret(id uint64) error {
+	return r.db.Model(&models.ServiceAccountSecret{}).
+		Where("id = ?", id).
+		UpdateColumn("last_used_at", time.Now()).Error
+}
+
+// findRoles loads the roles with the given IDs, it returns nil when one of them does not exist
+func findRoles(tx *gorm.DB, roleIDs []uint64) ([]models.Role, error) {
+	roles := []models.Role{}
+	if len(roleIDs) == 0 {
+		return roles, nil
+	}
+
+	if err := tx.Where("id IN ?", roleIDs).Find(&roles).Error; err != nil {
+		return nil, err
+	}
+	if len(roles) != len(slices.Compact(slices.Sorted(slices.Values(roleIDs)))) {
+		return nil, nil
+	}
+	return roles, nil
+}
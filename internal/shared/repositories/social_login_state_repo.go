@@ -0,0 +1,49 @@
+package repositories
+
+import (
+	"modular-fx-fiber/internal/shared/database"
+	"modular-fx-fiber/internal/shared/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type (
+	SocialLoginStateRepository interface {
+		SaveLoginState(state *models.SocialLoginState) error
+		ConsumeLoginState(stateHash string) (*models.SocialLoginState, error)
+	}
+
+	socialLoginStateRepo struct {
+		db *gorm.DB
+	}
+)
+
+// NewSocialLoginStateRepository creates a new social login state repository
+func NewSocialLoginStateRepository(db database.Database) SocialLoginStateRepository {
+	return &socialLoginStateRepo{db: db.GetDB()}
+}
+
+// SaveLoginState saves the state of a social login and drops expired ones
+func (r *socialLoginStateRepo) SaveLoginState(state *models.SocialLoginState) error {
+	if err := r.db.Where("expires_at < NOW()").Delete(&models.SocialLoginState{}).Error; err != nil {
+		return err
+	}
+	return r.db.Create(state).Error
+}
+
+// ConsumeLoginState deletes an unexpired login state and returns it, so a state
+// can only be used once. It returns nil when no such state exists.
+func (r *socialLoginStateRepo) ConsumeLoginState(stateHash string) (*models.SocialLoginState, error) {
+	var states []models.SocialLoginState
+	err := r.db.Clauses(clause.Returning{}).
+		Where("state_hash = ? AND expires_at > NOW()", stateHash).
+		Delete(&states).Error
+	if err != nil {
+		return nil, err
+	}
+	if len(states) == 0 {
+		return nil, nil
+	}
+	return &states[0], nil
+}
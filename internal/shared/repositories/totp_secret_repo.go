@@ -0,0 +1,73 @@
+package repositories
+
+import (
+	"errors"
+	"modular-fx-fiber/internal/shared/database"
+	"modular-fx-fiber/internal/shared/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type (
+	TOTPSecretRepository interface {
+		SaveTOTPSecret(secret *models.UserTOTPSecret) error
+		GetTOTPSecret(userID uint64) (*models.UserTOTPSecret, error)
+		ConfirmTOTPSecret(id uint64) error
+		UpdateLastUsedStep(id uint64, step int64) (bool, error)
+		DeleteTOTPSecret(userID uint64) error
+	}
+
+	totpSecretRepo struct {
+		db *gorm.DB
+	}
+)
+
+// NewTOTPSecretRepository creates a new TOTP secret repository
+func NewTOTPSecretRepository(db database.Database) TOTPSecretRepository {
+	return &totpSecretRepo{db: db.GetDB()}
+}
+
+// SaveTOTPSecret replaces any existing secret of the user with a new one
+func (r *totpSecretRepo) SaveTOTPSecret(secret *models.UserTOTPSecret) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", secret.UserID).Delete(&models.UserTOTPSecret{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(secret).Error
+	})
+}
+
+// GetTOTPSecret retrieves the TOTP secret of a user
+func (r *totpSecretRepo) GetTOTPSecret(userID uint64) (*models.UserTOTPSecret, error) {
+	var secret models.UserTOTPSecret
+	if err := r.db.Where("user_id = ?", userID).First(&secret).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &secret, nil
+}
+
+// ConfirmTOTPSecret marks a TOTP secret as confirmed
+func (r *totpSecretRepo) ConfirmTOTPSecret(id uint64) error {
+	return r.db.Model(&models.UserTOTPSecret{}).Where("id = ?", id).Update("confirmed_at", time.Now()).Error
+}
+
+// UpdateLastUsedStep records the last accepted time step. It reports false when
+// the step is not newer than the stored one, i.e. the code was already used.
+func (r *totpSecretRepo) UpdateLastUsedStep(id uint64, step int64) (bool, error) {
+	result := r.db.Model(&models.UserTOTPSecret{}).
+		Where("id = ? AND last_used_step < ?", id, step).
+		Update("last_used_step", step)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// DeleteTOTPSecret deletes the TOTP secret of a user
+func (r *totpSecretRepo) DeleteTOTPSecret(userID uint64) error {
+	return r.db.Where("user_id = ?", userID).Delete(&models.UserTOTPSecret{}).Error
+}
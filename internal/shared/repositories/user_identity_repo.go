@@ -0,0 +1,42 @@
+package repositories
+
+import (
+	"errors"
+	"modular-fx-fiber/internal/shared/database"
+	"modular-fx-fiber/internal/shared/models"
+
+	"gorm.io/gorm"
+)
+
+type (
+	UserIdentityRepository interface {
+		CreateIdentity(identity *models.UserIdentity) error
+		GetIdentity(provider string, subject string) (*models.UserIdentity, error)
+	}
+
+	userIdentityRepo struct {
+		db *gorm.DB
+	}
+)
+
+// NewUserIdentityRepository creates a new user identity repository
+func NewUserIdentityRepository(db database.Database) UserIdentityRepository {
+	return &userIdentityRepo{db: db.GetDB()}
+}
+
+// CreateIdentity links an external identity to a user
+func (r *userIdentityRepo) CreateIdentity(identity *models.UserIdentity) error {
+	return r.db.Create(identity).Error
+}
+
+// GetIdentity retrieves the identity of a provider subject
+func (r *userIdentityRepo) GetIdentity(provider string, subject string) (*models.UserIdentity, error) {
+	var identity models.UserIdentity
+	if err := r.db.Where("provider = ? AND subject = ?", provider, subject).First(&identity).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &identity, nil
+}
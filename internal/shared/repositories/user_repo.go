@@ -14,6 +14,8 @@ type (
 		Update(user *models.User) error
 		GetByEmail(email string) (*models.User, error)
 		GetByID(id uint64) (*models.User, error)
+		GetByWebAuthnHandle(handle []byte) (*models.User, error)
+		GetByIDWithPermissions(id uint64) (*models.User, error)
 		List(page int, pageSize int) ([]models.User, int64, error)
 		Delete(id uint64) error
 	}
@@ -65,6 +67,22 @@ func (r *userRepo) GetByID(id uint64) (*models.User, error) {
 	return &user, nil
 }
 
+// GetByIDWithPermissions retrieves a user by ID together with their roles, the ancestors of those
+// roles and their permissions, as needed by User.HasPermission and User.HasRole
+func (r *userRepo) GetByIDWithPermissions(id uint64) (*models.User, error) {
+	var user models.User
+	if err := r.db.Preload("Roles.Permissions").First(&user, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if err := loadRoleAncestors(r.db, user.Roles); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
 // GetByEmail retrieves a user by email
 func (r *userRepo) GetByEmail(email string) (*models.User, error) {
 	var user models.User
@@ -76,6 +94,18 @@ func (r *userRepo) GetByEmail(email string) (*models.User, error) {
 	return &user, nil
 }
 
+// GetByWebAuthnHandle retrieves a user by the handle given to their WebAuthn authenticators
+func (r *userRepo) GetByWebAuthnHandle(handle []byte) (*models.User, error) {
+	var user models.User
+	if err := r.db.First(&user, "webauthn_handle = ?", handle).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &user, nil
+}
+
 // Update updates an existing user
 func (r *userRepo) Update(user *models.User) error {
 	return r.db.Save(user).Error
@@ -0,0 +1,93 @@
+package repositories
+
+import (
+	"modular-fx-fiber/internal/shared/database"
+	"modular-fx-fiber/internal/shared/models"
+	"slices"
+
+	"gorm.io/gorm"
+)
+
+type (
+	UserRoleRepository interface {
+		AssignRolesToUser(userID uint64, roleIDs []uint64) error
+		RemoveRolesFromUser(userID uint64, roleIDs []uint64) error
+		GetUserRoles(userID uint64) ([]uint64, error)
+		GetRoleUsers(roleID uint64) ([]uint64, error)
+	}
+
+	userRoleRepo struct {
+		db *gorm.DB
+	}
+)
+
+// NewUserRoleRepository creates a new user role repository
+func NewUserRoleRepository(db database.Database) UserRoleRepository {
+	return &userRoleRepo{db: db.GetDB()}
+}
+
+// AssignRolesToUser gives roles to a user, roles the user already has are kept. Assignments
+// that were removed before are restored. It returns ErrUserNotFound or ErrRoleNotFound,
+// and changes nothing, when one of them does not exist.
+func (r *userRoleRepo) AssignRolesToUser(userID uint64, roleIDs []uint64) error {
+	roleIDs = slices.Compact(slices.Sorted(slices.Values(roleIDs)))
+	if len(roleIDs) == 0 {
+		return nil
+	}
+
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var userCount int64
+		if err := tx.Model(&models.User{}).Where("id = ?", userID).Count(&userCount).Error; err != nil {
+			return err
+		}
+		if userCount == 0 {
+			return ErrUserNotFound
+		}
+
+		var roleCount int64
+		if err := tx.Model(&models.Role{}).Where("id IN ?", roleIDs).Count(&roleCount).Error; err != nil {
+			return err
+		}
+		if roleCount != int64(len(roleIDs)) {
+			return ErrRoleNotFound
+		}
+
+		userRoles := make([]models.UserRole, 0, len(roleIDs))
+		for _, roleID := range roleIDs {
+			userRoles = append(userRoles, models.UserRole{UserID: userID, RoleID: roleID})
+		}
+
+		return tx.Omit("User", "Role").
+			Clauses(restoreOnConflict("user_id", "role_id")).
+			Create(&userRoles).Error
+	})
+}
+
+// RemoveRolesFromUser soft-deletes the assignments of roles to a user, roles the user does not have are ignored
+func (r *userRoleRepo) RemoveRolesFromUser(userID uint64, roleIDs []uint64) error {
+	if len(roleIDs) == 0 {
+		return nil
+	}
+	return r.db.Where("user_id = ? AND role_id IN ?", userID, roleIDs).
+		Delete(&models.UserRole{}).Error
+}
+
+// GetUserRoles lists the IDs of the roles assigned to a user
+func (r *userRoleRepo) GetUserRoles(userID uint64) ([]uint64, error) {
+	roleIDs := []uint64{}
+	err := r.db.Model(&models.UserRole{}).
+		Where("user_id = ?", userID).
+		Order("role_id").
+		Pluck("role_id", &roleIDs).Error
+	return roleIDs, err
+}
+
+// GetRoleUsers lists the IDs of the users a role is assigned to
+func (r *userRoleRepo) GetRoleUsers(roleID uint64) ([]uint64, error) {
+	userIDs := []uint64{}
+	err := r.db.Model(&models.UserRole{}).
+		Where("role_id = ?", roleID).
+		Order("user_id").
+		Pluck("user_id", &userIDs).Error
+	return userIDs, err
+}
@@ -0,0 +1,113 @@
+package repositories
+
+import (
+	"errors"
+	"modular-fx-fiber/internal/shared/database"
+	"modular-fx-fiber/internal/shared/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type (
+	VerificationCodeRepository interface {
+		ReplaceCode(code *models.VerificationCode) error
+		GetActiveCode(userID uint64, purpose string) (*models.VerificationCode, error)
+		GetLatestCode(userID uint64, purpose string) (*models.VerificationCode, error)
+		IncrementAttempts(id uint64) (int, error)
+		MarkCodeUsed(id uint64) (bool, error)
+		CountTargetCodesSince(target string, since time.Time) (int64, *time.Time, error)
+	}
+
+	verificationCodeRepo struct {
+		db *gorm.DB
+	}
+)
+
+// NewVerificationCodeRepository creates a new verification code repository
+func NewVerificationCodeRepository(db database.Database) VerificationCodeRepository {
+	return &verificationCodeRepo{db: db.GetDB()}
+}
+
+// ReplaceCode saves a new code and invalidates the unused codes of the same user and purpose
+func (r *verificationCodeRepo) ReplaceCode(code *models.VerificationCode) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&models.VerificationCode{}).
+			Where("user_id = ? AND purpose = ? AND used_at IS NULL AND expires_at > NOW()", code.UserID, code.Purpose).
+			Update("expires_at", time.Now()).Error
+		if err != nil {
+			return err
+		}
+		return tx.Create(code).Error
+	})
+}
+
+// GetActiveCode retrieves the unused, unexpired code of a user for the purpose
+func (r *verificationCodeRepo) GetActiveCode(userID uint64, purpose string) (*models.VerificationCode, error) {
+	var code models.VerificationCode
+	err := r.db.
+		Where("user_id = ? AND purpose = ? AND used_at IS NULL AND expires_at > NOW()", userID, purpose).
+		Order("created_at DESC").
+		First(&code).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &code, nil
+}
+
+// GetLatestCode retrieves the most recently sent code of a user for the purpose, whatever its state
+func (r *verificationCodeRepo) GetLatestCode(userID uint64, purpose string) (*models.VerificationCode, error) {
+	var code models.VerificationCode
+	err := r.db.
+		Where("user_id = ? AND purpose = ?", userID, purpose).
+		Order("created_at DESC").
+		First(&code).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &code, nil
+}
+
+// IncrementAttempts records a wrong guess and returns the new number of attempts.
+// The increment happens in the database so concurrent guesses are all counted.
+func (r *verificationCodeRepo) IncrementAttempts(id uint64) (int, error) {
+	var code models.VerificationCode
+	err := r.db.Model(&code).
+		Clauses(clause.Returning{Columns: []clause.Column{{Name: "attempts"}}}).
+		Where("id = ?", id).
+		UpdateColumn("attempts", gorm.Expr("attempts + 1")).Error
+	return code.Attempts, err
+}
+
+// MarkCodeUsed marks a code as used. It reports false when the code was already used,
+// so concurrent requests cannot consume it twice.
+func (r *verificationCodeRepo) MarkCodeUsed(id uint64) (bool, error) {
+	result := r.db.Model(&models.VerificationCode{}).
+		Where("id = ? AND used_at IS NULL", id).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// CountTargetCodesSince counts the codes sent to an address since the given time, for any user
+// and purpose. It also returns when the oldest of them was sent, nil when there are none.
+func (r *verificationCodeRepo) CountTargetCodesSince(target string, since time.Time) (int64, *time.Time, error) {
+	var result struct {
+		Count  int64
+		Oldest *time.Time
+	}
+	err := r.db.Model(&models.VerificationCode{}).
+		Select("COUNT(*) AS count, MIN(created_at) AS oldest").
+		Where("target = ? AND created_at >= ?", target, since).
+		Scan(&result).Error
+	return result.Count, result.Oldest, err
+}
@@ -0,0 +1,75 @@
+package repositories
+
+import (
+	"errors"
+	"modular-fx-fiber/internal/shared/database"
+	"modular-fx-fiber/internal/shared/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type (
+	WebAuthnCredentialRepository interface {
+		CreateCredential(credential *models.WebAuthnCredential) error
+		GetByCredentialID(credentialID []byte) (*models.WebAuthnCredential, error)
+		ListUserCredentials(userID uint64) ([]*models.WebAuthnCredential, error)
+		UpdateCredentialUse(credential *models.WebAuthnCredential) error
+		DeleteUserCredential(userID uint64, id uint64) (bool, error)
+	}
+
+	webAuthnCredentialRepo struct {
+		db *gorm.DB
+	}
+)
+
+// NewWebAuthnCredentialRepository creates a new WebAuthn credential repository
+func NewWebAuthnCredentialRepository(db database.Database) WebAuthnCredentialRepository {
+	return &webAuthnCredentialRepo{db: db.GetDB()}
+}
+
+// CreateCredential saves a newly registered credential
+func (r *webAuthnCredentialRepo) CreateCredential(credential *models.WebAuthnCredential) error {
+	return r.db.Create(credential).Error
+}
+
+// GetByCredentialID retrieves a credential by the ID chosen by the authenticator
+func (r *webAuthnCredentialRepo) GetByCredentialID(credentialID []byte) (*models.WebAuthnCredential, error) {
+	var credential models.WebAuthnCredential
+	if err := r.db.First(&credential, "credential_id = ?", credentialID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &credential, nil
+}
+
+// ListUserCredentials lists the credentials of a user, oldest first
+func (r *webAuthnCredentialRepo) ListUserCredentials(userID uint64) ([]*models.WebAuthnCredential, error) {
+	var credentials []*models.WebAuthnCredential
+	err := r.db.Where("user_id = ?", userID).Order("created_at ASC").Find(&credentials).Error
+	return credentials, err
+}
+
+// UpdateCredentialUse saves the sign count and flags reported by the authenticator on a login
+func (r *webAuthnCredentialRepo) UpdateCredentialUse(credential *models.WebAuthnCredential) error {
+	return r.db.Model(&models.WebAuthnCredential{}).
+		Where("id = ?", credential.ID).
+		Updates(map[string]interface{}{
+			"sign_count":    credential.SignCount,
+			"clone_warning": credential.CloneWarning,
+			"user_verified": credential.UserVerified,
+			"backup_state":  credential.BackupState,
+			"last_used_at":  time.Now(),
+		}).Error
+}
+
+// DeleteUserCredential removes a credential of a user. It reports false when the user has no such credential.
+func (r *webAuthnCredentialRepo) DeleteUserCredential(userID uint64, id uint64) (bool, error) {
+	result := r.db.Where("id = ? AND user_id = ?", id, userID).Delete(&models.WebAuthnCredential{})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
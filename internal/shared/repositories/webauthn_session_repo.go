@@ -0,0 +1,49 @@
+package repositories
+
+import (
+	"modular-fx-fiber/internal/shared/database"
+	"modular-fx-fiber/internal/shared/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type (
+	WebAuthnSessionRepository interface {
+		SaveSession(session *models.WebAuthnSession) error
+		ConsumeSession(sessionHash string, ceremony string) (*models.WebAuthnSession, error)
+	}
+
+	webAuthnSessionRepo struct {
+		db *gorm.DB
+	}
+)
+
+// NewWebAuthnSessionRepository creates a new WebAuthn session repository
+func NewWebAuthnSessionRepository(db database.Database) WebAuthnSessionRepository {
+	return &webAuthnSessionRepo{db: db.GetDB()}
+}
+
+// SaveSession saves the challenge of a WebAuthn ceremony and drops expired ones
+func (r *webAuthnSessionRepo) SaveSession(session *models.WebAuthnSession) error {
+	if err := r.db.Where("expires_at < NOW()").Delete(&models.WebAuthnSession{}).Error; err != nil {
+		return err
+	}
+	return r.db.Create(session).Error
+}
+
+// ConsumeSession deletes an unexpired session of the given ceremony and returns it, so a
+// challenge can only be answered once. It returns nil when no such session exists.
+func (r *webAuthnSessionRepo) ConsumeSession(sessionHash string, ceremony string) (*models.WebAuthnSession, error) {
+	var sessions []models.WebAuthnSession
+	err := r.db.Clauses(clause.Returning{}).
+		Where("session_hash = ? AND ceremony = ? AND expires_at > NOW()", sessionHash, ceremony).
+		Delete(&sessions).Error
+	if err != nil {
+		return nil, err
+	}
+	if len(sessions) == 0 {
+		return nil, nil
+	}
+	return &sessions[0], nil
+}
@@ -0,0 +1,99 @@
+package revocation
+
+import (
+	"sync"
+	"time"
+)
+
+// memoryStore keeps revocations in process memory.
+// Revocations are lost on restart and not shared between instances.
+type memoryStore struct {
+	mu         sync.RWMutex
+	tokens     map[string]time.Time
+	sessions   map[string]time.Time
+	watermarks map[uint64]time.Time
+}
+
+// NewMemoryStore creates an in-memory revocation store
+func NewMemoryStore() Store {
+	return &memoryStore{
+		tokens:     make(map[string]time.Time),
+		sessions:   make(map[string]time.Time),
+		watermarks: make(map[uint64]time.Time),
+	}
+}
+
+// RevokeToken revokes a single access token until it expires
+func (s *memoryStore) RevokeToken(jti string, userID uint64, expiresAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Drop revocations of tokens that expired in the meantime
+	now := time.Now()
+	for id, exp := range s.tokens {
+		if now.After(exp) {
+			delete(s.tokens, id)
+		}
+	}
+
+	s.tokens[jti] = expiresAt
+	return nil
+}
+
+// IsTokenRevoked reports whether the access token with the given jti was revoked
+func (s *memoryStore) IsTokenRevoked(jti string) (bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	_, ok := s.tokens[jti]
+	return ok, nil
+}
+
+// RevokeSession revokes every access token of a session until the last of them expires
+func (s *memoryStore) RevokeSession(sessionID string, userID uint64, expiresAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Drop revocations of sessions whose tokens expired in the meantime
+	now := time.Now()
+	for id, exp := range s.sessions {
+		if now.After(exp) {
+			delete(s.sessions, id)
+		}
+	}
+
+	s.sessions[sessionID] = expiresAt
+	return nil
+}
+
+// IsSessionRevoked reports whether the session with the given sid was revoked
+func (s *memoryStore) IsSessionRevoked(sessionID string) (bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	_, ok := s.sessions[sessionID]
+	return ok, nil
+}
+
+// RevokeUserTokens invalidates every access token of a user issued before the given time
+func (s *memoryStore) RevokeUserTokens(userID uint64, before time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if current, ok := s.watermarks[userID]; !ok || before.After(current) {
+		s.watermarks[userID] = before
+	}
+	return nil
+}
+
+// UserTokensRevokedBefore returns the watermark of a user, nil when none was set
+func (s *memoryStore) UserTokensRevokedBefore(userID uint64) (*time.Time, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	before, ok := s.watermarks[userID]
+	if !ok {
+		return nil, nil
+	}
+	return &before, nil
+}
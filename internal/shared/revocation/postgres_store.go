@@ -0,0 +1,103 @@
+package revocation
+
+import (
+	"errors"
+	"modular-fx-fiber/internal/shared/database"
+	"modular-fx-fiber/internal/shared/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// postgresStore keeps revocations in the revoked_access_tokens, revoked_sessions and user_token_watermarks tables
+type postgresStore struct {
+	db *gorm.DB
+}
+
+// NewPostgresStore creates a revocation store backed by Postgres
+func NewPostgresStore(db database.Database) Store {
+	return &postgresStore{db: db.GetDB()}
+}
+
+// RevokeToken revokes a single access token until it expires
+func (s *postgresStore) RevokeToken(jti string, userID uint64, expiresAt time.Time) error {
+	// Drop revocations of tokens that expired in the meantime
+	if err := s.db.Where("expires_at < NOW()").Delete(&models.RevokedAccessToken{}).Error; err != nil {
+		return err
+	}
+
+	return s.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.RevokedAccessToken{
+		JTI:       jti,
+		UserID:    userID,
+		ExpiresAt: expiresAt,
+	}).Error
+}
+
+// IsTokenRevoked reports whether the access token with the given jti was revoked
+func (s *postgresStore) IsTokenRevoked(jti string) (bool, error) {
+	var count int64
+	err := s.db.Model(&models.RevokedAccessToken{}).
+		Where("jti = ?", jti).
+		Count(&count).Error
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// RevokeSession revokes every access token of a session until the last of them expires
+func (s *postgresStore) RevokeSession(sessionID string, userID uint64, expiresAt time.Time) error {
+	// Drop revocations of sessions whose tokens expired in the meantime
+	if err := s.db.Where("expires_at < NOW()").Delete(&models.RevokedSession{}).Error; err != nil {
+		return err
+	}
+
+	return s.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "session_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"expires_at"}),
+	}).Create(&models.RevokedSession{
+		SessionID: sessionID,
+		UserID:    userID,
+		ExpiresAt: expiresAt,
+	}).Error
+}
+
+// IsSessionRevoked reports whether the session with the given sid was revoked
+func (s *postgresStore) IsSessionRevoked(sessionID string) (bool, error) {
+	var count int64
+	err := s.db.Model(&models.RevokedSession{}).
+		Where("session_id = ?", sessionID).
+		Count(&count).Error
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// RevokeUserTokens invalidates every access token of a user issued before the given time.
+// The watermark only ever moves forward.
+func (s *postgresStore) RevokeUserTokens(userID uint64, before time.Time) error {
+	return s.db.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.Assignments(map[string]any{
+			"revoked_before": gorm.Expr("GREATEST(user_token_watermarks.revoked_before, EXCLUDED.revoked_before)"),
+			"updated_at":     gorm.Expr("EXCLUDED.updated_at"),
+		}),
+	}).Create(&models.UserTokenWatermark{
+		UserID:        userID,
+		RevokedBefore: before,
+	}).Error
+}
+
+// UserTokensRevokedBefore returns the watermark of a user, nil when none was set
+func (s *postgresStore) UserTokensRevokedBefore(userID uint64) (*time.Time, error) {
+	var mark models.UserTokenWatermark
+	if err := s.db.Where("user_id = ?", userID).First(&mark).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &mark.RevokedBefore, nil
+}
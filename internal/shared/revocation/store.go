@@ -0,0 +1,45 @@
+package revocation
+
+import (
+	"fmt"
+	"modular-fx-fiber/internal/core/config"
+	"modular-fx-fiber/internal/shared/database"
+	"time"
+)
+
+// Store backends selectable with jwt.revocation_store
+const (
+	StoreMemory   = "memory"
+	StorePostgres = "postgres"
+)
+
+// Store keeps track of access tokens that must be rejected before their expiry.
+// Single tokens are revoked by jti, the tokens of a session by sid and all tokens of a user
+// by an "issued before" watermark.
+type Store interface {
+	// RevokeToken revokes a single access token until it expires
+	RevokeToken(jti string, userID uint64, expiresAt time.Time) error
+	// IsTokenRevoked reports whether the access token with the given jti was revoked
+	IsTokenRevoked(jti string) (bool, error)
+	// RevokeSession revokes every access token of a session until the last of them expires
+	RevokeSession(sessionID string, userID uint64, expiresAt time.Time) error
+	// IsSessionRevoked reports whether the session with the given sid was revoked
+	IsSessionRevoked(sessionID string) (bool, error)
+	// RevokeUserTokens invalidates every access token of a user issued before the given time.
+	// The iat claim only has second precision, so tokens issued at or before the watermark are rejected.
+	RevokeUserTokens(userID uint64, before time.Time) error
+	// UserTokensRevokedBefore returns the watermark of a user, nil when none was set
+	UserTokensRevokedBefore(userID uint64) (*time.Time, error)
+}
+
+// NewStore creates the revocation store configured in jwt.revocation_store
+func NewStore(c *config.Config, db database.Database) (Store, error) {
+	switch c.JWT.RevocationStore {
+	case StoreMemory:
+		return NewMemoryStore(), nil
+	case StorePostgres, "":
+		return NewPostgresStore(db), nil
+	default:
+		return nil, fmt.Errorf("unsupported revocation store %q", c.JWT.RevocationStore)
+	}
+}
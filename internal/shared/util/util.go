@@ -2,7 +2,12 @@ package util
 
 import (
 	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
+	"net/url"
+	"strings"
 )
 
 func GenerateRandomCode(length int) string {
@@ -25,6 +30,56 @@ func GenerateRandomCode(length int) string {
 	return string(result)
 }
 
+// GenerateRandomToken returns a URL-safe random token built from the given number of random bytes
+func GenerateRandomToken(length int) (string, error) {
+	if length <= 0 {
+		length = 32
+	}
+
+	b := make([]byte, length)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// HashToken returns the hex encoded SHA-256 hash of a token, used to store tokens at rest
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// BasicCredentials extracts the client credentials of an HTTP Basic authorization header.
+// Both parts are form-urlencoded as required by RFC 6749 section 2.3.1.
+func BasicCredentials(header string) (string, string, bool) {
+	encoded, found := strings.CutPrefix(header, "Basic ")
+	if !found {
+		return "", "", false
+	}
+
+	decoded, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", "", false
+	}
+
+	rawID, rawSecret, found := strings.Cut(string(decoded), ":")
+	if !found {
+		return "", "", false
+	}
+
+	clientID, err := url.QueryUnescape(rawID)
+	if err != nil {
+		return "", "", false
+	}
+	clientSecret, err := url.QueryUnescape(rawSecret)
+	if err != nil {
+		return "", "", false
+	}
+
+	return clientID, clientSecret, true
+}
+
 func StructToMap(obj any) (map[string]any, error) {
 	data, err := json.Marshal(obj)
 	if err != nil {
@@ -35,3 +90,21 @@ func StructToMap(obj any) (map[string]any, error) {
 	err = json.Unmarshal(data, &result)
 	return result, err
 }
+
+// NormalizePhoneNumber returns a phone number in E.164 format. Vietnamese numbers written
+// in the local format (0912345678) or without the plus sign (84912345678) are converted,
+// other numbers are expected to be in E.164 format already.
+func NormalizePhoneNumber(phone string) string {
+	phone = strings.NewReplacer(" ", "", "-", "", ".", "", "(", "", ")", "").Replace(phone)
+
+	switch {
+	case strings.HasPrefix(phone, "+"):
+		return phone
+	case strings.HasPrefix(phone, "0") && len(phone) == 10:
+		return "+84" + phone[1:]
+	case strings.HasPrefix(phone, "84") && len(phone) == 11:
+		return "+" + phone
+	default:
+		return phone
+	}
+}